@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// checkConfig validates the configuration file and
+// prints the problems found. The exit code is non-zero
+// if the configuration has errors.
+//
+//	alice-lg check-config [-config /etc/alice-lg/alice.conf]
+func checkConfig(args []string) int {
+	flags := flag.NewFlagSet("check-config", flag.ExitOnError)
+	configFilenameFlag := flags.String(
+		"config", "/etc/alice-lg/alice.conf",
+		"Alice looking glass configuration file",
+	)
+	flags.Parse(args)
+
+	filename := *configFilenameFlag
+	if flags.NArg() > 0 {
+		filename = flags.Arg(0)
+	}
+
+	// Silence the log output while loading the config
+	log.SetOutput(io.Discard)
+	diagnostics, err := config.CheckConfig(filename)
+	log.SetOutput(os.Stderr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+
+	for _, d := range diagnostics {
+		fmt.Println(d)
+	}
+	if len(diagnostics) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(diagnostics))
+		return 1
+	}
+	fmt.Fprintln(os.Stderr, "configuration ok:", filename)
+	return 0
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// convertConfig converts an ini configuration into YAML.
+// The result is written to stdout or the output file.
+//
+//	alice-lg convert-config [-config alice.conf] [-o alice.yaml]
+func convertConfig(args []string) int {
+	flags := flag.NewFlagSet("convert-config", flag.ExitOnError)
+	configFilenameFlag := flags.String(
+		"config", "/etc/alice-lg/alice.conf",
+		"Alice looking glass configuration file",
+	)
+	outputFlag := flags.String(
+		"o", "", "write the YAML configuration to this file",
+	)
+	flags.Parse(args)
+
+	filename := *configFilenameFlag
+	if flags.NArg() > 0 {
+		filename = flags.Arg(0)
+	}
+
+	data, err := config.ConvertToYAML(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	if *outputFlag == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+	// The configuration contains credentials
+	if err := os.WriteFile(*outputFlag, data, 0o600); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	return 0
+}
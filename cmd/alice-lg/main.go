@@ -6,16 +6,21 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/alice-lg/alice-lg/pkg/alerts"
 	"github.com/alice-lg/alice-lg/pkg/config"
 	"github.com/alice-lg/alice-lg/pkg/http"
 	"github.com/alice-lg/alice-lg/pkg/store"
 	"github.com/alice-lg/alice-lg/pkg/store/backends/memory"
 	"github.com/alice-lg/alice-lg/pkg/store/backends/postgres"
+	"github.com/alice-lg/alice-lg/pkg/tracing"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 )
@@ -57,8 +62,74 @@ func startMemoryProfile(prefix string) {
 	}
 }
 
+// reloadOnSignal reloads the configuration when
+// a SIGHUP is received.
+func reloadOnSignal(ctx context.Context, server *http.Server) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			log.Println("received SIGHUP, reloading configuration")
+			if _, err := server.ReloadConfig(ctx); err != nil {
+				log.Println("reloading the configuration failed:", err)
+			}
+		}
+	}
+}
+
+// startService runs the service in the background. The
+// service is added to the wait group and must return
+// when the context is done.
+func startService(
+	ctx context.Context,
+	services *sync.WaitGroup,
+	start func(context.Context),
+) {
+	services.Add(1)
+	go func() {
+		defer services.Done()
+		start(ctx)
+	}()
+}
+
+// awaitServices waits for the services to return until
+// the timeout expires. False is returned on timeout.
+func awaitServices(services *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		services.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// tracingFlushTimeout limits the time for exporting
+// the pending spans when shutting down.
+const tracingFlushTimeout = 5 * time.Second
+
 func main() {
-	ctx := context.Background()
+	// Shut down on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(
+		context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check-config":
+			os.Exit(checkConfig(os.Args[2:]))
+		case "convert-config":
+			os.Exit(convertConfig(os.Args[2:]))
+		}
+	}
 
 	// Handle commandline parameters
 	configFilenameFlag := flag.String(
@@ -87,6 +158,12 @@ func main() {
 	// Tune garbage collection
 	debug.SetGCPercent(10)
 
+	// Export traces
+	stopTracing, err := tracing.Start(ctx, cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Setup local routes store and use backend from configuration
 	var (
 		neighborsBackend store.NeighborsStoreBackend = memory.NewNeighborsBackend()
@@ -127,23 +204,64 @@ func main() {
 	printBanner(cfg, neighborsStore, routesStore)
 	log.Println("Using configuration:", cfg.File)
 
-	// Start stores
-	if cfg.Server.EnablePrefixLookup {
-		go neighborsStore.Start(ctx)
-		go routesStore.Start(ctx)
+	// Evaluate alerting rules after each refresh
+	var alertsEngine *alerts.Engine
+	if len(cfg.Alerts.Rules) > 0 {
+		alertsEngine = alerts.NewEngine(cfg)
+		neighborsStore.AddRefreshHook(alertsEngine.EvaluateNeighbors)
+		routesStore.AddRefreshHook(alertsEngine.EvaluateRoutes)
+		go alertsEngine.Start(ctx)
 	}
 
 	// Start exporting metrics
 	if cfg.Server.EnableMetrics {
-		go store.StartMetrics(ctx, neighborsStore)
+		go store.StartMetrics(ctx, neighborsStore, routesStore)
 	}
 
-	// Start the Housekeeping
-	go store.StartHousekeeping(ctx, cfg)
+	// The services are awaited when shutting down
+	services := &sync.WaitGroup{}
+
+	// Start stores
+	if cfg.Server.EnablePrefixLookup {
+		startService(ctx, services, neighborsStore.Start)
+		startService(ctx, services, routesStore.Start)
+	}
 
 	// Start HTTP API
-	server := http.NewServer(cfg, pool, routesStore, neighborsStore)
-	go server.Start(ctx)
+	server := http.NewServer(
+		cfg, pool, routesStore, neighborsStore, alertsEngine)
+	startService(ctx, services, server.Start)
+
+	// Reload the configuration on SIGHUP
+	go reloadOnSignal(ctx, server)
+
+	// Start the Housekeeping
+	startService(ctx, services, func(ctx context.Context) {
+		store.StartHousekeeping(ctx, server.Config)
+	})
 
 	<-ctx.Done()
+	stop() // Another signal terminates immediately
+
+	// Wait for the requests and refreshes in progress.
+	// Open transactions are rolled back by the database
+	// when the connections are closed on exit.
+	timeout := time.Duration(server.Config().Server.ShutdownTimeout) * time.Second
+	log.Println("Shutting down, waiting up to", timeout)
+	if !awaitServices(services, timeout) {
+		log.Println("Shutdown timeout expired, exiting")
+		os.Exit(1)
+	}
+	if pool != nil {
+		pool.Close()
+	}
+
+	// Flush the pending spans
+	flushCtx, cancel := context.WithTimeout(
+		context.Background(), tracingFlushTimeout)
+	defer cancel()
+	if err := stopTracing(flushCtx); err != nil {
+		log.Println("exporting the pending spans failed:", err)
+	}
+	log.Println("Shutdown complete")
 }
@@ -0,0 +1,245 @@
+// Package alerts evaluates alerting rules after
+// a refresh of the stores and delivers the alerts
+// to webhooks.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// MaxAlertPrefixes is the maximum number of prefixes
+// included in an alert raised by a route rule.
+const MaxAlertPrefixes = 100
+
+// WebhookQueueSize is the number of pending
+// deliveries for each webhook.
+const WebhookQueueSize = 1024
+
+// firingAlert is the state of an alert which
+// has not been resolved yet.
+type firingAlert struct {
+	rule     string
+	sourceID string
+	alert    *api.Alert
+
+	// Prefixes matching a route rule
+	prefixes map[string]bool
+}
+
+// delivery of an alert to a webhook
+type delivery struct {
+	alertID string
+	payload []byte
+	state   *api.AlertDelivery
+}
+
+// Engine evaluates the alerting rules and keeps
+// the history of the alerts. Alerts are deduplicated:
+// While an alert is firing, it is not raised again.
+type Engine struct {
+	sync.Mutex
+
+	rules    []*config.AlertRuleConfig
+	webhooks []*config.WebhookConfig
+	reasons  api.BGPCommunityMap
+
+	client       *http.Client
+	retryBackoff time.Duration
+	queues       map[string]chan *delivery
+
+	seq         uint64
+	historySize int
+	history     []*api.Alert
+
+	// Firing alerts by key
+	firing map[string]*firingAlert
+
+	// Filtered routes of the neighbors at
+	// the previous refresh by source
+	filtered map[string]map[string]int
+}
+
+// NewEngine creates a new alerting engine
+func NewEngine(cfg *config.Config) *Engine {
+	queues := make(map[string]chan *delivery)
+	for _, w := range cfg.Alerts.Webhooks {
+		queues[w.ID] = make(chan *delivery, WebhookQueueSize)
+	}
+	return &Engine{
+		rules:        cfg.Alerts.Rules,
+		webhooks:     cfg.Alerts.Webhooks,
+		reasons:      cfg.UI.RoutesRejections.Reasons,
+		client:       &http.Client{},
+		retryBackoff: time.Second,
+		queues:       queues,
+		historySize:  cfg.Alerts.HistorySize,
+		history:      []*api.Alert{},
+		firing:       make(map[string]*firingAlert),
+		filtered:     make(map[string]map[string]int),
+	}
+}
+
+// Start delivering alerts to the webhooks
+func (e *Engine) Start(ctx context.Context) {
+	log.Println("Starting alerts engine with", len(e.rules), "rules")
+	for _, w := range e.webhooks {
+		go e.deliverWebhook(ctx, w, e.queues[w.ID])
+	}
+	<-ctx.Done()
+}
+
+// SetReasons replaces the filter reasons used by the
+// rules, e.g. after the configuration was reloaded.
+func (e *Engine) SetReasons(reasons api.BGPCommunityMap) {
+	e.Lock()
+	defer e.Unlock()
+	e.reasons = reasons
+}
+
+// History returns the alerts, the most recent first
+func (e *Engine) History() []*api.Alert {
+	e.Lock()
+	defer e.Unlock()
+
+	alerts := make([]*api.Alert, 0, len(e.history))
+	for i := len(e.history) - 1; i >= 0; i-- {
+		alert := *e.history[i]
+		alert.Deliveries = make([]*api.AlertDelivery, len(alert.Deliveries))
+		for j, d := range e.history[i].Deliveries {
+			state := *d
+			alert.Deliveries[j] = &state
+		}
+		alerts = append(alerts, &alert)
+	}
+	return alerts
+}
+
+// alertKey identifies the condition of a rule
+// for a neighbor.
+func alertKey(ruleID, sourceID, neighborID string) string {
+	return ruleID + "/" + sourceID + "/" + neighborID
+}
+
+// newAlert creates a new alert for a neighbor
+func (e *Engine) newAlert(
+	key string,
+	rule *config.AlertRuleConfig,
+	status string,
+	sourceID string,
+	n *api.Neighbor,
+	now time.Time,
+) *api.Alert {
+	e.seq++
+	return &api.Alert{
+		ID:            fmt.Sprintf("%d-%d", now.Unix(), e.seq),
+		Key:           key,
+		Rule:          rule.ID,
+		Status:        status,
+		StartsAt:      now,
+		CreatedAt:     now,
+		RouteServerID: sourceID,
+		NeighborID:    n.ID,
+		Address:       n.Address,
+		ASN:           n.ASN,
+		Description:   n.Description,
+	}
+}
+
+// notify adds the alert to the history and
+// queues the deliveries to the webhooks of the rule.
+// The engine must be locked.
+func (e *Engine) notify(rule *config.AlertRuleConfig, alert *api.Alert) {
+	log.Println("[alerts]", alert.Status, alert.Message)
+
+	payload, err := json.Marshal(alert)
+	if err != nil {
+		log.Println("[alerts] could not encode alert:", err)
+		return
+	}
+
+	alert.Deliveries = make([]*api.AlertDelivery, 0, len(rule.Webhooks))
+	for _, id := range rule.Webhooks {
+		state := &api.AlertDelivery{
+			Webhook: id,
+			Status:  api.AlertDeliveryPending,
+		}
+		alert.Deliveries = append(alert.Deliveries, state)
+
+		select {
+		case e.queues[id] <- &delivery{
+			alertID: alert.ID,
+			payload: payload,
+			state:   state,
+		}:
+		default:
+			state.Status = api.AlertDeliveryFailed
+			state.LastError = "delivery queue is full"
+		}
+	}
+
+	e.history = append(e.history, alert)
+	if len(e.history) > e.historySize {
+		e.history = e.history[len(e.history)-e.historySize:]
+	}
+}
+
+// resolve an alert if it is firing.
+// The engine must be locked.
+func (e *Engine) resolve(key string, now time.Time) {
+	f, ok := e.firing[key]
+	if !ok {
+		return
+	}
+	delete(e.firing, key)
+
+	rule := e.ruleByID(f.rule)
+	if rule == nil {
+		return
+	}
+
+	e.seq++
+	alert := *f.alert
+	alert.ID = fmt.Sprintf("%d-%d", now.Unix(), e.seq)
+	alert.Status = api.AlertStatusResolved
+	alert.CreatedAt = now
+	alert.Message = f.alert.Message + ": resolved"
+	alert.Prefixes = nil
+	alert.MatchedRoutes = 0
+	alert.Deliveries = nil
+	e.notify(rule, &alert)
+}
+
+// resolveMissing resolves the firing alerts of
+// the rules for a source which were not evaluated.
+// The engine must be locked.
+func (e *Engine) resolveMissing(
+	sourceID string,
+	rules map[string]bool,
+	seen map[string]bool,
+	now time.Time,
+) {
+	for key, f := range e.firing {
+		if f.sourceID != sourceID || !rules[f.rule] || seen[key] {
+			continue
+		}
+		e.resolve(key, now)
+	}
+}
+
+func (e *Engine) ruleByID(id string) *config.AlertRuleConfig {
+	for _, rule := range e.rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func makeTestEngine(rules ...*config.AlertRuleConfig) *Engine {
+	cfg := &config.Config{
+		Alerts: config.AlertsConfig{
+			HistorySize: 100,
+			Rules:       rules,
+		},
+		UI: config.UIConfig{
+			RoutesRejections: config.RejectionsConfig{
+				Reasons: api.BGPCommunityMap{
+					"6695": api.BGPCommunityMap{
+						"1000": api.BGPCommunityMap{
+							"4": "RPKI Invalid",
+						},
+					},
+				},
+			},
+		},
+	}
+	return NewEngine(cfg)
+}
+
+func TestEvaluateNeighbors(t *testing.T) {
+	ctx := context.Background()
+	e := makeTestEngine(
+		&config.AlertRuleConfig{
+			ID:            "session_down",
+			ASNs:          []int{2342},
+			NeighborState: "down",
+		},
+		&config.AlertRuleConfig{
+			ID:                     "filtered_jump",
+			RoutesFilteredIncrease: intPtr(10),
+		},
+	)
+
+	e.EvaluateNeighbors(ctx, "rs1", api.Neighbors{
+		{ID: "n1", ASN: 2342, State: "up", RoutesFiltered: 1},
+		{ID: "n2", ASN: 2343, State: "down"},
+	})
+	if len(e.History()) != 0 {
+		t.Fatal("unexpected alerts:", e.History())
+	}
+
+	// Session goes down and the filtered routes jump
+	down := api.Neighbors{
+		{ID: "n1", ASN: 2342, State: "down", RoutesFiltered: 20},
+		{ID: "n2", ASN: 2343, State: "down"},
+	}
+	e.EvaluateNeighbors(ctx, "rs1", down)
+	alerts := e.History()
+	if len(alerts) != 2 {
+		t.Fatal("expected 2 alerts, got:", alerts)
+	}
+
+	// The alert is not raised again, the increase
+	// is resolved.
+	e.EvaluateNeighbors(ctx, "rs1", down)
+	alerts = e.History()
+	if len(alerts) != 3 {
+		t.Fatal("expected 3 alerts, got:", alerts)
+	}
+	if alerts[0].Rule != "filtered_jump" ||
+		alerts[0].Status != api.AlertStatusResolved {
+		t.Error("unexpected alert:", alerts[0])
+	}
+
+	// Neighbor is gone
+	e.EvaluateNeighbors(ctx, "rs1", api.Neighbors{})
+	alerts = e.History()
+	if alerts[0].Rule != "session_down" ||
+		alerts[0].Status != api.AlertStatusResolved ||
+		alerts[0].NeighborID != "n1" {
+		t.Error("unexpected alert:", alerts[0])
+	}
+	if len(e.firing) != 0 {
+		t.Error("expected no firing alerts")
+	}
+}
+
+func TestEvaluateRoutes(t *testing.T) {
+	ctx := context.Background()
+	query, _ := api.ParseSearchQuery("large:6695:1000:4")
+	e := makeTestEngine(
+		&config.AlertRuleConfig{
+			ID:         "rpki_invalid",
+			RouteQuery: query,
+		},
+		&config.AlertRuleConfig{
+			ID:            "rpki_filtered",
+			FilterReasons: []string{"rpki invalid"},
+		},
+	)
+
+	n := &api.Neighbor{ID: "n1", ASN: 2342}
+	route := func(network, state string) *api.LookupRoute {
+		return &api.LookupRoute{
+			Route: &api.Route{
+				Network: network,
+				BGP: &api.BGPInfo{
+					LargeCommunities: api.Communities{{6695, 1000, 4}},
+				},
+			},
+			State:    state,
+			Neighbor: n,
+		}
+	}
+
+	e.EvaluateRoutes(ctx, "rs1", api.LookupRoutes{
+		route("10.0.0.0/8", api.RouteStateImported),
+	})
+	alerts := e.History()
+	if len(alerts) != 1 || alerts[0].Rule != "rpki_invalid" ||
+		alerts[0].Prefixes[0] != "10.0.0.0/8" {
+		t.Fatal("unexpected alerts:", alerts)
+	}
+
+	// A new prefix appears
+	e.EvaluateRoutes(ctx, "rs1", api.LookupRoutes{
+		route("10.0.0.0/8", api.RouteStateImported),
+		route("10.1.0.0/16", api.RouteStateFiltered),
+	})
+	alerts = e.History()
+	if len(alerts) != 3 {
+		t.Fatal("expected 3 alerts, got:", alerts)
+	}
+	for _, a := range alerts[:2] {
+		if len(a.Prefixes) != 1 || a.Prefixes[0] != "10.1.0.0/16" {
+			t.Error("unexpected alert:", a)
+		}
+	}
+
+	// Nothing changed
+	e.EvaluateRoutes(ctx, "rs1", api.LookupRoutes{
+		route("10.0.0.0/8", api.RouteStateImported),
+		route("10.1.0.0/16", api.RouteStateFiltered),
+	})
+	if len(e.History()) != 3 {
+		t.Error("expected no new alerts")
+	}
+
+	// All gone
+	e.EvaluateRoutes(ctx, "rs1", api.LookupRoutes{})
+	alerts = e.History()
+	if len(alerts) != 5 || alerts[0].Status != api.AlertStatusResolved {
+		t.Error("expected resolved alerts:", alerts)
+	}
+}
+
+func TestSetReasons(t *testing.T) {
+	ctx := context.Background()
+	e := makeTestEngine(&config.AlertRuleConfig{
+		ID:            "blackholed",
+		FilterReasons: []string{"blackhole"},
+	})
+	route := &api.LookupRoute{
+		Route: &api.Route{
+			Network: "10.0.0.0/8",
+			BGP: &api.BGPInfo{
+				LargeCommunities: api.Communities{{6695, 1000, 4}},
+			},
+		},
+		State:    api.RouteStateFiltered,
+		Neighbor: &api.Neighbor{ID: "n1", ASN: 2342},
+	}
+
+	e.EvaluateRoutes(ctx, "rs1", api.LookupRoutes{route})
+	if len(e.History()) != 0 {
+		t.Fatal("unexpected alerts:", e.History())
+	}
+
+	// The reasons are reloaded
+	e.SetReasons(api.BGPCommunityMap{
+		"6695": api.BGPCommunityMap{
+			"1000": api.BGPCommunityMap{
+				"4": "Blackhole",
+			},
+		},
+	})
+	e.EvaluateRoutes(ctx, "rs1", api.LookupRoutes{route})
+	if len(e.History()) != 1 {
+		t.Error("expected an alert with the new reasons:", e.History())
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	var mu sync.Mutex
+	received := []*api.Alert{}
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests++
+			if requests == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			if req.Header.Get("Authorization") != "Bearer secret" ||
+				req.Header.Get("X-Alice-Alert-ID") == "" {
+				t.Error("unexpected headers:", req.Header)
+			}
+			alert := &api.Alert{}
+			json.NewDecoder(req.Body).Decode(alert)
+			received = append(received, alert)
+		}))
+	defer srv.Close()
+
+	cfg := &config.Config{
+		Alerts: config.AlertsConfig{
+			HistorySize: 10,
+			Webhooks: []*config.WebhookConfig{{
+				ID:      "noc",
+				URL:     srv.URL,
+				Timeout: 1,
+				Retries: 2,
+				Headers: map[string]string{
+					"Authorization": "Bearer secret",
+				},
+			}},
+			Rules: []*config.AlertRuleConfig{{
+				ID:            "session_down",
+				Webhooks:      []string{"noc"},
+				NeighborState: "down",
+			}},
+		},
+	}
+	e := NewEngine(cfg)
+	e.retryBackoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.Start(ctx)
+
+	e.EvaluateNeighbors(ctx, "rs1", api.Neighbors{
+		{ID: "n1", ASN: 2342, State: "down"},
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		alerts := e.History()
+		if alerts[0].Deliveries[0].Status != api.AlertDeliveryPending {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	delivery := e.History()[0].Deliveries[0]
+	if delivery.Status != api.AlertDeliveryDelivered || delivery.Attempts != 2 {
+		t.Error("unexpected delivery:", delivery)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 1 || received[0].NeighborID != "n1" ||
+		received[0].Status != api.AlertStatusFiring {
+		t.Error("unexpected alerts received:", received)
+	}
+}
+
+func TestWebhookDeliveryErrorWithoutURL(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	webhookURL := srv.URL + "/services/T0001/secret-token"
+	srv.Close() // Refuse all connections
+
+	webhook := &config.WebhookConfig{
+		ID:      "slack",
+		URL:     webhookURL,
+		Timeout: 1,
+	}
+	e := NewEngine(&config.Config{})
+	d := &delivery{
+		alertID: "a1",
+		payload: []byte("{}"),
+		state:   &api.AlertDelivery{Status: api.AlertDeliveryPending},
+	}
+	e.deliver(context.Background(), webhook, d)
+
+	if d.state.Status != api.AlertDeliveryFailed || d.state.LastError == "" {
+		t.Fatal("expected a failed delivery:", d.state)
+	}
+	if strings.Contains(d.state.LastError, "secret-token") {
+		t.Error("webhook url in error:", d.state.LastError)
+	}
+}
@@ -0,0 +1,301 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// matchSelectors checks if the rule applies to
+// the source and the neighbor ASN.
+func matchSelectors(
+	rule *config.AlertRuleConfig,
+	sourceID string,
+	asn int,
+) bool {
+	if len(rule.Sources) > 0 && !slices.Contains(rule.Sources, sourceID) {
+		return false
+	}
+	if len(rule.ASNs) > 0 && !slices.Contains(rule.ASNs, asn) {
+		return false
+	}
+	return true
+}
+
+// matchNeighbor checks the neighbor conditions of
+// a rule. The reasons describe the met conditions.
+func matchNeighbor(
+	rule *config.AlertRuleConfig,
+	n *api.Neighbor,
+	prevFiltered int,
+	hasPrev bool,
+) ([]string, bool) {
+	reasons := []string{}
+
+	if state := rule.NeighborState; state != "" {
+		up := api.IsNeighborStateUp(n.State)
+		switch state {
+		case "up":
+			if !up {
+				return nil, false
+			}
+		case "down":
+			if up {
+				return nil, false
+			}
+		default:
+			if !strings.EqualFold(state, n.State) {
+				return nil, false
+			}
+		}
+		reasons = append(reasons, "state is "+n.State)
+	}
+
+	if limit := rule.RoutesReceivedBelow; limit != nil {
+		if n.RoutesReceived >= *limit {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf(
+			"%d routes received (below %d)", n.RoutesReceived, *limit))
+	}
+
+	if limit := rule.RoutesFilteredAbove; limit != nil {
+		if n.RoutesFiltered <= *limit {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf(
+			"%d routes filtered (above %d)", n.RoutesFiltered, *limit))
+	}
+
+	if limit := rule.RoutesFilteredIncrease; limit != nil {
+		if !hasPrev || n.RoutesFiltered-prevFiltered < *limit {
+			return nil, false
+		}
+		reasons = append(reasons, fmt.Sprintf(
+			"filtered routes increased from %d to %d",
+			prevFiltered, n.RoutesFiltered))
+	}
+
+	return reasons, true
+}
+
+// matchRoute checks the route conditions of a rule
+func (e *Engine) matchRoute(
+	rule *config.AlertRuleConfig,
+	r *api.LookupRoute,
+) bool {
+	if rule.RouteQuery != nil && !rule.RouteQuery.Match(r) {
+		return false
+	}
+	if len(rule.FilterReasons) > 0 && !e.matchFilterReasons(rule, r) {
+		return false
+	}
+	return true
+}
+
+// matchFilterReasons checks if the route was filtered
+// for one of the reasons of the rule. The wildcard *
+// matches any known reason. The engine must be locked.
+func (e *Engine) matchFilterReasons(
+	rule *config.AlertRuleConfig,
+	r *api.LookupRoute,
+) bool {
+	if r.State != api.RouteStateFiltered || r.BGP == nil || e.reasons == nil {
+		return false
+	}
+	communities := make(api.Communities, 0,
+		len(r.BGP.Communities)+len(r.BGP.LargeCommunities))
+	communities = append(communities, r.BGP.Communities...)
+	communities = append(communities, r.BGP.LargeCommunities...)
+
+	for _, c := range communities {
+		reason, err := e.reasons.Lookup(c.String())
+		if err != nil {
+			continue
+		}
+		for _, match := range rule.FilterReasons {
+			if match == "*" || strings.EqualFold(match, reason) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EvaluateNeighbors evaluates the neighbor rules after
+// a refresh of the neighbors of a source.
+func (e *Engine) EvaluateNeighbors(
+	_ context.Context,
+	sourceID string,
+	neighbors api.Neighbors,
+) {
+	now := time.Now().UTC()
+
+	e.Lock()
+	defer e.Unlock()
+
+	prev, hasPrev := e.filtered[sourceID]
+
+	rules := map[string]bool{}
+	seen := map[string]bool{}
+	for _, rule := range e.rules {
+		if rule.IsRouteRule() {
+			continue
+		}
+		rules[rule.ID] = true
+		for _, n := range neighbors {
+			if !matchSelectors(rule, sourceID, n.ASN) {
+				continue
+			}
+			key := alertKey(rule.ID, sourceID, n.ID)
+			seen[key] = true
+
+			prevFiltered, ok := prev[n.ID]
+			reasons, match := matchNeighbor(rule, n, prevFiltered, hasPrev && ok)
+			if !match {
+				e.resolve(key, now)
+				continue
+			}
+			if _, ok := e.firing[key]; ok {
+				continue // Already firing
+			}
+
+			alert := e.newAlert(key, rule, api.AlertStatusFiring, sourceID, n, now)
+			alert.State = n.State
+			alert.Routes = &api.NeighborRoutesCount{
+				Received: n.RoutesReceived,
+				Filtered: n.RoutesFiltered,
+				Accepted: n.RoutesAccepted,
+			}
+			alert.Message = fmt.Sprintf(
+				"%s: AS%d %s on %s: %s",
+				rule.ID, n.ASN, n.Description, sourceID,
+				strings.Join(reasons, ", "))
+			e.firing[key] = &firingAlert{
+				rule:     rule.ID,
+				sourceID: sourceID,
+				alert:    alert,
+			}
+			e.notify(rule, alert)
+		}
+	}
+	e.resolveMissing(sourceID, rules, seen, now)
+
+	filtered := make(map[string]int, len(neighbors))
+	for _, n := range neighbors {
+		filtered[n.ID] = n.RoutesFiltered
+	}
+	e.filtered[sourceID] = filtered
+}
+
+// routeMatches are the routes of a neighbor
+// matching a rule.
+type routeMatches struct {
+	neighbor *api.Neighbor
+	prefixes map[string]bool
+	count    int
+}
+
+// EvaluateRoutes evaluates the route rules after a
+// refresh of the routes of a source. The matching routes
+// are grouped by neighbor. An alert is raised when new
+// prefixes match.
+func (e *Engine) EvaluateRoutes(
+	_ context.Context,
+	sourceID string,
+	routes api.LookupRoutes,
+) {
+	now := time.Now().UTC()
+
+	e.Lock()
+	defer e.Unlock()
+
+	rules := map[string]bool{}
+	seen := map[string]bool{}
+	for _, rule := range e.rules {
+		if !rule.IsRouteRule() {
+			continue
+		}
+		rules[rule.ID] = true
+
+		matches := map[string]*routeMatches{}
+		for _, r := range routes {
+			if r.Neighbor == nil ||
+				!matchSelectors(rule, sourceID, r.Neighbor.ASN) ||
+				!e.matchRoute(rule, r) {
+				continue
+			}
+			m, ok := matches[r.Neighbor.ID]
+			if !ok {
+				m = &routeMatches{
+					neighbor: r.Neighbor,
+					prefixes: map[string]bool{},
+				}
+				matches[r.Neighbor.ID] = m
+			}
+			m.prefixes[r.Network] = true
+			m.count++
+		}
+
+		for neighborID, m := range matches {
+			key := alertKey(rule.ID, sourceID, neighborID)
+			seen[key] = true
+			e.fireRoutes(key, rule, sourceID, m, now)
+		}
+	}
+	e.resolveMissing(sourceID, rules, seen, now)
+}
+
+// fireRoutes raises an alert for prefixes which
+// did not match at the previous refresh.
+// The engine must be locked.
+func (e *Engine) fireRoutes(
+	key string,
+	rule *config.AlertRuleConfig,
+	sourceID string,
+	m *routeMatches,
+	now time.Time,
+) {
+	f, firing := e.firing[key]
+	prefixes := []string{}
+	for prefix := range m.prefixes {
+		if firing && f.prefixes[prefix] {
+			continue
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	if firing {
+		f.prefixes = m.prefixes
+	}
+	if len(prefixes) == 0 {
+		return // Nothing new
+	}
+	slices.Sort(prefixes)
+	if len(prefixes) > MaxAlertPrefixes {
+		prefixes = prefixes[:MaxAlertPrefixes]
+	}
+
+	n := m.neighbor
+	alert := e.newAlert(key, rule, api.AlertStatusFiring, sourceID, n, now)
+	alert.Prefixes = prefixes
+	alert.MatchedRoutes = m.count
+	alert.Message = fmt.Sprintf(
+		"%s: AS%d %s on %s: %d routes matching",
+		rule.ID, n.ASN, n.Description, sourceID, m.count)
+	if firing {
+		alert.StartsAt = f.alert.StartsAt
+	} else {
+		e.firing[key] = &firingAlert{
+			rule:     rule.ID,
+			sourceID: sourceID,
+			alert:    alert,
+			prefixes: m.prefixes,
+		}
+	}
+	e.notify(rule, alert)
+}
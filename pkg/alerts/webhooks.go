@@ -0,0 +1,119 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// deliverWebhook sends the queued alerts to the
+// webhook. The deliveries are sequential, so the
+// webhook receives the alerts in order.
+func (e *Engine) deliverWebhook(
+	ctx context.Context,
+	webhook *config.WebhookConfig,
+	queue chan *delivery,
+) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-queue:
+			e.deliver(ctx, webhook, d)
+		}
+	}
+}
+
+// deliver posts the alert to the webhook and retries
+// with an exponential backoff if the delivery failed.
+func (e *Engine) deliver(
+	ctx context.Context,
+	webhook *config.WebhookConfig,
+	d *delivery,
+) {
+	for attempt := 0; attempt <= webhook.Retries; attempt++ {
+		if attempt > 0 {
+			backoff := e.retryBackoff << (attempt - 1)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+		}
+
+		err := e.post(ctx, webhook, d)
+
+		e.Lock()
+		d.state.Attempts++
+		if err == nil {
+			now := time.Now().UTC()
+			d.state.Status = api.AlertDeliveryDelivered
+			d.state.DeliveredAt = &now
+			d.state.LastError = ""
+			e.Unlock()
+			return
+		}
+		d.state.LastError = err.Error()
+		e.Unlock()
+	}
+
+	e.Lock()
+	d.state.Status = api.AlertDeliveryFailed
+	e.Unlock()
+	log.Println(
+		"[alerts] delivering alert", d.alertID,
+		"to webhook", webhook.ID, "failed:", d.state.LastError)
+}
+
+// post sends the payload to the webhook. The alert
+// ID is included as header, so receivers can
+// deduplicate retried deliveries.
+func (e *Engine) post(
+	ctx context.Context,
+	webhook *config.WebhookConfig,
+	d *delivery,
+) error {
+	timeout := time.Duration(webhook.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, webhook.URL, bytes.NewReader(d.payload))
+	if err != nil {
+		return withoutURL(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Alice-Alert-ID", d.alertID)
+	for name, value := range webhook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return nil
+}
+
+// withoutURL removes the webhook URL from the error.
+// The URL can contain a secret token and the error is
+// shown in the public alert deliveries.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
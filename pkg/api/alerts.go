@@ -0,0 +1,66 @@
+package api
+
+import (
+	"time"
+)
+
+// Alert status
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// Webhook delivery status
+const (
+	AlertDeliveryPending   = "pending"
+	AlertDeliveryDelivered = "delivered"
+	AlertDeliveryFailed    = "failed"
+)
+
+// AlertDelivery is the state of the delivery
+// of an alert to a webhook.
+type AlertDelivery struct {
+	Webhook     string     `json:"webhook"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Alert is raised when the conditions of an alerting
+// rule are met for a neighbor. An alert is resolved
+// when the conditions are no longer met.
+//
+// Alerts with the same key refer to the same condition.
+type Alert struct {
+	ID        string    `json:"id"`
+	Key       string    `json:"key"`
+	Rule      string    `json:"rule"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message"`
+	StartsAt  time.Time `json:"starts_at"`
+	CreatedAt time.Time `json:"created_at"`
+
+	RouteServerID string `json:"routeserver_id"`
+	NeighborID    string `json:"neighbor_id"`
+	Address       string `json:"address"`
+	ASN           int    `json:"asn"`
+	Description   string `json:"description"`
+
+	// Neighbor rules
+	State  string               `json:"state,omitempty"`
+	Routes *NeighborRoutesCount `json:"routes,omitempty"`
+
+	// Route rules: The prefixes which raised the
+	// alert and the total number of matching routes.
+	Prefixes      []string `json:"prefixes,omitempty"`
+	MatchedRoutes int      `json:"matched_routes,omitempty"`
+
+	Deliveries []*AlertDelivery `json:"deliveries,omitempty"`
+}
+
+// AlertsResponse is the alert history
+type AlertsResponse struct {
+	Response
+	Alerts []*Alert `json:"alerts"`
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -136,6 +137,7 @@ func (c BGPCommunityMap) Set(community string, label string) {
 // Communities enumerates all bgp communities into
 // a set of api.Communities.
 // CAVEAT: Wildcards are substituted by 0 and ** ARE NOT ** expanded.
+// The communities are sorted, so the result is stable.
 func (c BGPCommunityMap) Communities() Communities {
 	communities := Communities{}
 	// We could do this recursive, or assume that
@@ -171,6 +173,9 @@ func (c BGPCommunityMap) Communities() Communities {
 			}
 		}
 	}
+	slices.SortFunc(communities, func(a, b Community) int {
+		return slices.Compare(a, b)
+	})
 	return communities
 }
 
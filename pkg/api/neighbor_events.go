@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Neighbor event types
+const (
+	NeighborEventUp            = "neighbor_up"
+	NeighborEventDown          = "neighbor_down"
+	NeighborEventRoutesChanged = "routes_changed"
+)
+
+// NeighborRoutesCount are the route counters of
+// a neighbor included in an event.
+type NeighborRoutesCount struct {
+	Received int `json:"received"`
+	Filtered int `json:"filtered"`
+	Accepted int `json:"accepted"`
+}
+
+// NeighborEvent is a change of the state or the route
+// counts of a neighbor detected by a neighbors refresh.
+type NeighborEvent struct {
+	ID            uint64    `json:"id"`
+	Type          string    `json:"type"`
+	Timestamp     time.Time `json:"timestamp"`
+	RouteServerID string    `json:"routeserver_id"`
+
+	NeighborID  string `json:"neighbor_id"`
+	Address     string `json:"address"`
+	ASN         int    `json:"asn"`
+	Description string `json:"description"`
+
+	State       string `json:"state"`
+	StateBefore string `json:"state_before"`
+
+	Routes       NeighborRoutesCount  `json:"routes"`
+	RoutesBefore *NeighborRoutesCount `json:"routes_before,omitempty"`
+}
+
+// NeighborEventsFilter selects the events of
+// a subscription by source and neighbor ASN.
+// Empty lists match all events.
+type NeighborEventsFilter struct {
+	SourceIDs []string
+	ASNs      []int
+}
+
+// NeighborEventsFilterFromQuery decodes the filter from
+// comma separated lists in the sources and asn parameters.
+func NeighborEventsFilterFromQuery(
+	q url.Values,
+) (*NeighborEventsFilter, error) {
+	filter := &NeighborEventsFilter{
+		SourceIDs: splitQueryList(q.Get("sources")),
+	}
+	for _, value := range splitQueryList(q.Get("asn")) {
+		asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(value), "AS"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid asn: %s", value)
+		}
+		filter.ASNs = append(filter.ASNs, asn)
+	}
+	return filter, nil
+}
+
+// Match checks if the event is selected by the filter
+func (f *NeighborEventsFilter) Match(e *NeighborEvent) bool {
+	if len(f.SourceIDs) > 0 && !slices.Contains(f.SourceIDs, e.RouteServerID) {
+		return false
+	}
+	if len(f.ASNs) > 0 && !slices.Contains(f.ASNs, e.ASN) {
+		return false
+	}
+	return true
+}
+
+func splitQueryList(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
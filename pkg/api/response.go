@@ -1,7 +1,9 @@
 package api
 
 import (
+	"net/netip"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,11 @@ type Response struct {
 	Meta *Meta `json:"api"`
 }
 
+// GetMeta returns the meta information of the response
+func (res Response) GetMeta() *Meta {
+	return res.Meta
+}
+
 // ErrorResponse encodes an error message and code
 type ErrorResponse struct {
 	Message       string `json:"message"`
@@ -51,6 +58,16 @@ type ConfigResponse struct {
 	PrefixLookupEnabled bool `json:"prefix_lookup_enabled"`
 }
 
+// ConfigReloadResponse lists the sources changed by
+// reloading the configuration and the sections which
+// are only applied after a restart.
+type ConfigReloadResponse struct {
+	Added           []string `json:"added"`
+	Removed         []string `json:"removed"`
+	Changed         []string `json:"changed"`
+	RestartRequired []string `json:"restart_required"`
+}
+
 // Noexport options
 type Noexport struct {
 	LoadOnDemand bool `json:"load_on_demand"`
@@ -94,6 +111,10 @@ type SourceStatus struct {
 	LastRefresh     time.Time     `json:"last_refresh"`
 	State           string        `json:"state"`
 	Initialized     bool          `json:"initialized"`
+
+	// ErrorSince is set while the refreshes of
+	// the source fail.
+	ErrorSince *time.Time `json:"error_since,omitempty"`
 }
 
 // StoreStatus is meta data for a store
@@ -164,6 +185,10 @@ type LookupRouteServer struct {
 	Name string  `json:"name"`
 }
 
+// CommunityWildcard matches any value of a community
+// component when used in a filter.
+const CommunityWildcard = -1
+
 // Community is a BGP community
 type Community []int
 
@@ -176,6 +201,10 @@ func (com Community) String() string {
 		if i > 0 {
 			s += ":"
 		}
+		if v == CommunityWildcard {
+			s += "*"
+			continue
+		}
 		s += strconv.Itoa(v)
 	}
 	return s
@@ -221,6 +250,10 @@ func (com ExtCommunity) String() string {
 		if i > 0 {
 			res += ":"
 		}
+		if v.(int) == CommunityWildcard {
+			res += "*"
+			continue
+		}
 		res += strconv.Itoa(v.(int))
 	}
 	return res
@@ -261,6 +294,33 @@ type BGPInfo struct {
 	OTC              *int           `json:"otc"`
 }
 
+// NextHopAddr parses the next hop address. Some sources
+// include a link local address after the global next hop,
+// only the first address is considered.
+func (bgp *BGPInfo) NextHopAddr() (netip.Addr, bool) {
+	if bgp.NextHop == nil {
+		return netip.Addr{}, false
+	}
+	fields := strings.Fields(*bgp.NextHop)
+	if len(fields) == 0 {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(fields[0], ","))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// Private matchCommunityValue compares a component of a
+// community with a value, which may be a wildcard.
+func matchCommunityValue(v any, pattern any) bool {
+	if pattern == CommunityWildcard {
+		return true
+	}
+	return v == pattern
+}
+
 // HasCommunity checks for the presence of a BGP community.
 func (bgp *BGPInfo) HasCommunity(community Community) bool {
 	if len(community) != 2 {
@@ -272,8 +332,8 @@ func (bgp *BGPInfo) HasCommunity(community Community) bool {
 			continue // This can't match.
 		}
 
-		if com[0] == community[0] &&
-			com[1] == community[1] {
+		if matchCommunityValue(com[0], community[0]) &&
+			matchCommunityValue(com[1], community[1]) {
 			return true
 		}
 	}
@@ -293,9 +353,9 @@ func (bgp *BGPInfo) HasExtCommunity(community ExtCommunity) bool {
 			continue // This can't match.
 		}
 
-		if com[0] == community[0] &&
-			com[1] == community[1] &&
-			com[2] == community[2] {
+		if (community[0] == "*" || com[0] == community[0]) &&
+			matchCommunityValue(com[1], community[1]) &&
+			matchCommunityValue(com[2], community[2]) {
 			return true
 		}
 	}
@@ -315,9 +375,9 @@ func (bgp *BGPInfo) HasLargeCommunity(community Community) bool {
 			continue // This can't match.
 		}
 
-		if com[0] == community[0] &&
-			com[1] == community[1] &&
-			com[2] == community[2] {
+		if matchCommunityValue(com[0], community[0]) &&
+			matchCommunityValue(com[1], community[1]) &&
+			matchCommunityValue(com[2], community[2]) {
 			return true
 		}
 	}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"slices"
+)
+
+// Kinds of differences of a route between sources
+const (
+	RouteDiffMissing    = "missing"
+	RouteDiffState      = "state"
+	RouteDiffAttributes = "attributes"
+)
+
+// RouteDiffPath is the route of a prefix as seen
+// on a single source.
+type RouteDiffPath struct {
+	NeighborID string   `json:"neighbor_id"`
+	State      string   `json:"state"`
+	BGP        *BGPInfo `json:"bgp"`
+}
+
+// RouteDiff describes how a prefix differs between
+// sources. Paths are keyed by source ID.
+type RouteDiff struct {
+	Network    string                    `json:"network"`
+	Kind       string                    `json:"kind"`
+	MissingOn  []string                  `json:"missing_on,omitempty"`
+	Attributes []string                  `json:"attributes,omitempty"`
+	Paths      map[string]*RouteDiffPath `json:"paths"`
+}
+
+// NeighborRoutesDiff lists the differences of the routes
+// of a neighbor present on multiple sources.
+// Neighbors are identified by their address.
+type NeighborRoutesDiff struct {
+	Address     string `json:"address"`
+	ASN         int    `json:"asn"`
+	Description string `json:"description"`
+
+	// NeighborIDs maps the source ID to the neighbor ID
+	NeighborIDs map[string]string `json:"neighbor_ids"`
+
+	// The neighbor is not present on these sources
+	MissingOn []string `json:"missing_on,omitempty"`
+
+	Routes []*RouteDiff `json:"routes"`
+}
+
+// RoutesDiffResponse is the result of comparing the
+// routes of multiple sources.
+type RoutesDiffResponse struct {
+	Response
+	Sources   []string              `json:"sources"`
+	Neighbors []*NeighborRoutesDiff `json:"neighbors"`
+}
+
+// DiffAttributes compares the BGP attributes and
+// returns the names of the attributes which differ.
+// Communities are compared regardless of their order.
+func (bgp *BGPInfo) DiffAttributes(other *BGPInfo) []string {
+	if bgp == nil || other == nil {
+		if bgp == other {
+			return nil
+		}
+		return []string{"bgp"}
+	}
+
+	diff := []string{}
+	if derefString(bgp.Origin) != derefString(other.Origin) {
+		diff = append(diff, "origin")
+	}
+	if !slices.Equal(bgp.AsPath, other.AsPath) {
+		diff = append(diff, "as_path")
+	}
+	if derefString(bgp.NextHop) != derefString(other.NextHop) {
+		diff = append(diff, "next_hop")
+	}
+	if !equalStringSets(bgp.Communities, other.Communities) {
+		diff = append(diff, "communities")
+	}
+	if !equalStringSets(bgp.LargeCommunities, other.LargeCommunities) {
+		diff = append(diff, "large_communities")
+	}
+	if !equalStringSets(bgp.ExtCommunities, other.ExtCommunities) {
+		diff = append(diff, "ext_communities")
+	}
+	if bgp.LocalPref != other.LocalPref {
+		diff = append(diff, "local_pref")
+	}
+	if bgp.Med != other.Med {
+		diff = append(diff, "med")
+	}
+	if (bgp.OTC == nil) != (other.OTC == nil) ||
+		(bgp.OTC != nil && *bgp.OTC != *other.OTC) {
+		diff = append(diff, "otc")
+	}
+	return diff
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// Private equalStringSets compares two lists by the
+// string representation of their elements.
+func equalStringSets[T interface{ String() string }](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]int, len(a))
+	for _, v := range a {
+		set[v.String()]++
+	}
+	for _, v := range b {
+		k := v.String()
+		if set[k] == 0 {
+			return false
+		}
+		set[k]--
+	}
+	return true
+}
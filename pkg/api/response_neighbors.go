@@ -29,6 +29,9 @@ type Neighbor struct {
 	// Per-channel route counts (dualchannel ipv4, ipv6)
 	RoutesChannels map[string]*RoutesChannel `json:"routes_channels"`
 
+	// Changes of the routes within the retained snapshots
+	RouteChanges *RouteChangesSummary `json:"route_changes,omitempty"`
+
 	// Original response
 	Details map[string]any `json:"details"`
 }
@@ -40,6 +43,13 @@ func (n *Neighbor) String() string {
 	return string(repr)
 }
 
+// IsNeighborStateUp checks if a neighbor state
+// describes an established session.
+func IsNeighborStateUp(state string) bool {
+	state = strings.ToLower(state)
+	return state == "up" || state == "established"
+}
+
 // Neighbors is a collection of neighbors
 type Neighbors []*Neighbor
 
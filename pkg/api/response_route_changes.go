@@ -0,0 +1,56 @@
+package api
+
+import (
+	"time"
+)
+
+// RouteChange describes a prefix which was added, withdrawn
+// or modified between two refreshes.
+type RouteChange struct {
+	Network     string   `json:"network"`
+	StateBefore string   `json:"state_before,omitempty"`
+	StateAfter  string   `json:"state_after,omitempty"`
+	Before      *BGPInfo `json:"before,omitempty"`
+	After       *BGPInfo `json:"after,omitempty"`
+	Attributes  []string `json:"attributes,omitempty"`
+}
+
+// RouteChanges are the changes of the routes of a neighbor
+// between the refreshes at From and To. The changes are not
+// complete if the kept refreshes do not reach back to the
+// requested time.
+type RouteChanges struct {
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"`
+	Complete  bool           `json:"complete"`
+	Added     []*RouteChange `json:"added"`
+	Withdrawn []*RouteChange `json:"withdrawn"`
+	Modified  []*RouteChange `json:"modified"`
+}
+
+// Summary counts the changes
+func (c *RouteChanges) Summary() *RouteChangesSummary {
+	return &RouteChangesSummary{
+		Since:     c.From,
+		Added:     len(c.Added),
+		Withdrawn: len(c.Withdrawn),
+		Modified:  len(c.Modified),
+	}
+}
+
+// RouteChangesSummary is the number of changed routes
+// of a neighbor since a point in time.
+type RouteChangesSummary struct {
+	Since     time.Time `json:"since"`
+	Added     int       `json:"added"`
+	Withdrawn int       `json:"withdrawn"`
+	Modified  int       `json:"modified"`
+}
+
+// RouteChangesResponse is the response for the
+// route changes of a neighbor.
+type RouteChangesResponse struct {
+	Response
+	RouteChanges
+	NeighborID string `json:"neighbor_id"`
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"log"
+	"net/netip"
 	"time"
 )
 
@@ -65,6 +66,57 @@ func (r *Route) MatchLargeCommunity(community Community) bool {
 	return r.BGP.HasLargeCommunity(community)
 }
 
+// MatchPrefixLength checks if the length of the
+// network is within the range.
+func (r *Route) MatchPrefixLength(rng IntRange) bool {
+	length := PrefixLength(r.Network)
+	if length < 0 {
+		return false
+	}
+	return searchFilterCmpIntInRange(length, rng)
+}
+
+// MatchLocalPref checks the local pref of the route
+func (r *Route) MatchLocalPref(rng IntRange) bool {
+	if r.BGP == nil {
+		return false
+	}
+	return searchFilterCmpIntInRange(r.BGP.LocalPref, rng)
+}
+
+// MatchMED checks the multi exit discriminator of the route
+func (r *Route) MatchMED(rng IntRange) bool {
+	if r.BGP == nil {
+		return false
+	}
+	return searchFilterCmpIntInRange(r.BGP.Med, rng)
+}
+
+// MatchAge checks if the age of the route in seconds
+// is within the range.
+func (r *Route) MatchAge(rng IntRange) bool {
+	return searchFilterCmpIntInRange(int(r.Age.Seconds()), rng)
+}
+
+// MatchOTC checks for the presence (or absence) of
+// the only to customer attribute.
+func (r *Route) MatchOTC(present bool) bool {
+	hasOTC := r.BGP != nil && r.BGP.OTC != nil
+	return hasOTC == present
+}
+
+// MatchNextHop checks if the next hop is within the prefix
+func (r *Route) MatchNextHop(prefix netip.Prefix) bool {
+	if r.BGP == nil {
+		return false
+	}
+	nextHop, ok := r.BGP.NextHopAddr()
+	if !ok {
+		return false
+	}
+	return prefix.Contains(nextHop)
+}
+
 // Routes is a collection of routes
 type Routes []*Route
 
@@ -159,6 +211,9 @@ const (
 	// RouteStateImported indicates that the route was
 	// imported by the route server.
 	RouteStateImported = "imported"
+	// RouteStateNotExported indicates that the route
+	// was not exported to the neighbor.
+	RouteStateNotExported = "not_exported"
 )
 
 // NeighborQuery is used in finding routes by neighbors.
@@ -209,6 +264,36 @@ func (r *LookupRoute) MatchAddrFamily(family uint8) bool {
 	return r.Route.MatchAddrFamily(family)
 }
 
+// MatchPrefixLength matches the length of the network.
+func (r *LookupRoute) MatchPrefixLength(rng IntRange) bool {
+	return r.Route.MatchPrefixLength(rng)
+}
+
+// MatchLocalPref matches the local pref.
+func (r *LookupRoute) MatchLocalPref(rng IntRange) bool {
+	return r.Route.MatchLocalPref(rng)
+}
+
+// MatchMED matches the multi exit discriminator.
+func (r *LookupRoute) MatchMED(rng IntRange) bool {
+	return r.Route.MatchMED(rng)
+}
+
+// MatchAge matches the route age in seconds.
+func (r *LookupRoute) MatchAge(rng IntRange) bool {
+	return r.Route.MatchAge(rng)
+}
+
+// MatchOTC matches the presence of the otc attribute.
+func (r *LookupRoute) MatchOTC(present bool) bool {
+	return r.Route.MatchOTC(present)
+}
+
+// MatchNextHop matches the next hop.
+func (r *LookupRoute) MatchNextHop(prefix netip.Prefix) bool {
+	return r.Route.MatchNextHop(prefix)
+}
+
 // MatchNeighborQuery matches a neighbor query
 func (r *LookupRoute) MatchNeighborQuery(query *NeighborQuery) bool {
 	if r.RouteServer.ID != query.SourceID {
@@ -272,6 +357,33 @@ type PaginatedRoutesResponse struct {
 	RoutesResponse
 }
 
+// CommunityLabel is a community of a route with the
+// label or the filter reason from the configuration.
+type CommunityLabel struct {
+	Community string `json:"community"`
+	Label     string `json:"label"`
+}
+
+// RoutePath is a path of a prefix with the details
+// reported by the source.
+type RoutePath struct {
+	*Route
+	State string `json:"state"` // imported, filtered or not_exported
+
+	CommunityLabels []*CommunityLabel `json:"community_labels"`
+	FilterReasons   []*CommunityLabel `json:"filter_reasons"`
+}
+
+// RouteDetailsResponse has all paths of a prefix
+// learned from or not exported to a neighbor.
+type RouteDetailsResponse struct {
+	Response
+	TimedResponse
+	Network    string       `json:"network"`
+	NeighborID string       `json:"neighbor_id"`
+	Paths      []*RoutePath `json:"paths"`
+}
+
 // A PaginatedRoutesLookupResponse TODO
 type PaginatedRoutesLookupResponse struct {
 	Response
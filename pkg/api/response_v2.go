@@ -0,0 +1,51 @@
+package api
+
+// CursorPagination is the pagination of the v2 API.
+// The next page is requested with the opaque next cursor.
+// The generation identifies the state of the cache or
+// store the results were taken from.
+type CursorPagination struct {
+	Sort         string `json:"sort"`
+	Limit        int    `json:"limit"`
+	TotalResults int    `json:"total_results"`
+	Generation   string `json:"generation"`
+
+	// GenerationChanged is set when the data was refreshed
+	// after the cursor was issued. Routes added or removed
+	// by the refresh may be missing from the pages.
+	GenerationChanged bool `json:"generation_changed"`
+
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// RoutesPage is a page of the routes of a neighbor
+type RoutesPage struct {
+	Response
+	TimedResponse
+	FilteredResponse
+	Routes     Routes           `json:"routes"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+// LookupRoutesPage is a page of the routes of a lookup
+type LookupRoutesPage struct {
+	Response
+	TimedResponse
+	FilteredResponse
+	Routes     LookupRoutes     `json:"routes"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+// ErrorDetails describe an error of the v2 API
+type ErrorDetails struct {
+	Code          string `json:"code"`
+	Status        int    `json:"status"`
+	Message       string `json:"message"`
+	Param         string `json:"param,omitempty"`
+	RouteServerID string `json:"routeserver_id,omitempty"`
+}
+
+// ErrorEnvelope wraps all errors of the v2 API
+type ErrorEnvelope struct {
+	Error *ErrorDetails `json:"error"`
+}
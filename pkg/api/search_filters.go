@@ -3,6 +3,7 @@ package api
 import (
 	"fmt"
 	"log"
+	"net/netip"
 	"net/url"
 	"strconv"
 	"strings"
@@ -16,6 +17,13 @@ const (
 	SearchKeyExtCommunities   = "ext_communities"
 	SearchKeyLargeCommunities = "large_communities"
 	SearchKeyAddrFamily       = "addr_family"
+	SearchKeyPrefixLength     = "prefix_length"
+	SearchKeyLocalPref        = "local_pref"
+	SearchKeyMED              = "med"
+	SearchKeyAge              = "age"
+	SearchKeyOTC              = "otc"
+	SearchKeyNextHop          = "next_hop"
+	SearchKeyQuery            = "query"
 )
 
 // Filterable objects provide methods for matching
@@ -27,6 +35,12 @@ type Filterable interface {
 	MatchExtCommunity(community ExtCommunity) bool
 	MatchLargeCommunity(community Community) bool
 	MatchAddrFamily(family uint8) bool
+	MatchPrefixLength(r IntRange) bool
+	MatchLocalPref(r IntRange) bool
+	MatchMED(r IntRange) bool
+	MatchAge(r IntRange) bool
+	MatchOTC(present bool) bool
+	MatchNextHop(prefix netip.Prefix) bool
 }
 
 // FilterValue can be anything
@@ -126,6 +140,14 @@ func (f *SearchFilter) Equal(other *SearchFilter) bool {
 		cmp = searchFilterCmpString
 	case *string:
 		cmp = searchFilterCmpString
+	case IntRange:
+		cmp = searchFilterCmpIntRange
+	case netip.Prefix:
+		cmp = searchFilterCmpPrefix
+	case bool:
+		cmp = searchFilterCmpBool
+	case *SearchQuery:
+		cmp = searchFilterCmpQuery
 	}
 
 	if cmp == nil {
@@ -177,6 +199,14 @@ func filterValueAsString(value any) string {
 		return v.String()
 	case ExtCommunity:
 		return v.String()
+	case IntRange:
+		return v.String()
+	case netip.Prefix:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case *SearchQuery:
+		return v.String()
 	}
 	panic("unexpected filter value: " + fmt.Sprintf("%v", value))
 }
@@ -278,6 +308,54 @@ func searchFilterMatchAddrFamily(route Filterable, value any) bool {
 	return route.MatchAddrFamily(uint8(family))
 }
 
+func searchFilterMatchPrefixLength(route Filterable, value any) bool {
+	r, ok := value.(IntRange)
+	if !ok {
+		return false
+	}
+	return route.MatchPrefixLength(r)
+}
+
+func searchFilterMatchLocalPref(route Filterable, value any) bool {
+	r, ok := value.(IntRange)
+	if !ok {
+		return false
+	}
+	return route.MatchLocalPref(r)
+}
+
+func searchFilterMatchMED(route Filterable, value any) bool {
+	r, ok := value.(IntRange)
+	if !ok {
+		return false
+	}
+	return route.MatchMED(r)
+}
+
+func searchFilterMatchAge(route Filterable, value any) bool {
+	r, ok := value.(IntRange)
+	if !ok {
+		return false
+	}
+	return route.MatchAge(r)
+}
+
+func searchFilterMatchOTC(route Filterable, value any) bool {
+	present, ok := value.(bool)
+	if !ok {
+		return false
+	}
+	return route.MatchOTC(present)
+}
+
+func searchFilterMatchNextHop(route Filterable, value any) bool {
+	prefix, ok := value.(netip.Prefix)
+	if !ok {
+		return false
+	}
+	return route.MatchNextHop(prefix)
+}
+
 func selectCmpFuncByKey(key string) SearchFilterComparator {
 	var cmp SearchFilterComparator
 	switch key {
@@ -293,6 +371,20 @@ func selectCmpFuncByKey(key string) SearchFilterComparator {
 		cmp = searchFilterMatchLargeCommunity
 	case SearchKeyAddrFamily:
 		cmp = searchFilterMatchAddrFamily
+	case SearchKeyPrefixLength:
+		cmp = searchFilterMatchPrefixLength
+	case SearchKeyLocalPref:
+		cmp = searchFilterMatchLocalPref
+	case SearchKeyMED:
+		cmp = searchFilterMatchMED
+	case SearchKeyAge:
+		cmp = searchFilterMatchAge
+	case SearchKeyOTC:
+		cmp = searchFilterMatchOTC
+	case SearchKeyNextHop:
+		cmp = searchFilterMatchNextHop
+	case SearchKeyQuery:
+		cmp = searchFilterMatchQuery
 	default:
 		cmp = nil
 	}
@@ -386,6 +478,41 @@ func NewSearchFilters() *SearchFilters {
 			Filters:    []*SearchFilter{},
 			filtersIdx: make(map[string]int),
 		},
+		&SearchFilterGroup{
+			Key:        SearchKeyPrefixLength,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
+		&SearchFilterGroup{
+			Key:        SearchKeyLocalPref,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
+		&SearchFilterGroup{
+			Key:        SearchKeyMED,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
+		&SearchFilterGroup{
+			Key:        SearchKeyAge,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
+		&SearchFilterGroup{
+			Key:        SearchKeyOTC,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
+		&SearchFilterGroup{
+			Key:        SearchKeyNextHop,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
+		&SearchFilterGroup{
+			Key:        SearchKeyQuery,
+			Filters:    []*SearchFilter{},
+			filtersIdx: make(map[string]int),
+		},
 	}
 
 	return groups
@@ -409,6 +536,20 @@ func (s *SearchFilters) GetGroupByKey(key string) *SearchFilterGroup {
 		return (*s)[4]
 	case SearchKeyAddrFamily:
 		return (*s)[5]
+	case SearchKeyPrefixLength:
+		return (*s)[6]
+	case SearchKeyLocalPref:
+		return (*s)[7]
+	case SearchKeyMED:
+		return (*s)[8]
+	case SearchKeyAge:
+		return (*s)[9]
+	case SearchKeyOTC:
+		return (*s)[10]
+	case SearchKeyNextHop:
+		return (*s)[11]
+	case SearchKeyQuery:
+		return (*s)[12]
 	}
 	return nil
 }
@@ -457,6 +598,50 @@ func (s *SearchFilters) UpdateCommunitiesFromLookupRoute(r *LookupRoute) {
 	}
 }
 
+// UpdateAttributesFromRoute updates the filters for
+// the route attributes: prefix length, local pref, MED,
+// age, OTC presence and next hop.
+func (s *SearchFilters) UpdateAttributesFromRoute(r *Route) {
+	if length := PrefixLength(r.Network); length >= 0 {
+		s.GetGroupByKey(SearchKeyPrefixLength).AddFilter(&SearchFilter{
+			Name:  "/" + strconv.Itoa(length),
+			Value: NewIntRange(length, length),
+		})
+	}
+
+	ageName, ageRange := routeAgeFacet(int(r.Age.Seconds()))
+	s.GetGroupByKey(SearchKeyAge).AddFilter(&SearchFilter{
+		Name:  ageName,
+		Value: ageRange,
+	})
+
+	if r.BGP == nil {
+		return
+	}
+
+	s.GetGroupByKey(SearchKeyLocalPref).AddFilter(&SearchFilter{
+		Name:  strconv.Itoa(r.BGP.LocalPref),
+		Value: NewIntRange(r.BGP.LocalPref, r.BGP.LocalPref),
+	})
+	s.GetGroupByKey(SearchKeyMED).AddFilter(&SearchFilter{
+		Name:  strconv.Itoa(r.BGP.Med),
+		Value: NewIntRange(r.BGP.Med, r.BGP.Med),
+	})
+
+	hasOTC := r.BGP.OTC != nil
+	s.GetGroupByKey(SearchKeyOTC).AddFilter(&SearchFilter{
+		Name:  otcFacetName(hasOTC),
+		Value: hasOTC,
+	})
+
+	if nextHop, ok := r.BGP.NextHopAddr(); ok {
+		s.GetGroupByKey(SearchKeyNextHop).AddFilter(&SearchFilter{
+			Name:  nextHop.String(),
+			Value: netip.PrefixFrom(nextHop, nextHop.BitLen()),
+		})
+	}
+}
+
 // UpdateFromLookupRoute updates a filter
 // and its counters.
 //
@@ -467,13 +652,15 @@ func (s *SearchFilters) UpdateFromLookupRoute(r *LookupRoute) {
 	s.UpdateSourcesFromLookupRoute(r)
 	s.UpdateASNSFromLookupRoute(r)
 	s.UpdateCommunitiesFromLookupRoute(r)
+	s.UpdateAttributesFromRoute(r.Route)
 }
 
 // UpdateFromRoute updates a search filter, however as
 // information of the route server or neighbor is not
 // present, as this is not a lookup route, only
-// communities are considered.
+// communities and route attributes are considered.
 func (s *SearchFilters) UpdateFromRoute(r *Route) {
+	s.UpdateAttributesFromRoute(r)
 
 	// Add communities
 	communities := s.GetGroupByKey(SearchKeyCommunities)
@@ -524,6 +711,23 @@ func (s *SearchFilters) addFilterAddrFamily(af uint8) {
 	})
 }
 
+// FilterError is returned by FiltersFromQuery
+// for an invalid value of a query parameter.
+type FilterError struct {
+	Key string
+	Err error
+}
+
+// Error implements the error interface
+func (e *FilterError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the parser
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
 // FiltersFromQuery builds a filter struct from
 // query parameters.
 //
@@ -531,6 +735,14 @@ func (s *SearchFilters) addFilterAddrFamily(af uint8) {
 //
 //	asns=2342,23123&communities=23:42&large_communities=23:42:42
 //
+// Numeric route attributes (prefix_length, local_pref, med
+// and age in seconds) accept ranges like `25-32`, `>0` or `<=24`,
+// next_hop accepts an address or a CIDR and otc is either
+// true or false.
+//
+// A boolean search query can be passed as query,
+// see ParseSearchQuery for the syntax.
+//
 // yields a filtering struct of
 //
 //	Groups[
@@ -547,35 +759,35 @@ func FiltersFromQuery(query url.Values) (*SearchFilters, error) {
 		case SearchKeySources:
 			filters, err := parseQueryValueList(parseStringValue, value)
 			if err != nil {
-				return nil, err
+				return nil, &FilterError{Key: key, Err: err}
 			}
 			queryFilters.GetGroupByKey(SearchKeySources).AddFilters(filters)
 
 		case SearchKeyASNS:
 			filters, err := parseQueryValueList(parseIntValue, value)
 			if err != nil {
-				return nil, err
+				return nil, &FilterError{Key: key, Err: err}
 			}
 			queryFilters.GetGroupByKey(SearchKeyASNS).AddFilters(filters)
 
 		case SearchKeyCommunities:
 			filters, err := parseQueryValueList(parseCommunityValue, value)
 			if err != nil {
-				return nil, err
+				return nil, &FilterError{Key: key, Err: err}
 			}
 			queryFilters.GetGroupByKey(SearchKeyCommunities).AddFilters(filters)
 
 		case SearchKeyExtCommunities:
 			filters, err := parseQueryValueList(parseExtCommunityValue, value)
 			if err != nil {
-				return nil, err
+				return nil, &FilterError{Key: key, Err: err}
 			}
 			queryFilters.GetGroupByKey(SearchKeyExtCommunities).AddFilters(filters)
 
 		case SearchKeyLargeCommunities:
 			filters, err := parseQueryValueList(parseCommunityValue, value)
 			if err != nil {
-				return nil, err
+				return nil, &FilterError{Key: key, Err: err}
 			}
 			queryFilters.GetGroupByKey(SearchKeyLargeCommunities).AddFilters(filters)
 
@@ -583,9 +795,39 @@ func FiltersFromQuery(query url.Values) (*SearchFilters, error) {
 			// Parse as int values for address family
 			filters, err := parseQueryValueList(parseIntValue, value)
 			if err != nil {
-				return nil, err
+				return nil, &FilterError{Key: key, Err: err}
 			}
 			queryFilters.GetGroupByKey(SearchKeyAddrFamily).AddFilters(filters)
+
+		case SearchKeyPrefixLength, SearchKeyLocalPref, SearchKeyMED, SearchKeyAge:
+			// Numeric attributes can be queried by ranges
+			filters, err := parseQueryValueList(parseIntRangeValue, value)
+			if err != nil {
+				return nil, &FilterError{Key: key, Err: err}
+			}
+			queryFilters.GetGroupByKey(key).AddFilters(filters)
+
+		case SearchKeyOTC:
+			filters, err := parseQueryValueList(parseOTCValue, value)
+			if err != nil {
+				return nil, &FilterError{Key: key, Err: err}
+			}
+			queryFilters.GetGroupByKey(SearchKeyOTC).AddFilters(filters)
+
+		case SearchKeyNextHop:
+			filters, err := parseQueryValueList(parseNextHopValue, value)
+			if err != nil {
+				return nil, &FilterError{Key: key, Err: err}
+			}
+			queryFilters.GetGroupByKey(SearchKeyNextHop).AddFilters(filters)
+
+		case SearchKeyQuery:
+			// The query is not a list of values
+			filter, err := parseSearchQueryValue(value)
+			if err != nil {
+				return nil, &FilterError{Key: key, Err: err}
+			}
+			queryFilters.GetGroupByKey(SearchKeyQuery).AddFilter(filter)
 		}
 	}
 	return queryFilters, nil
@@ -677,6 +919,27 @@ func (s *SearchFilters) MatchRoute(r Filterable) bool {
 		return false
 	}
 
+	// Route attributes: Any value within a group
+	// has to match.
+	for _, key := range []string{
+		SearchKeyPrefixLength,
+		SearchKeyLocalPref,
+		SearchKeyMED,
+		SearchKeyAge,
+		SearchKeyOTC,
+		SearchKeyNextHop,
+	} {
+		if !s.GetGroupByKey(key).MatchAny(r) {
+			return false
+		}
+	}
+
+	// Search queries
+	queries := s.GetGroupByKey(SearchKeyQuery)
+	if !queries.MatchAll(r) {
+		return false
+	}
+
 	return true
 }
 
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// IntRange is a closed interval of integers used for
+// filtering numeric route attributes like the prefix
+// length, local pref or MED. Open ends are represented
+// by math.MinInt and math.MaxInt.
+type IntRange struct {
+	Min int
+	Max int
+}
+
+// NewIntRange creates a new range including min and max.
+func NewIntRange(min, max int) IntRange {
+	return IntRange{Min: min, Max: max}
+}
+
+// Contains checks if a value is within the range
+func (r IntRange) Contains(v int) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// String encodes the range in the same notation
+// which is accepted by ParseIntRange.
+func (r IntRange) String() string {
+	switch {
+	case r.Min == math.MinInt && r.Max == math.MaxInt:
+		return "*"
+	case r.Min == r.Max:
+		return strconv.Itoa(r.Min)
+	case r.Min == math.MinInt:
+		return "<=" + strconv.Itoa(r.Max)
+	case r.Max == math.MaxInt:
+		return ">=" + strconv.Itoa(r.Min)
+	}
+	return strconv.Itoa(r.Min) + "-" + strconv.Itoa(r.Max)
+}
+
+// MarshalJSON encodes the range as string
+func (r IntRange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// ParseIntRange decodes a range from a string.
+// Supported are exact values (`24`), intervals (`25-32`),
+// comparisons (`>0`, `>=25`, `<100`, `<=24`) and
+// the wildcard `*`.
+func ParseIntRange(s string) (IntRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return IntRange{}, ErrInvalidRange
+	}
+	if s == "*" {
+		return NewIntRange(math.MinInt, math.MaxInt), nil
+	}
+
+	// Comparisons
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		val, ok := strings.CutPrefix(s, op)
+		if !ok {
+			continue
+		}
+		v, err := strconv.Atoi(strings.TrimSpace(val))
+		if err != nil {
+			return IntRange{}, ErrInvalidRange
+		}
+		switch op {
+		case ">=":
+			return NewIntRange(v, math.MaxInt), nil
+		case ">":
+			if v == math.MaxInt {
+				return IntRange{}, ErrInvalidRange // No value is greater
+			}
+			return NewIntRange(v+1, math.MaxInt), nil
+		case "<=":
+			return NewIntRange(math.MinInt, v), nil
+		default: // <
+			if v == math.MinInt {
+				return IntRange{}, ErrInvalidRange // No value is less
+			}
+			return NewIntRange(math.MinInt, v-1), nil
+		}
+	}
+
+	// Intervals
+	if lower, upper, ok := strings.Cut(s, "-"); ok {
+		min, err := strconv.Atoi(strings.TrimSpace(lower))
+		if err != nil {
+			return IntRange{}, ErrInvalidRange
+		}
+		max, err := strconv.Atoi(strings.TrimSpace(upper))
+		if err != nil {
+			return IntRange{}, ErrInvalidRange
+		}
+		if min > max {
+			return IntRange{}, ErrInvalidRange
+		}
+		return NewIntRange(min, max), nil
+	}
+
+	// Exact value
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return IntRange{}, ErrInvalidRange
+	}
+	return NewIntRange(v, v), nil
+}
+
+// ParseNextHopPrefix decodes a next hop filter. This can
+// either be a prefix in CIDR notation or a single address.
+func ParseNextHopPrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, ErrInvalidNextHop
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, ErrInvalidNextHop
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Compare int ranges
+func searchFilterCmpIntRange(a FilterValue, b FilterValue) bool {
+	return a.(IntRange) == b.(IntRange)
+}
+
+// searchFilterCmpIntInRange is a numeric comparison
+// checking if the value a is within the range b.
+func searchFilterCmpIntInRange(a FilterValue, b FilterValue) bool {
+	v, ok := a.(int)
+	if !ok {
+		return false
+	}
+	r, ok := b.(IntRange)
+	if !ok {
+		return false
+	}
+	return r.Contains(v)
+}
+
+// Compare prefixes
+func searchFilterCmpPrefix(a FilterValue, b FilterValue) bool {
+	return a.(netip.Prefix) == b.(netip.Prefix)
+}
+
+// Compare booleans
+func searchFilterCmpBool(a FilterValue, b FilterValue) bool {
+	return a.(bool) == b.(bool)
+}
+
+// Route ages are grouped into buckets for
+// the available filters.
+var routeAgeFacets = []struct {
+	name string
+	rng  IntRange
+}{
+	{"< 1h", NewIntRange(0, 3599)},
+	{"1h - 1d", NewIntRange(3600, 86399)},
+	{"1d - 7d", NewIntRange(86400, 604799)},
+	{"> 7d", NewIntRange(604800, math.MaxInt)},
+}
+
+// routeAgeFacet selects the bucket for a route age
+// in seconds.
+func routeAgeFacet(age int) (string, IntRange) {
+	for _, f := range routeAgeFacets {
+		if f.rng.Contains(age) {
+			return f.name, f.rng
+		}
+	}
+	return routeAgeFacets[0].name, routeAgeFacets[0].rng
+}
+
+// PrefixLength returns the number of bits in the prefix
+// or -1 if the network can not be parsed.
+func PrefixLength(network string) int {
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return -1
+	}
+	return prefix.Bits()
+}
+
+func otcFacetName(present bool) string {
+	if present {
+		return "present"
+	}
+	return "absent"
+}
@@ -9,6 +9,9 @@ import (
 // Errors
 var (
 	ErrExtCommunityIncomplete = errors.New("incomplete extended community")
+	ErrInvalidRange           = errors.New("invalid range")
+	ErrInvalidNextHop         = errors.New("invalid next hop prefix")
+	ErrInvalidOTC             = errors.New("invalid otc filter, use true or false")
 )
 
 // FilterQueryParser parses a filter value into a search filter
@@ -86,3 +89,39 @@ func parseExtCommunityValue(value string) (*SearchFilter, error) {
 		Value: community,
 	}, nil
 }
+
+// parseIntRangeValue parses a range query value
+func parseIntRangeValue(value string) (*SearchFilter, error) {
+	r, err := ParseIntRange(value)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchFilter{
+		Name:  r.String(),
+		Value: r,
+	}, nil
+}
+
+// parseNextHopValue parses a next hop query value
+func parseNextHopValue(value string) (*SearchFilter, error) {
+	prefix, err := ParseNextHopPrefix(value)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchFilter{
+		Name:  prefix.String(),
+		Value: prefix,
+	}, nil
+}
+
+// parseOTCValue parses the otc presence query value
+func parseOTCValue(value string) (*SearchFilter, error) {
+	present, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, ErrInvalidOTC
+	}
+	return &SearchFilter{
+		Name:  otcFacetName(present),
+		Value: present,
+	}, nil
+}
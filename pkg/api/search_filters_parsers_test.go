@@ -1,6 +1,8 @@
 package api
 
 import (
+	"math"
+	"strconv"
 	"testing"
 )
 
@@ -81,3 +83,58 @@ func TestPartialParseExtCommunityValue(t *testing.T) {
 		t.Error("Expected error, result:", filter)
 	}
 }
+
+func TestParseIntRange(t *testing.T) {
+	tests := map[string]string{
+		"24":    "24",
+		"25-32": "25-32",
+		">0":    ">=1",
+		">=25":  ">=25",
+		"<100":  "<=99",
+		"<=24":  "<=24",
+		"*":     "*",
+	}
+	for input, expected := range tests {
+		r, err := ParseIntRange(input)
+		if err != nil {
+			t.Error(input, err)
+			continue
+		}
+		if r.String() != expected {
+			t.Error("expected", expected, "for", input, "got:", r.String())
+		}
+	}
+
+	// Comparisons beyond the integer bounds
+	overflows := []string{
+		">" + strconv.Itoa(math.MaxInt),
+		"<" + strconv.Itoa(math.MinInt),
+	}
+	for _, input := range append(overflows, "", "a", "32-25", ">x", "1-b") {
+		if _, err := ParseIntRange(input); err == nil {
+			t.Error("expected error for:", input)
+		}
+	}
+}
+
+func TestParseNextHopValue(t *testing.T) {
+	f, err := parseNextHopValue("193.42.155.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "193.42.155.0/24" {
+		t.Error("unexpected name:", f.Name)
+	}
+
+	f, err = parseNextHopValue("2001:db8::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name != "2001:db8::1/128" {
+		t.Error("unexpected name:", f.Name)
+	}
+
+	if _, err := parseNextHopValue("foo"); err == nil {
+		t.Error("expected error for invalid next hop")
+	}
+}
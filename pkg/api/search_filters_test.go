@@ -3,6 +3,7 @@ package api
 import (
 	"net/url"
 	"testing"
+	"time"
 )
 
 var (
@@ -789,3 +790,95 @@ func TestFiltersFromTokensInvalid(t *testing.T) {
 	}
 	t.Log(err)
 }
+
+func TestSearchFilterRouteAttributes(t *testing.T) {
+	nextHop := "193.42.155.9"
+	otc := 23042
+	route := makeTestLookupRoute()
+	route.Route.Network = "193.200.230.0/25"
+	route.Route.Age = 2 * time.Hour
+	route.Route.BGP.LocalPref = 100
+	route.Route.BGP.Med = 10
+	route.Route.BGP.NextHop = &nextHop
+	route.Route.BGP.OTC = &otc
+
+	tests := map[string]bool{
+		"prefix_length=25-32":               true,
+		"prefix_length=<=24":                false,
+		"prefix_length=24,25":               true,
+		"local_pref=100":                    true,
+		"local_pref=>100":                   false,
+		"med=>0":                            true,
+		"med=0":                             false,
+		"age=>=3600":                        true,
+		"age=<3600":                         false,
+		"otc=true":                          true,
+		"otc=false":                         false,
+		"next_hop=193.42.155.0/24":          true,
+		"next_hop=193.42.155.9":             true,
+		"next_hop=10.0.0.0/8":               false,
+		"med=>0&prefix_length=25":           true,
+		"med=>0&prefix_length=26-32":        false,
+		"next_hop=193.42.0.0/16&otc=1":      true,
+		"local_pref=90-110&med=<=10":        true,
+		"local_pref=90-110&med=<10":         false,
+		"next_hop=2001:db8::/32,10.0.0.0/8": false,
+	}
+
+	for query, expected := range tests {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filters, err := FiltersFromQuery(values)
+		if err != nil {
+			t.Error(query, err)
+			continue
+		}
+		if filters.MatchRoute(route) != expected {
+			t.Error("expected", query, "to match:", expected)
+		}
+	}
+}
+
+func TestSearchFiltersUpdateAttributesFromRoute(t *testing.T) {
+	nextHop := "193.42.155.9"
+	filters := NewSearchFilters()
+	for _, network := range []string{"10.0.0.0/24", "10.0.1.0/24", "10.1.0.0/16"} {
+		route := makeTestRoute()
+		route.Network = network
+		route.BGP.NextHop = &nextHop
+		route.BGP.LocalPref = 100
+		filters.UpdateFromRoute(route)
+	}
+
+	lengths := filters.GetGroupByKey(SearchKeyPrefixLength)
+	if len(lengths.Filters) != 2 {
+		t.Fatal("expected 2 prefix length filters, got:", lengths.Filters)
+	}
+	f := lengths.GetFilterByValue(NewIntRange(24, 24))
+	if f == nil || f.Cardinality != 2 || f.Name != "/24" {
+		t.Error("unexpected prefix length filter:", f)
+	}
+
+	otc := filters.GetGroupByKey(SearchKeyOTC).GetFilterByValue(false)
+	if otc == nil || otc.Cardinality != 3 {
+		t.Error("unexpected otc filter:", otc)
+	}
+
+	nh := filters.GetGroupByKey(SearchKeyNextHop).Filters
+	if len(nh) != 1 || nh[0].Name != "193.42.155.9" {
+		t.Error("unexpected next hop filters:", nh)
+	}
+
+	// Applied filters should be removed from the available
+	values, _ := url.ParseQuery("prefix_length=24")
+	applied, err := FiltersFromQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	available := filters.Sub(applied)
+	if len(available.GetGroupByKey(SearchKeyPrefixLength).Filters) != 1 {
+		t.Error("applied prefix length filter should not be available")
+	}
+}
@@ -0,0 +1,491 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var (
+	// ErrQuerySyntax is returned when a search query
+	// can not be parsed.
+	ErrQuerySyntax = errors.New("invalid search query")
+)
+
+// Search query operators
+const (
+	SearchQueryOpAnd = "AND"
+	SearchQueryOpOr  = "OR"
+	SearchQueryOpNot = "NOT"
+)
+
+// SearchQueryExpr is a node in the search query AST.
+type SearchQueryExpr interface {
+	Match(route Filterable) bool
+	String() string
+}
+
+// SearchQueryAnd matches if all expressions match.
+type SearchQueryAnd struct {
+	Exprs []SearchQueryExpr
+}
+
+// Match implements the SearchQueryExpr interface
+func (q *SearchQueryAnd) Match(route Filterable) bool {
+	for _, e := range q.Exprs {
+		if !e.Match(route) {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *SearchQueryAnd) String() string {
+	return joinSearchQueryExprs(q.Exprs, SearchQueryOpAnd)
+}
+
+// SearchQueryOr matches if any expression matches.
+type SearchQueryOr struct {
+	Exprs []SearchQueryExpr
+}
+
+// Match implements the SearchQueryExpr interface
+func (q *SearchQueryOr) Match(route Filterable) bool {
+	for _, e := range q.Exprs {
+		if e.Match(route) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *SearchQueryOr) String() string {
+	return joinSearchQueryExprs(q.Exprs, SearchQueryOpOr)
+}
+
+// SearchQueryNot negates an expression.
+type SearchQueryNot struct {
+	Expr SearchQueryExpr
+}
+
+// Match implements the SearchQueryExpr interface
+func (q *SearchQueryNot) Match(route Filterable) bool {
+	return !q.Expr.Match(route)
+}
+
+func (q *SearchQueryNot) String() string {
+	return SearchQueryOpNot + " " + q.Expr.String()
+}
+
+// SearchQueryTerm is a single predicate, like
+// `community:65000:1`. The key is a search filter key
+// and the value is compared using the same comparator
+// as the search filters.
+type SearchQueryTerm struct {
+	Key   string
+	Value FilterValue
+}
+
+// Match implements the SearchQueryExpr interface
+func (q *SearchQueryTerm) Match(route Filterable) bool {
+	cmp := selectCmpFuncByKey(q.Key)
+	if cmp == nil {
+		return false
+	}
+	return cmp(route, q.Value)
+}
+
+func (q *SearchQueryTerm) String() string {
+	name, ok := searchQueryTermNames[q.Key]
+	if !ok {
+		name = q.Key
+	}
+	return name + ":" + filterValueAsString(q.Value)
+}
+
+// Private joinSearchQueryExprs renders a list of
+// expressions with parentheses.
+func joinSearchQueryExprs(exprs []SearchQueryExpr, op string) string {
+	parts := make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		parts = append(parts, e.String())
+	}
+	return "(" + strings.Join(parts, " "+op+" ") + ")"
+}
+
+// SearchQuery is a parsed boolean search query.
+type SearchQuery struct {
+	Expr SearchQueryExpr
+}
+
+// Match checks if the route matches the query.
+func (q *SearchQuery) Match(route Filterable) bool {
+	return q.Expr.Match(route)
+}
+
+func (q *SearchQuery) String() string {
+	return q.Expr.String()
+}
+
+// HasTerm checks if the query contains a term
+// with one of the keys.
+func (q *SearchQuery) HasTerm(keys ...string) bool {
+	return searchQueryHasTerm(q.Expr, keys)
+}
+
+// Private searchQueryHasTerm walks the expression
+// and checks the keys of the terms.
+func searchQueryHasTerm(expr SearchQueryExpr, keys []string) bool {
+	switch e := expr.(type) {
+	case *SearchQueryAnd:
+		for _, x := range e.Exprs {
+			if searchQueryHasTerm(x, keys) {
+				return true
+			}
+		}
+	case *SearchQueryOr:
+		for _, x := range e.Exprs {
+			if searchQueryHasTerm(x, keys) {
+				return true
+			}
+		}
+	case *SearchQueryNot:
+		return searchQueryHasTerm(e.Expr, keys)
+	case *SearchQueryTerm:
+		return slices.Contains(keys, e.Key)
+	}
+	return false
+}
+
+// MarshalJSON encodes the query as string
+func (q *SearchQuery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.String())
+}
+
+// The term names used in the query language
+// and the search filter keys they map to.
+var searchQueryTermKeys = map[string]string{
+	"source":          SearchKeySources,
+	"asn":             SearchKeyASNS,
+	"community":       SearchKeyCommunities,
+	"ext":             SearchKeyExtCommunities,
+	"ext_community":   SearchKeyExtCommunities,
+	"large":           SearchKeyLargeCommunities,
+	"large_community": SearchKeyLargeCommunities,
+	"family":          SearchKeyAddrFamily,
+	"prefix_length":   SearchKeyPrefixLength,
+	"local_pref":      SearchKeyLocalPref,
+	"med":             SearchKeyMED,
+	"age":             SearchKeyAge,
+	"otc":             SearchKeyOTC,
+	"next_hop":        SearchKeyNextHop,
+}
+
+// The canonical term name for each key
+var searchQueryTermNames = map[string]string{
+	SearchKeySources:          "source",
+	SearchKeyASNS:             "asn",
+	SearchKeyCommunities:      "community",
+	SearchKeyExtCommunities:   "ext",
+	SearchKeyLargeCommunities: "large",
+	SearchKeyAddrFamily:       "family",
+	SearchKeyPrefixLength:     "prefix_length",
+	SearchKeyLocalPref:        "local_pref",
+	SearchKeyMED:              "med",
+	SearchKeyAge:              "age",
+	SearchKeyOTC:              "otc",
+	SearchKeyNextHop:          "next_hop",
+}
+
+// ParseSearchQuery parses a boolean search query like
+//
+//	community:65000:1 AND NOT large:6695:0:* OR asn:64500
+//
+// Terms are combined with AND, OR and NOT and can be
+// grouped using parentheses. NOT binds stronger than AND,
+// which binds stronger than OR. Terms without an operator
+// in between are combined with AND.
+// Community values may contain `*` as a wildcard.
+func ParseSearchQuery(s string) (*SearchQuery, error) {
+	p := &searchQueryParser{tokens: tokenizeSearchQuery(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("%w: query is empty", ErrQuerySyntax)
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrQuerySyntax, tok)
+	}
+	return &SearchQuery{Expr: expr}, nil
+}
+
+// Private tokenizeSearchQuery splits the query into
+// parentheses and words.
+func tokenizeSearchQuery(s string) []string {
+	tokens := []string{}
+	word := strings.Builder{}
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, c := range s {
+		switch c {
+		case '(', ')':
+			flush()
+			tokens = append(tokens, string(c))
+		case ' ', '\t', '\n', '\r':
+			flush()
+		default:
+			word.WriteRune(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// Private searchQueryParser is a recursive descent
+// parser for search queries.
+type searchQueryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *searchQueryParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *searchQueryParser) next() (string, bool) {
+	tok, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return tok, ok
+}
+
+// Private isSearchQueryOp checks if the token is the
+// operator. Operators are case insensitive.
+func isSearchQueryOp(tok, op string) bool {
+	return strings.EqualFold(tok, op)
+}
+
+// or := and (OR and)*
+func (p *searchQueryParser) parseOr() (SearchQueryExpr, error) {
+	expr, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []SearchQueryExpr{expr}
+	for {
+		tok, ok := p.peek()
+		if !ok || !isSearchQueryOp(tok, SearchQueryOpOr) {
+			break
+		}
+		p.next()
+		expr, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return &SearchQueryOr{Exprs: exprs}, nil
+}
+
+// and := not ([AND] not)*
+func (p *searchQueryParser) parseAnd() (SearchQueryExpr, error) {
+	expr, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	exprs := []SearchQueryExpr{expr}
+	for {
+		tok, ok := p.peek()
+		if !ok || tok == ")" || isSearchQueryOp(tok, SearchQueryOpOr) {
+			break
+		}
+		if isSearchQueryOp(tok, SearchQueryOpAnd) {
+			p.next()
+		}
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return &SearchQueryAnd{Exprs: exprs}, nil
+}
+
+// not := NOT not | ( or ) | term
+func (p *searchQueryParser) parseNot() (SearchQueryExpr, error) {
+	tok, ok := p.next()
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected end of query", ErrQuerySyntax)
+	}
+	switch {
+	case isSearchQueryOp(tok, SearchQueryOpNot):
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &SearchQueryNot{Expr: expr}, nil
+	case tok == "(":
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if tok, ok := p.next(); !ok || tok != ")" {
+			return nil, fmt.Errorf("%w: missing )", ErrQuerySyntax)
+		}
+		return expr, nil
+	case tok == ")",
+		isSearchQueryOp(tok, SearchQueryOpAnd),
+		isSearchQueryOp(tok, SearchQueryOpOr):
+		return nil, fmt.Errorf("%w: unexpected %q", ErrQuerySyntax, tok)
+	}
+	return parseSearchQueryTerm(tok)
+}
+
+// Private parseSearchQueryTerm decodes a `key:value` term
+func parseSearchQueryTerm(tok string) (*SearchQueryTerm, error) {
+	name, value, ok := strings.Cut(tok, ":")
+	if !ok || value == "" {
+		return nil, fmt.Errorf(
+			"%w: expected key:value, got %q", ErrQuerySyntax, tok)
+	}
+	key, ok := searchQueryTermKeys[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrQuerySyntax, name)
+	}
+
+	var (
+		filter *SearchFilter
+		err    error
+	)
+	switch key {
+	case SearchKeySources:
+		filter, err = parseStringValue(value)
+	case SearchKeyASNS:
+		filter, err = parseIntValue(value)
+	case SearchKeyCommunities:
+		filter, err = parseCommunityPattern(value, 2)
+	case SearchKeyLargeCommunities:
+		filter, err = parseCommunityPattern(value, 3)
+	case SearchKeyExtCommunities:
+		filter, err = parseExtCommunityPattern(value)
+	case SearchKeyAddrFamily:
+		filter, err = parseAddrFamilyValue(value)
+	case SearchKeyPrefixLength, SearchKeyLocalPref, SearchKeyMED, SearchKeyAge:
+		filter, err = parseIntRangeValue(value)
+	case SearchKeyOTC:
+		filter, err = parseOTCValue(value)
+	case SearchKeyNextHop:
+		filter, err = parseNextHopValue(value)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrQuerySyntax, tok, err)
+	}
+	return &SearchQueryTerm{Key: key, Value: filter.Value}, nil
+}
+
+// Private parseCommunityPattern parses a community with
+// n components. A component may be a wildcard.
+func parseCommunityPattern(value string, n int) (*SearchFilter, error) {
+	components := strings.Split(value, ":")
+	if len(components) != n {
+		return nil, fmt.Errorf("expected %d components", n)
+	}
+	community := make(Community, n)
+	for i, c := range components {
+		if c == "*" {
+			community[i] = CommunityWildcard
+			continue
+		}
+		v, err := strconv.Atoi(c)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid component %q", c)
+		}
+		community[i] = v
+	}
+	return &SearchFilter{
+		Name:  community.String(),
+		Value: community,
+	}, nil
+}
+
+// Private parseExtCommunityPattern parses an extended
+// community which may contain wildcards.
+func parseExtCommunityPattern(value string) (*SearchFilter, error) {
+	components := strings.Split(value, ":")
+	if len(components) != 3 || components[0] == "" {
+		return nil, ErrExtCommunityIncomplete
+	}
+	community := ExtCommunity{components[0], 0, 0}
+	for i, c := range components[1:] {
+		if c == "*" {
+			community[i+1] = CommunityWildcard
+			continue
+		}
+		v, err := strconv.Atoi(c)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("invalid component %q", c)
+		}
+		community[i+1] = v
+	}
+	return &SearchFilter{
+		Name:  community.String(),
+		Value: community,
+	}, nil
+}
+
+// Private parseAddrFamilyValue accepts the address family
+// as 4 or 6 or the value of the addr_family filter.
+func parseAddrFamilyValue(value string) (*SearchFilter, error) {
+	switch strings.ToLower(value) {
+	case "1", "4", "ipv4":
+		return &SearchFilter{Value: AddrFamilyIPv4}, nil
+	case "2", "6", "ipv6":
+		return &SearchFilter{Value: AddrFamilyIPv6}, nil
+	}
+	return nil, errors.New("address family must be 4 or 6")
+}
+
+// Compare search queries
+func searchFilterCmpQuery(a FilterValue, b FilterValue) bool {
+	return a.(*SearchQuery).String() == b.(*SearchQuery).String()
+}
+
+func searchFilterMatchQuery(route Filterable, value any) bool {
+	q, ok := value.(*SearchQuery)
+	if !ok {
+		return false
+	}
+	return q.Match(route)
+}
+
+// parseSearchQueryValue parses the query search filter
+func parseSearchQueryValue(value string) (*SearchFilter, error) {
+	q, err := ParseSearchQuery(value)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchFilter{
+		Name:  q.String(),
+		Value: q,
+	}, nil
+}
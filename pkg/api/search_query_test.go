@@ -0,0 +1,112 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := map[string]string{
+		"community:65000:1":                "community:65000:1",
+		"community:65000:1 AND asn:64500":  "(community:65000:1 AND asn:64500)",
+		"community:65000:1 asn:64500":      "(community:65000:1 AND asn:64500)",
+		"NOT large:6695:0:*":               "NOT large:6695:0:*",
+		"ext:rt:65000:* or source:rs1":     "(ext:rt:65000:* OR source:rs1)",
+		"prefix_length:>=25 AND family:6":  "(prefix_length:>=25 AND family:2)",
+		"next_hop:10.0.0.0/8 and otc:true": "(next_hop:10.0.0.0/8 AND otc:true)",
+
+		// Precedence: NOT > AND > OR
+		"community:65000:1 AND NOT large:6695:0:* OR asn:64500":   "((community:65000:1 AND NOT large:6695:0:*) OR asn:64500)",
+		"community:65000:1 AND (NOT large:6695:0:* OR asn:64500)": "(community:65000:1 AND (NOT large:6695:0:* OR asn:64500))",
+	}
+	for query, expected := range tests {
+		q, err := ParseSearchQuery(query)
+		if err != nil {
+			t.Error(query, err)
+			continue
+		}
+		if q.String() != expected {
+			t.Error("expected", expected, "got", q.String())
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"community",
+		"foo:23",
+		"community:65000",
+		"community:65000:a",
+		"large:1:2",
+		"asn:1 AND",
+		"(asn:1 OR asn:2",
+		"asn:1)",
+		"OR asn:1",
+		"local_pref:10-1",
+		"family:5",
+	}
+	for _, query := range tests {
+		_, err := ParseSearchQuery(query)
+		if !errors.Is(err, ErrQuerySyntax) {
+			t.Error("expected syntax error for:", query, err)
+		}
+	}
+}
+
+func TestSearchQueryMatch(t *testing.T) {
+	route := makeTestLookupRoute()
+	tests := map[string]bool{
+		"community:23:42":                         true,
+		"community:23:*":                          true,
+		"community:*:11":                          true,
+		"community:23:11":                         false,
+		"NOT community:23:11":                     true,
+		"large:1000:*:*":                          true,
+		"large:1000:23:1":                         false,
+		"ext:ro:23:*":                             true,
+		"ext:*:*:123":                             true,
+		"ext:rt:23:123":                           false,
+		"asn:23042 AND community:111:11":          true,
+		"asn:23042 AND NOT community:111:11":      false,
+		"asn:1 OR community:111:11":               true,
+		"source:3 AND (asn:1 OR large:1000:*:42)": true,
+		"NOT (asn:1 OR asn:23042)":                false,
+	}
+	for query, expected := range tests {
+		q, err := ParseSearchQuery(query)
+		if err != nil {
+			t.Fatal(query, err)
+		}
+		if q.Match(route) != expected {
+			t.Error(query, "should match:", expected)
+		}
+	}
+}
+
+func TestSearchFiltersQuery(t *testing.T) {
+	values, _ := url.ParseQuery("asns=23042&query=community:23:42+AND+NOT+community:1:1")
+	filters, err := FiltersFromQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !filters.HasGroup(SearchKeyQuery) {
+		t.Error("expected query filter")
+	}
+	route := makeTestLookupRoute()
+	if !filters.MatchRoute(route) {
+		t.Error("route should match")
+	}
+
+	values, _ = url.ParseQuery("query=NOT+community:23:42")
+	filters, _ = FiltersFromQuery(values)
+	if filters.MatchRoute(route) {
+		t.Error("route should not match")
+	}
+
+	values, _ = url.ParseQuery("query=community:23:42+AND")
+	if _, err := FiltersFromQuery(values); !errors.Is(err, ErrQuerySyntax) {
+		t.Error("expected syntax error, got:", err)
+	}
+}
@@ -79,3 +79,46 @@ func (stats *NeighborsStoreStats) Log() {
 			rs.Neighbors)
 	}
 }
+
+// StatisticsCount is the number of routes with
+// an attribute, like an origin ASN or a community.
+// The name is the label of the value, if known.
+type StatisticsCount struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count uint   `json:"count"`
+}
+
+// RoutesStatistics describe the distribution
+// of route attributes.
+type RoutesStatistics struct {
+	Routes RoutesStats `json:"routes"`
+
+	// Histograms: address family -> prefix length -> count
+	PrefixLengths map[string]map[int]uint `json:"prefix_lengths"`
+	ASPathLengths map[int]uint            `json:"as_path_lengths"`
+
+	TopOriginASNs       []*StatisticsCount `json:"top_origin_asns"`
+	TopCommunities      []*StatisticsCount `json:"top_communities"`
+	TopLargeCommunities []*StatisticsCount `json:"top_large_communities"`
+	TopFilterReasons    []*StatisticsCount `json:"top_filter_reasons"`
+}
+
+// RouteServerStatistics are the routes statistics
+// of a single route server.
+type RouteServerStatistics struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Group     string    `json:"group"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	RoutesStatistics
+}
+
+// StatisticsResponse contains the statistics for
+// all route servers and the aggregate.
+type StatisticsResponse struct {
+	Response
+	Global       *RoutesStatistics        `json:"global"`
+	RouteServers []*RouteServerStatistics `json:"route_servers"`
+}
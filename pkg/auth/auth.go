@@ -0,0 +1,154 @@
+// Package auth authenticates members with JWT bearer
+// tokens or static API keys and authorizes them for
+// the ASNs of their networks.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// ErrInvalidCredentials is returned for an unknown API key
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Identity is an authenticated member
+type Identity struct {
+	Subject string
+	ASNs    []int
+	All     bool // Authorized for all ASNs
+}
+
+// CanAccessASN checks if the identity is authorized
+// for the ASN. A nil identity is anonymous.
+func (id *Identity) CanAccessASN(asn int) bool {
+	if id == nil {
+		return false
+	}
+	return id.All || slices.Contains(id.ASNs, asn)
+}
+
+type contextKey struct{}
+
+// ContextWithIdentity adds the identity to the context
+func ContextWithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// IdentityFromContext gets the identity from the
+// context. Nil is returned for anonymous requests.
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(contextKey{}).(*Identity)
+	return id
+}
+
+// Authenticator identifies the member making a request
+type Authenticator struct {
+	cfg  *config.AuthConfig
+	keys *KeySet
+	now  func() time.Time
+}
+
+// NewAuthenticator creates an authenticator and loads
+// the JWKS file if configured.
+func NewAuthenticator(cfg *config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{
+		cfg: cfg,
+		now: time.Now,
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := LoadKeySet(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+	}
+	return a, nil
+}
+
+// Authenticate gets the identity from the bearer token
+// or API key of the request. Requests without credentials
+// are anonymous: nil is returned without an error.
+func (a *Authenticator) Authenticate(req *http.Request) (*Identity, error) {
+	if key := req.Header.Get(a.cfg.APIKeyHeader); key != "" {
+		return a.authenticateAPIKey(key)
+	}
+	authorization := req.Header.Get("Authorization")
+	if authorization == "" {
+		return nil, nil
+	}
+	scheme, token, _ := strings.Cut(authorization, " ")
+	if !strings.EqualFold(scheme, "Bearer") || a.keys == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return a.authenticateToken(strings.TrimSpace(token))
+}
+
+// authenticateAPIKey looks up the static API key
+func (a *Authenticator) authenticateAPIKey(key string) (*Identity, error) {
+	for _, k := range a.cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+			return &Identity{
+				Subject: "api_key:" + k.ID,
+				ASNs:    k.ASNs,
+				All:     k.All,
+			}, nil
+		}
+	}
+	return nil, ErrInvalidCredentials
+}
+
+// authenticateToken validates the token and maps the
+// subject and the ASN claim to the ASNs.
+func (a *Authenticator) authenticateToken(token string) (*Identity, error) {
+	claims, err := a.keys.VerifyToken(token, a.now())
+	if err != nil {
+		return nil, err
+	}
+	if a.cfg.Issuer != "" && claims["iss"] != a.cfg.Issuer {
+		return nil, ErrInvalidIssuer
+	}
+	if a.cfg.Audience != "" && !claims.HasAudience(a.cfg.Audience) {
+		return nil, ErrInvalidAudience
+	}
+
+	id := &Identity{
+		Subject: claims.Subject(),
+		ASNs:    claimASNs(claims[a.cfg.ASNClaim]),
+	}
+	if grant, ok := a.cfg.Subjects[id.Subject]; ok {
+		id.ASNs = append(id.ASNs, grant.ASNs...)
+		id.All = grant.All
+	}
+	return id, nil
+}
+
+// claimASNs decodes the ASN claim: a list of numbers
+// or strings like AS2342, or a single value.
+func claimASNs(claim any) []int {
+	values, ok := claim.([]any)
+	if !ok {
+		values = []any{claim}
+	}
+	asns := []int{}
+	for _, v := range values {
+		switch asn := v.(type) {
+		case float64:
+			asns = append(asns, int(asn))
+		case string:
+			n, err := strconv.Atoi(
+				strings.TrimPrefix(strings.ToUpper(asn), "AS"))
+			if err == nil {
+				asns = append(asns, n)
+			}
+		}
+	}
+	return asns
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+func testAuthenticator(t *testing.T) (*Authenticator, func(Claims) string) {
+	rsaKey, _, doc := testKeys(t)
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksFile, doc, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewAuthenticator(&config.AuthConfig{
+		Enabled:      true,
+		JWKSFile:     jwksFile,
+		Issuer:       "https://sso.example.com",
+		Audience:     "alice-lg",
+		ASNClaim:     config.DefaultAuthASNClaim,
+		APIKeyHeader: config.DefaultAuthAPIKeyHeader,
+		Subjects: map[string]*config.ASNGrant{
+			"noc@example.com": {All: true},
+		},
+		APIKeys: []*config.APIKeyConfig{
+			{ID: "member", Key: "s3cr3t", ASNGrant: config.ASNGrant{
+				ASNs: []int{2342},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(c Claims) string {
+		return signRS256(t, rsaKey, "rsa1", c)
+	}
+	return a, sign
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	a, sign := testAuthenticator(t)
+	exp := time.Now().Add(time.Hour).Unix()
+
+	req := httptest.NewRequest("GET", "/api/v1/status", nil)
+	req.Header.Set("Authorization", "Bearer "+sign(Claims{
+		"iss":  "https://sso.example.com",
+		"aud":  "alice-lg",
+		"sub":  "member@example.net",
+		"exp":  exp,
+		"asns": []any{31078, "AS2342"},
+	}))
+	id, err := a.Authenticate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.CanAccessASN(31078) || !id.CanAccessASN(2342) ||
+		id.CanAccessASN(65000) {
+		t.Error("unexpected asns:", id.ASNs)
+	}
+
+	// Subject mapping
+	req.Header.Set("Authorization", "Bearer "+sign(Claims{
+		"iss": "https://sso.example.com",
+		"aud": []any{"alice-lg"},
+		"sub": "noc@example.com",
+		"exp": exp,
+	}))
+	id, err = a.Authenticate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !id.CanAccessASN(65000) {
+		t.Error("expected access to all asns")
+	}
+
+	// Issuer and audience
+	req.Header.Set("Authorization", "Bearer "+sign(Claims{
+		"iss": "https://evil.example.com",
+		"aud": "alice-lg",
+		"exp": exp,
+	}))
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrInvalidIssuer) {
+		t.Error("expected invalid issuer, got:", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+sign(Claims{
+		"iss": "https://sso.example.com",
+		"aud": "other",
+		"exp": exp,
+	}))
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrInvalidAudience) {
+		t.Error("expected invalid audience, got:", err)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a, _ := testAuthenticator(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/status", nil)
+	id, err := a.Authenticate(req)
+	if err != nil || id != nil {
+		t.Error("expected an anonymous request:", id, err)
+	}
+
+	req.Header.Set("X-API-Key", "s3cr3t")
+	id, err = a.Authenticate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "api_key:member" || !id.CanAccessASN(2342) {
+		t.Error("unexpected identity:", id)
+	}
+
+	req.Header.Set("X-API-Key", "guessed")
+	if _, err := a.Authenticate(req); !errors.Is(err, ErrInvalidCredentials) {
+		t.Error("expected invalid credentials, got:", err)
+	}
+}
+
+func TestIdentityCanAccessASN(t *testing.T) {
+	var anonymous *Identity
+	if anonymous.CanAccessASN(2342) {
+		t.Error("anonymous identity must not access any asn")
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// ErrUnknownKey is returned when the key of a
+// token is not in the key set.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// jsonWebKey is a key of a JWKS document (RFC 7517).
+// Only the public key parameters are used.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet holds the public keys for validating
+// the signatures of tokens.
+type KeySet struct {
+	keys map[string]crypto.PublicKey
+}
+
+// LoadKeySet reads a JWKS file
+func LoadKeySet(filename string) (*KeySet, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeySet(data)
+}
+
+// ParseKeySet decodes a JWKS document. Keys which
+// are not used for signatures are skipped.
+func ParseKeySet(data []byte) (*KeySet, error) {
+	doc := struct {
+		Keys []*jsonWebKey `json:"keys"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	ks := &KeySet{keys: map[string]crypto.PublicKey{}}
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k.Kid, err)
+		}
+		ks.keys[k.Kid] = key
+	}
+	if len(ks.keys) == 0 {
+		return nil, errors.New("the key set has no signing keys")
+	}
+	return ks, nil
+}
+
+// Key gets a key by id. If the id is empty and there
+// is only one key, this key is returned.
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	if key, ok := ks.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, nil
+		}
+	}
+	return nil, ErrUnknownKey
+}
+
+// publicKey decodes the key parameters
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("invalid ec point")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+}
+
+// decodeBigInt decodes a base64url encoded integer
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // Register the hash functions
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Token validation errors
+var (
+	ErrMalformedToken       = errors.New("malformed token")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature     = errors.New("invalid token signature")
+	ErrTokenExpired         = errors.New("token expired")
+	ErrMissingExpiration    = errors.New("token has no expiration")
+	ErrTokenNotValidYet     = errors.New("token not valid yet")
+	ErrInvalidIssuer        = errors.New("invalid token issuer")
+	ErrInvalidAudience      = errors.New("invalid token audience")
+)
+
+// Tolerated clock difference when checking
+// the validity period of a token.
+const clockSkew = time.Minute
+
+// Claims of a JWT
+type Claims map[string]any
+
+// Subject returns the sub claim
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// time gets a NumericDate claim
+func (c Claims) time(name string) (time.Time, bool) {
+	value, ok := c[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// HasAudience checks if the audience is included
+// in the aud claim, which is a string or a list.
+func (c Claims) HasAudience(audience string) bool {
+	switch aud := c["aud"].(type) {
+	case string:
+		return aud == audience
+	case []any:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// signingAlgorithm describes a JWS algorithm
+type signingAlgorithm struct {
+	hash   crypto.Hash
+	verify func(key crypto.PublicKey, hash crypto.Hash, digest, sig []byte) bool
+}
+
+func verifyRSA(key crypto.PublicKey, hash crypto.Hash, digest, sig []byte) bool {
+	k, ok := key.(*rsa.PublicKey)
+	return ok && rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil
+}
+
+func verifyRSAPSS(key crypto.PublicKey, hash crypto.Hash, digest, sig []byte) bool {
+	k, ok := key.(*rsa.PublicKey)
+	return ok && rsa.VerifyPSS(k, hash, digest, sig, nil) == nil
+}
+
+// verifyECDSA checks the signature in the JWS
+// encoding: the concatenated r and s values.
+func verifyECDSA(key crypto.PublicKey, _ crypto.Hash, digest, sig []byte) bool {
+	k, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return false
+	}
+	size := (k.Curve.Params().BitSize + 7) / 8
+	if len(sig) != 2*size {
+		return false
+	}
+	r := new(big.Int).SetBytes(sig[:size])
+	s := new(big.Int).SetBytes(sig[size:])
+	return ecdsa.Verify(k, digest, r, s)
+}
+
+// The supported algorithms. Symmetric algorithms
+// and "none" are not accepted.
+var signingAlgorithms = map[string]*signingAlgorithm{
+	"RS256": {crypto.SHA256, verifyRSA},
+	"RS384": {crypto.SHA384, verifyRSA},
+	"RS512": {crypto.SHA512, verifyRSA},
+	"PS256": {crypto.SHA256, verifyRSAPSS},
+	"PS384": {crypto.SHA384, verifyRSAPSS},
+	"PS512": {crypto.SHA512, verifyRSAPSS},
+	"ES256": {crypto.SHA256, verifyECDSA},
+	"ES384": {crypto.SHA384, verifyECDSA},
+	"ES512": {crypto.SHA512, verifyECDSA},
+}
+
+// VerifyToken checks the signature and the validity
+// period of a compact serialized JWT and returns
+// the claims.
+func (ks *KeySet) VerifyToken(token string, now time.Time) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	alg, ok := signingAlgorithms[header.Alg]
+	if !ok {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	key, err := ks.Key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	h := alg.hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	if !alg.verify(key, alg.hash, h.Sum(nil), sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	claims := Claims{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	exp, ok := claims.time("exp")
+	if !ok {
+		return nil, ErrMissingExpiration
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims.time("nbf"); ok && now.Add(clockSkew).Before(nbf) {
+		return nil, ErrTokenNotValidYet
+	}
+	return claims, nil
+}
+
+// decodeSegment decodes a base64url encoded json segment
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return ErrMalformedToken
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrMalformedToken
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// encodeSegment encodes a json segment of a token
+func encodeSegment(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+// signRS256 makes a token signed with the rsa key
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims Claims) string {
+	payload := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) +
+		"." + encodeSegment(t, claims)
+	h := crypto.SHA256.New()
+	h.Write([]byte(payload))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// signES256 makes a token signed with the ec key
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims Claims) string {
+	payload := encodeSegment(t, map[string]string{"alg": "ES256", "kid": kid}) +
+		"." + encodeSegment(t, claims)
+	h := crypto.SHA256.New()
+	h.Write([]byte(payload))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return payload + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// testKeys generates a key pair of each type and
+// the jwks document with the public keys.
+func testKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey, []byte) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "rsa1",
+				"use": "sig",
+				"n":   encodeBigInt(rsaKey.N),
+				"e":   encodeBigInt(big.NewInt(int64(rsaKey.E))),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec1",
+				"crv": "P-256",
+				"x":   encodeBigInt(ecKey.X),
+				"y":   encodeBigInt(ecKey.Y),
+			},
+			{
+				"kty": "RSA",
+				"kid": "enc1",
+				"use": "enc",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rsaKey, ecKey, doc
+}
+
+func TestVerifyToken(t *testing.T) {
+	rsaKey, ecKey, doc := testKeys(t)
+	ks, err := ParseKeySet(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ks.keys) != 2 {
+		t.Error("expected 2 signing keys, got:", len(ks.keys))
+	}
+
+	now := time.Now()
+	claims := Claims{
+		"sub": "member@example.net",
+		"exp": now.Add(time.Hour).Unix(),
+	}
+
+	for _, token := range []string{
+		signRS256(t, rsaKey, "rsa1", claims),
+		signES256(t, ecKey, "ec1", claims),
+	} {
+		verified, err := ks.VerifyToken(token, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verified.Subject() != "member@example.net" {
+			t.Error("unexpected subject:", verified.Subject())
+		}
+	}
+}
+
+func TestVerifyTokenErrors(t *testing.T) {
+	rsaKey, ecKey, doc := testKeys(t)
+	ks, err := ParseKeySet(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	valid := Claims{"exp": now.Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"malformed", "foo.bar", ErrMalformedToken},
+		{"unknown key", signRS256(t, rsaKey, "rsa2", valid), ErrUnknownKey},
+		{"wrong key", signES256(t, ecKey, "rsa1", valid), ErrInvalidSignature},
+		{"expired", signRS256(t, rsaKey, "rsa1", Claims{
+			"exp": now.Add(-time.Hour).Unix(),
+		}), ErrTokenExpired},
+		{"no expiration", signRS256(t, rsaKey, "rsa1", Claims{}),
+			ErrMissingExpiration},
+		{"not valid yet", signRS256(t, rsaKey, "rsa1", Claims{
+			"exp": now.Add(2 * time.Hour).Unix(),
+			"nbf": now.Add(time.Hour).Unix(),
+		}), ErrTokenNotValidYet},
+		{"none", encodeSegment(t, map[string]string{"alg": "none"}) +
+			"." + encodeSegment(t, valid) + ".", ErrUnsupportedAlgorithm},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ks.VerifyToken(tc.token, now)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected %v, got: %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestClaimsHasAudience(t *testing.T) {
+	c := Claims{"aud": []any{"foo", "alice-lg"}}
+	if !c.HasAudience("alice-lg") {
+		t.Error("expected audience in list")
+	}
+	c = Claims{"aud": "foo"}
+	if c.HasAudience("alice-lg") {
+		t.Error("unexpected audience")
+	}
+}
@@ -0,0 +1,44 @@
+package caches
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/alice-lg/alice-lg/pkg/caches")
+
+// The hit ratio of a cache is the rate of the
+// hits divided by the rate of all lookups.
+var cacheLookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Number of lookups in the response caches of the sources by result",
+	},
+	[]string{"cache", "result"},
+)
+
+func init() {
+	prometheus.MustRegister(cacheLookups)
+}
+
+// startLookup starts the span of a lookup in the cache
+func startLookup(ctx context.Context, cache string) trace.Span {
+	_, span := tracer.Start(ctx, "cache."+cache)
+	return span
+}
+
+// observeLookup counts a lookup in the cache and ends
+// its span. Expired responses are misses.
+func observeLookup(span trace.Span, cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+	span.SetAttributes(attribute.Bool("alice.cache.hit", hit))
+	span.End()
+}
@@ -0,0 +1,40 @@
+package caches
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func TestCacheLookupMetrics(t *testing.T) {
+	hits := testutil.ToFloat64(cacheLookups.WithLabelValues("routes", "hit"))
+	misses := testutil.ToFloat64(cacheLookups.WithLabelValues("routes", "miss"))
+
+	cache := NewRoutesCache(false, 2)
+	cache.Get(context.Background(), "n1")
+	cache.Set("n1", &api.RoutesResponse{
+		Response: api.Response{
+			Meta: &api.Meta{TTL: time.Now().UTC().Add(time.Minute)},
+		},
+	})
+	cache.Get(context.Background(), "n1")
+	cache.Get(context.Background(), "n1")
+
+	if n := testutil.ToFloat64(cacheLookups.WithLabelValues("routes", "hit")); n-hits != 2 {
+		t.Error("expected 2 hits, got:", n-hits)
+	}
+	if n := testutil.ToFloat64(cacheLookups.WithLabelValues("routes", "miss")); n-misses != 1 {
+		t.Error("expected 1 miss, got:", n-misses)
+	}
+
+	// Lookups in disabled caches are not counted
+	disabled := NewRoutesCache(true, 2)
+	disabled.Get(context.Background(), "n1")
+	if n := testutil.ToFloat64(cacheLookups.WithLabelValues("routes", "miss")); n-misses != 1 {
+		t.Error("expected no additional miss, got:", n-misses)
+	}
+}
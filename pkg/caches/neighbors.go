@@ -1,6 +1,8 @@
 package caches
 
 import (
+	"context"
+
 	"github.com/alice-lg/alice-lg/pkg/api"
 )
 
@@ -32,19 +34,18 @@ func NewNeighborsCache(disabled bool) *NeighborsCache {
 
 // Get retrieves the neighbors response from the cache, if present,
 // and makes sure the information is still up to date.
-func (cache *NeighborsCache) Get() *api.NeighborsResponse {
+func (cache *NeighborsCache) Get(ctx context.Context) *api.NeighborsResponse {
 	if cache.disabled {
 		return nil
 	}
 
-	if cache.response == nil {
-		return nil
-	}
-
-	if cache.response.CacheTTL() < 0 {
+	span := startLookup(ctx, "neighbors")
+	if cache.response == nil || cache.response.CacheTTL() < 0 {
+		observeLookup(span, "neighbors", false)
 		return nil
 	}
 
+	observeLookup(span, "neighbors", true)
 	return cache.response
 }
 
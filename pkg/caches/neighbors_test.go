@@ -1,6 +1,7 @@
 package caches
 
 import (
+	"context"
 	"github.com/alice-lg/alice-lg/pkg/api"
 
 	"testing"
@@ -22,13 +23,13 @@ func TestNeighborsCacheSetGet(t *testing.T) {
 		},
 	}
 
-	if cache.Get() != nil {
+	if cache.Get(context.Background()) != nil {
 		t.Error("There should not be anything cached yet!")
 	}
 
 	cache.Set(response)
 
-	fromCache := cache.Get()
+	fromCache := cache.Get(context.Background())
 	if fromCache != response {
 		t.Error("Expected", response, "got", fromCache)
 	}
@@ -36,7 +37,7 @@ func TestNeighborsCacheSetGet(t *testing.T) {
 	// Wait a bit
 	time.Sleep(33 * time.Millisecond)
 
-	fromCache = cache.Get()
+	fromCache = cache.Get(context.Background())
 	if fromCache != nil {
 		t.Error("Expected empty cache result, got:", fromCache)
 	}
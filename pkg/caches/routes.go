@@ -1,6 +1,7 @@
 package caches
 
 import (
+	"context"
 	"sync"
 	"time"
 
@@ -38,23 +39,25 @@ func NewRoutesCache(disabled bool, size int) *RoutesCache {
 }
 
 // Get retrieves all routes for a given neighbor
-func (cache *RoutesCache) Get(neighborID string) *api.RoutesResponse {
+func (cache *RoutesCache) Get(
+	ctx context.Context,
+	neighborID string,
+) *api.RoutesResponse {
 	if cache.disabled {
 		return nil
 	}
 
+	span := startLookup(ctx, "routes")
 	cache.Lock()
 	defer cache.Unlock()
 
 	response, ok := cache.responses[neighborID]
-	if !ok {
-		return nil
-	}
-
-	if response.CacheTTL() < 0 {
+	if !ok || response.CacheTTL() < 0 {
+		observeLookup(span, "routes", false)
 		return nil
 	}
 
+	observeLookup(span, "routes", true)
 	cache.accessedAt[neighborID] = time.Now()
 
 	return response
@@ -1,6 +1,7 @@
 package caches
 
 import (
+	"context"
 	"github.com/alice-lg/alice-lg/pkg/api"
 
 	"testing"
@@ -20,20 +21,20 @@ func TestRoutesCacheSetGet(t *testing.T) {
 
 	nID := "neighbor_42"
 
-	if cache.Get(nID) != nil {
+	if cache.Get(context.Background(), nID) != nil {
 		t.Error("There should not be anything cached yet!")
 	}
 
 	cache.Set(nID, response)
 
-	fromCache := cache.Get(nID)
+	fromCache := cache.Get(context.Background(), nID)
 	if fromCache != response {
 		t.Error("Expected", response, "got", fromCache)
 	}
 
 	time.Sleep(33 * time.Millisecond)
 
-	fromCache = cache.Get(nID)
+	fromCache = cache.Get(context.Background(), nID)
 	if fromCache != nil {
 		t.Error("Expected empty cache result, got:", fromCache)
 	}
@@ -71,7 +72,7 @@ func TestRoutesCacheLru(t *testing.T) {
 	if cache.accessedAt.LRU() != "n3" {
 		t.Log("Expected n3 to be LRU")
 	}
-	cache.Get("n3")
+	cache.Get(context.Background(), "n3")
 	cache.Set("n1", response)
 
 	// n2 should not be part of the key set
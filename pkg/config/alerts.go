@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/decoders"
+)
+
+const (
+	// DefaultAlertsHistorySize is the number of alerts
+	// kept in the history.
+	DefaultAlertsHistorySize = 1000
+
+	// DefaultWebhookTimeout is the timeout in seconds
+	// for delivering an alert to a webhook.
+	DefaultWebhookTimeout = 10
+
+	// DefaultWebhookRetries is the number of retries
+	// for a failed delivery.
+	DefaultWebhookRetries = 3
+)
+
+// AlertsConfig holds the alerting rules and
+// the webhooks the alerts are delivered to.
+type AlertsConfig struct {
+	HistorySize int `ini:"history_size"`
+
+	Webhooks []*WebhookConfig
+	Rules    []*AlertRuleConfig
+}
+
+// WebhookByID returns a webhook by id
+func (cfg *AlertsConfig) WebhookByID(id string) *WebhookConfig {
+	for _, w := range cfg.Webhooks {
+		if w.ID == id {
+			return w
+		}
+	}
+	return nil
+}
+
+// WebhookConfig is the configuration of a webhook
+// receiving JSON encoded alerts.
+type WebhookConfig struct {
+	ID      string
+	URL     string `ini:"url"`
+	Timeout int    `ini:"timeout"`
+	Retries int    `ini:"retries"`
+
+	// Additional headers set on the request,
+	// configured as header.<name> = <value>
+	Headers map[string]string
+}
+
+// AlertRuleConfig describes the conditions for raising
+// an alert. Neighbor rules are evaluated after a refresh
+// of the neighbors, route rules after a refresh of the
+// routes of a source.
+//
+// All conditions of a rule must be met.
+type AlertRuleConfig struct {
+	ID       string
+	Webhooks []string
+
+	// Selectors
+	Sources []string
+	ASNs    []int
+
+	// Neighbor conditions
+	NeighborState          string
+	RoutesReceivedBelow    *int
+	RoutesFilteredAbove    *int
+	RoutesFilteredIncrease *int
+
+	// Route conditions
+	FilterReasons []string
+	RouteQuery    *api.SearchQuery
+}
+
+// IsRouteRule checks if the rule has route conditions
+func (r *AlertRuleConfig) IsRouteRule() bool {
+	return len(r.FilterReasons) > 0 || r.RouteQuery != nil
+}
+
+// hasNeighborConditions checks if any neighbor
+// condition is present.
+func (r *AlertRuleConfig) hasNeighborConditions() bool {
+	return r.NeighborState != "" ||
+		r.RoutesReceivedBelow != nil ||
+		r.RoutesFilteredAbove != nil ||
+		r.RoutesFilteredIncrease != nil
+}
+
+// getOptionalInt reads an integer if the key is present
+func getOptionalInt(section *ini.Section, key string) (*int, error) {
+	if !section.HasKey(key) {
+		return nil, nil
+	}
+	value, err := section.Key(key).Int()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s must be an integer", section.Name(), key)
+	}
+	return &value, nil
+}
+
+// getWebhooks reads the [webhook.<id>] sections
+func getWebhooks(config *ini.File) ([]*WebhookConfig, error) {
+	webhooks := []*WebhookConfig{}
+	for _, section := range config.ChildSections("webhook") {
+		webhook := &WebhookConfig{
+			ID:      section.Name()[len("webhook."):],
+			Timeout: DefaultWebhookTimeout,
+			Retries: DefaultWebhookRetries,
+			Headers: map[string]string{},
+		}
+		if err := section.MapTo(webhook); err != nil {
+			return nil, err
+		}
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("%s: url is required", section.Name())
+		}
+		if webhook.Timeout <= 0 {
+			return nil, fmt.Errorf(
+				"%s: timeout must be a positive number of seconds",
+				section.Name())
+		}
+		if webhook.Retries < 0 {
+			return nil, fmt.Errorf(
+				"%s: retries must not be negative", section.Name())
+		}
+		for _, key := range section.Keys() {
+			if name, ok := strings.CutPrefix(key.Name(), "header."); ok {
+				webhook.Headers[name] = key.String()
+			}
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// getAlertRule reads an [alert.<id>] section
+func getAlertRule(section *ini.Section) (*AlertRuleConfig, error) {
+	var err error
+	rule := &AlertRuleConfig{
+		ID: section.Name()[len("alert."):],
+		Webhooks: decoders.TrimmedCSVStringList(
+			section.Key("webhooks").String()),
+		Sources: decoders.TrimmedCSVStringList(
+			section.Key("sources").String()),
+		NeighborState: strings.ToLower(
+			section.Key("neighbor_state").String()),
+		FilterReasons: decoders.TrimmedCSVStringList(
+			section.Key("filter_reason").String()),
+	}
+
+	for _, value := range decoders.TrimmedCSVStringList(
+		section.Key("asn").String()) {
+		asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(value), "AS"))
+		if err != nil || asn < 0 {
+			return nil, fmt.Errorf("%s: invalid asn: %s", section.Name(), value)
+		}
+		rule.ASNs = append(rule.ASNs, asn)
+	}
+
+	if rule.RoutesReceivedBelow, err = getOptionalInt(
+		section, "routes_received_below"); err != nil {
+		return nil, err
+	}
+	if rule.RoutesFilteredAbove, err = getOptionalInt(
+		section, "routes_filtered_above"); err != nil {
+		return nil, err
+	}
+	if rule.RoutesFilteredIncrease, err = getOptionalInt(
+		section, "routes_filtered_increase"); err != nil {
+		return nil, err
+	}
+
+	if query := section.Key("route_query").String(); query != "" {
+		rule.RouteQuery, err = api.ParseSearchQuery(query)
+		if err != nil {
+			return nil, fmt.Errorf("%s: route_query: %w", section.Name(), err)
+		}
+	}
+
+	if rule.IsRouteRule() && rule.hasNeighborConditions() {
+		return nil, fmt.Errorf(
+			"%s: route and neighbor conditions can not be combined",
+			section.Name())
+	}
+	if !rule.IsRouteRule() && !rule.hasNeighborConditions() {
+		return nil, fmt.Errorf("%s: the rule has no conditions", section.Name())
+	}
+	return rule, nil
+}
+
+// getAlertsConfig reads the alerting rules and webhooks
+func getAlertsConfig(config *ini.File) (AlertsConfig, error) {
+	alerts := AlertsConfig{
+		HistorySize: DefaultAlertsHistorySize,
+	}
+	if err := config.Section("alerts").MapTo(&alerts); err != nil {
+		return alerts, err
+	}
+
+	webhooks, err := getWebhooks(config)
+	if err != nil {
+		return alerts, err
+	}
+	alerts.Webhooks = webhooks
+
+	for _, section := range config.ChildSections("alert") {
+		rule, err := getAlertRule(section)
+		if err != nil {
+			return alerts, err
+		}
+		for _, id := range rule.Webhooks {
+			if alerts.WebhookByID(id) == nil {
+				return alerts, fmt.Errorf(
+					"%s: unknown webhook: %s", section.Name(), id)
+			}
+		}
+		alerts.Rules = append(alerts.Rules, rule)
+	}
+
+	return alerts, nil
+}
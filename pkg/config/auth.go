@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/decoders"
+)
+
+// Visibility of the routes of a source
+const (
+	// VisibilityPublic shows all routes and neighbor
+	// details to everyone.
+	VisibilityPublic = "public"
+
+	// VisibilityMembers shows filtered and not exported
+	// routes and the neighbor details only to the
+	// members authorized for the neighbor ASN.
+	VisibilityMembers = "members"
+)
+
+// Authentication defaults
+const (
+	DefaultAuthASNClaim     = "asns"
+	DefaultAuthAPIKeyHeader = "X-API-Key"
+)
+
+// AuthConfig configures the authentication of members
+// with JWT bearer tokens or static API keys.
+type AuthConfig struct {
+	Enabled bool `ini:"enabled"`
+
+	// Bearer tokens are validated with the keys from a
+	// local JWKS file, the issuer and audience are checked
+	// when configured.
+	JWKSFile string `ini:"jwks_file"`
+	Issuer   string `ini:"issuer"`
+	Audience string `ini:"audience"`
+
+	// ASNClaim is the claim of the token listing the
+	// ASNs of the member.
+	ASNClaim string `ini:"asn_claim"`
+
+	// APIKeyHeader is the header of the static API keys
+	APIKeyHeader string `ini:"api_key_header"`
+
+	// Subjects maps token subjects to ASNs,
+	// configured in the [auth.subjects] section.
+	Subjects map[string]*ASNGrant
+
+	APIKeys []*APIKeyConfig
+}
+
+// ASNGrant lists the ASNs an identity is authorized for
+type ASNGrant struct {
+	ASNs []int
+	All  bool // Authorized for all ASNs
+}
+
+// APIKeyConfig is a static API key, configured
+// in a [api_key.<id>] section.
+type APIKeyConfig struct {
+	ID  string
+	Key string
+	ASNGrant
+}
+
+// parseASNGrant parses a list of ASNs. A * grants
+// access to all ASNs.
+func parseASNGrant(value string) (*ASNGrant, error) {
+	grant := &ASNGrant{}
+	for _, v := range decoders.TrimmedCSVStringList(value) {
+		if v == "*" {
+			grant.All = true
+			continue
+		}
+		asn, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(v), "AS"))
+		if err != nil || asn < 0 {
+			return nil, fmt.Errorf("invalid asn: %s", v)
+		}
+		grant.ASNs = append(grant.ASNs, asn)
+	}
+	return grant, nil
+}
+
+// getAuthConfig reads the [auth], [auth.subjects]
+// and [api_key.<id>] sections.
+func getAuthConfig(config *ini.File) (AuthConfig, error) {
+	auth := AuthConfig{
+		ASNClaim:     DefaultAuthASNClaim,
+		APIKeyHeader: DefaultAuthAPIKeyHeader,
+		Subjects:     map[string]*ASNGrant{},
+	}
+	if err := config.Section("auth").MapTo(&auth); err != nil {
+		return auth, err
+	}
+
+	if config.HasSection("auth.subjects") {
+		for _, key := range config.Section("auth.subjects").Keys() {
+			grant, err := parseASNGrant(key.String())
+			if err != nil {
+				return auth, fmt.Errorf("auth.subjects: %s: %w", key.Name(), err)
+			}
+			auth.Subjects[key.Name()] = grant
+		}
+	}
+
+	for _, section := range config.ChildSections("api_key") {
+		grant, err := parseASNGrant(section.Key("asns").String())
+		if err != nil {
+			return auth, fmt.Errorf("%s: %w", section.Name(), err)
+		}
+		key := &APIKeyConfig{
+			ID:       section.Name()[len("api_key."):],
+			Key:      section.Key("key").String(),
+			ASNGrant: *grant,
+		}
+		if key.Key == "" {
+			return auth, fmt.Errorf("%s: key is required", section.Name())
+		}
+		auth.APIKeys = append(auth.APIKeys, key)
+	}
+
+	if auth.Enabled && auth.JWKSFile == "" && len(auth.APIKeys) == 0 {
+		return auth, fmt.Errorf("auth: jwks_file or api keys are required")
+	}
+	return auth, nil
+}
@@ -0,0 +1,404 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// A Diagnostic is a problem found while checking
+// the configuration. Line is 0 if the problem can
+// not be attributed to a line of the file.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Message string
+}
+
+// Error implements the error interface
+func (d *Diagnostic) Error() string {
+	if d.Line == 0 {
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+}
+
+// sectionSchema describes the keys of a section
+type sectionSchema struct {
+	keys     []string
+	prefixes []string // e.g. header.<name>
+	anyKey   bool
+
+	// checkLine validates the lines of sections
+	// which are not parsed as key value pairs.
+	checkLine func(line string) error
+}
+
+var (
+	communitiesSchema = &sectionSchema{checkLine: checkCommunityLine}
+	freeformSchema    = &sectionSchema{anyKey: true}
+)
+
+// Keys of the sections which are read with section.Key.
+var (
+	sourceKeys = []string{
+		"name", "group", "blackholes", "visibility",
+	}
+	alertRuleKeys = []string{
+		"webhooks", "sources", "neighbor_state", "filter_reason",
+		"asn", "routes_received_below", "routes_filtered_above",
+		"routes_filtered_increase", "route_query",
+	}
+	apiKeyKeys = []string{"key", "asns"}
+)
+
+// getSectionSchema looks up the schema of a section.
+// Sections with an unknown name have no schema.
+func getSectionSchema(name string) (*sectionSchema, error) {
+	switch name {
+	case "server":
+		return &sectionSchema{keys: sources.IniKeys(ServerConfig{})}, nil
+	case "postgres":
+		return &sectionSchema{keys: sources.IniKeys(PostgresConfig{})}, nil
+	case "housekeeping":
+		return &sectionSchema{keys: sources.IniKeys(HousekeepingConfig{})}, nil
+	case "theme":
+		return &sectionSchema{keys: sources.IniKeys(ThemeConfig{})}, nil
+	case "pagination":
+		return &sectionSchema{keys: sources.IniKeys(PaginationConfig{})}, nil
+	case "noexport":
+		return &sectionSchema{keys: sources.IniKeys(NoexportsConfig{})}, nil
+	case "alerts":
+		return &sectionSchema{keys: sources.IniKeys(AlertsConfig{})}, nil
+	case "rate_limit":
+		return &sectionSchema{keys: sources.IniKeys(RateLimitConfig{})}, nil
+	case "auth":
+		return &sectionSchema{keys: sources.IniKeys(AuthConfig{})}, nil
+	case "health":
+		return &sectionSchema{keys: sources.IniKeys(HealthConfig{})}, nil
+	case "tracing":
+		return &sectionSchema{keys: sources.IniKeys(TracingConfig{})}, nil
+	case "routes_columns", "neighbors_columns", "lookup_columns", "auth.subjects":
+		return freeformSchema, nil
+	case "bgp_communities", "rejection_reasons", "noexport_reasons":
+		return communitiesSchema, nil
+	case "blackhole_communities":
+		return &sectionSchema{checkLine: checkRangeCommunityLine}, nil
+	case "rejection_candidates":
+		return &sectionSchema{checkLine: checkRejectionCandidatesLine}, nil
+	case "rpki":
+		return &sectionSchema{checkLine: checkRpkiLine}, nil
+	}
+
+	prefix, id, _ := strings.Cut(name, ".")
+	if id == "" {
+		return nil, fmt.Errorf("unknown section [%s]", name)
+	}
+	switch prefix {
+	case "alert":
+		return &sectionSchema{keys: alertRuleKeys}, nil
+	case "webhook":
+		return &sectionSchema{
+			keys:     sources.IniKeys(WebhookConfig{}),
+			prefixes: []string{"header."},
+		}, nil
+	case "rate_limit":
+		return &sectionSchema{keys: sources.IniKeys(RateLimitClassConfig{})}, nil
+	case "api_key":
+		return &sectionSchema{keys: apiKeyKeys}, nil
+	case "source":
+		_, backendName, ok := strings.Cut(id, ".")
+		if !ok {
+			return &sectionSchema{keys: sourceKeys}, nil
+		}
+		backend, ok := sources.GetBackend(backendName)
+		if !ok {
+			return nil, fmt.Errorf(
+				"unknown source backend [%s], available backends: %s",
+				name, strings.Join(sources.Backends(), ", "))
+		}
+		return &sectionSchema{keys: backend.Keys}, nil
+	}
+	return nil, fmt.Errorf("unknown section [%s]", name)
+}
+
+// hasKey checks if the key is known in the section
+func (schema *sectionSchema) hasKey(key string) bool {
+	if schema.anyKey || slices.Contains(schema.keys, key) {
+		return true
+	}
+	for _, prefix := range schema.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkCommunityPattern validates a community of
+// a BGPCommunityMap. Each part is a number or a
+// wildcard, extended communities start with the type.
+func checkCommunityPattern(pattern string) error {
+	parts := strings.Split(pattern, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ErrInvalidCommunity(pattern)
+	}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "*" {
+			continue
+		}
+		if i == 0 && len(parts) == 3 && isExtCommunityType(part) {
+			continue
+		}
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return ErrInvalidCommunity(pattern)
+		}
+	}
+	return nil
+}
+
+// isExtCommunityType checks for the type of an
+// extended community, e.g. rt or ro.
+func isExtCommunityType(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCommunityLine validates a line of a
+// community section: <community> = <label>
+func checkCommunityLine(line string) error {
+	community, label, ok := strings.Cut(line, "=")
+	if !ok || strings.TrimSpace(label) == "" {
+		return fmt.Errorf("expected <community> = <label>: %s", line)
+	}
+	return checkCommunityPattern(strings.TrimSpace(community))
+}
+
+// checkRangeCommunity validates a community with
+// ranges and wildcards, e.g. 65535:0-100 or 23:42:1-*
+func checkRangeCommunity(community string) error {
+	parts := strings.Split(community, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return ErrInvalidCommunity(community)
+	}
+	for i, part := range parts {
+		if i == 0 && len(parts) == 3 && isExtCommunityType(part) {
+			continue
+		}
+		for value := range strings.SplitSeq(part, "-") {
+			if value == "*" {
+				continue
+			}
+			if _, err := strconv.ParseUint(value, 10, 32); err != nil {
+				return ErrInvalidCommunity(community)
+			}
+		}
+	}
+	return nil
+}
+
+// checkRangeCommunityLine validates a line
+// of the blackhole communities
+func checkRangeCommunityLine(line string) error {
+	if _, err := parseRangeCommunity(line); err != nil {
+		return err
+	}
+	return checkRangeCommunity(line)
+}
+
+// checkRejectionCandidatesLine validates the
+// communities of the rejection candidates
+func checkRejectionCandidatesLine(line string) error {
+	key, value, _ := strings.Cut(line, "=")
+	if strings.TrimSpace(key) != "communities" {
+		return fmt.Errorf("unknown key %s", strings.TrimSpace(key))
+	}
+	for c := range strings.SplitSeq(value, ",") {
+		if err := checkCommunityPattern(strings.TrimSpace(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRpkiLine validates the rpki settings
+func checkRpkiLine(line string) error {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return fmt.Errorf("invalid rpki config line: %s", line)
+	}
+	key = strings.TrimSpace(key)
+	switch key {
+	case "enabled":
+		return nil
+	case "valid", "unknown", "not_checked", "invalid":
+		return checkRangeCommunity(strings.TrimSpace(value))
+	}
+	return fmt.Errorf("unknown key %s", key)
+}
+
+// configChecker collects the diagnostics
+type configChecker struct {
+	diagnostics []*Diagnostic
+}
+
+func (c *configChecker) report(line configLine, format string, args ...any) {
+	c.diagnostics = append(c.diagnostics, &Diagnostic{
+		File:    line.File,
+		Line:    line.Line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// CheckConfig validates a configuration file and the
+// included files. Unknown sections and keys, invalid
+// communities, duplicate sources, missing theme paths,
+// invalid variables and conflicts between files are
+// reported with the file and line. Finally the
+// configuration is loaded to report all other errors.
+// An error is returned if the file can not be read.
+func CheckConfig(filename string) ([]*Diagnostic, error) {
+	filename, err := getConfigFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	loader := newConfigLoader()
+	lines, err := loader.load(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &configChecker{diagnostics: loader.errs}
+
+	var (
+		section   string
+		schema    *sectionSchema
+		sections  = map[string]configLine{}
+		sourceIDs = map[string]configLine{}
+		themePath string
+		themeLine configLine
+	)
+	for _, line := range lines {
+		l := strings.TrimSpace(line.Text)
+		if l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, ";") {
+			continue
+		}
+
+		// Section header
+		if strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]") {
+			section = strings.TrimSpace(l[1 : len(l)-1])
+			schema, err = getSectionSchema(section)
+			if err != nil {
+				c.report(line, "%s", err)
+			}
+			id, isSource := strings.CutPrefix(section, "source.")
+			isSource = isSource && !strings.Contains(id, ".")
+			prev, ok := sections[section]
+			if !ok {
+				sections[section] = line
+			} else if prev.File == line.File && prev.Line != line.Line {
+				// Conflicts between files are
+				// reported by the loader.
+				if isSource {
+					c.report(line,
+						"duplicate source id %s, first defined on line %d",
+						id, prev.Line)
+				} else {
+					c.report(line,
+						"duplicate section [%s], first defined on line %d",
+						section, prev.Line)
+				}
+			}
+			if isSource {
+				sourceIDs[id] = line
+			}
+			continue
+		}
+
+		if section == "" {
+			c.report(line, "key outside of a section: %s", l)
+			continue
+		}
+		if schema == nil {
+			continue // The section was reported
+		}
+		if schema.checkLine != nil {
+			if err := schema.checkLine(l); err != nil {
+				c.report(line, "[%s]: %s", section, err)
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(l, "=")
+		if !ok {
+			key, value, ok = strings.Cut(l, ":")
+		}
+		if !ok {
+			c.report(line, "[%s]: expected <key> = <value>: %s", section, l)
+			continue
+		}
+		key = strings.Trim(strings.TrimSpace(key), "`")
+		if !schema.hasKey(key) {
+			c.report(line, "[%s]: unknown key %s", section, key)
+		}
+		if section == "theme" && key == "path" {
+			themePath = iniValue(value)
+			themeLine = line
+		}
+	}
+
+	// Backend sections require the source
+	for name, line := range sections {
+		id, ok := strings.CutPrefix(name, "source.")
+		if !ok {
+			continue
+		}
+		if id, _, isBackend := strings.Cut(id, "."); isBackend {
+			if _, ok := sourceIDs[id]; !ok {
+				c.report(line, "[%s]: source [source.%s] is not defined",
+					name, id)
+			}
+		}
+	}
+
+	if themePath != "" {
+		if info, err := os.Stat(themePath); err != nil {
+			c.report(themeLine, "[theme]: path can not be read: %s", err)
+		} else if !info.IsDir() {
+			c.report(themeLine, "[theme]: path %s is not a directory", themePath)
+		}
+	}
+
+	// Report everything else found by loading the config
+	if len(loader.errs) == 0 {
+		if _, err := LoadConfig(filename); err != nil {
+			c.report(configLine{File: filename}, "%s", err)
+		}
+	}
+
+	// Diagnostics of the configuration file come first
+	slices.SortStableFunc(c.diagnostics, func(a, b *Diagnostic) int {
+		switch {
+		case a.File == b.File:
+		case a.File == filename:
+			return -1
+		case b.File == filename:
+			return 1
+		default:
+			return strings.Compare(a.File, b.File)
+		}
+		return a.Line - b.Line
+	})
+	return c.diagnostics, nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, data string) string {
+	filename := filepath.Join(t.TempDir(), "alice.conf")
+	if err := os.WriteFile(filename, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestCheckConfig(t *testing.T) {
+	filename := writeTestConfig(t, strings.Join([]string{
+		"$ASN = 9033",                  // 1
+		"[server]",                     // 2
+		"listen_http = 127.0.0.1:7340", // 3
+		"listen_https = :443",          // 4
+		"asn = {ASN}",                  // 5
+		"[bgp_communities]",            // 6
+		"{ASN}:1 = tagged",             // 7
+		"9033:x = broken",              // 8
+		"[themes]",                     // 9
+		"[theme]",                      // 10
+		"path = /does/not/exist",       // 11
+		"[source.rs1]",                 // 12
+		"name = {MISSING}",             // 13
+		"[source.rs1.birdwatcher]",     // 14
+		"api = http://rs1/",            // 15
+		"type = multi_table",           // 16
+		"[source.rs1]",                 // 17
+		"[source.rs1.bird]",            // 18
+	}, "\n"))
+
+	diagnostics, err := CheckConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int]string{
+		4:  "unknown key listen_https",
+		8:  "invalid community: 9033:x",
+		9:  "unknown section [themes]",
+		11: "path can not be read",
+		13: "invalid variable expansion",
+		17: "duplicate source id rs1, first defined on line 12",
+		18: "unknown source backend [source.rs1.bird]",
+	}
+	for _, d := range diagnostics {
+		msg, ok := expected[d.Line]
+		if !ok {
+			t.Error("unexpected diagnostic:", d)
+			continue
+		}
+		if !strings.Contains(d.Message, msg) {
+			t.Errorf("line %d: expected %q, got: %s", d.Line, msg, d.Message)
+		}
+		delete(expected, d.Line)
+	}
+	for line, msg := range expected {
+		t.Errorf("line %d: missing diagnostic %q", line, msg)
+	}
+}
+
+func TestCheckConfigValid(t *testing.T) {
+	filename := writeTestConfig(t, strings.Join([]string{
+		"[server]",
+		"asn = 9033",
+		"[blackhole_communities]",
+		"12345:1105-1189:*",
+		"[rpki]",
+		"enabled = true",
+		"invalid = 9033:1000:4-*",
+		"[source.rs1]",
+		"name = rs1",
+		"[source.rs1.birdwatcher]",
+		"api = http://rs1/",
+		"type = multi_table",
+	}, "\n"))
+
+	diagnostics, err := CheckConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diagnostics {
+		t.Error("unexpected diagnostic:", d)
+	}
+
+	// Errors found while loading the configuration
+	// are reported without a line.
+	filename = writeTestConfig(t, "[server]\nasn = 9033\n"+
+		"[source.rs1]\n[source.rs1.birdwatcher]\ntype = dual_table\n")
+	diagnostics, err = CheckConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Line != 0 {
+		t.Error("expected a load error, got:", diagnostics)
+	}
+}
+
+func TestCheckConfigYAML(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "alice.yaml")
+	data := strings.Join([]string{
+		"server:",               // 1
+		"  asn: 9033",           // 2
+		"  listen_https: :443",  // 3
+		"bgp_communities:",      // 4
+		"  \"9033:x\": broken",  // 5
+		"source:",               // 6
+		"  rs1:",                // 7
+		"    name: rs1",         // 8
+		"    birdwatcher:",      // 9
+		"      api: http://rs1", // 10
+		"      typ: multi",      // 11
+	}, "\n")
+	if err := os.WriteFile(filename, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	diagnostics, err := CheckConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []int{}
+	for _, d := range diagnostics {
+		lines = append(lines, d.Line)
+	}
+	// The missing birdwatcher type is reported on load
+	if !reflect.DeepEqual(lines, []int{0, 3, 5, 11}) {
+		t.Error("unexpected diagnostics:", diagnostics)
+	}
+}
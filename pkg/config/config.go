@@ -5,13 +5,11 @@
 package config
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/go-ini/ini"
 
@@ -47,22 +45,24 @@ const (
 	SourceTypeOpenBGPD = "openbgpd"
 )
 
+// The built-in source backends. Additional backends
+// are registered with sources.Register.
 const (
 	// SourceBackendBirdwatcher is used to indicate that
 	// the source is using a birdwatcher interface.
-	SourceBackendBirdwatcher = "birdwatcher"
+	SourceBackendBirdwatcher = birdwatcher.Backend
 
 	// SourceBackendGoBGP is used when the source is consuming
 	// a GoBGP daemon via grpc API.
-	SourceBackendGoBGP = "gobgp"
+	SourceBackendGoBGP = gobgp.Backend
 
 	// SourceBackendOpenBGPDStateServer is used when the openbgpd
 	// is exported using the openbgpd-state-server.
-	SourceBackendOpenBGPDStateServer = "openbgpd-state-server"
+	SourceBackendOpenBGPDStateServer = openbgpd.BackendStateServer
 
 	// SourceBackendOpenBGPDBgplgd is used when the openbgpd
 	// state is exported through the bgplgd.
-	SourceBackendOpenBGPDBgplgd = "openbgpd-bgplgd"
+	SourceBackendOpenBGPDBgplgd = openbgpd.BackendBgplgd
 )
 
 const (
@@ -70,6 +70,10 @@ const (
 	// server will timeout.
 	DefaultHTTPTimeout = 120
 
+	// DefaultShutdownTimeout is the time in seconds the server
+	// waits for requests and refreshes when shutting down.
+	DefaultShutdownTimeout = 30
+
 	// DefaultPrefixLookupCommunityFilterCutoff is the number of
 	// routes after which the community filter will not be
 	// available.
@@ -78,6 +82,15 @@ const (
 	// DefaultRoutesStoreQueryLimit is the default limit for
 	// prefixes returned from the store.
 	DefaultRoutesStoreQueryLimit = 200000
+
+	// DefaultRoutesStoreHistory is the default number of
+	// refreshes the route changes are kept for. With a refresh
+	// interval of 5 minutes, this is the last day.
+	DefaultRoutesStoreHistory = 288
+
+	// DefaultGraphQLMaxCost is the default limit for the
+	// estimated cost of a GraphQL query.
+	DefaultGraphQLMaxCost = 1000
 )
 
 // A ServerConfig holds the runtime configuration
@@ -85,6 +98,7 @@ const (
 type ServerConfig struct {
 	Listen                            string `ini:"listen_http"`
 	HTTPTimeout                       int    `ini:"http_timeout"`
+	ShutdownTimeout                   int    `ini:"shutdown_timeout"`
 	EnablePrefixLookup                bool   `ini:"enable_prefix_lookup"`
 	PrefixLookupCommunityFilterCutoff int    `ini:"prefix_lookup_community_filter_cutoff"`
 	NeighborsStoreRefreshInterval     int    `ini:"neighbors_store_refresh_interval"`
@@ -92,11 +106,16 @@ type ServerConfig struct {
 	RoutesStoreRefreshInterval        int    `ini:"routes_store_refresh_interval"`
 	RoutesStoreRefreshParallelism     int    `ini:"routes_store_refresh_parallelism"`
 	RoutesStoreQueryLimit             uint   `ini:"routes_store_query_limit"`
+	RoutesStoreHistory                int    `ini:"routes_store_history"`
 	StoreBackend                      string `ini:"store_backend"`
 	DefaultAsn                        int    `ini:"asn"`
 	EnableNeighborsStatusRefresh      bool   `ini:"enable_neighbors_status_refresh"`
 	StreamParserThrottle              int    `ini:"stream_parser_throttle"`
 	EnableMetrics                     bool   `ini:"enable_metrics"`
+	EnableGraphQL                     bool   `ini:"enable_graphql"`
+	GraphQLMaxCost                    int    `ini:"graphql_max_cost"`
+	EnableConfigReload                bool   `ini:"enable_config_reload"`
+	SourcesDir                        string `ini:"sources_dir"`
 }
 
 // PostgresConfig is the configuration for the database
@@ -105,6 +124,11 @@ type PostgresConfig struct {
 	URL      string `ini:"url"`
 	MaxConns int32  `ini:"max_connections"`
 	MinConns int32  `ini:"min_connections"`
+
+	// User and Password override the credentials
+	// of the URL, so the URL can be kept free of secrets.
+	User     string `ini:"user"`
+	Password string `ini:"password"`
 }
 
 // HousekeepingConfig describes the housekeeping interval
@@ -193,12 +217,17 @@ type SourceConfig struct {
 	// Blackhole IPs
 	Blackholes []string
 
+	// Visibility of the filtered routes and
+	// neighbor details: public or members
+	Visibility string
+
 	// Source configurations
-	Type        string
-	Backend     string
-	Birdwatcher birdwatcher.Config
-	GoBGP       gobgp.Config
-	OpenBGPD    openbgpd.Config
+	Type    string
+	Backend string
+
+	// BackendConfig is the configuration decoded
+	// by the backend, e.g. a *birdwatcher.Config.
+	BackendConfig any
 
 	// Source instance
 	instance sources.Source
@@ -211,6 +240,11 @@ type Config struct {
 	Housekeeping HousekeepingConfig
 	UI           UIConfig
 	Sources      []*SourceConfig
+	Alerts       AlertsConfig
+	RateLimit    RateLimitConfig
+	Auth         AuthConfig
+	Health       HealthConfig
+	Tracing      TracingConfig
 	File         string
 }
 
@@ -239,37 +273,17 @@ func isSourceBase(section *ini.Section) bool {
 	return len(strings.Split(section.Name(), ".")) == 2
 }
 
-// Get backend configuration type
-func sourceBackendTypeFromConfig(section *ini.Section) (string, error) {
-	name := section.Name()
-	if strings.HasSuffix(name, "birdwatcher") {
-		return SourceBackendBirdwatcher, nil
-	} else if strings.HasSuffix(name, "gobgp") {
-		return SourceBackendGoBGP, nil
-	} else if strings.HasSuffix(name, "openbgpd-bgplgd") {
-		return SourceBackendOpenBGPDBgplgd, nil
-	} else if strings.HasSuffix(name, "openbgpd-state-server") {
-		return SourceBackendOpenBGPDStateServer, nil
-	}
-
-	return "", ErrSourceTypeUnknown
-}
-
-// sourceTypeFromBackendType will return the backend source type
-// for a given backend type
-func sourceTypeFromBackendType(t string) string {
-	switch t {
-	case SourceBackendBirdwatcher:
-		return SourceTypeBird
-	case SourceBackendGoBGP:
-		return SourceTypeGoBGP
-	case SourceBackendOpenBGPDStateServer:
-		return SourceTypeOpenBGPD
-	case SourceBackendOpenBGPDBgplgd:
-		return SourceTypeOpenBGPD
-	default:
-		return ""
+// sourceBackend gets the registered backend from
+// the name of the backend configuration section.
+func sourceBackend(
+	section, backendSection *ini.Section,
+) (*sources.Backend, error) {
+	name := strings.TrimPrefix(backendSection.Name(), section.Name()+".")
+	backend, ok := sources.GetBackend(name)
+	if !ok {
+		return nil, ErrSourceTypeUnknown
 	}
+	return backend, nil
 }
 
 // Get UI config: Routes Columns Default
@@ -700,8 +714,19 @@ func getUIConfig(config *ini.File) (UIConfig, error) {
 	return uiConfig, nil
 }
 
-func getSources(config *ini.File) ([]*SourceConfig, error) {
-	sources := []*SourceConfig{}
+func getSources(
+	config *ini.File,
+	server ServerConfig,
+) ([]*SourceConfig, error) {
+	sourceConfigs := []*SourceConfig{}
+
+	// The reject communities are used by the
+	// openbgpd sources.
+	rc, err := getRoutesRejections(config)
+	if err != nil {
+		return nil, err
+	}
+	rejectComms := rc.Reasons.Communities()
 
 	order := 0
 	sourceSections := config.ChildSections("source")
@@ -728,18 +753,22 @@ func getSources(config *ini.File) ([]*SourceConfig, error) {
 
 		// Configure backend
 		backendConfig := sourceConfigSections[0]
-		backendType, err := sourceBackendTypeFromConfig(backendConfig)
+		backend, err := sourceBackend(section, backendConfig)
 		if err != nil {
 			return nil, fmt.Errorf("%s has an unsupported backend", section.Name())
 		}
 
-		sourceType := sourceTypeFromBackendType(backendType)
-
 		// Make config
 		sourceName := section.Key("name").MustString("Unknown Source")
 		sourceGroup := section.Key("group").MustString("")
 		sourceBlackholes := decoders.TrimmedCSVStringList(
 			section.Key("blackholes").MustString(""))
+		sourceVisibility := section.Key("visibility").MustString(VisibilityPublic)
+		if sourceVisibility != VisibilityPublic &&
+			sourceVisibility != VisibilityMembers {
+			return nil, fmt.Errorf(
+				"%s: visibility must be public or members", section.Name())
+		}
 
 		srcCfg := &SourceConfig{
 			ID:         sourceID,
@@ -747,162 +776,65 @@ func getSources(config *ini.File) ([]*SourceConfig, error) {
 			Name:       sourceName,
 			Group:      sourceGroup,
 			Blackholes: sourceBlackholes,
-			Backend:    backendType,
-			Type:       sourceType,
+			Visibility: sourceVisibility,
+			Backend:    backend.Name,
+			Type:       backend.Type,
 		}
 
 		// Register route server ID with pool
 		pools.RouteServers.Acquire(sourceID)
 
-		// Set backend
-		switch backendType {
-		case SourceBackendBirdwatcher:
-			sourceType := backendConfig.Key("type").MustString("")
-			mainTable := backendConfig.Key("main_table").MustString("master")
-
-			mainTable4 := backendConfig.Key("main_table4").MustString("")
-			mainTable6 := backendConfig.Key("main_table6").MustString("")
-
-			peerTablePrefix := backendConfig.Key("peer_table_prefix").MustString("T")
-			pipeProtocolPrefix := backendConfig.Key("pipe_protocol_prefix").MustString("M")
-
-			if sourceType != "single_table" &&
-				sourceType != "multi_table" {
-				log.Fatal("Configuration error (birdwatcher source) unknown birdwatcher type:", sourceType)
-			}
-
-			c := birdwatcher.Config{
-				ID:   srcCfg.ID,
-				Name: srcCfg.Name,
-
-				Timezone:        "UTC",
-				ServerTime:      "2006-01-02T15:04:05.999999999Z07:00",
-				ServerTimeShort: "2006-01-02",
-				ServerTimeExt:   "Mon, 02 Jan 2006 15:04:05 -0700",
-
-				Type:               sourceType,
-				MainTable:          mainTable,
-				MainTable4:         mainTable4,
-				MainTable6:         mainTable6,
-				PeerTablePrefix:    peerTablePrefix,
-				PipeProtocolPrefix: pipeProtocolPrefix,
-			}
-
-			if err := backendConfig.MapTo(&c); err != nil {
-				return nil, err
-			}
-			srcCfg.Birdwatcher = c
-
-			log.Println("Adding birdwatcher source", c.Name, "of type", sourceType)
-			if sourceType == "multi_table" {
-				log.Println("  Peer table prefix:", peerTablePrefix)
-				log.Println("  Pipe protocol prefix:", pipeProtocolPrefix)
-				if c.AltPipeProtocolSuffix != "" {
-					log.Println("  Alternative pipe protocol prefix:", c.AltPipeProtocolPrefix)
-					log.Println("  Alternative pipe protocol suffix:", c.AltPipeProtocolSuffix)
-				}
-			}
-
-		case SourceBackendGoBGP:
-			c := gobgp.Config{
-				ID:   srcCfg.ID,
-				Name: srcCfg.Name,
-			}
-
-			if err := backendConfig.MapTo(&c); err != nil {
-				return nil, err
-			}
-			// Update defaults:
-			//  - processing_timeout
-			if c.ProcessingTimeout == 0 {
-				c.ProcessingTimeout = 300
-			}
-
-			srcCfg.GoBGP = c
-
-		case SourceBackendOpenBGPDStateServer:
-			// Get cache TTL and reject communities from the config
-			cacheTTL := time.Second * time.Duration(backendConfig.Key("cache_ttl").MustInt(300))
-			routesCacheSize := backendConfig.Key("routes_cache_size").MustInt(1024)
-			rc, err := getRoutesRejections(config)
-			if err != nil {
-				return nil, err
-			}
-			rejectComms := rc.Reasons.Communities()
-
-			c := openbgpd.Config{
-				ID:                srcCfg.ID,
-				Name:              srcCfg.Name,
-				CacheTTL:          cacheTTL,
-				RoutesCacheSize:   routesCacheSize,
-				RejectCommunities: rejectComms,
-			}
-			if err := backendConfig.MapTo(&c); err != nil {
-				return nil, err
-			}
-			srcCfg.OpenBGPD = c
-
-		case SourceBackendOpenBGPDBgplgd:
-			// Get cache TTL from the config
-			cacheTTL := time.Second * time.Duration(backendConfig.Key("cache_ttl").MustInt(300))
-			routesCacheSize := backendConfig.Key("routes_cache_size").MustInt(1024)
-			rc, err := getRoutesRejections(config)
-			if err != nil {
-				return nil, err
-			}
-			rejectComms := rc.Reasons.Communities()
-
-			c := openbgpd.Config{
-				ID:                srcCfg.ID,
-				Name:              srcCfg.Name,
-				CacheTTL:          cacheTTL,
-				RoutesCacheSize:   routesCacheSize,
-				RejectCommunities: rejectComms,
-			}
-			if err := backendConfig.MapTo(&c); err != nil {
-				return nil, err
-			}
-			srcCfg.OpenBGPD = c
+		// Decode the backend configuration
+		srcCfg.BackendConfig, err = backend.DecodeConfig(&sources.Settings{
+			ID:                   srcCfg.ID,
+			Name:                 srcCfg.Name,
+			RejectCommunities:    rejectComms,
+			StreamParserThrottle: server.StreamParserThrottle,
+		}, backendConfig)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", backendConfig.Name(), err)
 		}
 
 		// Add to list of sources
-		sources = append(sources, srcCfg)
+		sourceConfigs = append(sourceConfigs, srcCfg)
 		order++
 	}
 
-	return sources, nil
+	return sourceConfigs, nil
 }
 
-// preprocessConfig parses the variables in the config
-// and applies it to the rest of the config.
-func preprocessConfig(data []byte) []byte {
-	lines := bytes.Split(data, []byte("\n"))
-	config := make([][]byte, 0, len(lines))
-
-	expMap := ExpandMap{}
-	for _, line := range lines {
-		l := strings.TrimSpace(string(line))
-		if strings.HasPrefix(l, "$") {
-			expMap.AddExpr(l[1:])
-			continue
-		}
-		config = append(config, line)
-	}
+// The bgp communities and rpki sections are
+// handled by our own parser.
+var unparseableSections = []string{
+	"bgp_communities",
+	"blackhole_communities",
+	"rejection_reasons",
+	"rejection_candidates",
+	"noexport_reasons",
+	"rpki",
+}
 
-	// Now apply to config
-	configLines := []string{}
-	for _, line := range config {
-		l := string(line)
-		exp, err := expMap.Expand(l)
-		if err != nil {
-			log.Fatal("Error expanding expression in config:", l, err)
-		}
-		configLines = append(configLines, exp...)
+// loadIniConfig reads the configuration file with the
+// included files and the sources_dir. YAML configurations
+// are translated into ini.
+func loadIniConfig(file string) (*ini.File, error) {
+	loader := newConfigLoader()
+	lines, err := loader.load(file)
+	if err != nil {
+		return nil, err
+	}
+	if err := loader.err(); err != nil {
+		return nil, err
 	}
-	return []byte(strings.Join(configLines, "\n"))
+	return ini.LoadSources(ini.LoadOptions{
+		UnparseableSections: unparseableSections,
+	}, iniText(lines))
 }
 
 // LoadConfig reads a configuration from a file.
+// The file is read as YAML if the extension is
+// .yaml or .yml, otherwise as ini. Keys are
+// overridden by ALICE_ environment variables.
 func LoadConfig(file string) (*Config, error) {
 	// Try to get config file, fallback to alternatives
 	file, err := getConfigFile(file)
@@ -910,38 +842,26 @@ func LoadConfig(file string) (*Config, error) {
 		return nil, err
 	}
 
-	// Read the config file and preprocess it
-	configData, err := os.ReadFile(file)
+	parsedConfig, err := loadIniConfig(file)
 	if err != nil {
 		return nil, err
 	}
-	configData = preprocessConfig(configData)
-
-	// Load configuration, but handle bgp communities section
-	// with our own parser
-	parsedConfig, err := ini.LoadSources(ini.LoadOptions{
-		UnparseableSections: []string{
-			"bgp_communities",
-			"blackhole_communities",
-			"rejection_reasons",
-			"rejection_candidates",
-			"noexport_reasons",
-			"rpki",
-		},
-	}, configData)
-	if err != nil {
+	if err := applyEnvOverrides(parsedConfig, os.Environ()); err != nil {
 		return nil, err
 	}
 
 	// Map sections
 	server := ServerConfig{
 		HTTPTimeout:                       DefaultHTTPTimeout,
+		ShutdownTimeout:                   DefaultShutdownTimeout,
 		PrefixLookupCommunityFilterCutoff: DefaultPrefixLookupCommunityFilterCutoff,
 		StoreBackend:                      "memory",
 		RoutesStoreRefreshParallelism:     1,
 		NeighborsStoreRefreshParallelism:  1,
 		RoutesStoreQueryLimit:             DefaultRoutesStoreQueryLimit,
+		RoutesStoreHistory:                DefaultRoutesStoreHistory,
 		EnableMetrics:                     true,
+		GraphQLMaxCost:                    DefaultGraphQLMaxCost,
 	}
 	if err := parsedConfig.Section("server").MapTo(&server); err != nil {
 		return nil, err
@@ -965,7 +885,7 @@ func LoadConfig(file string) (*Config, error) {
 	}
 
 	// Get all sources
-	sources, err := getSources(parsedConfig)
+	sources, err := getSources(parsedConfig, server)
 	if err != nil {
 		return nil, err
 	}
@@ -976,11 +896,34 @@ func LoadConfig(file string) (*Config, error) {
 		return nil, err
 	}
 
-	// Update stream parser throttle on all birdwatcher sources
-	for _, src := range sources {
-		if src.Backend == SourceBackendBirdwatcher {
-			src.Birdwatcher.StreamParserThrottle = server.StreamParserThrottle
-		}
+	// Alerting rules and webhooks
+	alerts, err := getAlertsConfig(parsedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request rate limits
+	rateLimit, err := getRateLimitConfig(parsedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Member authentication
+	auth, err := getAuthConfig(parsedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Readiness checks
+	health, err := getHealthConfig(parsedConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// Trace export
+	tracing, err := getTracingConfig(parsedConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	config := &Config{
@@ -989,6 +932,11 @@ func LoadConfig(file string) (*Config, error) {
 		Housekeeping: housekeeping,
 		UI:           ui,
 		Sources:      sources,
+		Alerts:       alerts,
+		RateLimit:    rateLimit,
+		Auth:         auth,
+		Health:       health,
+		Tracing:      tracing,
 		File:         file,
 	}
 
@@ -1001,17 +949,11 @@ func (cfg *SourceConfig) GetInstance() sources.Source {
 		return cfg.instance
 	}
 
-	var instance sources.Source
-	switch cfg.Backend {
-	case SourceBackendBirdwatcher:
-		instance = birdwatcher.NewBirdwatcher(cfg.Birdwatcher)
-	case SourceBackendGoBGP:
-		instance = gobgp.NewGoBGP(cfg.GoBGP)
-	case SourceBackendOpenBGPDStateServer:
-		instance = openbgpd.NewStateServerSource(&cfg.OpenBGPD)
-	case SourceBackendOpenBGPDBgplgd:
-		instance = openbgpd.NewBgplgdSource(&cfg.OpenBGPD)
+	backend, ok := sources.GetBackend(cfg.Backend)
+	if !ok {
+		return nil
 	}
+	instance := sources.Instrumented(cfg.ID, backend.New(cfg.BackendConfig))
 
 	cfg.instance = instance
 	return instance
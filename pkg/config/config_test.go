@@ -3,6 +3,8 @@ package config
 import (
 	"testing"
 
+	"github.com/go-ini/ini"
+
 	"github.com/alice-lg/alice-lg/pkg/sources/birdwatcher"
 	"github.com/alice-lg/alice-lg/pkg/sources/gobgp"
 )
@@ -58,28 +60,26 @@ func TestSourceConfig(t *testing.T) {
 	rs2 := config.Sources[1] // Birdwatcher v6
 	rs3 := config.Sources[2] // GoBGP
 
-	nilBirdwatcherConfig := birdwatcher.Config{}
-	if rs1.Birdwatcher == nilBirdwatcherConfig {
+	if _, ok := rs1.BackendConfig.(*birdwatcher.Config); !ok {
 		t.Errorf(
 			"Example routeserver %s should have been identified as a birdwatcher source but was not",
 			rs1.Name,
 		)
 	}
-	if rs2.Birdwatcher == nilBirdwatcherConfig {
+	if rs2Config, ok := rs2.BackendConfig.(*birdwatcher.Config); !ok {
 		t.Errorf(
 			"Example routeserver %s should have been identified as a birdwatcher source but was not",
 			rs2.Name,
 		)
 	} else {
-		if rs2.Birdwatcher.AltPipeProtocolSuffix != "_lg" {
-			t.Error("unexpected alt_pipe_suffix:", rs2.Birdwatcher.AltPipeProtocolSuffix)
+		if rs2Config.AltPipeProtocolSuffix != "_lg" {
+			t.Error("unexpected alt_pipe_suffix:", rs2Config.AltPipeProtocolSuffix)
 		}
-		if rs2.Birdwatcher.StreamParserThrottle != 2342 {
-			t.Error("Unexpected StreamParserThrottle", rs2.Birdwatcher.StreamParserThrottle)
+		if rs2Config.StreamParserThrottle != 2342 {
+			t.Error("Unexpected StreamParserThrottle", rs2Config.StreamParserThrottle)
 		}
 	}
-	nilGoBGPConfig := gobgp.Config{}
-	if rs3.GoBGP == nilGoBGPConfig {
+	if _, ok := rs3.BackendConfig.(*gobgp.Config); !ok {
 		t.Errorf(
 			"Example routeserver %s should have been identified as a gobgp source but was not",
 			rs3.Name,
@@ -87,6 +87,28 @@ func TestSourceConfig(t *testing.T) {
 	}
 }
 
+func TestSourceConfigUnknownBackend(t *testing.T) {
+	cfg, err := ini.Load([]byte(
+		"[source.rs1]\nname = rs1\n[source.rs1.bird-unknown]\napi = x\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getSources(cfg, ServerConfig{}); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
+
+func TestSourceConfigBackendValidation(t *testing.T) {
+	cfg, err := ini.Load([]byte(
+		"[source.rs1]\nname = rs1\n[source.rs1.birdwatcher]\ntype = dual_table\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getSources(cfg, ServerConfig{}); err == nil {
+		t.Error("expected a validation error for the birdwatcher type")
+	}
+}
+
 func TestSourceConfigDefaultsOverride(t *testing.T) {
 	config, err := LoadConfig("testdata/alice.conf")
 	if err != nil {
@@ -94,15 +116,15 @@ func TestSourceConfigDefaultsOverride(t *testing.T) {
 	}
 
 	// Get sources
-	rs1 := config.Sources[0] // Birdwatcher v4
-	rs2 := config.Sources[1] // Birdwatcher v6
-	rs3 := config.Sources[2] // GoBGP
+	rs1 := config.Sources[0].BackendConfig.(*birdwatcher.Config) // Birdwatcher v4
+	rs2 := config.Sources[1].BackendConfig.(*birdwatcher.Config) // Birdwatcher v6
+	rs3 := config.Sources[2].BackendConfig.(*gobgp.Config)       // GoBGP
 
 	// Source 1 should be on default time
 	// Source 2 should have an override
 	// For now it should be sufficient to test if
 	// the serverTime(rs1) != serverTime(rs2)
-	if rs1.Birdwatcher.ServerTime == rs2.Birdwatcher.ServerTime {
+	if rs1.ServerTime == rs2.ServerTime {
 		t.Error("Server times should be different between",
 			"source 1 and 2 in example configuration",
 			"(alice.example.conf)")
@@ -110,18 +132,18 @@ func TestSourceConfigDefaultsOverride(t *testing.T) {
 
 	// Check presence of timezone, default: UTC (rs1)
 	// override: Europe/Bruessels (rs2)
-	if rs1.Birdwatcher.Timezone != "UTC" {
+	if rs1.Timezone != "UTC" {
 		t.Error("Expected RS1 Timezone to be default: UTC")
 	}
 
-	if rs2.Birdwatcher.Timezone != "Europe/Brussels" {
-		t.Error("Expected 'Europe/Brussels', got", rs2.Birdwatcher.Timezone)
+	if rs2.Timezone != "Europe/Brussels" {
+		t.Error("Expected 'Europe/Brussels', got", rs2.Timezone)
 	}
 
-	if rs3.GoBGP.ProcessingTimeout != 300 {
+	if rs3.ProcessingTimeout != 300 {
 		t.Error(
 			"Expected GoBGP example to set 300s 'processing_timeout', got",
-			rs3.GoBGP.ProcessingTimeout,
+			rs3.ProcessingTimeout,
 		)
 	}
 }
@@ -243,6 +265,19 @@ func TestDefaultHTTPTimeout(t *testing.T) {
 	}
 }
 
+// TestDefaultShutdownTimeout checks the default of the
+// shutdown timeout
+func TestDefaultShutdownTimeout(t *testing.T) {
+	config, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal("Could not load test config:", err)
+	}
+	if config.Server.ShutdownTimeout != DefaultShutdownTimeout {
+		t.Error("Expected shutdown timeout be set to", DefaultShutdownTimeout,
+			"but got", config.Server.ShutdownTimeout)
+	}
+}
+
 func TestPostgresStoreConfig(t *testing.T) {
 	config, _ := LoadConfig("testdata/alice.conf")
 	if config.Server.StoreBackend != "postgres" {
@@ -272,3 +307,170 @@ func TestGetBlackholeCommunities(t *testing.T) {
 	}
 	t.Log(comms)
 }
+
+func TestAlertsConfig(t *testing.T) {
+	config, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal("Could not load test config:", err)
+	}
+	alerts := config.Alerts
+	if alerts.HistorySize != 500 {
+		t.Error("unexpected history size:", alerts.HistorySize)
+	}
+
+	webhook := alerts.WebhookByID("noc")
+	if webhook == nil {
+		t.Fatal("expected webhook noc")
+	}
+	if webhook.Retries != 5 || webhook.Timeout != DefaultWebhookTimeout {
+		t.Error("unexpected webhook config:", webhook)
+	}
+	if webhook.Headers["Authorization"] != "Bearer secret" {
+		t.Error("unexpected headers:", webhook.Headers)
+	}
+
+	if len(alerts.Rules) != 2 {
+		t.Fatal("expected 2 rules, got:", len(alerts.Rules))
+	}
+	rule := alerts.Rules[0]
+	if rule.ID != "session_down" || rule.NeighborState != "down" ||
+		len(rule.ASNs) != 2 || rule.ASNs[0] != 2342 || rule.IsRouteRule() {
+		t.Error("unexpected rule:", rule)
+	}
+	rule = alerts.Rules[1]
+	if rule.ID != "rpki_invalid" || !rule.IsRouteRule() ||
+		rule.Sources[0] != "rs1-example" {
+		t.Error("unexpected rule:", rule)
+	}
+}
+
+func TestWebhookConfigInvalid(t *testing.T) {
+	for _, section := range []string{
+		"[webhook.noc]\nurl = http://localhost\ntimeout = 0\n",
+		"[webhook.noc]\nurl = http://localhost\nretries = -1\n",
+	} {
+		cfg, err := ini.Load([]byte(section))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := getWebhooks(cfg); err == nil {
+			t.Error("expected an error for:", section)
+		}
+	}
+}
+
+func TestRateLimitConfig(t *testing.T) {
+	config, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal("Could not load test config:", err)
+	}
+	limits := config.RateLimit
+	if !limits.Enabled || limits.MaxConcurrentLookups != 4 ||
+		limits.MaxEventStreams != 2 {
+		t.Error("unexpected rate limit config:", limits)
+	}
+	if limits.APIKeyHeader != DefaultRateLimitAPIKeyHeader {
+		t.Error("unexpected api key header:", limits.APIKeyHeader)
+	}
+	lookup := limits.Classes[RateLimitClassLookup]
+	if lookup == nil || lookup.Rate != 0.5 || lookup.Burst != 10 {
+		t.Error("unexpected lookup limit:", lookup)
+	}
+	if limits.Classes[RateLimitClassRoutes] != nil {
+		t.Error("routes should not be limited")
+	}
+}
+
+func TestRateLimitConfigUnknownClass(t *testing.T) {
+	cfg, err := ini.Load([]byte("[rate_limit.search]\nrate = 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getRateLimitConfig(cfg); err == nil {
+		t.Error("expected an error for an unknown class")
+	}
+}
+
+func TestHealthConfig(t *testing.T) {
+	config, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal("Could not load test config:", err)
+	}
+	health := config.Health
+	if !health.CheckPostgres || health.SourcesInitialized != 50 ||
+		health.MaxSourceError != 600 {
+		t.Error("unexpected health config:", health)
+	}
+
+	cfg, err := ini.Load([]byte("[health]\nsources_initialized = 120\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getHealthConfig(cfg); err == nil {
+		t.Error("expected an error for an invalid percentage")
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	cfg, err := ini.Load([]byte(
+		"[tracing]\nenabled = true\nendpoint = http://localhost:4318\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing, err := getTracingConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tracing.Enabled || tracing.ServiceName != DefaultTracingServiceName ||
+		tracing.SampleRatio != 1 {
+		t.Error("unexpected tracing config:", tracing)
+	}
+
+	cfg, err = ini.Load([]byte("[tracing]\nsample_ratio = 1.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getTracingConfig(cfg); err == nil {
+		t.Error("expected an error for an invalid sample ratio")
+	}
+}
+
+func TestAuthConfig(t *testing.T) {
+	config, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal("Could not load test config:", err)
+	}
+	auth := config.Auth
+	if !auth.Enabled || auth.Issuer != "https://sso.example.com" ||
+		auth.ASNClaim != DefaultAuthASNClaim {
+		t.Error("unexpected auth config:", auth)
+	}
+	if !auth.Subjects["noc@example.com"].All {
+		t.Error("expected a grant for all ASNs")
+	}
+	grant := auth.Subjects["member@example.net"]
+	if grant == nil || len(grant.ASNs) != 2 || grant.ASNs[0] != 31078 {
+		t.Error("unexpected grant:", grant)
+	}
+	if len(auth.APIKeys) != 1 || auth.APIKeys[0].ID != "member" ||
+		auth.APIKeys[0].Key != "s3cr3t" {
+		t.Error("unexpected api keys:", auth.APIKeys)
+	}
+
+	if config.Sources[0].Visibility != VisibilityPublic {
+		t.Error("expected public visibility:", config.Sources[0].Visibility)
+	}
+	if config.Sources[1].Visibility != VisibilityMembers {
+		t.Error("expected members visibility:", config.Sources[1].Visibility)
+	}
+}
+
+func TestAuthConfigWithoutCredentials(t *testing.T) {
+	cfg, err := ini.Load([]byte("[auth]\nenabled = true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getAuthConfig(cfg); err == nil {
+		t.Error("expected an error without jwks file or api keys")
+	}
+}
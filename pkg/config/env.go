@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/go-ini/ini"
+)
+
+// EnvPrefix is the prefix of the environment variables
+// overriding keys of the configuration:
+//
+//	ALICE_SERVER_LISTEN_HTTP=:7340
+//	ALICE_SOURCE_RS1_BIRDWATCHER_API=http://rs1:29184/
+//
+// The name of the section and the key are upper case,
+// dots and dashes are replaced by underscores. With the
+// suffix _FILE the value is read from a file:
+//
+//	ALICE_POSTGRES_PASSWORD_FILE=/run/secrets/postgres
+const EnvPrefix = "ALICE_"
+
+// envSuffixFile marks variables read from a file
+const envSuffixFile = "_FILE"
+
+// Sections which can be overridden, even if
+// they are not present in the configuration.
+var envSections = []string{
+	"server",
+	"postgres",
+	"housekeeping",
+	"theme",
+	"pagination",
+	"noexport",
+	"alerts",
+	"rate_limit",
+	"auth",
+	"health",
+	"tracing",
+}
+
+// envName converts a section name into the
+// name used in environment variables.
+func envName(name string) string {
+	name = strings.NewReplacer(".", "_", "-", "_").Replace(name)
+	return strings.ToUpper(name)
+}
+
+// applyEnvOverrides sets the keys of the configuration
+// from the environment. Variables which do not match
+// a section are ignored, unknown keys are an error.
+func applyEnvOverrides(config *ini.File, environ []string) error {
+	sections := map[string]string{} // env name -> section
+	for _, name := range envSections {
+		sections[envName(name)] = name
+	}
+	for _, name := range config.SectionStrings() {
+		if name == ini.DefaultSection || isUnparseableSection(name) {
+			continue
+		}
+		sections[envName(name)] = name
+	}
+
+	for _, env := range environ {
+		name, value, _ := strings.Cut(env, "=")
+		name, ok := strings.CutPrefix(name, EnvPrefix)
+		if !ok {
+			continue
+		}
+
+		// Find the longest matching section, as a
+		// source and its backend share the prefix.
+		section, key := "", ""
+		for envSection, s := range sections {
+			k, ok := strings.CutPrefix(name, envSection+"_")
+			if ok && len(s) > len(section) {
+				section, key = s, strings.ToLower(k)
+			}
+		}
+		if section == "" {
+			log.Printf(
+				"ignoring environment variable %s%s: no matching section",
+				EnvPrefix, name)
+			continue
+		}
+
+		schema, err := getSectionSchema(section)
+		if err != nil {
+			return err
+		}
+		fileKey, isFile := strings.CutSuffix(key, strings.ToLower(envSuffixFile))
+		if isFile && !schema.hasKey(key) {
+			data, err := os.ReadFile(value)
+			if err != nil {
+				return fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+			}
+			key = fileKey
+			value = strings.TrimRight(string(data), "\r\n")
+		}
+		if !schema.hasKey(key) {
+			return fmt.Errorf("%s%s: unknown key %s in [%s]",
+				EnvPrefix, name, key, section)
+		}
+		config.Section(section).Key(key).SetValue(value)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/sources/birdwatcher"
+	"github.com/alice-lg/alice-lg/pkg/sources/openbgpd"
+)
+
+func TestApplyEnvOverrides(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(secret, []byte("s3cr3t\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := ini.Load([]byte(
+		"[server]\nlisten_http = 127.0.0.1:7340\n" +
+			"[source.rs1]\nname = rs1\n" +
+			"[source.rs1.birdwatcher]\napi = http://localhost/\n" +
+			"[source.rs1-v6]\nname = rs1 v6\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = applyEnvOverrides(config, []string{
+		"HOME=/root",
+		"ALICE_SERVER_LISTEN_HTTP=:8080",
+		"ALICE_SOURCE_RS1_BIRDWATCHER_API=http://rs1:29184/",
+		"ALICE_SOURCE_RS1_BIRDWATCHER_PASSWORD_FILE=" + secret,
+		"ALICE_SOURCE_RS1_V6_NAME=rs1 (IPv6)",
+		"ALICE_POSTGRES_URL=postgres:///alice",
+		"ALICE_AUTH_JWKS_FILE=/etc/alice-lg/jwks.json",
+		"ALICE_TEST_DB_URL=postgres:///alice_test",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"server.listen_http":              ":8080",
+		"source.rs1.birdwatcher.api":      "http://rs1:29184/",
+		"source.rs1.birdwatcher.password": "s3cr3t",
+		"source.rs1-v6.name":              "rs1 (IPv6)",
+		"source.rs1.name":                 "rs1",
+		"postgres.url":                    "postgres:///alice",
+		"auth.jwks_file":                  "/etc/alice-lg/jwks.json",
+	}
+	for path, value := range expected {
+		dot := strings.LastIndex(path, ".")
+		v := config.Section(path[:dot]).Key(path[dot+1:]).String()
+		if v != value {
+			t.Errorf("%s: expected %q, got %q", path, value, v)
+		}
+	}
+
+	// Unknown keys are an error
+	err = applyEnvOverrides(config, []string{"ALICE_SERVER_LISTEN_HTTPS=:443"})
+	if err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+	err = applyEnvOverrides(config, []string{"ALICE_POSTGRES_PASSWORD_FILE=/does/not/exist"})
+	if err == nil {
+		t.Error("expected an error for a missing secret file")
+	}
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(secret, []byte("pgpass\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ALICE_POSTGRES_PASSWORD_FILE", secret)
+	t.Setenv("ALICE_SOURCE_RS4_EXAMPLE_BGPLGD_OPENBGPD_BGPLGD_TOKEN", "t0k3n")
+	t.Setenv("ALICE_SOURCE_RS1_EXAMPLE_V6_BIRDWATCHER_USERNAME", "alice")
+
+	cfg, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Postgres.Password != "pgpass" {
+		t.Error("unexpected postgres password:", cfg.Postgres.Password)
+	}
+	bgplgd := cfg.SourceByID("rs4-example-bgplgd").BackendConfig.(*openbgpd.Config)
+	if bgplgd.Auth.Token != "t0k3n" {
+		t.Error("unexpected token:", bgplgd.Auth.Token)
+	}
+	bw := cfg.SourceByID("rs1-example-v6").BackendConfig.(*birdwatcher.Config)
+	if bw.Auth.Username != "alice" {
+		t.Error("unexpected username:", bw.Auth.Username)
+	}
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -56,8 +58,10 @@ func (e ExpandMap) matchWildcard(pattern string) []string {
 	// Strip the wildcard from the pattern.
 	pattern = strings.TrimSuffix(pattern, "*")
 
-	// Iterate variables and add match to result set
-	for k := range e {
+	// Iterate variables and add match to result set.
+	// The variables are sorted, so the expanded lines
+	// have the same order every time.
+	for _, k := range slices.Sorted(maps.Keys(e)) {
 		if key, ok := strings.CutPrefix(k, pattern); ok {
 			matches = append(matches, key)
 		}
@@ -167,21 +171,30 @@ func (e ExpandMap) Expand(s string) ([]string, error) {
 // AddExpr inserts a new variable to the map. Key and value are
 // expanded.
 func (e ExpandMap) AddExpr(expr string) error {
+	_, err := e.addExpr(expr)
+	return err
+}
+
+// addExpr inserts the variables of the expression
+// and returns their keys.
+func (e ExpandMap) addExpr(expr string) ([]string, error) {
 	// Expand expression
 	res, err := e.Expand(expr)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	keys := make([]string, 0, len(res))
 	for _, exp := range res {
 		// Split key and value
 		parts := strings.SplitN(exp, "=", 2)
 		if len(parts) != 2 {
-			return fmt.Errorf("invalid expression '%s'", expr)
+			return nil, fmt.Errorf("invalid expression '%s'", expr)
 		}
 		key := strings.TrimSpace(parts[0])
 		val := strings.TrimSpace(parts[1])
 		e[key] = val
+		keys = append(keys, key)
 	}
 
-	return nil
+	return keys, nil
 }
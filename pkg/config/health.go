@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/go-ini/ini"
+)
+
+// DefaultHealthSourcesInitialized is the percentage of
+// sources which must be initialized for being ready.
+const DefaultHealthSourcesInitialized = 100
+
+// HealthConfig configures the readiness checks
+// of the /readyz endpoint.
+type HealthConfig struct {
+	// CheckPostgres requires the database to be reachable
+	// and migrated when the postgres store backend is used.
+	CheckPostgres bool `ini:"check_postgres"`
+
+	// SourcesInitialized is the percentage of sources
+	// which must be initialized in the neighbors and
+	// routes stores.
+	SourcesInitialized int `ini:"sources_initialized"`
+
+	// MaxSourceError is the time in seconds the refreshes
+	// of a source may fail. 0 disables the check.
+	MaxSourceError int `ini:"max_source_error"`
+}
+
+// getHealthConfig reads the [health] section
+func getHealthConfig(config *ini.File) (HealthConfig, error) {
+	health := HealthConfig{
+		CheckPostgres:      true,
+		SourcesInitialized: DefaultHealthSourcesInitialized,
+	}
+	if err := config.Section("health").MapTo(&health); err != nil {
+		return health, err
+	}
+	if health.SourcesInitialized < 0 || health.SourcesInitialized > 100 {
+		return health, fmt.Errorf(
+			"health: sources_initialized must be a percentage")
+	}
+	if health.MaxSourceError < 0 {
+		return health, fmt.Errorf(
+			"health: max_source_error must not be negative")
+	}
+	return health, nil
+}
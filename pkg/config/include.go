@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A configuration can be split into multiple files.
+// Other files are included with a directive:
+//
+//	@include communities.conf
+//	@include sources/*.conf
+//
+// The files are read in place of the directive, patterns
+// are expanded in lexical order. Relative paths are
+// resolved from the directory of the including file.
+//
+// All files in the sources_dir of the [server] section are
+// read after the configuration file in lexical order. These
+// files may only define sources.
+//
+// The `$` variables of all files are shared. A section may
+// span multiple files, but keys defined with different values
+// in two files, sources defined in two files and variables
+// defined in two files are conflicts.
+
+const includeDirective = "@include"
+
+// The extensions of the files read from the sources_dir
+var sourcesDirExtensions = []string{".conf", ".yaml", ".yml"}
+
+// Sections mapping communities to labels. The same community
+// must not be labeled differently in two files.
+var communityMapSections = []string{
+	"bgp_communities",
+	"rejection_reasons",
+	"noexport_reasons",
+}
+
+// configLoader reads the lines of a configuration
+// file, the included files and the sources_dir.
+type configLoader struct {
+	vars     ExpandMap
+	varsDefs map[string]configLine
+	lines    []configLine
+
+	// Files which are currently read,
+	// for detecting include cycles.
+	reading []string
+
+	// Files read from the sources_dir
+	sourcesDirFiles map[string]bool
+
+	errs []*Diagnostic
+}
+
+func newConfigLoader() *configLoader {
+	return &configLoader{
+		vars:            ExpandMap{},
+		varsDefs:        map[string]configLine{},
+		sourcesDirFiles: map[string]bool{},
+	}
+}
+
+// report adds an error of the line
+func (l *configLoader) report(line configLine, format string, args ...any) {
+	l.errs = append(l.errs, &Diagnostic{
+		File:    line.File,
+		Line:    line.Line,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// err joins the errors found while loading
+func (l *configLoader) err() error {
+	errs := make([]error, 0, len(l.errs))
+	for _, d := range l.errs {
+		errs = append(errs, d)
+	}
+	return errors.Join(errs...)
+}
+
+// load reads the configuration file, the included files
+// and the sources_dir and expands the variables. Problems
+// in the files are collected in errs. An error is returned
+// if the configuration file can not be read.
+func (l *configLoader) load(filename string) ([]configLine, error) {
+	if err := l.readFile(filename); err != nil {
+		return nil, err
+	}
+	l.readSourcesDir()
+	lines := l.expand()
+	l.checkConflicts(lines)
+	return lines, nil
+}
+
+// readFile adds the lines of the file, reads the
+// included files and collects the variables.
+func (l *configLoader) readFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	// YAML files do not have directives
+	if isYAMLFile(filename) {
+		lines, err := yamlToIni(data)
+		if err != nil {
+			l.report(configLine{File: filename}, "%s", err)
+			return nil
+		}
+		for _, line := range lines {
+			line.File = filename
+			l.lines = append(l.lines, line)
+		}
+		return nil
+	}
+
+	l.reading = append(l.reading, filename)
+	defer func() {
+		l.reading = l.reading[:len(l.reading)-1]
+	}()
+
+	// Included files must start a section and the including
+	// file must start a new section after the include.
+	included := len(l.reading) > 1
+	section := ""
+	for n, text := range strings.Split(string(data), "\n") {
+		line := configLine{File: filename, Line: n + 1, Text: text}
+		t := strings.TrimSpace(text)
+		if t == "" || strings.HasPrefix(t, "#") || strings.HasPrefix(t, ";") {
+			l.lines = append(l.lines, line)
+			continue
+		}
+		if strings.HasPrefix(t, "$") {
+			l.addVariable(line, t[1:])
+			continue
+		}
+		if strings.Fields(t)[0] == includeDirective {
+			pattern := strings.TrimPrefix(t, includeDirective)
+			l.include(line, strings.TrimSpace(pattern))
+			section = ""
+			included = true
+			continue
+		}
+		if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+			section = t
+		} else if section == "" && included {
+			l.report(line, "expected a section before: %s", t)
+			continue
+		}
+		l.lines = append(l.lines, line)
+	}
+	return nil
+}
+
+// addVariable adds the variables of the expression.
+// Defining a variable in two files is a conflict.
+func (l *configLoader) addVariable(line configLine, expr string) {
+	prev := maps.Clone(l.vars)
+	keys, err := l.vars.addExpr(expr)
+	if err != nil {
+		l.report(line, "invalid variable $%s: %s", expr, err)
+		return
+	}
+	for _, key := range keys {
+		def, ok := l.varsDefs[key]
+		if !ok {
+			l.varsDefs[key] = line
+			continue
+		}
+		if def.File != line.File && prev[key] != l.vars[key] {
+			l.report(line,
+				"variable %s conflicts with the definition in %s:%d",
+				key, def.File, def.Line)
+		}
+	}
+}
+
+// include reads the files matching the pattern
+func (l *configLoader) include(line configLine, pattern string) {
+	if pattern == "" {
+		l.report(line, "%s requires a file", includeDirective)
+		return
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(line.File), pattern)
+	}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		l.report(line, "invalid include pattern %s: %s", pattern, err)
+		return
+	}
+	// A missing file is an error, a pattern
+	// might match no files.
+	if len(files) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		l.report(line, "can not include %s: file not found", pattern)
+		return
+	}
+	for _, filename := range files {
+		l.readIncluded(line, filename)
+	}
+}
+
+// readIncluded reads a file included by the line
+func (l *configLoader) readIncluded(line configLine, filename string) {
+	if slices.Contains(l.reading, filename) {
+		l.report(line, "include cycle: %s -> %s",
+			strings.Join(l.reading, " -> "), filename)
+		return
+	}
+	if err := l.readFile(filename); err != nil {
+		l.report(line, "can not include %s: %s", filename, err)
+	}
+}
+
+// readSourcesDir reads the files of the sources_dir
+// option of the [server] section.
+func (l *configLoader) readSourcesDir() {
+	var (
+		section string
+		dirLine configLine
+		dir     string
+	)
+	for _, line := range l.lines {
+		t := strings.TrimSpace(line.Text)
+		if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+			section = strings.TrimSpace(t[1 : len(t)-1])
+			continue
+		}
+		if section != "server" {
+			continue
+		}
+		key, value, ok := strings.Cut(t, "=")
+		if !ok || strings.TrimSpace(key) != "sources_dir" {
+			continue
+		}
+		dir, dirLine = iniValue(value), line
+	}
+	if dir == "" {
+		return
+	}
+
+	if expanded, err := l.vars.Expand(dir); err == nil && len(expanded) == 1 {
+		dir = expanded[0]
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(dirLine.File), dir)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		l.report(dirLine, "can not read sources_dir: %s", err)
+		return
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || !slices.Contains(sourcesDirExtensions, ext) {
+			continue
+		}
+		filename := filepath.Join(dir, entry.Name())
+		l.sourcesDirFiles[filename] = true
+
+		// The files are read as if included
+		// by the configuration file.
+		l.reading = append(l.reading, dirLine.File)
+		l.readIncluded(dirLine, filename)
+		l.reading = l.reading[:len(l.reading)-1]
+	}
+}
+
+// expand applies the variables to the lines. The
+// lines of YAML files are not expanded.
+func (l *configLoader) expand() []configLine {
+	lines := make([]configLine, 0, len(l.lines))
+	for _, line := range l.lines {
+		if isYAMLFile(line.File) {
+			lines = append(lines, line)
+			continue
+		}
+		expanded, err := l.vars.Expand(line.Text)
+		if err != nil {
+			l.report(line, "invalid variable expansion: %s", err)
+			continue
+		}
+		for _, text := range expanded {
+			lines = append(lines, configLine{
+				File: line.File,
+				Line: line.Line,
+				Text: text,
+			})
+		}
+	}
+	return lines
+}
+
+// checkConflicts reports sources defined in two files
+// and keys defined with different values in two files.
+func (l *configLoader) checkConflicts(lines []configLine) {
+	var (
+		section  string
+		sections = map[string]configLine{}
+		keys     = map[string]configLine{}
+		values   = map[string]string{}
+	)
+	for _, line := range lines {
+		t := strings.TrimSpace(line.Text)
+		if t == "" || strings.HasPrefix(t, "#") || strings.HasPrefix(t, ";") {
+			continue
+		}
+		if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+			section = strings.TrimSpace(t[1 : len(t)-1])
+			isSource := strings.HasPrefix(section, "source.")
+			if l.sourcesDirFiles[line.File] && !isSource {
+				l.report(line,
+					"[%s]: only sources can be defined in the sources_dir",
+					section)
+			}
+			prev, ok := sections[section]
+			if !ok {
+				sections[section] = line
+				continue
+			}
+			if isSource && prev.File != line.File {
+				l.report(line, "[%s] is already defined in %s:%d",
+					section, prev.File, prev.Line)
+			}
+			continue
+		}
+
+		// Sources can not span files, lists can
+		if section == "" || strings.HasPrefix(section, "source.") {
+			continue
+		}
+		if isUnparseableSection(section) &&
+			!slices.Contains(communityMapSections, section) {
+			continue
+		}
+		key, value, ok := strings.Cut(t, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = iniValue(value)
+		id := section + "\n" + key
+		prev, ok := keys[id]
+		if ok && prev.File != line.File && values[id] != value {
+			l.report(line,
+				"[%s]: %s conflicts with the definition in %s:%d",
+				section, key, prev.File, prev.Line)
+			continue
+		}
+		keys[id] = line
+		values[id] = value
+	}
+}
+
+// iniValue strips inline comments and quotes from a value
+func iniValue(value string) string {
+	value = strings.TrimSpace(value)
+	for _, q := range []string{`"""`, "`", `"`} {
+		if len(value) >= 2*len(q) &&
+			strings.HasPrefix(value, q) && strings.HasSuffix(value, q) {
+			return value[len(q) : len(value)-len(q)]
+		}
+	}
+	if i := strings.IndexAny(value, "#;"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFiles creates the files in a temporary
+// directory and returns the path of the first file.
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, data := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "alice.conf")
+}
+
+func TestLoadConfigIncludes(t *testing.T) {
+	filename := writeConfigFiles(t, map[string]string{
+		"alice.conf": strings.Join([]string{
+			"$ASN = 9033",
+			"[server]",
+			"sources_dir = sources.d",
+			"@include communities/*.conf",
+			"[source.rs0]",
+			"name = rs0",
+			"[source.rs0.birdwatcher]",
+			"api = http://rs0.example.net/",
+			"type = single_table",
+		}, "\n"),
+		"communities/labels.conf": strings.Join([]string{
+			"$RS = rs1",
+			"[bgp_communities]",
+			"{ASN}:1 = tagged by AS{ASN}",
+		}, "\n"),
+		"sources.d/20-rs2.yaml": strings.Join([]string{
+			"source:",
+			"  rs2:",
+			"    name: rs2",
+			"    birdwatcher:",
+			"      api: http://rs2.example.net/",
+			"      type: multi_table",
+		}, "\n"),
+		"sources.d/10-rs1.conf": strings.Join([]string{
+			"[source.{RS}]",
+			"name = {RS} (AS{ASN})",
+			"[source.{RS}.birdwatcher]",
+			"api = http://{RS}.example.net/",
+			"type = single_table",
+		}, "\n"),
+		"sources.d/README": "not a configuration",
+	})
+
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, src := range cfg.Sources {
+		ids = append(ids, src.ID)
+	}
+	if strings.Join(ids, ",") != "rs0,rs1,rs2" {
+		t.Error("unexpected sources:", ids)
+	}
+	if name := cfg.SourceByID("rs1").Name; name != "rs1 (AS9033)" {
+		t.Error("unexpected source name:", name)
+	}
+	label, err := cfg.UI.BGPCommunities.Lookup("9033:1")
+	if err != nil || label != "tagged by AS9033" {
+		t.Error("unexpected community label:", label, err)
+	}
+}
+
+func TestLoadConfigIncludeConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "source in two files",
+			files: map[string]string{
+				"alice.conf":       "[server]\nsources_dir = sources.d\n",
+				"sources.d/a.conf": "[source.rs1]\nname = a\n",
+				"sources.d/b.conf": "[source.rs1]\nname = b\n",
+			},
+			err: "b.conf:1: [source.rs1] is already defined in ",
+		},
+		{
+			name: "community label",
+			files: map[string]string{
+				"alice.conf": "[bgp_communities]\n1:23 = a\n@include b.conf\n",
+				"b.conf":     "[bgp_communities]\n1:23 = b\n",
+			},
+			err: "b.conf:2: [bgp_communities]: 1:23 conflicts with the definition in ",
+		},
+		{
+			name: "variable",
+			files: map[string]string{
+				"alice.conf": "$ASN = 1\n@include b.conf\n",
+				"b.conf":     "$ASN = 2\n",
+			},
+			err: "b.conf:1: variable ASN conflicts with the definition in ",
+		},
+		{
+			name: "section in sources_dir",
+			files: map[string]string{
+				"alice.conf":       "[server]\nsources_dir = sources.d\n",
+				"sources.d/a.conf": "[server]\nasn = 1\n",
+			},
+			err: "a.conf:1: [server]: only sources can be defined in the sources_dir",
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"alice.conf": "@include b.conf\n",
+				"b.conf":     "@include alice.conf\n",
+			},
+			err: "include cycle",
+		},
+		{
+			name: "missing section",
+			files: map[string]string{
+				"alice.conf": "[server]\n@include b.conf\nasn = 1\n",
+				"b.conf":     "asn = 2\n",
+			},
+			err: "b.conf:1: expected a section before: asn = 2",
+		},
+		{
+			name: "missing file",
+			files: map[string]string{
+				"alice.conf": "@include b.conf\n",
+			},
+			err: "alice.conf:1: can not include",
+		},
+	}
+	for _, tc := range tests {
+		filename := writeConfigFiles(t, tc.files)
+		_, err := LoadConfig(filename)
+		if err == nil {
+			t.Error(tc.name, ": expected an error")
+			continue
+		}
+		if !strings.Contains(err.Error(), tc.err) {
+			t.Error(tc.name, ": unexpected error:", err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/go-ini/ini"
+)
+
+// Endpoint classes of the rate limits
+const (
+	// RateLimitClassLookup are the prefix lookups,
+	// diffs, exports and GraphQL queries.
+	RateLimitClassLookup = "lookup"
+
+	// RateLimitClassRoutes are the requests for the
+	// neighbors and routes of a route server.
+	RateLimitClassRoutes = "routes"
+
+	// RateLimitClassDefault are all other API requests.
+	RateLimitClassDefault = "default"
+)
+
+// RateLimitClasses are the available endpoint classes
+var RateLimitClasses = []string{
+	RateLimitClassLookup,
+	RateLimitClassRoutes,
+	RateLimitClassDefault,
+}
+
+// DefaultRateLimitAPIKeyHeader is the header identifying
+// a client by API key instead of the IP address.
+const DefaultRateLimitAPIKeyHeader = "X-API-Key"
+
+// DefaultRateLimitMaxEventStreams is the number of
+// event streams a client can subscribe at the same time.
+const DefaultRateLimitMaxEventStreams = 4
+
+// RateLimitConfig configures the request rate limits
+// per client and the concurrency of the lookups.
+type RateLimitConfig struct {
+	Enabled bool `ini:"enabled"`
+
+	// Clients are identified by a configured API key
+	// in the header or by their IP address.
+	APIKeyHeader string `ini:"api_key_header"`
+
+	// Use the rightmost address of X-Forwarded-For when
+	// running behind a reverse proxy.
+	TrustProxyHeaders bool `ini:"trust_proxy_headers"`
+
+	// MaxConcurrentLookups limits the number of lookups
+	// processed at the same time. 0 is unlimited.
+	MaxConcurrentLookups int `ini:"max_concurrent_lookups"`
+
+	// MaxEventStreams limits the number of event streams
+	// of a client at the same time. 0 is unlimited.
+	MaxEventStreams int `ini:"max_event_streams"`
+
+	// Classes map endpoint classes to limits. Requests
+	// of classes without limits are not limited.
+	Classes map[string]*RateLimitClassConfig
+}
+
+// RateLimitClassConfig is the token bucket of a
+// client for an endpoint class: The bucket holds
+// up to burst requests and is refilled with rate
+// requests per second.
+type RateLimitClassConfig struct {
+	Rate  float64 `ini:"rate"`
+	Burst int     `ini:"burst"`
+}
+
+// getRateLimitConfig reads the [rate_limit] and
+// [rate_limit.<class>] sections.
+func getRateLimitConfig(config *ini.File) (RateLimitConfig, error) {
+	limits := RateLimitConfig{
+		APIKeyHeader:    DefaultRateLimitAPIKeyHeader,
+		MaxEventStreams: DefaultRateLimitMaxEventStreams,
+		Classes:         map[string]*RateLimitClassConfig{},
+	}
+	if err := config.Section("rate_limit").MapTo(&limits); err != nil {
+		return limits, err
+	}
+	if limits.MaxConcurrentLookups < 0 {
+		return limits, fmt.Errorf(
+			"rate_limit: max_concurrent_lookups must not be negative")
+	}
+	if limits.MaxEventStreams < 0 {
+		return limits, fmt.Errorf(
+			"rate_limit: max_event_streams must not be negative")
+	}
+
+	for _, section := range config.ChildSections("rate_limit") {
+		class := section.Name()[len("rate_limit."):]
+		if !slices.Contains(RateLimitClasses, class) {
+			return limits, fmt.Errorf(
+				"%s: unknown endpoint class: %s", section.Name(), class)
+		}
+		limit := &RateLimitClassConfig{}
+		if err := section.MapTo(limit); err != nil {
+			return limits, err
+		}
+		if limit.Rate <= 0 {
+			return limits, fmt.Errorf("%s: rate must be positive", section.Name())
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		limits.Classes[class] = limit
+	}
+	return limits, nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// SourcesDiff lists the sources added, removed or
+// reconfigured between two configurations.
+type SourcesDiff struct {
+	Added     []*SourceConfig
+	Removed   []*SourceConfig
+	Changed   []*SourceConfig
+	Unchanged []*SourceConfig
+}
+
+// IsEmpty is true if no source was added, removed
+// or reconfigured.
+func (diff *SourcesDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 &&
+		len(diff.Removed) == 0 &&
+		len(diff.Changed) == 0
+}
+
+// Reset lists the ids of the sources which data
+// must be discarded: removed and reconfigured sources.
+func (diff *SourcesDiff) Reset() []string {
+	ids := make([]string, 0, len(diff.Removed)+len(diff.Changed))
+	for _, src := range diff.Removed {
+		ids = append(ids, src.ID)
+	}
+	for _, src := range diff.Changed {
+		ids = append(ids, src.ID)
+	}
+	return ids
+}
+
+// Equal checks if the source configurations are the
+// same. The position in the configuration file and the
+// source instance are not compared.
+func (cfg *SourceConfig) Equal(other *SourceConfig) bool {
+	a, b := *cfg, *other
+	a.Order, b.Order = 0, 0
+	a.instance, b.instance = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// IsInstance checks if the instance was created
+// from this source configuration.
+func (cfg *SourceConfig) IsInstance(instance sources.Source) bool {
+	return cfg.instance != nil && cfg.instance == instance
+}
+
+// DiffSources compares the sources of the current and
+// the next configuration. The instances of unchanged
+// sources are handed over to the next configuration,
+// so caches and connections are kept.
+func DiffSources(current, next *Config) *SourcesDiff {
+	diff := &SourcesDiff{}
+	for _, src := range next.Sources {
+		prev := current.SourceByID(src.ID)
+		if prev == nil {
+			diff.Added = append(diff.Added, src)
+			continue
+		}
+		if !prev.Equal(src) {
+			diff.Changed = append(diff.Changed, src)
+			continue
+		}
+		src.instance = prev.instance
+		diff.Unchanged = append(diff.Unchanged, src)
+	}
+	for _, src := range current.Sources {
+		if next.SourceByID(src.ID) == nil {
+			diff.Removed = append(diff.Removed, src)
+		}
+	}
+	return diff
+}
+
+// RestartRequired lists the sections which were changed
+// in the next configuration, but are only applied when
+// alice is restarted.
+func (cfg *Config) RestartRequired(next *Config) []string {
+	sections := []string{}
+
+	// The sources are read from the sources_dir
+	// when the configuration is reloaded.
+	server, nextServer := cfg.Server, next.Server
+	server.SourcesDir, nextServer.SourcesDir = "", ""
+	if !reflect.DeepEqual(server, nextServer) {
+		sections = append(sections, "server")
+	}
+	if !reflect.DeepEqual(cfg.Postgres, next.Postgres) {
+		sections = append(sections, "postgres")
+	}
+	if !reflect.DeepEqual(cfg.RateLimit, next.RateLimit) {
+		sections = append(sections, "rate_limit")
+	}
+	if !reflect.DeepEqual(cfg.Auth, next.Auth) {
+		sections = append(sections, "auth")
+	}
+	if !reflect.DeepEqual(cfg.Alerts, next.Alerts) {
+		sections = append(sections, "alerts")
+	}
+	if !reflect.DeepEqual(cfg.Tracing, next.Tracing) {
+		sections = append(sections, "tracing")
+	}
+	return sections
+}
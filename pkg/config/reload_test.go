@@ -0,0 +1,75 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDiffSources(t *testing.T) {
+	current, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	instance := current.Sources[0].GetInstance()
+
+	next.Sources[1].Name = "rs1.example.net (IPv6)"
+	next.Sources = append(next.Sources, &SourceConfig{ID: "rs5"})
+	next.Sources = slices.DeleteFunc(next.Sources, func(src *SourceConfig) bool {
+		return src.ID == "rs2-example"
+	})
+
+	diff := DiffSources(current, next)
+	ids := func(sources []*SourceConfig) []string {
+		ids := []string{}
+		for _, src := range sources {
+			ids = append(ids, src.ID)
+		}
+		return ids
+	}
+	if !slices.Equal(ids(diff.Added), []string{"rs5"}) {
+		t.Error("unexpected added sources:", ids(diff.Added))
+	}
+	if !slices.Equal(ids(diff.Removed), []string{"rs2-example"}) {
+		t.Error("unexpected removed sources:", ids(diff.Removed))
+	}
+	if !slices.Equal(ids(diff.Changed), []string{"rs1-example-v6"}) {
+		t.Error("unexpected changed sources:", ids(diff.Changed))
+	}
+	if !slices.Equal(diff.Reset(), []string{"rs2-example", "rs1-example-v6"}) {
+		t.Error("unexpected reset sources:", diff.Reset())
+	}
+	if next.Sources[0].GetInstance() != instance {
+		t.Error("unchanged source should keep the instance")
+	}
+	if !next.Sources[0].IsInstance(instance) {
+		t.Error("expected instance of the unchanged source")
+	}
+
+	if DiffSources(current, current).IsEmpty() != true {
+		t.Error("expected empty diff")
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	current, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sections := current.RestartRequired(next); len(sections) != 0 {
+		t.Error("unexpected sections:", sections)
+	}
+	next.Server.Listen = "[::1]:7340"
+	next.RateLimit.Enabled = !next.RateLimit.Enabled
+	sections := current.RestartRequired(next)
+	if !slices.Equal(sections, []string{"server", "rate_limit"}) {
+		t.Error("unexpected sections:", sections)
+	}
+}
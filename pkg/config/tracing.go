@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/go-ini/ini"
+)
+
+// DefaultTracingServiceName is the service name
+// of the exported traces.
+const DefaultTracingServiceName = "alice-lg"
+
+// TracingConfig configures the export of the
+// traces to an OpenTelemetry collector.
+type TracingConfig struct {
+	Enabled bool `ini:"enabled"`
+
+	// Endpoint is the URL of the OTLP/HTTP receiver,
+	// e.g. http://localhost:4318. If not set, the
+	// OTEL_EXPORTER_OTLP_ENDPOINT variable is used.
+	Endpoint string `ini:"endpoint"`
+
+	ServiceName string `ini:"service_name"`
+
+	// SampleRatio is the fraction of the traces
+	// started by Alice which are recorded.
+	SampleRatio float64 `ini:"sample_ratio"`
+}
+
+// getTracingConfig reads the [tracing] section
+func getTracingConfig(config *ini.File) (TracingConfig, error) {
+	tracing := TracingConfig{
+		ServiceName: DefaultTracingServiceName,
+		SampleRatio: 1,
+	}
+	if err := config.Section("tracing").MapTo(&tracing); err != nil {
+		return tracing, err
+	}
+	if tracing.SampleRatio < 0 || tracing.SampleRatio > 1 {
+		return tracing, fmt.Errorf(
+			"tracing: sample_ratio must be between 0 and 1")
+	}
+	if tracing.Endpoint != "" {
+		u, err := url.Parse(tracing.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return tracing, fmt.Errorf(
+				"tracing: endpoint must be a http(s) URL: %s", tracing.Endpoint)
+		}
+	}
+	return tracing, nil
+}
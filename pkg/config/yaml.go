@@ -0,0 +1,412 @@
+package config
+
+import (
+	"fmt"
+	"iter"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-ini/ini"
+	"gopkg.in/yaml.v3"
+)
+
+// The YAML configuration has the same sections and
+// keys as the ini configuration:
+//
+//	server:
+//	  listen_http: 127.0.0.1:7340
+//	bgp_communities:
+//	  "65535:666": blackhole
+//	blackhole_communities:
+//	  - "12345:1105-1189:*"
+//	rpki:
+//	  enabled: true
+//	  valid:
+//	    - "9033:1000:1"
+//	source:
+//	  rs1:
+//	    name: rs1.example.net (IPv4)
+//	    birdwatcher:
+//	      api: http://rs1.example.net:29184/
+//
+// Nested mappings are subsections, e.g. [source.rs1]
+// and [source.rs1.birdwatcher]. Lists are joined with
+// commas, or repeated as lines in the community sections.
+// The YAML document is translated into an ini file,
+// so both formats are read by the same code.
+
+// configLine is a line of the configuration attributed
+// to the line of the file it was generated from.
+type configLine struct {
+	File string
+	Line int
+	Text string
+}
+
+// isYAMLFile checks the extension of the filename
+func isYAMLFile(filename string) bool {
+	ext := filepath.Ext(filename)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// isUnparseableSection checks if the section is read
+// line by line instead of as key value pairs.
+func isUnparseableSection(name string) bool {
+	return slices.Contains(unparseableSections, name)
+}
+
+// yamlToIni translates the YAML configuration into
+// the lines of an ini configuration.
+func yamlToIni(data []byte) ([]configLine, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return []configLine{}, nil // Empty document
+	}
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf(
+			"line %d: expected a mapping of sections", root.Line)
+	}
+
+	lines := []configLine{}
+	for key, value := range mappingEntries(root) {
+		switch value.Kind {
+		case yaml.MappingNode:
+			section, err := yamlSectionToIni(key.Value, key.Line, value)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, section...)
+		case yaml.SequenceNode:
+			// Sections with a list of communities
+			lines = append(lines, configLine{
+				Line: key.Line,
+				Text: "[" + key.Value + "]",
+			})
+			for _, item := range value.Content {
+				item = resolveAlias(item)
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf(
+						"line %d: [%s]: expected a list of values",
+						item.Line, key.Value)
+				}
+				lines = append(lines, configLine{
+					Line: item.Line,
+					Text: item.Value,
+				})
+			}
+		default:
+			return nil, fmt.Errorf(
+				"line %d: [%s]: expected a mapping or a list",
+				key.Line, key.Value)
+		}
+	}
+	return lines, nil
+}
+
+// yamlSectionToIni translates a mapping into a section.
+// Mappings in the section are added as subsections.
+func yamlSectionToIni(
+	name string,
+	line int,
+	section *yaml.Node,
+) ([]configLine, error) {
+	lines := []configLine{}
+	subsections := []configLine{}
+	unparseable := isUnparseableSection(name)
+
+	// Mappings only grouping subsections do not
+	// have a section on their own, e.g. source.
+	hasKeys := len(section.Content) == 0 || strings.Contains(name, ".")
+	for key, value := range mappingEntries(section) {
+		key, err := yamlToIniKey(name, key, unparseable)
+		if err != nil {
+			return nil, err
+		}
+		switch value.Kind {
+		case yaml.ScalarNode:
+			hasKeys = true
+			text, err := yamlToIniValue(name, value, unparseable)
+			if err != nil {
+				return nil, err
+			}
+			lines = append(lines, configLine{
+				Line: value.Line,
+				Text: key + " = " + text,
+			})
+		case yaml.SequenceNode:
+			hasKeys = true
+			values := []string{}
+			for _, item := range value.Content {
+				item = resolveAlias(item)
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf(
+						"line %d: [%s]: %s: expected a list of values",
+						item.Line, name, key)
+				}
+				text, err := yamlToIniValue(name, item, unparseable)
+				if err != nil {
+					return nil, err
+				}
+				if unparseable {
+					lines = append(lines, configLine{
+						Line: item.Line,
+						Text: key + " = " + text,
+					})
+				}
+				values = append(values, text)
+			}
+			if !unparseable {
+				lines = append(lines, configLine{
+					Line: value.Line,
+					Text: key + " = " + strings.Join(values, ", "),
+				})
+			}
+		case yaml.MappingNode:
+			sub, err := yamlSectionToIni(name+"."+key, value.Line, value)
+			if err != nil {
+				return nil, err
+			}
+			subsections = append(subsections, sub...)
+		}
+	}
+
+	if !hasKeys {
+		return subsections, nil
+	}
+	header := configLine{Line: line, Text: "[" + name + "]"}
+	lines = append([]configLine{header}, lines...)
+	return append(lines, subsections...), nil
+}
+
+// yamlToIniKey quotes the key if required
+func yamlToIniKey(
+	section string,
+	key *yaml.Node,
+	unparseable bool,
+) (string, error) {
+	if key.Kind != yaml.ScalarNode {
+		return "", fmt.Errorf(
+			"line %d: [%s]: expected a key", key.Line, section)
+	}
+	if unparseable || !strings.ContainsAny(key.Value, "=:") {
+		return key.Value, nil
+	}
+	if strings.Contains(key.Value, "`") {
+		return "", fmt.Errorf(
+			"line %d: [%s]: invalid key %s", key.Line, section, key.Value)
+	}
+	return "`" + key.Value + "`", nil
+}
+
+// yamlToIniValue quotes the value if required
+func yamlToIniValue(
+	section string,
+	value *yaml.Node,
+	unparseable bool,
+) (string, error) {
+	if value.Tag == "!!null" {
+		return "", nil
+	}
+	if strings.Contains(value.Value, "\n") {
+		return "", fmt.Errorf(
+			"line %d: [%s]: multiline values are not supported",
+			value.Line, section)
+	}
+	if unparseable || !strings.ContainsAny(value.Value, "#;`\"") {
+		return value.Value, nil
+	}
+	if strings.Contains(value.Value, "`") {
+		return `"""` + value.Value + `"""`, nil
+	}
+	return "`" + value.Value + "`", nil
+}
+
+// resolveAlias follows an alias to the anchored node
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// mappingEntries iterates the keys and values of a
+// mapping. The entries of mappings merged with `<<`
+// are inserted in place. Later keys override earlier
+// keys when the ini file is read.
+func mappingEntries(mapping *yaml.Node) iter.Seq2[*yaml.Node, *yaml.Node] {
+	return func(yield func(k, v *yaml.Node) bool) {
+		var entries func(m *yaml.Node) bool
+		entries = func(m *yaml.Node) bool {
+			for i := 0; i+1 < len(m.Content); i += 2 {
+				key, value := m.Content[i], resolveAlias(m.Content[i+1])
+				if key.Tag == "!!merge" {
+					merged := []*yaml.Node{value}
+					if value.Kind == yaml.SequenceNode {
+						merged = value.Content
+					}
+					for _, m := range merged {
+						if m = resolveAlias(m); m.Kind == yaml.MappingNode {
+							if !entries(m) {
+								return false
+							}
+						}
+					}
+					continue
+				}
+				if !yield(key, value) {
+					return false
+				}
+			}
+			return true
+		}
+		entries(mapping)
+	}
+}
+
+// iniText joins the configuration lines
+func iniText(lines []configLine) []byte {
+	text := make([]string, 0, len(lines))
+	for _, l := range lines {
+		text = append(text, l.Text)
+	}
+	return []byte(strings.Join(text, "\n"))
+}
+
+// ConvertToYAML reads an ini configuration and encodes
+// it as YAML. Variables are expanded, comments are
+// not preserved.
+func ConvertToYAML(filename string) ([]byte, error) {
+	filename, err := getConfigFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := loadIniConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, section := range parsed.Sections() {
+		name := section.Name()
+		if name == ini.DefaultSection {
+			if len(section.Keys()) > 0 {
+				return nil, fmt.Errorf(
+					"keys outside of a section are not supported")
+			}
+			continue
+		}
+
+		// Find or create the mapping of the section
+		node := root
+		for part := range strings.SplitSeq(name, ".") {
+			node = yamlMappingChild(node, part)
+		}
+
+		if isUnparseableSection(name) {
+			body := yamlSectionBody(section.Body())
+			if body.Kind == yaml.SequenceNode {
+				*node = *body
+				continue
+			}
+			node.Content = append(node.Content, body.Content...)
+			continue
+		}
+		for _, key := range section.Keys() {
+			node.Content = append(node.Content,
+				yamlScalar(key.Name()),
+				yamlScalar(key.Value()))
+		}
+	}
+
+	doc := &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{root},
+	}
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+// yamlMappingChild finds or adds the mapping of the key
+func yamlMappingChild(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, yamlScalar(key), child)
+	return child
+}
+
+// yamlSectionBody converts the body of a section which
+// is read line by line. A body without key value pairs
+// becomes a list, repeated keys become lists.
+func yamlSectionBody(body string) *yaml.Node {
+	values := []string{}
+	keys := []string{}
+	keyValues := map[string][]string{}
+	for line := range strings.Lines(body) {
+		l := strings.TrimSpace(line)
+		if l == "" || strings.HasPrefix(l, "#") || strings.HasPrefix(l, ";") {
+			continue
+		}
+		values = append(values, l)
+		key, value, ok := strings.Cut(l, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if _, ok := keyValues[key]; !ok {
+			keys = append(keys, key)
+		}
+		keyValues[key] = append(keyValues[key], strings.TrimSpace(value))
+	}
+
+	if len(keys) == 0 && len(values) > 0 {
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, v := range values {
+			list.Content = append(list.Content, yamlScalar(v))
+		}
+		return list
+	}
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range keys {
+		vals := keyValues[key]
+		if len(vals) == 1 {
+			mapping.Content = append(mapping.Content,
+				yamlScalar(key), yamlScalar(vals[0]))
+			continue
+		}
+		list := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, v := range vals {
+			list.Content = append(list.Content, yamlScalar(v))
+		}
+		mapping.Content = append(mapping.Content, yamlScalar(key), list)
+	}
+	return mapping
+}
+
+// yamlScalar creates a scalar node. The style is
+// chosen by the encoder, values which would be read
+// as null are quoted.
+func yamlScalar(value string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+	switch value {
+	case "~", "null", "Null", "NULL":
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	return node
+}
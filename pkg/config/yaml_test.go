@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/sources/birdwatcher"
+)
+
+func TestConvertToYAML(t *testing.T) {
+	data, err := ConvertToYAML("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), "alice.yaml")
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := LoadConfig("testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.File = expected.File
+
+	// A source requires the [source.<id>] section in ini,
+	// in YAML the backend section implies the source.
+	rs3 := cfg.SourceByID("rs3-example")
+	if rs3 == nil || expected.SourceByID("rs3-example") != nil {
+		t.Error("expected rs3-example only in the converted config")
+	}
+	cfg.Sources = slices.DeleteFunc(cfg.Sources, func(src *SourceConfig) bool {
+		return src == rs3
+	})
+	if len(cfg.Sources) != len(expected.Sources) {
+		t.Fatal("unexpected number of sources:", len(cfg.Sources))
+	}
+	for i, src := range cfg.Sources {
+		if !src.Equal(expected.Sources[i]) {
+			t.Error("converted source differs:", src.ID)
+		}
+	}
+	cfg.Sources = expected.Sources
+	if !reflect.DeepEqual(cfg, expected) {
+		t.Error("converted configuration differs from the ini configuration")
+	}
+}
+
+func TestLoadYAMLConfig(t *testing.T) {
+	data := strings.Join([]string{
+		"server:",
+		"  asn: 9033",
+		"bgp_communities:",
+		"  \"0:*\": do not redistribute to AS$1",
+		"  9033:65666:1: ip bogon detected",
+		"blackhole_communities:",
+		"  - \"12345:1105-1189:*\"",
+		"rpki:",
+		"  enabled: true",
+		"  invalid: [\"9033:1000:4-*\", \"9033:1000:5\"]",
+		"routes_columns:",
+		"  network: Network",
+		"  bgp.as_path: AS Path",
+		"source:",
+		"  rs1:",
+		"    name: \"rs1 # primary\"",
+		"    blackholes: [10.23.6.666, 10.23.6.665]",
+		"    birdwatcher: &birdwatcher",
+		"      api: http://rs1.example.net:29184/",
+		"      type: multi_table",
+		"      peer_table_prefix: T",
+		"      pipe_protocol_prefix: M",
+		"  rs2:",
+		"    birdwatcher:",
+		"      <<: *birdwatcher",
+		"      api: http://rs2.example.net:29184/",
+		"      type: single_table",
+	}, "\n")
+	filename := filepath.Join(t.TempDir(), "alice.yml")
+	if err := os.WriteFile(filename, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Server.DefaultAsn != 9033 {
+		t.Error("unexpected asn:", cfg.Server.DefaultAsn)
+	}
+	label, err := cfg.UI.BGPCommunities.Lookup("0:4223")
+	if err != nil || label != "do not redistribute to AS$1" {
+		t.Error("unexpected wildcard community label:", label, err)
+	}
+	if len(cfg.UI.BGPBlackholeCommunities.Large) != 1 {
+		t.Error("unexpected blackhole communities:",
+			cfg.UI.BGPBlackholeCommunities)
+	}
+	if !cfg.UI.Rpki.Enabled || len(cfg.UI.Rpki.Invalid) != 2 {
+		t.Error("unexpected rpki config:", cfg.UI.Rpki)
+	}
+	if cfg.UI.RoutesColumnsOrder[1] != "bgp.as_path" {
+		t.Error("unexpected routes columns:", cfg.UI.RoutesColumnsOrder)
+	}
+
+	if len(cfg.Sources) != 2 {
+		t.Fatal("expected two sources, got:", len(cfg.Sources))
+	}
+	rs1 := cfg.Sources[0]
+	if rs1.ID != "rs1" || rs1.Name != "rs1 # primary" {
+		t.Error("unexpected source:", rs1.ID, rs1.Name)
+	}
+	if len(rs1.Blackholes) != 2 {
+		t.Error("unexpected blackholes:", rs1.Blackholes)
+	}
+	rs2 := cfg.Sources[1]
+	bw, ok := rs2.BackendConfig.(*birdwatcher.Config)
+	if !ok {
+		t.Fatal("unexpected backend:", rs2.Backend)
+	}
+	if bw.API != "http://rs2.example.net:29184/" ||
+		bw.Type != "single_table" || bw.PeerTablePrefix != "T" {
+		t.Error("unexpected merged birdwatcher config:", bw)
+	}
+}
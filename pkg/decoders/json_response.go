@@ -3,11 +3,16 @@ package decoders
 // Helper for decoding json bodies from responses
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("github.com/alice-lg/alice-lg/pkg/decoders")
+
 // ReadJSONResponse reads a json blob from a
 // http response and decodes it into a map
 func ReadJSONResponse(res *http.Response) (map[string]any, error) {
@@ -18,7 +23,14 @@ func ReadJSONResponse(res *http.Response) (map[string]any, error) {
 		return nil, err
 	}
 
-	// Parse JSON
+	// Parse JSON in the trace of the request
+	ctx := context.Background()
+	if res.Request != nil {
+		ctx = res.Request.Context()
+	}
+	_, span := tracer.Start(ctx, "decoders.ReadJSONResponse")
+	defer span.End()
+
 	payload := make(map[string]any)
 	if err := json.Unmarshal(data, &payload); err != nil {
 		return nil, err
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// columnFunc extracts the value of a column from a route
+type columnFunc func(r *api.LookupRoute) string
+
+// Columns available in the CSV export. The keys
+// match the keys of the routes and lookup columns
+// in the UI config.
+var columns = map[string]columnFunc{
+	"network": func(r *api.LookupRoute) string {
+		return r.Network
+	},
+	"gateway": func(r *api.LookupRoute) string {
+		return derefString(r.Gateway)
+	},
+	"interface": func(r *api.LookupRoute) string {
+		return derefString(r.Interface)
+	},
+	"metric": func(r *api.LookupRoute) string {
+		return strconv.Itoa(r.Metric)
+	},
+	"age": func(r *api.LookupRoute) string {
+		return strconv.Itoa(int(r.Age.Seconds()))
+	},
+	"state": func(r *api.LookupRoute) string {
+		return r.State
+	},
+	"primary": func(r *api.LookupRoute) string {
+		return strconv.FormatBool(r.Primary)
+	},
+	"address_family": func(r *api.LookupRoute) string {
+		return strconv.Itoa(int(r.AddrFamily))
+	},
+	"neighbor_id": func(r *api.LookupRoute) string {
+		return derefString(r.NeighborID)
+	},
+	"neighbor.id": func(r *api.LookupRoute) string {
+		if r.Neighbor == nil {
+			return ""
+		}
+		return r.Neighbor.ID
+	},
+	"neighbor.address": func(r *api.LookupRoute) string {
+		if r.Neighbor == nil {
+			return ""
+		}
+		return r.Neighbor.Address
+	},
+	"neighbor.asn": func(r *api.LookupRoute) string {
+		if r.Neighbor == nil {
+			return ""
+		}
+		return strconv.Itoa(r.Neighbor.ASN)
+	},
+	"neighbor.description": func(r *api.LookupRoute) string {
+		if r.Neighbor == nil {
+			return ""
+		}
+		return r.Neighbor.Description
+	},
+	"routeserver.id": func(r *api.LookupRoute) string {
+		if r.RouteServer == nil {
+			return ""
+		}
+		return derefString(r.RouteServer.ID)
+	},
+	"routeserver.name": func(r *api.LookupRoute) string {
+		if r.RouteServer == nil {
+			return ""
+		}
+		return r.RouteServer.Name
+	},
+	"bgp.origin": bgpColumn(func(bgp *api.BGPInfo) string {
+		return derefString(bgp.Origin)
+	}),
+	"bgp.as_path": bgpColumn(func(bgp *api.BGPInfo) string {
+		path := make([]string, len(bgp.AsPath))
+		for i, asn := range bgp.AsPath {
+			path[i] = strconv.Itoa(asn)
+		}
+		return strings.Join(path, " ")
+	}),
+	"bgp.next_hop": bgpColumn(func(bgp *api.BGPInfo) string {
+		return derefString(bgp.NextHop)
+	}),
+	"bgp.local_pref": bgpColumn(func(bgp *api.BGPInfo) string {
+		return strconv.Itoa(bgp.LocalPref)
+	}),
+	"bgp.med": bgpColumn(func(bgp *api.BGPInfo) string {
+		return strconv.Itoa(bgp.Med)
+	}),
+	"bgp.otc": bgpColumn(func(bgp *api.BGPInfo) string {
+		if bgp.OTC == nil {
+			return ""
+		}
+		return strconv.Itoa(*bgp.OTC)
+	}),
+	"bgp.communities": bgpColumn(func(bgp *api.BGPInfo) string {
+		return joinCommunities(bgp.Communities)
+	}),
+	"bgp.large_communities": bgpColumn(func(bgp *api.BGPInfo) string {
+		return joinCommunities(bgp.LargeCommunities)
+	}),
+	"bgp.ext_communities": bgpColumn(func(bgp *api.BGPInfo) string {
+		values := make([]string, 0, len(bgp.ExtCommunities))
+		for _, c := range bgp.ExtCommunities {
+			values = append(values, formatExtCommunity(c))
+		}
+		return strings.Join(values, " ")
+	}),
+}
+
+// ErrUnknownColumn is returned for columns
+// not available in the export.
+type ErrUnknownColumn struct {
+	Column string
+}
+
+// Error implements the error interface
+func (err *ErrUnknownColumn) Error() string {
+	return fmt.Sprintf("unknown column: %s", err.Column)
+}
+
+// IsColumn checks if the column can be exported
+func IsColumn(column string) bool {
+	_, ok := columns[column]
+	return ok
+}
+
+// bgpColumn wraps an accessor for the bgp info
+func bgpColumn(fn func(*api.BGPInfo) string) columnFunc {
+	return func(r *api.LookupRoute) string {
+		if r.Route == nil || r.BGP == nil {
+			return ""
+		}
+		return fn(r.BGP)
+	}
+}
+
+func joinCommunities(communities api.Communities) string {
+	values := make([]string, len(communities))
+	for i, c := range communities {
+		values[i] = c.String()
+	}
+	return strings.Join(values, " ")
+}
+
+// formatExtCommunity renders the extended community.
+// The values may be strings or numbers depending on
+// the source and the store backend.
+func formatExtCommunity(c api.ExtCommunity) string {
+	values := make([]string, len(c))
+	for i, v := range c {
+		values[i] = fmt.Sprint(v)
+	}
+	return strings.Join(values, ":")
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// CSVWriter writes the selected columns of
+// the routes as comma separated values. The first
+// row contains the column keys.
+type CSVWriter struct {
+	w       *csv.Writer
+	columns []columnFunc
+	record  []string
+}
+
+// NewCSVWriter creates a new CSV writer. An error
+// is returned if a column is unknown.
+func NewCSVWriter(w io.Writer, keys []string) (*CSVWriter, error) {
+	funcs := make([]columnFunc, 0, len(keys))
+	for _, key := range keys {
+		fn, ok := columns[key]
+		if !ok {
+			return nil, &ErrUnknownColumn{Column: key}
+		}
+		funcs = append(funcs, fn)
+	}
+
+	cw := &CSVWriter{
+		w:       csv.NewWriter(w),
+		columns: funcs,
+		record:  make([]string, len(funcs)),
+	}
+	if err := cw.w.Write(keys); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write adds a row for the route
+func (w *CSVWriter) Write(r *api.LookupRoute) error {
+	for i, fn := range w.columns {
+		w.record[i] = fn(r)
+	}
+	return w.w.Write(w.record)
+}
+
+// Flush writes the buffered rows
+func (w *CSVWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// Close flushes the remaining rows
+func (w *CSVWriter) Close() error {
+	return w.Flush()
+}
@@ -0,0 +1,113 @@
+// Package export writes routes in bulk export
+// formats: newline delimited JSON, CSV and MRT.
+//
+// The writers encode the routes one by one, so a
+// large export does not have to be assembled in
+// memory before it is sent to the client.
+package export
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Export formats
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+	FormatMRT    = "mrt"
+)
+
+// Content types of the export formats
+var contentTypes = map[string]string{
+	FormatNDJSON: "application/x-ndjson",
+	FormatCSV:    "text/csv",
+	FormatMRT:    "application/octet-stream",
+}
+
+// File extensions of the export formats
+var fileExtensions = map[string]string{
+	FormatNDJSON: "ndjson",
+	FormatCSV:    "csv",
+	FormatMRT:    "mrt",
+}
+
+// ErrUnknownFormat is returned when the export
+// format is not supported.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// RoutesWriter encodes routes into an export format.
+// Close must be called after the last route was written.
+type RoutesWriter interface {
+	Write(r *api.LookupRoute) error
+	Close() error
+}
+
+// Options configure the routes writer
+type Options struct {
+	// Columns of the CSV export
+	Columns []string
+
+	// ViewName is included in the MRT peer index table
+	ViewName string
+
+	// Peers are the neighbors referenced by the routes
+	// in an MRT export.
+	Peers api.Neighbors
+
+	// Now is the time of the export
+	Now time.Time
+}
+
+// NewRoutesWriter creates a new writer for the format
+func NewRoutesWriter(
+	format string,
+	w io.Writer,
+	opts *Options,
+) (RoutesWriter, error) {
+	switch format {
+	case FormatNDJSON:
+		return NewNDJSONWriter(w), nil
+	case FormatCSV:
+		return NewCSVWriter(w, opts.Columns)
+	case FormatMRT:
+		return NewMRTWriter(w, opts.ViewName, opts.Peers, opts.Now)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// FormatFromContentType selects the export format
+// by a content type as used in an Accept header.
+// An empty string is returned if there is no match.
+func FormatFromContentType(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		mediaType = strings.TrimSpace(mediaType)
+		for format, contentType := range contentTypes {
+			if mediaType == contentType {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// ContentType returns the content type of the format
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// FileExtension returns the file extension for the format
+func FileExtension(format string) string {
+	return fileExtensions[format]
+}
+
+// IsFormat checks if the format is supported
+func IsFormat(format string) bool {
+	_, ok := contentTypes[format]
+	return ok
+}
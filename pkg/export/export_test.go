@@ -0,0 +1,127 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func makeTestExportRoute(
+	sourceID string,
+	neighborID string,
+	network string,
+) *api.LookupRoute {
+	origin := "IGP"
+	nextHop := "192.0.2.1"
+	return &api.LookupRoute{
+		Route: &api.Route{
+			NeighborID: &neighborID,
+			Network:    network,
+			Gateway:    &nextHop,
+			Age:        90 * time.Second,
+			BGP: &api.BGPInfo{
+				Origin:      &origin,
+				AsPath:      []int{2342, 23},
+				NextHop:     &nextHop,
+				LocalPref:   100,
+				Communities: api.Communities{{2342, 1}, {2342, 2}},
+				LargeCommunities: api.Communities{
+					{6695, 1000, 1},
+				},
+				ExtCommunities: api.ExtCommunities{
+					{"rt", 42, 23},
+				},
+			},
+		},
+		State: api.RouteStateImported,
+		Neighbor: &api.Neighbor{
+			ID:          neighborID,
+			Address:     "192.0.2.1",
+			ASN:         2342,
+			Description: "neighbor, " + neighborID,
+		},
+		RouteServer: &api.LookupRouteServer{
+			ID:   &sourceID,
+			Name: "Route Server " + sourceID,
+		},
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewRoutesWriter(FormatNDJSON, buf, &Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(makeTestExportRoute("rs1", "n1", "10.0.0.0/8"))
+	w.Write(makeTestExportRoute("rs1", "n1", "10.1.0.0/16"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatal("expected 2 lines, got:", len(lines))
+	}
+	r := &api.LookupRoute{}
+	if err := json.Unmarshal([]byte(lines[1]), r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Network != "10.1.0.0/16" {
+		t.Error("unexpected route:", r)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewRoutesWriter(FormatCSV, buf, &Options{
+		Columns: []string{
+			"network",
+			"bgp.as_path",
+			"neighbor.description",
+			"bgp.communities",
+			"bgp.ext_communities",
+			"age",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(makeTestExportRoute("rs1", "n1", "10.0.0.0/8"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "network,bgp.as_path,neighbor.description,bgp.communities,bgp.ext_communities,age\n" +
+		"10.0.0.0/8,2342 23,\"neighbor, n1\",2342:1 2342:2,rt:42:23,90\n"
+	if buf.String() != expected {
+		t.Error("unexpected csv:", buf.String())
+	}
+
+	// Unknown columns
+	_, err = NewCSVWriter(buf, []string{"network", "flags"})
+	var colErr *ErrUnknownColumn
+	if !errors.As(err, &colErr) || colErr.Column != "flags" {
+		t.Error("expected unknown column error, got:", err)
+	}
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	if f := FormatFromContentType("text/csv; charset=utf-8"); f != FormatCSV {
+		t.Error("unexpected format:", f)
+	}
+	if f := FormatFromContentType("text/html, application/x-ndjson"); f != FormatNDJSON {
+		t.Error("unexpected format:", f)
+	}
+	if f := FormatFromContentType("application/json"); f != "" {
+		t.Error("unexpected format:", f)
+	}
+	if _, err := NewRoutesWriter("xml", nil, &Options{}); err != ErrUnknownFormat {
+		t.Error("expected unknown format error, got:", err)
+	}
+}
@@ -0,0 +1,440 @@
+package export
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// MRT TABLE_DUMP_V2 (RFC 6396) types and subtypes
+const (
+	mrtTypeTableDumpV2 = 13
+
+	mrtSubtypePeerIndexTable = 1
+	mrtSubtypeRIBIPv4Unicast = 2
+	mrtSubtypeRIBIPv6Unicast = 4
+
+	mrtPeerTypeIPv6 = 0x01
+	mrtPeerTypeAS4  = 0x02
+)
+
+// BGP path attribute flags and types
+const (
+	bgpAttrFlagOptional   = 0x80
+	bgpAttrFlagTransitive = 0x40
+	bgpAttrFlagExtended   = 0x10
+
+	bgpAttrOrigin           = 1
+	bgpAttrASPath           = 2
+	bgpAttrNextHop          = 3
+	bgpAttrMED              = 4
+	bgpAttrLocalPref        = 5
+	bgpAttrCommunities      = 8
+	bgpAttrMPReachNLRI      = 14
+	bgpAttrExtCommunities   = 16
+	bgpAttrLargeCommunities = 32
+	bgpAttrOTC              = 35
+
+	bgpASPathSequence = 2
+
+	bgpOriginIGP        = 0
+	bgpOriginEGP        = 1
+	bgpOriginIncomplete = 2
+)
+
+// ErrTooManyPeers is returned when the peers do not
+// fit into the MRT peer index table.
+var ErrTooManyPeers = errors.New("too many peers for MRT peer index table")
+
+// ErrUnknownPeer is returned when the neighbor of a
+// route is not in the MRT peer index table.
+var ErrUnknownPeer = errors.New("neighbor of route is not in the peer index table")
+
+// MRTWriter writes the routes as MRT TABLE_DUMP_V2
+// RIB entries. The peer index table with all peers
+// is written first.
+//
+// All paths of a prefix are grouped in a single RIB
+// record, as long as the routes are ordered by network.
+type MRTWriter struct {
+	w     io.Writer
+	now   time.Time
+	peers map[string]uint16
+
+	seq     uint32
+	prefix  netip.Prefix
+	entries []byte
+	count   uint16
+}
+
+// NewMRTWriter creates a new MRT writer and
+// writes the peer index table.
+func NewMRTWriter(
+	w io.Writer,
+	viewName string,
+	peers api.Neighbors,
+	now time.Time,
+) (*MRTWriter, error) {
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if len(peers) > math.MaxUint16 {
+		return nil, ErrTooManyPeers
+	}
+
+	mw := &MRTWriter{
+		w:     w,
+		now:   now,
+		peers: make(map[string]uint16, len(peers)),
+	}
+
+	// Peer index table
+	table := make([]byte, 4, 64+len(peers)*12) // Collector BGP ID
+	table = binary.BigEndian.AppendUint16(table, uint16(len(viewName)))
+	table = append(table, viewName...)
+	table = binary.BigEndian.AppendUint16(table, uint16(len(peers)))
+	for i, peer := range peers {
+		mw.peers[mrtPeerKey(peer.RouteServerID, peer.ID)] = uint16(i)
+
+		addr, err := netip.ParseAddr(peer.Address)
+		if err != nil {
+			addr = netip.IPv4Unspecified()
+		}
+		addr = addr.Unmap()
+		peerType := byte(mrtPeerTypeAS4)
+		bgpID := []byte{0, 0, 0, 0}
+		if addr.Is6() {
+			peerType |= mrtPeerTypeIPv6
+		} else {
+			bgpID = addr.AsSlice()
+		}
+		table = append(table, peerType)
+		table = append(table, bgpID...)
+		table = append(table, addr.AsSlice()...)
+		table = binary.BigEndian.AppendUint32(table, uint32(peer.ASN))
+	}
+	if err := mw.writeRecord(mrtSubtypePeerIndexTable, table); err != nil {
+		return nil, err
+	}
+	return mw, nil
+}
+
+// mrtPeerKey identifies a neighbor of a source
+func mrtPeerKey(sourceID, neighborID string) string {
+	return sourceID + "/" + neighborID
+}
+
+// Write adds the route to the RIB record of the prefix.
+// Routes with an invalid network are skipped.
+func (w *MRTWriter) Write(r *api.LookupRoute) error {
+	prefix, err := netip.ParsePrefix(r.Network)
+	if err != nil {
+		return nil
+	}
+	prefix = prefix.Masked()
+
+	sourceID := ""
+	if r.RouteServer != nil && r.RouteServer.ID != nil {
+		sourceID = *r.RouteServer.ID
+	}
+	neighborID := derefString(r.NeighborID)
+	if r.Neighbor != nil {
+		neighborID = r.Neighbor.ID
+	}
+	index, ok := w.peers[mrtPeerKey(sourceID, neighborID)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPeer, neighborID)
+	}
+
+	if prefix != w.prefix || w.count == math.MaxUint16 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+		w.prefix = prefix
+	}
+
+	// RIB entry
+	originated := w.now.Add(-r.Age)
+	attrs := encodePathAttributes(prefix, r.BGP)
+	w.entries = binary.BigEndian.AppendUint16(w.entries, index)
+	w.entries = binary.BigEndian.AppendUint32(w.entries, uint32(originated.Unix()))
+	w.entries = binary.BigEndian.AppendUint16(w.entries, uint16(len(attrs)))
+	w.entries = append(w.entries, attrs...)
+	w.count++
+
+	return nil
+}
+
+// Close writes the last RIB record
+func (w *MRTWriter) Close() error {
+	return w.flush()
+}
+
+// flush writes the RIB record of the current prefix
+func (w *MRTWriter) flush() error {
+	if w.count == 0 {
+		return nil
+	}
+	subtype := uint16(mrtSubtypeRIBIPv4Unicast)
+	if w.prefix.Addr().Is6() {
+		subtype = mrtSubtypeRIBIPv6Unicast
+	}
+
+	bits := w.prefix.Bits()
+	addr := w.prefix.Addr().AsSlice()
+
+	rib := make([]byte, 0, 11+len(addr)+len(w.entries))
+	rib = binary.BigEndian.AppendUint32(rib, w.seq)
+	rib = append(rib, byte(bits))
+	rib = append(rib, addr[:(bits+7)/8]...)
+	rib = binary.BigEndian.AppendUint16(rib, w.count)
+	rib = append(rib, w.entries...)
+
+	w.seq++
+	w.entries = w.entries[:0]
+	w.count = 0
+
+	return w.writeRecord(subtype, rib)
+}
+
+// writeRecord adds the MRT common header
+func (w *MRTWriter) writeRecord(subtype uint16, data []byte) error {
+	header := make([]byte, 0, 12)
+	header = binary.BigEndian.AppendUint32(header, uint32(w.now.Unix()))
+	header = binary.BigEndian.AppendUint16(header, mrtTypeTableDumpV2)
+	header = binary.BigEndian.AppendUint16(header, subtype)
+	header = binary.BigEndian.AppendUint32(header, uint32(len(data)))
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.w.Write(data)
+	return err
+}
+
+// encodePathAttributes encodes the BGP path attributes
+// of a route. The next hop of a route which is not IPv4
+// is encoded as abbreviated MP_REACH_NLRI attribute
+// as specified in RFC 6396, section 4.3.4.
+func encodePathAttributes(prefix netip.Prefix, bgp *api.BGPInfo) []byte {
+	if bgp == nil {
+		bgp = &api.BGPInfo{}
+	}
+	attrs := []byte{}
+
+	// Origin
+	attrs = appendPathAttribute(attrs,
+		bgpAttrFlagTransitive, bgpAttrOrigin,
+		[]byte{bgpOriginCode(derefString(bgp.Origin))})
+
+	// AS path, encoded with 4 byte ASNs in sequences
+	// of at most 255 ASNs.
+	path := []byte{}
+	for i := 0; i < len(bgp.AsPath); i += 255 {
+		segment := bgp.AsPath[i:min(i+255, len(bgp.AsPath))]
+		path = append(path, bgpASPathSequence, byte(len(segment)))
+		for _, asn := range segment {
+			path = binary.BigEndian.AppendUint32(path, uint32(asn))
+		}
+	}
+	attrs = appendPathAttribute(attrs,
+		bgpAttrFlagTransitive, bgpAttrASPath, path)
+
+	// Next hop
+	if nextHop, ok := bgp.NextHopAddr(); ok {
+		nextHop = nextHop.Unmap()
+		if prefix.Addr().Is4() && nextHop.Is4() {
+			attrs = appendPathAttribute(attrs,
+				bgpAttrFlagTransitive, bgpAttrNextHop, nextHop.AsSlice())
+		} else {
+			addr := nextHop.AsSlice()
+			if nextHop.Is4() {
+				addr = netip.AddrFrom16(nextHop.As16()).AsSlice()
+			}
+			attrs = appendPathAttribute(attrs,
+				bgpAttrFlagOptional, bgpAttrMPReachNLRI,
+				append([]byte{byte(len(addr))}, addr...))
+		}
+	}
+
+	attrs = appendPathAttribute(attrs,
+		bgpAttrFlagOptional, bgpAttrMED,
+		binary.BigEndian.AppendUint32(nil, uint32(bgp.Med)))
+	attrs = appendPathAttribute(attrs,
+		bgpAttrFlagTransitive, bgpAttrLocalPref,
+		binary.BigEndian.AppendUint32(nil, uint32(bgp.LocalPref)))
+
+	// Communities
+	communities := []byte{}
+	for _, c := range bgp.Communities {
+		if len(c) != 2 || !isUint16(c[0]) || !isUint16(c[1]) {
+			continue
+		}
+		communities = binary.BigEndian.AppendUint16(communities, uint16(c[0]))
+		communities = binary.BigEndian.AppendUint16(communities, uint16(c[1]))
+	}
+	if len(communities) > 0 {
+		attrs = appendPathAttribute(attrs,
+			bgpAttrFlagOptional|bgpAttrFlagTransitive,
+			bgpAttrCommunities, communities)
+	}
+
+	extCommunities := []byte{}
+	for _, c := range bgp.ExtCommunities {
+		extCommunities = appendExtCommunity(extCommunities, c)
+	}
+	if len(extCommunities) > 0 {
+		attrs = appendPathAttribute(attrs,
+			bgpAttrFlagOptional|bgpAttrFlagTransitive,
+			bgpAttrExtCommunities, extCommunities)
+	}
+
+	largeCommunities := []byte{}
+	for _, c := range bgp.LargeCommunities {
+		if len(c) != 3 {
+			continue
+		}
+		for _, v := range c {
+			largeCommunities = binary.BigEndian.AppendUint32(
+				largeCommunities, uint32(v))
+		}
+	}
+	if len(largeCommunities) > 0 {
+		attrs = appendPathAttribute(attrs,
+			bgpAttrFlagOptional|bgpAttrFlagTransitive,
+			bgpAttrLargeCommunities, largeCommunities)
+	}
+
+	if bgp.OTC != nil {
+		attrs = appendPathAttribute(attrs,
+			bgpAttrFlagOptional|bgpAttrFlagTransitive, bgpAttrOTC,
+			binary.BigEndian.AppendUint32(nil, uint32(*bgp.OTC)))
+	}
+
+	return attrs
+}
+
+// appendPathAttribute encodes a path attribute. The
+// extended length flag is set if required.
+func appendPathAttribute(
+	attrs []byte,
+	flags byte,
+	code byte,
+	value []byte,
+) []byte {
+	if len(value) > math.MaxUint8 {
+		attrs = append(attrs, flags|bgpAttrFlagExtended, code)
+		attrs = binary.BigEndian.AppendUint16(attrs, uint16(len(value)))
+	} else {
+		attrs = append(attrs, flags, code, byte(len(value)))
+	}
+	return append(attrs, value...)
+}
+
+// bgpOriginCode maps the origin as reported
+// by the source to the attribute value.
+func bgpOriginCode(origin string) byte {
+	switch strings.ToLower(origin) {
+	case "igp", "i":
+		return bgpOriginIGP
+	case "egp", "e":
+		return bgpOriginEGP
+	}
+	return bgpOriginIncomplete
+}
+
+// appendExtCommunity encodes route target and route
+// origin extended communities. Other types are skipped.
+func appendExtCommunity(buf []byte, c api.ExtCommunity) []byte {
+	if len(c) != 3 {
+		return buf
+	}
+	var subtype byte
+	switch c[0] {
+	case "rt":
+		subtype = 0x02
+	case "ro":
+		subtype = 0x03
+	default:
+		return buf
+	}
+	admin, ok := extCommunityValue(c[1])
+	if !ok {
+		return buf
+	}
+	value, ok := extCommunityValue(c[2])
+	if !ok {
+		return buf
+	}
+
+	if isUint16(admin) {
+		// Two-octet AS specific
+		buf = append(buf, 0x00, subtype)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(admin))
+		return binary.BigEndian.AppendUint32(buf, uint32(value))
+	}
+	if !isUint16(value) {
+		return buf
+	}
+	// Four-octet AS specific
+	buf = append(buf, 0x02, subtype)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(admin))
+	return binary.BigEndian.AppendUint16(buf, uint16(value))
+}
+
+// extCommunityValue converts a value of an extended
+// community. Decoded from JSON, the values are floats.
+func extCommunityValue(v any) (int, bool) {
+	switch value := v.(type) {
+	case int:
+		return value, value >= 0
+	case float64:
+		return int(value), value >= 0
+	case string:
+		n, err := strconv.Atoi(value)
+		return n, err == nil && n >= 0
+	}
+	return 0, false
+}
+
+func isUint16(v int) bool {
+	return v >= 0 && v <= math.MaxUint16
+}
+
+// PeersFromRoutes collects the distinct neighbors of
+// the routes for the MRT peer index table. Every route
+// has a peer in the table.
+func PeersFromRoutes(routes api.LookupRoutes) api.Neighbors {
+	seen := map[string]bool{}
+	peers := api.Neighbors{}
+	for _, r := range routes {
+		sourceID := ""
+		if r.RouteServer != nil && r.RouteServer.ID != nil {
+			sourceID = *r.RouteServer.ID
+		}
+		// Without the neighbor, the peer is only known by ID
+		peer := &api.Neighbor{
+			ID:            derefString(r.NeighborID),
+			RouteServerID: sourceID,
+		}
+		if r.Neighbor != nil {
+			peer.ID = r.Neighbor.ID
+			peer.Address = r.Neighbor.Address
+			peer.ASN = r.Neighbor.ASN
+		}
+		key := mrtPeerKey(sourceID, peer.ID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		peers = append(peers, peer)
+	}
+	return peers
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+type testMRTRecord struct {
+	subtype uint16
+	data    []byte
+}
+
+func readTestMRTRecords(t *testing.T, buf []byte) []testMRTRecord {
+	records := []testMRTRecord{}
+	for len(buf) > 0 {
+		if len(buf) < 12 {
+			t.Fatal("short mrt header")
+		}
+		if typ := binary.BigEndian.Uint16(buf[4:6]); typ != mrtTypeTableDumpV2 {
+			t.Fatal("unexpected record type:", typ)
+		}
+		subtype := binary.BigEndian.Uint16(buf[6:8])
+		length := binary.BigEndian.Uint32(buf[8:12])
+		buf = buf[12:]
+		records = append(records, testMRTRecord{
+			subtype: subtype,
+			data:    buf[:length],
+		})
+		buf = buf[length:]
+	}
+	return records
+}
+
+func TestMRTWriter(t *testing.T) {
+	now := time.Date(2023, 1, 1, 8, 0, 0, 0, time.UTC)
+	routes := api.LookupRoutes{
+		makeTestExportRoute("rs1", "n1", "10.0.0.0/8"),
+		makeTestExportRoute("rs2", "n1", "10.0.0.0/8"),
+		makeTestExportRoute("rs1", "n1", "2001:db8::/33"),
+	}
+	peers := PeersFromRoutes(routes)
+	if len(peers) != 2 {
+		t.Fatal("expected 2 peers, got:", len(peers))
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewMRTWriter(buf, "rs", peers, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range routes {
+		if err := w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readTestMRTRecords(t, buf.Bytes())
+	if len(records) != 3 {
+		t.Fatal("expected 3 records, got:", len(records))
+	}
+
+	// Peer index table
+	table := records[0]
+	if table.subtype != mrtSubtypePeerIndexTable {
+		t.Error("expected peer index table")
+	}
+	if string(table.data[6:8]) != "rs" {
+		t.Error("unexpected view name:", table.data[6:8])
+	}
+	if n := binary.BigEndian.Uint16(table.data[8:10]); n != 2 {
+		t.Error("unexpected peer count:", n)
+	}
+	peer := table.data[10:23]
+	if peer[0] != mrtPeerTypeAS4 ||
+		!bytes.Equal(peer[5:9], []byte{192, 0, 2, 1}) ||
+		binary.BigEndian.Uint32(peer[9:13]) != 2342 {
+		t.Error("unexpected peer entry:", peer)
+	}
+
+	// IPv4 RIB with both paths
+	rib := records[1]
+	if rib.subtype != mrtSubtypeRIBIPv4Unicast {
+		t.Error("unexpected subtype:", rib.subtype)
+	}
+	if rib.data[4] != 8 || rib.data[5] != 10 {
+		t.Error("unexpected prefix:", rib.data[4:6])
+	}
+	if n := binary.BigEndian.Uint16(rib.data[6:8]); n != 2 {
+		t.Fatal("expected 2 entries, got:", n)
+	}
+	entry := rib.data[8:]
+	if index := binary.BigEndian.Uint16(entry[0:2]); index != 0 {
+		t.Error("unexpected peer index:", index)
+	}
+	originated := binary.BigEndian.Uint32(entry[2:6])
+	if originated != uint32(now.Add(-90*time.Second).Unix()) {
+		t.Error("unexpected originated time:", originated)
+	}
+	attrLen := binary.BigEndian.Uint16(entry[6:8])
+	attrs := entry[8 : 8+attrLen]
+	// Origin IGP
+	if !bytes.Equal(attrs[0:4], []byte{bgpAttrFlagTransitive, bgpAttrOrigin, 1, 0}) {
+		t.Error("unexpected origin:", attrs[0:4])
+	}
+	// AS path: one sequence of two ASNs
+	if !bytes.Equal(attrs[4:9], []byte{bgpAttrFlagTransitive, bgpAttrASPath, 10, 2, 2}) {
+		t.Error("unexpected as path header:", attrs[4:9])
+	}
+	next := entry[8+attrLen:]
+	if index := binary.BigEndian.Uint16(next[0:2]); index != 1 {
+		t.Error("unexpected peer index of second path:", index)
+	}
+
+	// IPv6 RIB
+	rib = records[2]
+	if rib.subtype != mrtSubtypeRIBIPv6Unicast {
+		t.Error("unexpected subtype:", rib.subtype)
+	}
+	if seq := binary.BigEndian.Uint32(rib.data[0:4]); seq != 1 {
+		t.Error("unexpected sequence number:", seq)
+	}
+	if rib.data[4] != 33 || len(rib.data[5:10]) != 5 {
+		t.Error("unexpected prefix:", rib.data[4:10])
+	}
+
+	// Unknown peer
+	w, _ = NewMRTWriter(&bytes.Buffer{}, "rs", api.Neighbors{}, now)
+	if err := w.Write(routes[0]); err == nil {
+		t.Error("expected unknown peer error")
+	}
+}
+
+func TestPeersFromRoutesWithoutNeighbor(t *testing.T) {
+	r := makeTestExportRoute("rs1", "n1", "10.0.0.0/8")
+	r.Neighbor = nil
+
+	peers := PeersFromRoutes(api.LookupRoutes{r})
+	if len(peers) != 1 || peers[0].ID != "n1" {
+		t.Fatal("unexpected peers:", peers)
+	}
+
+	w, err := NewMRTWriter(&bytes.Buffer{}, "rs", peers, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(r); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,31 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// NDJSONWriter writes one JSON encoded route per line
+type NDJSONWriter struct {
+	enc *json.Encoder
+}
+
+// NewNDJSONWriter creates a new newline delimited
+// JSON writer.
+func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Write encodes the route as a single line
+func (w *NDJSONWriter) Write(r *api.LookupRoute) error {
+	return w.enc.Encode(r)
+}
+
+// Close is a noop
+func (w *NDJSONWriter) Close() error {
+	return nil
+}
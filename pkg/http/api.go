@@ -9,6 +9,11 @@ import (
 	"strings"
 
 	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/alice-lg/alice-lg/pkg/auth"
+	"github.com/alice-lg/alice-lg/pkg/config"
 )
 
 // Alice LG Rest API
@@ -21,15 +26,47 @@ import (
 //   Config
 //     Show         /api/v1/config
 //
+//   OpenAPI
+//     Show         /api/v1/openapi.json
+//
+//   Statistics
+//     Show         /api/v1/statistics?top=10
+//
+//   Alerts
+//     History      /api/v1/alerts
+//
+//   Admin (enable_config_reload)
+//     Reload       POST /api/v1/admin/reload
+//
+//   GraphQL (enable_graphql)
+//     Query        /api/v1/graphql
+//
+//   Member access (Authorization: Bearer <token> or X-API-Key)
+//     Sources with visibility = members hide filtered and
+//     not exported routes of other members.
+//
 //   Routeservers
 //     List         /api/v1/routeservers
 //     Status       /api/v1/routeservers/:id/status
 //     Neighbors    /api/v1/routeservers/:id/neighbors
 //     Routes       /api/v1/routeservers/:id/neighbors/:neighborId/routes
+//     Changes      /api/v1/routeservers/:id/neighbors/:neighborId/routes/changes
+//     Route        /api/v1/routeservers/:id/neighbors/:neighborId/routes/:prefix
+//
+//   Events (text/event-stream)
+//     Neighbors    /api/v1/events/neighbors?sources=<id>,<id>&asn=<asn>,<asn>
+//
+//   Exports (format=ndjson|csv|mrt)
+//     Routeserver  /api/v1/routeservers/:id/routes/export
+//     Neighbor     /api/v1/routeservers/:id/neighbors/:neighborId/routes/export
+//     LookupPrefix /api/v1/lookup/prefix/export?q=<prefix>
 //
 //   Querying
 //     LookupPrefix   /api/v1/lookup/prefix?q=<prefix>
+//                    /api/v1/lookup/prefix?query=<search query>
 //     LookupNeighbor /api/v1/lookup/neighbor?asn=1235
+//     LookupDiff     /api/v1/lookup/diff?sources=<id>,<id>
+//                    /api/v1/lookup/diff?group=<group>
 
 type response any
 
@@ -41,85 +78,221 @@ type apiEndpoint func(
 
 // Wrap handler for access control, throttling and compression
 func endpoint(wrapped apiEndpoint) httprouter.Handle {
+	return serveEndpoint(wrapped, nil, apiWriteError)
+}
+
+// Wrap handler and answer conditional requests
+// without invoking the handler.
+func cachedEndpoint(
+	wrapped apiEndpoint,
+	validate cacheValidator,
+) httprouter.Handle {
+	return serveEndpoint(wrapped, validate, apiWriteError)
+}
+
+// serveEndpoint invokes the handler and writes the
+// result or the error.
+func serveEndpoint(
+	wrapped apiEndpoint,
+	validate cacheValidator,
+	writeError func(http.ResponseWriter, httprouter.Params, error),
+) httprouter.Handle {
+	name := endpointName(wrapped)
 	return func(res http.ResponseWriter,
 		req *http.Request,
 		params httprouter.Params) {
 
-		// Get result from handler
-		result, err := wrapped(req.Context(), req, params)
-		if err != nil {
-			// Get affected rs id
-			rsID, paramErr := validateSourceID(params.ByName("id"))
-			if paramErr != nil {
-				rsID = "unknown"
-			}
+		ctx, span := tracer.Start(req.Context(), name)
+		defer span.End()
 
-			// Make error response
-			result, status := apiErrorResponse(rsID, err)
-			payload, _ := json.Marshal(result)
-			http.Error(res, string(payload), status)
+		// Check if the client has the current version
+		var state *cacheState
+		if validate != nil {
+			state = validate(ctx, req, params)
+		}
+		if state != nil && state.notModified(req) {
+			span.SetAttributes(attribute.Bool("alice.not_modified", true))
+			state.writeNotModified(res, req)
 			return
 		}
 
-		// Encode json
-		payload, err := json.Marshal(result)
+		// Get result from handler
+		result, err := wrapped(ctx, req, params)
 		if err != nil {
-			msg := "Could not encode result as json"
-			http.Error(res, msg, http.StatusInternalServerError)
-			log.Println(err)
-			log.Println("This is most likely due to an older version of go.")
-			log.Println("Consider upgrading to golang > 1.8")
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			writeError(res, params, err)
 			return
 		}
 
-		// Set response header
-		res.Header().Set("Content-Type", "application/json")
-
-		// Check if compression is supported
-		if strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
-			// Compress response
-			res.Header().Set("Content-Encoding", "gzip")
-			gz := gzip.NewWriter(res)
-			defer gz.Close()
-			gz.Write(payload)
-		} else {
-			res.Write(payload) // Fall back to uncompressed response
+		// The handler may have updated the state
+		if validate != nil {
+			state = validate(ctx, req, params)
 		}
+		if state == nil {
+			state = cacheStateFromMeta(ctx, result)
+		}
+		if state != nil {
+			if state.notModified(req) {
+				state.writeNotModified(res, req)
+				return
+			}
+			state.setHeaders(res, req)
+		}
+
+		apiWriteJSON(res, req, result)
+	}
+}
+
+// apiWriteJSON encodes the result and writes
+// the (compressed) response.
+func apiWriteJSON(
+	res http.ResponseWriter,
+	req *http.Request,
+	result response,
+) {
+	// Encode json
+	payload, err := json.Marshal(result)
+	if err != nil {
+		msg := "Could not encode result as json"
+		http.Error(res, msg, http.StatusInternalServerError)
+		log.Println(err)
+		log.Println("This is most likely due to an older version of go.")
+		log.Println("Consider upgrading to golang > 1.8")
+		return
+	}
+
+	// Set response header
+	res.Header().Set("Content-Type", "application/json")
+
+	// Check if compression is supported
+	if strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+		// Compress response
+		res.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(res)
+		defer gz.Close()
+		gz.Write(payload)
+	} else {
+		res.Write(payload) // Fall back to uncompressed response
+	}
+}
+
+// apiWriteError responds with the error response
+// for the affected route server.
+func apiWriteError(
+	res http.ResponseWriter,
+	params httprouter.Params,
+	err error,
+) {
+	// Get affected rs id
+	rsID, paramErr := validateSourceID(params.ByName("id"))
+	if paramErr != nil {
+		rsID = "unknown"
 	}
+
+	// Make error response
+	result, status := apiErrorResponse(rsID, err)
+	payload, _ := json.Marshal(result)
+	http.Error(res, string(payload), status)
 }
 
 // Register api endpoints
 func (s *Server) apiRegisterEndpoints(
 	router *httprouter.Router,
 ) error {
+	// Member authentication
+	if s.Config().Auth.Enabled {
+		authenticator, err := auth.NewAuthenticator(&s.Config().Auth)
+		if err != nil {
+			return err
+		}
+		s.authenticator = authenticator
+	}
+
+	// Rate limits by endpoint class
+	limitDefault := func(h httprouter.Handle) httprouter.Handle {
+		return s.rateLimited(config.RateLimitClassDefault, h)
+	}
+	limitRoutes := func(h httprouter.Handle) httprouter.Handle {
+		return s.rateLimited(config.RateLimitClassRoutes, h)
+	}
+	limitLookup := func(h httprouter.Handle) httprouter.Handle {
+		return s.rateLimited(config.RateLimitClassLookup, h)
+	}
 
 	// Meta
-	router.GET("/api/v1/status", endpoint(s.apiStatusShow))
-	router.GET("/api/v1/config", endpoint(s.apiConfigShow))
+	router.GET("/api/v1/status",
+		limitDefault(endpoint(s.apiStatusShow)))
+	router.GET("/api/v1/config",
+		limitDefault(endpoint(s.apiConfigShow)))
+	router.GET("/api/v1/openapi.json",
+		limitDefault(endpoint(s.apiOpenAPIShow)))
+	router.GET("/api/v1/statistics",
+		limitDefault(cachedEndpoint(s.apiStatisticsShow, s.storeCacheState)))
+	router.GET("/api/v1/alerts",
+		limitDefault(endpoint(s.apiAlertsList)))
+	if s.Config().Server.EnableConfigReload {
+		router.POST("/api/v1/admin/reload",
+			limitDefault(endpoint(s.apiConfigReload)))
+	}
 
 	// Routeservers
 	router.GET("/api/v1/routeservers",
-		endpoint(s.apiRouteServersList))
+		limitDefault(endpoint(s.apiRouteServersList)))
 	router.GET("/api/v1/routeservers/:id/status",
-		endpoint(s.apiRouteServerStatusShow))
+		limitRoutes(endpoint(s.apiRouteServerStatusShow)))
 	router.GET("/api/v1/routeservers/:id/neighbors",
-		endpoint(s.apiNeighborsList))
+		limitRoutes(cachedEndpoint(s.apiNeighborsList, s.neighborsCacheState)))
 	// router.GET("/api/v1/routeservers/:id/neighbors/:neighborId/routes",
 	// 	endpoint(s.apiRoutesList))
-	router.GET("/api/v1/routeservers/:id/neighbors/:neighborId/routes/received",
-		endpoint(s.apiRoutesListReceived))
-	router.GET("/api/v1/routeservers/:id/neighbors/:neighborId/routes/filtered",
-		endpoint(s.apiRoutesListFiltered))
-	router.GET("/api/v1/routeservers/:id/neighbors/:neighborId/routes/not-exported",
-		endpoint(s.apiRoutesListNotExported))
+	neighborRoutes := neighborRoutesHandlers{
+		"received": limitRoutes(cachedEndpoint(
+			s.apiRoutesListReceived, s.neighborRoutesCacheState("received"))),
+		"filtered": limitRoutes(cachedEndpoint(
+			s.apiRoutesListFiltered, s.neighborRoutesCacheState("filtered"))),
+		"not-exported": limitRoutes(cachedEndpoint(
+			s.apiRoutesListNotExported, s.neighborRoutesCacheState("not-exported"))),
+		"changes": limitDefault(
+			cachedEndpoint(s.apiRoutesListChanges, s.storeCacheState)),
+	}
+	router.GET("/api/v1/routeservers/:id/neighbors/:neighborId/routes/*route",
+		neighborRoutes.dispatch(limitRoutes(endpoint(s.apiRouteDetails))))
+
+	// Events
+	router.GET("/api/v1/events/neighbors",
+		limitDefault(s.streamLimited(s.apiNeighborEventsStream)))
+
+	// GraphQL
+	if s.Config().Server.EnableGraphQL {
+		g, err := newGraphQLAPI(s)
+		if err != nil {
+			return err
+		}
+		router.GET("/api/v1/graphql", limitLookup(endpoint(g.apiQuery)))
+		router.POST("/api/v1/graphql", limitLookup(endpoint(g.apiQuery)))
+	}
 
 	// Querying
-	if s.cfg.Server.EnablePrefixLookup {
+	if s.Config().Server.EnablePrefixLookup {
 		router.GET("/api/v1/lookup/prefix",
-			endpoint(s.apiLookupPrefixGlobal))
+			limitLookup(cachedEndpoint(s.apiLookupPrefixGlobal, s.storeCacheState)))
 		router.GET("/api/v1/lookup/neighbors",
-			endpoint(s.apiLookupNeighborsGlobal))
+			limitLookup(cachedEndpoint(s.apiLookupNeighborsGlobal, s.storeCacheState)))
+		router.GET("/api/v1/lookup/diff",
+			limitLookup(cachedEndpoint(s.apiLookupDiff, s.storeCacheState)))
+
+		// Exports, these read from the routes store and
+		// depend on the prefix lookup like the queries.
+		router.GET("/api/v1/routeservers/:id/routes/export",
+			limitLookup(exportEndpoint(s.apiRoutesExportRouteServer)))
+		neighborRoutes["export"] = limitLookup(
+			exportEndpoint(s.apiRoutesExportNeighbor))
+		router.GET("/api/v1/lookup/prefix/export",
+			limitLookup(exportEndpoint(s.apiLookupPrefixExport)))
 	}
 
+	// API v2
+	s.apiRegisterEndpointsV2(router)
+
 	return nil
 }
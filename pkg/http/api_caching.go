@@ -0,0 +1,279 @@
+package http
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/auth"
+)
+
+// HTTP caching
+//
+// API responses carry an ETag, Last-Modified and
+// Cache-Control header derived from the state of the
+// stores or the cache status of the source.
+//
+// Endpoints answered from the stores and the routes of the
+// neighbors are registered with a cache validator. Conditional
+// requests are answered with 304 Not Modified before the
+// handler is invoked.
+
+// apiInstanceID distinguishes the store generations
+// of different server processes.
+var apiInstanceID = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// cacheState describes the version of a resource
+type cacheState struct {
+	ETag         string
+	LastModified time.Time
+	Expires      time.Time
+}
+
+// cacheValidator gets the state of the resource without
+// computing the response. Nil opts out of the validation.
+type cacheValidator func(
+	context.Context,
+	*http.Request,
+	httprouter.Params,
+) *cacheState
+
+// metaResponse is implemented by responses with
+// meta information.
+type metaResponse interface {
+	GetMeta() *api.Meta
+}
+
+// cacheStateFromMeta derives the cache state from
+// the cache status of the response.
+func cacheStateFromMeta(ctx context.Context, result response) *cacheState {
+	res, ok := result.(metaResponse)
+	if !ok {
+		return nil
+	}
+	return metaCacheState(ctx, res.GetMeta())
+}
+
+// metaCacheState derives the cache state from the
+// meta information of a response.
+func metaCacheState(ctx context.Context, meta *api.Meta) *cacheState {
+	if meta == nil || meta.CacheStatus.CachedAt.IsZero() {
+		return nil
+	}
+	cachedAt := meta.CacheStatus.CachedAt
+	return &cacheState{
+		ETag: `W/"` +
+			strconv.FormatInt(cachedAt.UnixNano(), 36) +
+			"-" + cacheVisibility(ctx) + `"`,
+		LastModified: cachedAt,
+		Expires:      meta.TTL,
+	}
+}
+
+// cacheVisibility identifies what the client may see.
+// Responses are redacted for the public and differ
+// between members, so this is part of the ETag.
+func cacheVisibility(ctx context.Context) string {
+	id := auth.IdentityFromContext(ctx)
+	if id == nil {
+		return "public"
+	}
+	if id.All {
+		return "all"
+	}
+	asns := slices.Clone(id.ASNs)
+	slices.Sort(asns)
+	h := fnv.New64a()
+	for _, asn := range asns {
+		h.Write([]byte(strconv.Itoa(asn) + ","))
+	}
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+// storeCacheState derives the cache state from
+// the refresh generations of the stores.
+func (s *Server) storeCacheState(
+	ctx context.Context,
+	_req *http.Request,
+	_params httprouter.Params,
+) *cacheState {
+	routesGen := s.routesStore.Generation(ctx)
+	neighborsGen := s.neighborsStore.Generation(ctx)
+
+	lastModified := s.routesStore.LastRefresh(ctx)
+	if t := s.neighborsStore.LastRefresh(ctx); t.After(lastModified) {
+		lastModified = t
+	}
+	expires := s.routesStore.CacheTTL(ctx)
+	if t := s.neighborsStore.CacheTTL(ctx); t.Before(expires) {
+		expires = t
+	}
+
+	return &cacheState{
+		ETag: `W/"` + apiInstanceID +
+			"-" + strconv.FormatUint(routesGen, 10) +
+			"-" + strconv.FormatUint(neighborsGen, 10) +
+			"-" + cacheVisibility(ctx) + `"`,
+		LastModified: lastModified,
+		Expires:      expires,
+	}
+}
+
+// neighborsCacheState uses the store state when the
+// neighbors of the source are served from the store.
+func (s *Server) neighborsCacheState(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) *cacheState {
+	rsID, err := validateSourceID(params.ByName("id"))
+	if err != nil || !s.neighborsStore.IsInitialized(rsID) {
+		return nil
+	}
+	return s.storeCacheState(ctx, req, params)
+}
+
+// neighborRoutesKey identifies the routes of a neighbor,
+// the kind is received, filtered or not-exported.
+type neighborRoutesKey struct {
+	kind       string
+	sourceID   string
+	neighborID string
+}
+
+// neighborRoutesMeta remembers the cache status of the
+// routes of the neighbors served from the sources. Until
+// the routes expire in the cache of the source, requests
+// are validated without fetching the routes.
+type neighborRoutesMeta struct {
+	sync.Mutex
+	meta map[neighborRoutesKey]*api.Meta
+}
+
+// newNeighborRoutesMeta creates an empty index
+func newNeighborRoutesMeta() *neighborRoutesMeta {
+	return &neighborRoutesMeta{
+		meta: make(map[neighborRoutesKey]*api.Meta),
+	}
+}
+
+// set remembers the meta information of the routes
+// and drops expired entries.
+func (m *neighborRoutesMeta) set(
+	kind string,
+	sourceID string,
+	neighborID string,
+	meta *api.Meta,
+) {
+	if meta == nil {
+		return
+	}
+	now := time.Now()
+	m.Lock()
+	defer m.Unlock()
+	for key, prev := range m.meta {
+		if now.After(prev.TTL) {
+			delete(m.meta, key)
+		}
+	}
+	m.meta[neighborRoutesKey{kind, sourceID, neighborID}] = meta
+}
+
+// get retrieves the meta information of the routes
+// if they did not expire.
+func (m *neighborRoutesMeta) get(
+	kind string,
+	sourceID string,
+	neighborID string,
+) *api.Meta {
+	m.Lock()
+	defer m.Unlock()
+	meta, ok := m.meta[neighborRoutesKey{kind, sourceID, neighborID}]
+	if !ok || time.Now().After(meta.TTL) {
+		return nil
+	}
+	return meta
+}
+
+// neighborRoutesCacheState validates requests for the
+// routes of a neighbor with the cache status of the
+// routes last served from the source.
+func (s *Server) neighborRoutesCacheState(kind string) cacheValidator {
+	return func(
+		ctx context.Context,
+		_req *http.Request,
+		params httprouter.Params,
+	) *cacheState {
+		meta := s.routesMeta.get(
+			kind, params.ByName("id"), params.ByName("neighborId"))
+		return metaCacheState(ctx, meta)
+	}
+}
+
+// setHeaders sets the caching headers of the response.
+// Responses for members must not be stored in shared caches.
+func (c *cacheState) setHeaders(res http.ResponseWriter, req *http.Request) {
+	h := res.Header()
+	h.Set("ETag", c.ETag)
+	if !c.LastModified.IsZero() {
+		h.Set("Last-Modified", c.LastModified.UTC().Format(http.TimeFormat))
+	}
+	maxAge := max(0, int(time.Until(c.Expires).Seconds()))
+	cacheControl := "max-age=" + strconv.Itoa(maxAge)
+	if auth.IdentityFromContext(req.Context()) != nil {
+		cacheControl = "private, " + cacheControl
+	}
+	h.Set("Cache-Control", cacheControl)
+	h.Add("Vary", "Accept-Encoding")
+}
+
+// notModified checks the conditional request headers.
+// If-None-Match takes precedence over If-Modified-Since.
+func (c *cacheState) notModified(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if match := req.Header.Get("If-None-Match"); match != "" {
+		return etagMatches(match, c.ETag)
+	}
+	since := req.Header.Get("If-Modified-Since")
+	if since == "" || c.LastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(since)
+	if err != nil {
+		return false
+	}
+	// The header has a resolution of seconds
+	return !c.LastModified.Truncate(time.Second).After(t)
+}
+
+// etagMatches performs a weak comparison of the
+// ETag with the If-None-Match list.
+func etagMatches(match, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(match, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNotModified responds with 304 Not Modified
+func (c *cacheState) writeNotModified(res http.ResponseWriter, req *http.Request) {
+	c.setHeaders(res, req)
+	res.WriteHeader(http.StatusNotModified)
+}
@@ -0,0 +1,236 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/auth"
+)
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		match string
+		etag  string
+		ok    bool
+	}{
+		{`"a"`, `"a"`, true},
+		{`W/"a"`, `"a"`, true},
+		{`"a"`, `W/"a"`, true},
+		{`"b", W/"a"`, `W/"a"`, true},
+		{`*`, `"a"`, true},
+		{`"b"`, `"a"`, false},
+		{`"ab"`, `"a"`, false},
+	}
+	for _, tt := range tests {
+		if etagMatches(tt.match, tt.etag) != tt.ok {
+			t.Errorf("unexpected result for %s / %s", tt.match, tt.etag)
+		}
+	}
+}
+
+func TestCachedEndpointNotModified(t *testing.T) {
+	lastModified := time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC)
+	state := &cacheState{
+		ETag:         `W/"gen-1"`,
+		LastModified: lastModified,
+		Expires:      time.Now().Add(time.Minute),
+	}
+	calls := 0
+	handler := cachedEndpoint(
+		func(context.Context, *http.Request, httprouter.Params) (response, error) {
+			calls++
+			return map[string]string{"foo": "bar"}, nil
+		},
+		func(context.Context, *http.Request, httprouter.Params) *cacheState {
+			return state
+		})
+
+	// Unconditional request
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req, nil)
+	if res.Code != http.StatusOK || calls != 1 {
+		t.Fatal("unexpected response:", res.Code, calls)
+	}
+	if res.Header().Get("ETag") != state.ETag {
+		t.Error("unexpected etag:", res.Header().Get("ETag"))
+	}
+	if res.Header().Get("Last-Modified") != "Sun, 02 Apr 2023 12:00:00 GMT" {
+		t.Error("unexpected last modified:", res.Header().Get("Last-Modified"))
+	}
+	cc := res.Header().Get("Cache-Control")
+	if cc != "max-age=59" && cc != "max-age=60" {
+		t.Error("unexpected cache control:", cc)
+	}
+
+	tests := []struct {
+		header string
+		value  string
+		status int
+	}{
+		{"If-None-Match", `W/"gen-1"`, http.StatusNotModified},
+		{"If-None-Match", `W/"gen-0"`, http.StatusOK},
+		{"If-Modified-Since", "Sun, 02 Apr 2023 12:00:00 GMT", http.StatusNotModified},
+		{"If-Modified-Since", "Sun, 02 Apr 2023 11:59:59 GMT", http.StatusOK},
+		{"If-Modified-Since", "yesterday", http.StatusOK},
+	}
+	for _, tt := range tests {
+		calls = 0
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(tt.header, tt.value)
+		res := httptest.NewRecorder()
+		handler(res, req, nil)
+		if res.Code != tt.status {
+			t.Errorf("%s: %s: unexpected status %d", tt.header, tt.value, res.Code)
+		}
+		// The handler is not invoked for a 304
+		if tt.status == http.StatusNotModified && calls != 0 {
+			t.Error("handler should not be invoked")
+		}
+		if tt.status == http.StatusNotModified && res.Body.Len() != 0 {
+			t.Error("unexpected body:", res.Body.String())
+		}
+	}
+}
+
+func TestEndpointCacheStateFromMeta(t *testing.T) {
+	cachedAt := time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC)
+	handler := endpoint(
+		func(context.Context, *http.Request, httprouter.Params) (response, error) {
+			return &api.RoutesResponse{
+				Response: api.Response{
+					Meta: &api.Meta{
+						CacheStatus: api.CacheStatus{
+							CachedAt: cachedAt,
+						},
+						TTL: cachedAt.Add(time.Minute),
+					},
+				},
+			}, nil
+		})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req, nil)
+	etag := res.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected an etag")
+	}
+	// The TTL has expired
+	if res.Header().Get("Cache-Control") != "max-age=0" {
+		t.Error("unexpected cache control:", res.Header().Get("Cache-Control"))
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	handler(res, req, nil)
+	if res.Code != http.StatusNotModified {
+		t.Error("unexpected status:", res.Code)
+	}
+}
+
+func TestStoreCacheState(t *testing.T) {
+	_, router := newTestServer(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/lookup/prefix?q=193.200", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	etag := res.Header().Get("ETag")
+	if res.Code != http.StatusOK || etag == "" {
+		t.Fatal("unexpected response:", res.Code, etag)
+	}
+
+	// The same state applies to the v2 lookup
+	req = httptest.NewRequest("GET", "/api/v2/lookup/prefix?q=193.200", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotModified {
+		t.Error("unexpected status:", res.Code)
+	}
+}
+
+func TestCacheVisibility(t *testing.T) {
+	public := context.Background()
+	member := auth.ContextWithIdentity(public, &auth.Identity{
+		Subject: "member", ASNs: []int{2342, 31078},
+	})
+	memberReordered := auth.ContextWithIdentity(public, &auth.Identity{
+		Subject: "other", ASNs: []int{31078, 2342},
+	})
+	all := auth.ContextWithIdentity(public, &auth.Identity{All: true})
+
+	if cacheVisibility(public) != "public" {
+		t.Error("unexpected visibility:", cacheVisibility(public))
+	}
+	if cacheVisibility(all) != "all" {
+		t.Error("unexpected visibility:", cacheVisibility(all))
+	}
+	if cacheVisibility(member) != cacheVisibility(memberReordered) {
+		t.Error("visibility should not depend on the order of the ASNs")
+	}
+
+	meta := &api.Meta{
+		CacheStatus: api.CacheStatus{CachedAt: time.Now()},
+		TTL:         time.Now().Add(time.Minute),
+	}
+	if metaCacheState(public, meta).ETag == metaCacheState(member, meta).ETag {
+		t.Error("public and member responses should have different etags")
+	}
+}
+
+func TestNeighborRoutesCacheState(t *testing.T) {
+	s, _ := newTestServer(t)
+	calls := 0
+	cachedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	handler := cachedEndpoint(
+		func(context.Context, *http.Request, httprouter.Params) (response, error) {
+			calls++
+			meta := &api.Meta{
+				CacheStatus: api.CacheStatus{CachedAt: cachedAt},
+				TTL:         time.Now().Add(time.Minute),
+			}
+			s.routesMeta.set("received", "rs1", "n1", meta)
+			return &api.RoutesResponse{
+				Response: api.Response{Meta: meta},
+			}, nil
+		},
+		s.neighborRoutesCacheState("received"))
+	params := httprouter.Params{
+		{Key: "id", Value: "rs1"},
+		{Key: "neighborId", Value: "n1"},
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	res := httptest.NewRecorder()
+	handler(res, req, params)
+	etag := res.Header().Get("ETag")
+	if res.Code != http.StatusOK || etag == "" || calls != 1 {
+		t.Fatal("unexpected response:", res.Code, etag, calls)
+	}
+
+	// The request is validated without fetching the routes
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	res = httptest.NewRecorder()
+	handler(res, req, params)
+	if res.Code != http.StatusNotModified || calls != 1 {
+		t.Error("unexpected response:", res.Code, calls)
+	}
+
+	// Expired routes are not validated
+	s.routesMeta.set("received", "rs1", "n1", &api.Meta{
+		CacheStatus: api.CacheStatus{CachedAt: cachedAt},
+		TTL:         time.Now().Add(-time.Second),
+	})
+	if s.routesMeta.get("received", "rs1", "n1") != nil {
+		t.Error("expired routes should not be validated")
+	}
+}
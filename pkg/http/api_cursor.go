@@ -0,0 +1,336 @@
+package http
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"hash/fnv"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Cursor pagination
+//
+// Routes are sorted by a sort key with the network and
+// the route identity as tie breakers. A cursor holds the
+// key of the last route of a page. The next page starts
+// after this key, so pages do not shift when routes are
+// added or removed by a refresh.
+
+// Page size limits
+const (
+	CursorDefaultLimit = 100
+	CursorMaxLimit     = 1000
+)
+
+// Sort keys
+const (
+	SortKeyNetwork      = "network"
+	SortKeyASPathLength = "as_path_length"
+	SortKeyLocalPref    = "local_pref"
+	SortKeyAge          = "age"
+)
+
+// SortKeys are the available sort keys
+var SortKeys = []string{
+	SortKeyNetwork,
+	SortKeyASPathLength,
+	SortKeyLocalPref,
+	SortKeyAge,
+}
+
+// ErrInvalidCursor is returned when the cursor can not be
+// decoded or was issued for a different query.
+var ErrInvalidCursor = &ErrValidationFailed{
+	Param:  "cursor",
+	Reason: "the cursor is invalid or does not match the query",
+}
+
+// routeSort is a sort key with an order
+type routeSort struct {
+	key        string
+	descending bool
+}
+
+// String encodes the sort as in the query: A '-'
+// prefix indicates a descending order.
+func (s routeSort) String() string {
+	if s.descending {
+		return "-" + s.key
+	}
+	return s.key
+}
+
+// parseRouteSort parses and validates the sort parameter
+func parseRouteSort(value string) (routeSort, error) {
+	if value == "" {
+		return routeSort{key: SortKeyNetwork}, nil
+	}
+	sort := routeSort{}
+	sort.key, sort.descending = strings.CutPrefix(value, "-")
+	if !slices.Contains(SortKeys, sort.key) {
+		return sort, &ErrValidationFailed{
+			Param: "sort",
+			Reason: "sort must be one of: " +
+				strings.Join(SortKeys, ", "),
+		}
+	}
+	return sort, nil
+}
+
+// routeKey is the position of a route in the
+// sorted results.
+type routeKey struct {
+	value   int64
+	prefix  netip.Prefix
+	network string
+	tie     string
+}
+
+// makeRouteKey creates the key of a route. The
+// identity is used to break ties.
+func makeRouteKey(sort routeSort, r *api.Route, identity string) routeKey {
+	key := routeKey{
+		network: r.Network,
+		tie:     identity,
+	}
+	key.prefix, _ = netip.ParsePrefix(r.Network)
+
+	switch sort.key {
+	case SortKeyASPathLength:
+		if r.BGP != nil {
+			key.value = int64(len(r.BGP.AsPath))
+		}
+	case SortKeyLocalPref:
+		if r.BGP != nil {
+			key.value = int64(r.BGP.LocalPref)
+		}
+	case SortKeyAge:
+		key.value = int64(r.Age)
+	}
+	return key
+}
+
+// routeIdentity identifies a route of a neighbor
+func routeIdentity(r *api.Route) string {
+	var gateway, neighborID string
+	if r.Gateway != nil {
+		gateway = *r.Gateway
+	}
+	if r.NeighborID != nil {
+		neighborID = *r.NeighborID
+	}
+	return gateway + "|" + neighborID
+}
+
+// lookupRouteIdentity identifies a route on a route server
+func lookupRouteIdentity(r *api.LookupRoute) string {
+	rsID := ""
+	if r.RouteServer != nil && r.RouteServer.ID != nil {
+		rsID = *r.RouteServer.ID
+	}
+	return rsID + "|" + routeIdentity(r.Route)
+}
+
+// compare orders the keys. Only the sort value is
+// affected by a descending order.
+func (s routeSort) compare(a, b routeKey) int {
+	if c := cmp.Compare(a.value, b.value); c != 0 {
+		if s.descending {
+			return -c
+		}
+		return c
+	}
+	// Valid prefixes first, then by address and length
+	if c := compareBool(b.prefix.IsValid(), a.prefix.IsValid()); c != 0 {
+		return c
+	}
+	if c := a.prefix.Addr().Compare(b.prefix.Addr()); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(a.prefix.Bits(), b.prefix.Bits()); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.network, b.network); c != 0 {
+		return c
+	}
+	return strings.Compare(a.tie, b.tie)
+}
+
+// Helper: false < true
+func compareBool(a, b bool) int {
+	if a == b {
+		return 0
+	}
+	if a {
+		return 1
+	}
+	return -1
+}
+
+// apiCursor is the position of the last route of
+// a page, with the query it was issued for.
+type apiCursor struct {
+	Generation string `json:"g"`
+	Sort       string `json:"s"`
+	Query      uint64 `json:"q"`
+	Value      int64  `json:"v"`
+	Network    string `json:"n"`
+	Tie        string `json:"t"`
+}
+
+// Encode the cursor as opaque string
+func (c *apiCursor) Encode() string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+// key of the route at the cursor
+func (c *apiCursor) key() routeKey {
+	prefix, _ := netip.ParsePrefix(c.Network)
+	return routeKey{
+		value:   c.Value,
+		prefix:  prefix,
+		network: c.Network,
+		tie:     c.Tie,
+	}
+}
+
+// decodeCursor decodes an opaque cursor
+func decodeCursor(value string) (*apiCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &apiCursor{}
+	if err := json.Unmarshal(payload, cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
+}
+
+// cursorQueryHash identifies the query of a cursor.
+// The cursor and the limit may change between pages.
+func cursorQueryHash(query url.Values) uint64 {
+	q := url.Values{}
+	for k, v := range query {
+		if k == "cursor" || k == "limit" {
+			continue
+		}
+		q[k] = v
+	}
+	h := fnv.New64a()
+	h.Write([]byte(q.Encode()))
+	return h.Sum64()
+}
+
+// cursorPage is the requested page
+type cursorPage struct {
+	sort       routeSort
+	limit      int
+	cursor     *apiCursor
+	query      uint64
+	generation string
+}
+
+// parseCursorPage validates the sort, limit and cursor
+// parameters of the query.
+func parseCursorPage(query url.Values, generation string) (*cursorPage, error) {
+	sort, err := parseRouteSort(query.Get("sort"))
+	if err != nil {
+		return nil, err
+	}
+
+	limit := CursorDefaultLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > CursorMaxLimit {
+			return nil, &ErrValidationFailed{
+				Param: "limit",
+				Reason: "limit must be a number between 1 and " +
+					strconv.Itoa(CursorMaxLimit),
+			}
+		}
+	}
+
+	page := &cursorPage{
+		sort:       sort,
+		limit:      limit,
+		query:      cursorQueryHash(query),
+		generation: generation,
+	}
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := decodeCursor(value)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != sort.String() || cursor.Query != page.query {
+			return nil, ErrInvalidCursor
+		}
+		page.cursor = cursor
+	}
+	return page, nil
+}
+
+// paginateCursor sorts the items and selects the page
+// after the cursor.
+func paginateCursor[T any](
+	page *cursorPage,
+	items []T,
+	key func(T) routeKey,
+) ([]T, api.CursorPagination) {
+	keys := make([]routeKey, len(items))
+	order := make([]int, len(items))
+	for i, item := range items {
+		keys[i] = key(item)
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return page.sort.compare(keys[a], keys[b])
+	})
+
+	pagination := api.CursorPagination{
+		Sort:         page.sort.String(),
+		Limit:        page.limit,
+		TotalResults: len(items),
+		Generation:   page.generation,
+	}
+
+	// Find the first route after the cursor
+	start := 0
+	if page.cursor != nil {
+		after := page.cursor.key()
+		start, _ = slices.BinarySearchFunc(order, after, func(i int, k routeKey) int {
+			if page.sort.compare(keys[i], k) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		pagination.GenerationChanged = page.cursor.Generation != page.generation
+	}
+	end := min(start+page.limit, len(order))
+
+	result := make([]T, 0, end-start)
+	for _, i := range order[start:end] {
+		result = append(result, items[i])
+	}
+
+	if end < len(order) {
+		last := keys[order[end-1]]
+		next := &apiCursor{
+			Generation: page.generation,
+			Sort:       page.sort.String(),
+			Query:      page.query,
+			Value:      last.value,
+			Network:    last.network,
+			Tie:        last.tie,
+		}
+		pagination.NextCursor = next.Encode()
+	}
+	return result, pagination
+}
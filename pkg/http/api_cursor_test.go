@@ -0,0 +1,166 @@
+package http
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func cursorTestRoutes() api.Routes {
+	route := func(network string, pathLen, localPref int) *api.Route {
+		gw := "192.0.2.1"
+		return &api.Route{
+			Network: network,
+			Gateway: &gw,
+			BGP: &api.BGPInfo{
+				AsPath:    make([]int, pathLen),
+				LocalPref: localPref,
+			},
+		}
+	}
+	return api.Routes{
+		route("10.0.0.0/8", 3, 100),
+		route("2001:db8::/32", 1, 200),
+		route("9.0.0.0/8", 2, 100),
+		route("10.0.0.0/16", 1, 300),
+		route("192.168.0.0/24", 2, 100),
+	}
+}
+
+func paginateTestRoutes(
+	t *testing.T,
+	query url.Values,
+	routes api.Routes,
+) (api.Routes, api.CursorPagination) {
+	page, err := parseCursorPage(query, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paginateCursor(page, routes, func(r *api.Route) routeKey {
+		return makeRouteKey(page.sort, r, routeIdentity(r))
+	})
+}
+
+func networks(routes api.Routes) []string {
+	result := make([]string, 0, len(routes))
+	for _, r := range routes {
+		result = append(result, r.Network)
+	}
+	return result
+}
+
+func TestCursorSortOrder(t *testing.T) {
+	tests := []struct {
+		sort     string
+		networks []string
+	}{
+		{"", []string{
+			"9.0.0.0/8", "10.0.0.0/8", "10.0.0.0/16",
+			"192.168.0.0/24", "2001:db8::/32"}},
+		{"as_path_length", []string{
+			"10.0.0.0/16", "2001:db8::/32", "9.0.0.0/8",
+			"192.168.0.0/24", "10.0.0.0/8"}},
+		{"-local_pref", []string{
+			"10.0.0.0/16", "2001:db8::/32", "9.0.0.0/8",
+			"10.0.0.0/8", "192.168.0.0/24"}},
+	}
+	for _, tt := range tests {
+		query := url.Values{"sort": {tt.sort}}
+		routes, pagination := paginateTestRoutes(t, query, cursorTestRoutes())
+		got := networks(routes)
+		for i := range tt.networks {
+			if got[i] != tt.networks[i] {
+				t.Errorf("sort %q: unexpected order: %v", tt.sort, got)
+				break
+			}
+		}
+		if pagination.NextCursor != "" {
+			t.Error("unexpected next cursor")
+		}
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	query := url.Values{
+		"sort":  {"as_path_length"},
+		"limit": {"2"},
+	}
+	seen := []string{}
+	for range 10 {
+		routes, pagination := paginateTestRoutes(t, query, cursorTestRoutes())
+		seen = append(seen, networks(routes)...)
+		if pagination.TotalResults != 5 {
+			t.Error("unexpected total results:", pagination.TotalResults)
+		}
+		if pagination.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", pagination.NextCursor)
+	}
+	if len(seen) != 5 {
+		t.Fatal("unexpected routes:", seen)
+	}
+
+	// Removing a route of a previous page must not
+	// shift the following pages.
+	query = url.Values{"limit": {"2"}}
+	_, pagination := paginateTestRoutes(t, query, cursorTestRoutes())
+	routes := cursorTestRoutes()[1:] // without 10.0.0.0/8
+	query.Set("cursor", pagination.NextCursor)
+	page, _ := paginateTestRoutes(t, query, routes)
+	if got := networks(page); got[0] != "10.0.0.0/16" {
+		t.Error("unexpected page:", got)
+	}
+}
+
+func TestCursorValidation(t *testing.T) {
+	_, pagination := paginateTestRoutes(t,
+		url.Values{"limit": {"1"}}, cursorTestRoutes())
+
+	tests := []struct {
+		query url.Values
+		param string
+	}{
+		{url.Values{"sort": {"foo"}}, "sort"},
+		{url.Values{"limit": {"0"}}, "limit"},
+		{url.Values{"limit": {"100000"}}, "limit"},
+		{url.Values{"cursor": {"not a cursor"}}, "cursor"},
+		// The cursor was issued for a different sort and query
+		{url.Values{"cursor": {pagination.NextCursor}, "sort": {"age"}}, "cursor"},
+		{url.Values{"cursor": {pagination.NextCursor}, "q": {"10."}}, "cursor"},
+	}
+	for _, tt := range tests {
+		_, err := parseCursorPage(tt.query, "1")
+		verr, ok := err.(*ErrValidationFailed)
+		if !ok || verr.Param != tt.param {
+			t.Errorf("expected validation error for %s, got: %v", tt.param, err)
+		}
+	}
+
+	// The limit may change between pages
+	query := url.Values{"cursor": {pagination.NextCursor}, "limit": {"3"}}
+	if _, err := parseCursorPage(query, "1"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCursorGenerationChanged(t *testing.T) {
+	_, pagination := paginateTestRoutes(t,
+		url.Values{"limit": {"1"}}, cursorTestRoutes())
+
+	query := url.Values{"limit": {"1"}, "cursor": {pagination.NextCursor}}
+	page, err := parseCursorPage(query, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pagination = paginateCursor(page, cursorTestRoutes(), func(r *api.Route) routeKey {
+		return makeRouteKey(page.sort, r, routeIdentity(r))
+	})
+	if !pagination.GenerationChanged {
+		t.Error("expected the generation to be changed")
+	}
+	if pagination.Generation != "2" {
+		t.Error("unexpected generation:", pagination.Generation)
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Handle alerts: The history of the alerts, the most
+// recent first. The alerts can be filtered by status,
+// rule and route server. Alerts of neighbors not visible
+// for the request are omitted.
+//
+//	/api/v1/alerts?status=firing&rule=session_down&routeserver=rs1
+func (s *Server) apiAlertsList(
+	ctx context.Context,
+	req *http.Request,
+	_params httprouter.Params,
+) (response, error) {
+	query := req.URL.Query()
+	status := query.Get("status")
+	rule := query.Get("rule")
+	rsID := query.Get("routeserver")
+
+	alerts := []*api.Alert{}
+	if s.alerts != nil {
+		for _, alert := range s.alerts.History() {
+			if status != "" && alert.Status != status {
+				continue
+			}
+			if rule != "" && alert.Rule != rule {
+				continue
+			}
+			if rsID != "" && alert.RouteServerID != rsID {
+				continue
+			}
+			alerts = append(alerts, alert)
+		}
+	}
+
+	response := &api.AlertsResponse{
+		Response: api.Response{
+			Meta: &api.Meta{
+				CacheStatus: api.CacheStatus{
+					CachedAt: time.Now().UTC(),
+				},
+			},
+		},
+		Alerts: s.visibleAlerts(ctx, alerts),
+	}
+	return response, nil
+}
@@ -0,0 +1,84 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Handle the comparison of routes between sources.
+// The sources are either passed as a list or selected
+// by their group:
+//
+//	/api/v1/lookup/diff?sources=rs1,rs2
+//	/api/v1/lookup/diff?group=FRA
+func (s *Server) apiLookupDiff(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (response, error) {
+	query := req.URL.Query()
+
+	sourceIDs := []string{}
+	if group := query.Get("group"); group != "" {
+		for _, src := range s.Config().Sources {
+			if src.Group == group {
+				sourceIDs = append(sourceIDs, src.ID)
+			}
+		}
+	} else {
+		value, err := validateQueryString(req, "sources")
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range strings.Split(value, ",") {
+			id, err := validateSourceID(strings.TrimSpace(id))
+			if err != nil {
+				return nil, err
+			}
+			if s.Config().SourceByID(id) == nil {
+				return nil, ErrSourceNotFound
+			}
+			sourceIDs = append(sourceIDs, id)
+		}
+	}
+	if len(sourceIDs) < 2 {
+		return nil, &ErrValidationFailed{
+			Param:  "sources",
+			Reason: "at least two sources are required",
+		}
+	}
+
+	// Filtered routes of neighbors not visible
+	// for the request are not compared.
+	visible := func(r *api.LookupRoute) *api.LookupRoute {
+		return s.visibleLookupRoute(ctx, r)
+	}
+	neighbors, err := s.routesStore.DiffSources(ctx, sourceIDs, visible)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &api.RoutesDiffResponse{
+		Response: api.Response{
+			Meta: &api.Meta{
+				CacheStatus: api.CacheStatus{
+					CachedAt: s.routesStore.CachedAt(ctx),
+				},
+				StoreStatus: &api.StoreStatusMeta{
+					Neighbors: s.neighborsStore.Status(ctx),
+					Routes:    s.routesStore.Status(ctx),
+				},
+				ResultFromCache: true,
+				TTL:             s.routesStore.CacheTTL(ctx),
+			},
+		},
+		Sources:   sourceIDs,
+		Neighbors: neighbors,
+	}
+	return response, nil
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// EventsKeepaliveInterval is the interval for sending
+// comments to keep idle event streams open.
+const EventsKeepaliveInterval = 30 * time.Second
+
+// Stream neighbor events as server-sent events. The
+// filtered routes of neighbors not visible for the
+// request are redacted. The events can be filtered
+// by sources and ASNs:
+//
+//	/api/v1/events/neighbors?sources=rs1,rs2&asn=2342
+func (s *Server) apiNeighborEventsStream(
+	res http.ResponseWriter,
+	req *http.Request,
+	params httprouter.Params,
+) {
+	filter, err := api.NeighborEventsFilterFromQuery(req.URL.Query())
+	if err != nil {
+		apiWriteError(res, params, &ErrValidationFailed{
+			Param:  "asn",
+			Reason: err.Error(),
+		})
+		return
+	}
+	for _, id := range filter.SourceIDs {
+		if _, err := validateSourceID(id); err != nil {
+			apiWriteError(res, params, err)
+			return
+		}
+		if s.Config().SourceByID(id) == nil {
+			apiWriteError(res, params, ErrSourceNotFound)
+			return
+		}
+	}
+
+	flusher, ok := res.(http.Flusher)
+	if !ok {
+		http.Error(res, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, cancel := s.neighborsStore.SubscribeEvents(filter)
+	defer cancel()
+
+	res.Header().Set("Content-Type", "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+
+	// The stream outlives the server write timeout,
+	// so the deadline is extended before each write.
+	rc := http.NewResponseController(res)
+	write := func(msg string) error {
+		rc.SetWriteDeadline(time.Now().Add(EventsKeepaliveInterval * 2))
+		if _, err := fmt.Fprint(res, msg); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	if err := write(": connected\n\n"); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(EventsKeepaliveInterval)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-s.stopping:
+			return // Shutting down
+		case <-keepalive.C:
+			if err := write(": keepalive\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			event = s.visibleNeighborEvent(req.Context(), event)
+			if event == nil {
+				continue
+			}
+			payload, err := json.Marshal(event)
+			if err != nil {
+				log.Println("could not encode neighbor event:", err)
+				continue
+			}
+			msg := fmt.Sprintf(
+				"id: %d\nevent: %s\ndata: %s\n\n",
+				event.ID, event.Type, payload)
+			if err := write(msg); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package http
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/export"
+)
+
+// ExportFlushInterval is the number of routes
+// written before the response is flushed.
+const ExportFlushInterval = 1000
+
+// routesExport is the result of an export endpoint
+type routesExport struct {
+	// Name of the exported file without extension
+	name string
+
+	// stream calls fn for each exported route, the
+	// routes are ordered by network.
+	stream func(fn func(*api.LookupRoute) error) error
+
+	// Peers of the MRT peer index table. All neighbors
+	// of the streamed routes must be included.
+	peers api.Neighbors
+
+	// Default columns of the CSV export
+	columns []string
+}
+
+type apiExportEndpoint func(
+	context.Context,
+	*http.Request,
+	httprouter.Params,
+) (*routesExport, error)
+
+// Wrap an export handler. The routes are written
+// incrementally in the requested format as they are
+// read from the store.
+//
+// The format is selected by the format query parameter
+// or the Accept header and defaults to ndjson.
+func exportEndpoint(wrapped apiExportEndpoint) httprouter.Handle {
+	return func(
+		res http.ResponseWriter,
+		req *http.Request,
+		params httprouter.Params,
+	) {
+		format, err := validateExportFormat(req)
+		if err != nil {
+			apiWriteError(res, params, err)
+			return
+		}
+
+		result, err := wrapped(req.Context(), req, params)
+		if err != nil {
+			apiWriteError(res, params, err)
+			return
+		}
+
+		columns, err := validateExportColumns(req, result.columns)
+		if err != nil {
+			apiWriteError(res, params, err)
+			return
+		}
+
+		opts := &export.Options{
+			Columns:  columns,
+			ViewName: result.name,
+			Now:      time.Now(),
+			Peers:    result.peers,
+		}
+
+		// Check the options before the response is started,
+		// e.g. the number of peers of the MRT export.
+		if _, err := export.NewRoutesWriter(format, io.Discard, opts); err != nil {
+			apiWriteError(res, params, err)
+			return
+		}
+
+		var (
+			w       export.RoutesWriter
+			gz      *gzip.Writer
+			started bool
+			count   int
+		)
+
+		// The response is started with the first route, so
+		// an error of the store can still be reported.
+		start := func() error {
+			started = true
+			filename := fmt.Sprintf(
+				"%s.%s", result.name, export.FileExtension(format))
+			res.Header().Set("Content-Type", export.ContentType(format))
+			res.Header().Set("Content-Disposition",
+				fmt.Sprintf("attachment; filename=%q", filename))
+
+			var out io.Writer = res
+			if strings.Contains(req.Header.Get("Accept-Encoding"), "gzip") {
+				res.Header().Set("Content-Encoding", "gzip")
+				gz = gzip.NewWriter(res)
+				out = gz
+			}
+
+			w, err = export.NewRoutesWriter(format, out, opts)
+			return err
+		}
+
+		flush := func() {
+			if f, ok := w.(interface{ Flush() error }); ok {
+				f.Flush()
+			}
+			if gz != nil {
+				gz.Flush()
+			}
+			if f, ok := res.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+
+		err = result.stream(func(r *api.LookupRoute) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			if err := w.Write(r); err != nil {
+				return err
+			}
+			count++
+			if count%ExportFlushInterval == 0 {
+				flush()
+			}
+			return nil
+		})
+		if err != nil && !started {
+			apiWriteError(res, params, err)
+			return
+		}
+		if !started {
+			err = start()
+		}
+		if gz != nil {
+			defer gz.Close()
+		}
+		if err != nil {
+			// The response is already started, the
+			// export is incomplete.
+			log.Println("export failed:", err)
+			return
+		}
+		if err := w.Close(); err != nil {
+			log.Println("export failed:", err)
+		}
+	}
+}
+
+// streamRoutes iterates routes in memory ordered by network
+func streamRoutes(
+	routes api.LookupRoutes,
+) func(fn func(*api.LookupRoute) error) error {
+	sort.Stable(routes)
+	return func(fn func(*api.LookupRoute) error) error {
+		for _, r := range routes {
+			if err := fn(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// exportPeers are the MRT peers of the neighbors of a source
+func exportPeers(sourceID string, neighbors api.Neighbors) api.Neighbors {
+	peers := make(api.Neighbors, 0, len(neighbors))
+	for _, n := range neighbors {
+		peers = append(peers, &api.Neighbor{
+			ID:            n.ID,
+			Address:       n.Address,
+			ASN:           n.ASN,
+			RouteServerID: sourceID,
+		})
+	}
+	return peers
+}
+
+// validateExportFormat gets the format from the query
+// or the accept header.
+func validateExportFormat(req *http.Request) (string, error) {
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatFromContentType(req.Header.Get("Accept"))
+	}
+	if format == "" {
+		return export.FormatNDJSON, nil
+	}
+	if !export.IsFormat(format) {
+		return "", &ErrValidationFailed{
+			Param:  "format",
+			Reason: "must be one of ndjson, csv or mrt",
+		}
+	}
+	return format, nil
+}
+
+// validateExportColumns gets the columns of the CSV
+// export from the query. Default columns which can
+// not be exported are skipped.
+func validateExportColumns(
+	req *http.Request,
+	defaults []string,
+) ([]string, error) {
+	value := req.URL.Query().Get("columns")
+	if value == "" {
+		columns := make([]string, 0, len(defaults))
+		for _, c := range defaults {
+			if export.IsColumn(c) {
+				columns = append(columns, c)
+			}
+		}
+		return columns, nil
+	}
+
+	columns := strings.Split(value, ",")
+	for i, c := range columns {
+		c = strings.TrimSpace(c)
+		if !export.IsColumn(c) {
+			return nil, &ErrValidationFailed{
+				Param:  "columns",
+				Reason: "unknown column: " + c,
+			}
+		}
+		columns[i] = c
+	}
+	return columns, nil
+}
+
+// exportFilters are the filters from the query string
+func exportFilters(req *http.Request) (*api.SearchFilters, error) {
+	return validateFilters(req.URL.Query())
+}
+
+// Export all routes of a route server
+func (s *Server) apiRoutesExportRouteServer(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (*routesExport, error) {
+	rsID, err := validateSourceID(params.ByName("id"))
+	if err != nil {
+		return nil, err
+	}
+	if s.Config().SourceByID(rsID) == nil {
+		return nil, ErrSourceNotFound
+	}
+	filters, err := exportFilters(req)
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors, err := s.neighborsStore.GetNeighborsAt(ctx, rsID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &routesExport{
+		name:    rsID,
+		stream:  s.streamVisibleRoutes(ctx, rsID, neighbors, filters),
+		peers:   exportPeers(rsID, neighbors),
+		columns: s.Config().UI.RoutesColumnsOrder,
+	}, nil
+}
+
+// streamVisibleRoutes streams the routes of neighbors
+// of a source from the store. The routes not visible
+// for the request are skipped or redacted.
+func (s *Server) streamVisibleRoutes(
+	ctx context.Context,
+	sourceID string,
+	neighbors api.Neighbors,
+	filters *api.SearchFilters,
+) func(fn func(*api.LookupRoute) error) error {
+	return func(fn func(*api.LookupRoute) error) error {
+		return s.routesStore.StreamPrefixForNeighbors(
+			ctx,
+			api.NeighborsLookupResults{sourceID: neighbors},
+			filters,
+			func(r *api.LookupRoute) error {
+				if r = s.visibleLookupRoute(ctx, r); r == nil {
+					return nil
+				}
+				return fn(r)
+			})
+	}
+}
+
+// Export the routes of a neighbor
+func (s *Server) apiRoutesExportNeighbor(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (*routesExport, error) {
+	rsID, err := validateSourceID(params.ByName("id"))
+	if err != nil {
+		return nil, err
+	}
+	if s.Config().SourceByID(rsID) == nil {
+		return nil, ErrSourceNotFound
+	}
+	filters, err := exportFilters(req)
+	if err != nil {
+		return nil, err
+	}
+
+	neighborID := params.ByName("neighborId")
+	neighbors, err := s.neighborsStore.GetNeighborsMapAt(ctx, rsID)
+	if err != nil {
+		return nil, err
+	}
+	neighbor, ok := neighbors[neighborID]
+	if !ok {
+		return nil, &ErrResourceNotFoundError{}
+	}
+
+	selected := api.Neighbors{neighbor}
+
+	return &routesExport{
+		name:    rsID + "-" + neighborID,
+		stream:  s.streamVisibleRoutes(ctx, rsID, selected, filters),
+		peers:   exportPeers(rsID, selected),
+		columns: s.Config().UI.RoutesColumnsOrder,
+	}, nil
+}
+
+// Export the results of a prefix lookup
+func (s *Server) apiLookupPrefixExport(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (*routesExport, error) {
+	routes, _, err := s.lookupPrefixRoutes(ctx, req.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	return &routesExport{
+		name:    "lookup",
+		stream:  streamRoutes(routes),
+		peers:   export.PeersFromRoutes(routes),
+		columns: s.Config().UI.LookupColumnsOrder,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+func TestExportRouteServer(t *testing.T) {
+	s, _ := newTestServer(t)
+	src := s.Config().Sources[0]
+	src.Visibility = config.VisibilityMembers
+
+	neighbors := api.Neighbors{{ID: "ID163_AS31078", ASN: 31078}}
+	handler := exportEndpoint(func(
+		ctx context.Context,
+		req *http.Request,
+		params httprouter.Params,
+	) (*routesExport, error) {
+		return &routesExport{
+			name: src.ID,
+			stream: s.streamVisibleRoutes(
+				ctx, src.ID, neighbors, api.NewSearchFilters()),
+			peers: exportPeers(src.ID, neighbors),
+		}, nil
+	})
+
+	// Only the visible routes are exported
+	expected, err := s.routesStore.LookupPrefixForNeighbors(
+		context.Background(),
+		api.NeighborsLookupResults{src.ID: neighbors},
+		api.NewSearchFilters())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = s.visibleLookupRoutes(context.Background(), expected)
+
+	req := httptest.NewRequest("GET", "/export", nil)
+	res := httptest.NewRecorder()
+	handler(res, req, nil)
+	if res.Code != http.StatusOK {
+		t.Fatal("unexpected status:", res.Code, res.Body.String())
+	}
+	lines := 0
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		lines++
+	}
+	if lines == 0 || lines != len(expected) {
+		t.Error("unexpected number of routes:", lines, len(expected))
+	}
+
+	// The peer index table is written first
+	req = httptest.NewRequest("GET", "/export?format=mrt", nil)
+	res = httptest.NewRecorder()
+	handler(res, req, nil)
+	if res.Code != http.StatusOK {
+		t.Fatal("unexpected status:", res.Code, res.Body.String())
+	}
+	if subtype := binary.BigEndian.Uint16(res.Body.Bytes()[6:8]); subtype != 1 {
+		t.Error("expected peer index table, got subtype:", subtype)
+	}
+}
+
+func TestExportStreamError(t *testing.T) {
+	errStore := errors.New("store unavailable")
+	handler := exportEndpoint(func(
+		context.Context,
+		*http.Request,
+		httprouter.Params,
+	) (*routesExport, error) {
+		return &routesExport{
+			name: "rs1",
+			stream: func(func(*api.LookupRoute) error) error {
+				return errStore
+			},
+		}, nil
+	})
+
+	// The error is reported, as the response was not started
+	req := httptest.NewRequest("GET", "/export?format=csv", nil)
+	res := httptest.NewRecorder()
+	handler(res, req, nil)
+	if res.Code != http.StatusInternalServerError {
+		t.Error("unexpected status:", res.Code)
+	}
+	if res.Header().Get("Content-Disposition") != "" {
+		t.Error("unexpected attachment")
+	}
+}
+
+func TestExportEmpty(t *testing.T) {
+	handler := exportEndpoint(func(
+		context.Context,
+		*http.Request,
+		httprouter.Params,
+	) (*routesExport, error) {
+		return &routesExport{
+			name:    "rs1",
+			stream:  streamRoutes(api.LookupRoutes{}),
+			columns: []string{"network"},
+		}, nil
+	})
+
+	req := httptest.NewRequest("GET", "/export?format=csv", nil)
+	res := httptest.NewRecorder()
+	handler(res, req, nil)
+	if res.Code != http.StatusOK {
+		t.Fatal("unexpected status:", res.Code)
+	}
+	if res.Body.String() != "network\n" {
+		t.Error("unexpected body:", res.Body.String())
+	}
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/julienschmidt/httprouter"
+)
+
+// GraphQLMaxRequestSize is the maximum size of
+// the body of a GraphQL request.
+const GraphQLMaxRequestSize = 1 << 20
+
+// GraphQLRequest is a GraphQL query with variables
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// decodeGraphQLRequest reads the query from the body of
+// a POST request or from the query string.
+func decodeGraphQLRequest(req *http.Request) (*GraphQLRequest, error) {
+	request := &GraphQLRequest{}
+	if req.Method == http.MethodPost {
+		body := io.LimitReader(req.Body, GraphQLMaxRequestSize)
+		if err := json.NewDecoder(body).Decode(request); err != nil {
+			return nil, &ErrValidationFailed{
+				Param:  "body",
+				Reason: "could not decode GraphQL request: " + err.Error(),
+			}
+		}
+	} else {
+		query := req.URL.Query()
+		request.Query = query.Get("query")
+		request.OperationName = query.Get("operationName")
+		if value := query.Get("variables"); value != "" {
+			if err := json.Unmarshal([]byte(value), &request.Variables); err != nil {
+				return nil, &ErrValidationFailed{
+					Param:  "variables",
+					Reason: "variables must be a JSON object",
+				}
+			}
+		}
+	}
+	if request.Query == "" {
+		return nil, NewErrMissingParam("query")
+	}
+	return request, nil
+}
+
+// Execute parses and validates the query and executes
+// it, if the estimated cost is within the limit.
+func (g *graphQLAPI) Execute(
+	ctx context.Context,
+	request *GraphQLRequest,
+) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{
+			Body: []byte(request.Query),
+			Name: "GraphQL request",
+		}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&g.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	if g.maxCost > 0 {
+		cost := g.queryCost(doc, request.OperationName, request.Variables)
+		if cost > g.maxCost {
+			err := &ErrGraphQLCostExceeded{Cost: cost, MaxCost: g.maxCost}
+			return &graphql.Result{
+				Errors: []gqlerrors.FormattedError{{
+					Message: err.Error(),
+					Extensions: map[string]any{
+						"code":    "QUERY_COST_EXCEEDED",
+						"cost":    cost,
+						"maxCost": g.maxCost,
+					},
+				}},
+			}
+		}
+	}
+
+	return graphql.Execute(graphql.ExecuteParams{
+		Schema:        g.schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+}
+
+// Handle GraphQL queries
+//
+//	POST /api/v1/graphql {"query": "{ routeServers { id } }"}
+//	GET  /api/v1/graphql?query={routeServers{id}}
+func (g *graphQLAPI) apiQuery(
+	ctx context.Context,
+	req *http.Request,
+	_params httprouter.Params,
+) (response, error) {
+	request, err := decodeGraphQLRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return g.Execute(ctx, request), nil
+}
@@ -27,24 +27,24 @@ func (s *Server) apiConfigShow(
 	_params httprouter.Params,
 ) (response, error) {
 	result := api.ConfigResponse{
-		BGPCommunities:          s.cfg.UI.BGPCommunities,
-		BGPBlackholeCommunities: s.cfg.UI.BGPBlackholeCommunities,
-		RejectReasons:           s.cfg.UI.RoutesRejections.Reasons,
+		BGPCommunities:          s.Config().UI.BGPCommunities,
+		BGPBlackholeCommunities: s.Config().UI.BGPBlackholeCommunities,
+		RejectReasons:           s.Config().UI.RoutesRejections.Reasons,
 		Noexport: api.Noexport{
-			LoadOnDemand: s.cfg.UI.RoutesNoexports.LoadOnDemand,
+			LoadOnDemand: s.Config().UI.RoutesNoexports.LoadOnDemand,
 		},
-		NoexportReasons: s.cfg.UI.RoutesNoexports.Reasons,
+		NoexportReasons: s.Config().UI.RoutesNoexports.Reasons,
 		RejectCandidates: api.RejectCandidates{
-			Communities: s.cfg.UI.RoutesRejectCandidates.Communities,
+			Communities: s.Config().UI.RoutesRejectCandidates.Communities,
 		},
-		Rpki:                  api.Rpki(s.cfg.UI.Rpki),
-		RoutesColumns:         s.cfg.UI.RoutesColumns,
-		RoutesColumnsOrder:    s.cfg.UI.RoutesColumnsOrder,
-		NeighborsColumns:      s.cfg.UI.NeighborsColumns,
-		NeighborsColumnsOrder: s.cfg.UI.NeighborsColumnsOrder,
-		LookupColumns:         s.cfg.UI.LookupColumns,
-		LookupColumnsOrder:    s.cfg.UI.LookupColumnsOrder,
-		PrefixLookupEnabled:   s.cfg.Server.EnablePrefixLookup,
+		Rpki:                  api.Rpki(s.Config().UI.Rpki),
+		RoutesColumns:         s.Config().UI.RoutesColumns,
+		RoutesColumnsOrder:    s.Config().UI.RoutesColumnsOrder,
+		NeighborsColumns:      s.Config().UI.NeighborsColumns,
+		NeighborsColumnsOrder: s.Config().UI.NeighborsColumnsOrder,
+		LookupColumns:         s.Config().UI.LookupColumns,
+		LookupColumnsOrder:    s.Config().UI.LookupColumnsOrder,
+		PrefixLookupEnabled:   s.Config().Server.EnablePrefixLookup,
 	}
 	return result, nil
 }
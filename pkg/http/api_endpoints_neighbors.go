@@ -51,10 +51,12 @@ func (s *Server) apiNeighborsList(
 					TTL:             s.neighborsStore.SourceCacheTTL(ctx, rsID),
 				},
 			},
-			Neighbors: neighbors,
+			Neighbors: withRouteChanges(
+				neighbors,
+				s.routesStore.RouteChangesSummaries(ctx, rsID)),
 		}
 	} else {
-		source := s.cfg.SourceInstanceByID(rsID)
+		source := s.Config().SourceInstanceByID(rsID)
 		if source == nil {
 			return nil, ErrSourceNotFound
 		}
@@ -65,7 +67,35 @@ func (s *Server) apiNeighborsList(
 		}
 	}
 
+	// Hide the details of other members
+	visible := *neighborsResponse
+	visible.Neighbors = s.visibleNeighbors(
+		ctx, rsID, neighborsResponse.Neighbors)
+
 	// Sort result
-	sort.Sort(&neighborsResponse.Neighbors)
-	return neighborsResponse, nil
+	sort.Sort(&visible.Neighbors)
+	return &visible, nil
+}
+
+// withRouteChanges adds the summary of the route changes
+// to copies of the neighbors.
+func withRouteChanges(
+	neighbors api.Neighbors,
+	summaries map[string]*api.RouteChangesSummary,
+) api.Neighbors {
+	if len(summaries) == 0 {
+		return neighbors
+	}
+	result := make(api.Neighbors, 0, len(neighbors))
+	for _, n := range neighbors {
+		summary, ok := summaries[n.ID]
+		if !ok {
+			result = append(result, n)
+			continue
+		}
+		neighbor := *n
+		neighbor.RouteChanges = summary
+		result = append(result, &neighbor)
+	}
+	return result
 }
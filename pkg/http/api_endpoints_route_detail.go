@@ -0,0 +1,198 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// neighborRoutesHandlers are the endpoints below the
+// routes of a neighbor. The static segments and the prefix
+// of the route details share the same position in the path,
+// which httprouter can not distinguish.
+type neighborRoutesHandlers map[string]httprouter.Handle
+
+// dispatch selects the handler by the first segment
+// of the route parameter. Everything else is a prefix.
+// The metrics are labeled with the selected endpoint.
+func (handlers neighborRoutesHandlers) dispatch(
+	details httprouter.Handle,
+) httprouter.Handle {
+	return func(
+		res http.ResponseWriter,
+		req *http.Request,
+		params httprouter.Params,
+	) {
+		route := strings.TrimPrefix(params.ByName("route"), "/")
+		if handler, ok := handlers[route]; ok {
+			refineRoute(req.Context(), "route", route)
+			handler(res, req, params)
+			return
+		}
+		refineRoute(req.Context(), "route", ":prefix")
+		params = append(params, httprouter.Param{Key: "prefix", Value: route})
+		details(res, req, params)
+	}
+}
+
+// validatePrefixParam parses the prefix of the route
+func validatePrefixParam(value string) (netip.Prefix, error) {
+	prefix, err := netip.ParsePrefix(value)
+	if err != nil {
+		return netip.Prefix{}, &ErrValidationFailed{
+			Param:  "prefix",
+			Reason: "prefix must be a network like 10.0.0.0/24",
+		}
+	}
+	return prefix.Masked(), nil
+}
+
+// Route details: all paths of a prefix received from,
+// filtered or not exported to the neighbor with the
+// raw details from the source.
+func (s *Server) apiRouteDetails(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (response, error) {
+	t0 := time.Now()
+
+	rsID, err := validateSourceID(params.ByName("id"))
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := validatePrefixParam(params.ByName("prefix"))
+	if err != nil {
+		return nil, err
+	}
+
+	neighborID := params.ByName("neighborId")
+	source := s.Config().SourceInstanceByID(rsID)
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+
+	received, err := source.RoutesReceived(ctx, neighborID)
+	if err != nil {
+		s.logSourceError("routes_received", rsID, neighborID, err)
+		return nil, err
+	}
+	paths := s.routePaths(prefix, api.RouteStateImported, received.Imported)
+
+	// Filtered and not exported routes are only
+	// visible for the member.
+	if s.canSeeNeighborRoutes(ctx, rsID, neighborID) {
+		filtered, err := source.RoutesFiltered(ctx, neighborID)
+		if err != nil {
+			s.logSourceError("routes_filtered", rsID, neighborID, err)
+			return nil, err
+		}
+		paths = append(paths,
+			s.routePaths(prefix, api.RouteStateFiltered, filtered.Filtered)...)
+
+		notExported, err := source.RoutesNotExported(ctx, neighborID)
+		if err != nil {
+			s.logSourceError("routes_not_exported", rsID, neighborID, err)
+			return nil, err
+		}
+		paths = append(paths,
+			s.routePaths(prefix, api.RouteStateNotExported, notExported.NotExported)...)
+	}
+
+	if len(paths) == 0 {
+		return nil, &ErrResourceNotFoundError{}
+	}
+
+	return &api.RouteDetailsResponse{
+		Response: api.Response{
+			Meta: received.Response.Meta,
+		},
+		TimedResponse: api.TimedResponse{
+			RequestDuration: DurationMs(time.Since(t0)),
+		},
+		Network:    prefix.String(),
+		NeighborID: neighborID,
+		Paths:      paths,
+	}, nil
+}
+
+// routePaths selects the routes of the prefix and
+// adds the community labels and filter reasons.
+func (s *Server) routePaths(
+	prefix netip.Prefix,
+	state string,
+	routes api.Routes,
+) []*api.RoutePath {
+	paths := []*api.RoutePath{}
+	for _, r := range routes {
+		network, err := netip.ParsePrefix(r.Network)
+		if err != nil || network.Masked() != prefix {
+			continue
+		}
+		path := &api.RoutePath{
+			Route:           r,
+			State:           state,
+			CommunityLabels: []*api.CommunityLabel{},
+			FilterReasons:   []*api.CommunityLabel{},
+		}
+		if r.BGP == nil {
+			paths = append(paths, path)
+			continue
+		}
+		path.CommunityLabels = communityLabels(
+			r.BGP, s.Config().UI.BGPCommunities, true)
+		switch state {
+		case api.RouteStateFiltered:
+			path.FilterReasons = communityLabels(
+				r.BGP, s.Config().UI.RoutesRejections.Reasons, false)
+		case api.RouteStateNotExported:
+			path.FilterReasons = communityLabels(
+				r.BGP, s.Config().UI.RoutesNoexports.Reasons, false)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// communityLabels looks up the labels of the communities
+// of the route. Extended communities are only included
+// when requested, as they are not used for filter reasons.
+func communityLabels(
+	bgp *api.BGPInfo,
+	labels api.BGPCommunityMap,
+	withExt bool,
+) []*api.CommunityLabel {
+	result := []*api.CommunityLabel{}
+	if labels == nil {
+		return result
+	}
+	communities := []string{}
+	for _, c := range bgp.Communities {
+		communities = append(communities, c.String())
+	}
+	for _, c := range bgp.LargeCommunities {
+		communities = append(communities, c.String())
+	}
+	if withExt {
+		for _, c := range bgp.ExtCommunities {
+			communities = append(communities, c.String())
+		}
+	}
+	for _, c := range communities {
+		label, err := labels.Lookup(c)
+		if err != nil {
+			continue
+		}
+		result = append(result, &api.CommunityLabel{
+			Community: c,
+			Label:     label,
+		})
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func TestNeighborRoutesDispatch(t *testing.T) {
+	var called, prefix string
+	handler := func(name string) httprouter.Handle {
+		return func(
+			_ http.ResponseWriter,
+			_ *http.Request,
+			params httprouter.Params,
+		) {
+			called = name
+			prefix = params.ByName("prefix")
+		}
+	}
+	handlers := neighborRoutesHandlers{
+		"received": handler("received"),
+		"filtered": handler("filtered"),
+	}
+	router := httprouter.New()
+	router.GET("/rs/:id/neighbors/:neighborId/routes/*route",
+		handlers.dispatch(handler("details")))
+
+	tests := []struct {
+		path   string
+		called string
+		prefix string
+	}{
+		{"/rs/rs1/neighbors/n1/routes/received", "received", ""},
+		{"/rs/rs1/neighbors/n1/routes/filtered", "filtered", ""},
+		{"/rs/rs1/neighbors/n1/routes/10.0.0.0/24", "details", "10.0.0.0/24"},
+		{"/rs/rs1/neighbors/n1/routes/10.0.0.0%2F24", "details", "10.0.0.0/24"},
+		{"/rs/rs1/neighbors/n1/routes/2001:db8::/32", "details", "2001:db8::/32"},
+	}
+	for _, tc := range tests {
+		called, prefix = "", ""
+		req := httptest.NewRequest("GET", tc.path, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if called != tc.called || prefix != tc.prefix {
+			t.Errorf("%s: unexpected handler %s with prefix %s",
+				tc.path, called, prefix)
+		}
+	}
+}
+
+func TestValidatePrefixParam(t *testing.T) {
+	prefix, err := validatePrefixParam("10.0.0.1/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix.String() != "10.0.0.0/24" {
+		t.Error("expected masked prefix, got:", prefix)
+	}
+	if _, err := validatePrefixParam("received/"); err == nil {
+		t.Error("expected a validation error")
+	}
+}
+
+func TestRoutePaths(t *testing.T) {
+	s, _ := newTestServer(t)
+	prefix, _ := validatePrefixParam("10.0.0.0/24")
+
+	routes := api.Routes{
+		&api.Route{
+			Network: "10.0.0.0/24",
+			BGP: &api.BGPInfo{
+				Communities:      api.Communities{{1, 23}},
+				LargeCommunities: api.Communities{{23, 42, 1}},
+			},
+		},
+		&api.Route{
+			Network: "10.0.1.0/24",
+			BGP:     &api.BGPInfo{},
+		},
+	}
+
+	paths := s.routePaths(prefix, api.RouteStateFiltered, routes)
+	if len(paths) != 1 {
+		t.Fatal("expected one path, got:", len(paths))
+	}
+	path := paths[0]
+	if path.State != api.RouteStateFiltered || path.Route != routes[0] {
+		t.Error("unexpected path:", path)
+	}
+	if len(path.CommunityLabels) != 1 ||
+		path.CommunityLabels[0].Community != "1:23" ||
+		path.CommunityLabels[0].Label != "some tag" {
+		t.Error("unexpected community labels:", path.CommunityLabels)
+	}
+	if len(path.FilterReasons) != 1 ||
+		path.FilterReasons[0].Label != "Some made up reason" {
+		t.Error("unexpected filter reasons:", path.FilterReasons)
+	}
+
+	// Received routes have no filter reasons
+	paths = s.routePaths(prefix, api.RouteStateImported, routes)
+	if len(paths[0].FilterReasons) != 0 {
+		t.Error("unexpected filter reasons:", paths[0].FilterReasons)
+	}
+}
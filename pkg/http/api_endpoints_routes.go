@@ -23,7 +23,7 @@ func (s *Server) apiRoutesList(
 	}
 	neighborID := params.ByName("neighborId")
 
-	source := s.cfg.SourceInstanceByID(rsID)
+	source := s.Config().SourceInstanceByID(rsID)
 	if source == nil {
 		return nil, ErrSourceNotFound
 	}
@@ -52,7 +52,7 @@ func (s *Server) apiRoutesListReceived(
 	}
 
 	neighborID := params.ByName("neighborId")
-	source := s.cfg.SourceInstanceByID(rsID)
+	source := s.Config().SourceInstanceByID(rsID)
 	if source == nil {
 		return nil, ErrSourceNotFound
 	}
@@ -62,13 +62,14 @@ func (s *Server) apiRoutesListReceived(
 		s.logSourceError("routes_received", rsID, neighborID, err)
 		return nil, err
 	}
+	s.routesMeta.set("received", rsID, neighborID, result.Meta)
 
 	// Filter routes based on criteria if present
 	allRoutes := apiQueryFilterNextHopGateway(req, "q", result.Imported)
 	routes := api.Routes{}
 
 	// Apply other (community) filters
-	filtersApplied, err := api.FiltersFromQuery(req.URL.Query())
+	filtersApplied, err := validateRouteFilters(req.URL.Query())
 	if err != nil {
 		return nil, err
 	}
@@ -92,7 +93,7 @@ func (s *Server) apiRoutesListReceived(
 
 	// Paginate results
 	page := apiQueryMustInt(req, "page", 0)
-	pageSize := s.cfg.UI.Pagination.RoutesAcceptedPageSize
+	pageSize := s.Config().UI.Pagination.RoutesAcceptedPageSize
 	routes, pagination := apiPaginateRoutes(routes, page, pageSize)
 
 	// Calculate query duration
@@ -134,7 +135,7 @@ func (s *Server) apiRoutesListFiltered(
 	}
 
 	neighborID := params.ByName("neighborId")
-	source := s.cfg.SourceInstanceByID(rsID)
+	source := s.Config().SourceInstanceByID(rsID)
 	if source == nil {
 		return nil, ErrSourceNotFound
 	}
@@ -144,13 +145,17 @@ func (s *Server) apiRoutesListFiltered(
 		s.logSourceError("routes_filtered", rsID, neighborID, err)
 		return nil, err
 	}
+	s.routesMeta.set("filtered", rsID, neighborID, result.Meta)
 
 	// Filter routes based on criteria if present
 	allRoutes := apiQueryFilterNextHopGateway(req, "q", result.Filtered)
+	if !s.canSeeNeighborRoutes(ctx, rsID, neighborID) {
+		allRoutes = api.Routes{} // Only visible for the member
+	}
 	routes := api.Routes{}
 
 	// Apply other (community) filters
-	filtersApplied, err := api.FiltersFromQuery(req.URL.Query())
+	filtersApplied, err := validateRouteFilters(req.URL.Query())
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +180,7 @@ func (s *Server) apiRoutesListFiltered(
 
 	// Paginate results
 	page := apiQueryMustInt(req, "page", 0)
-	pageSize := s.cfg.UI.Pagination.RoutesFilteredPageSize
+	pageSize := s.Config().UI.Pagination.RoutesFilteredPageSize
 	routes, pagination := apiPaginateRoutes(routes, page, pageSize)
 
 	// Calculate query duration
@@ -217,7 +222,7 @@ func (s *Server) apiRoutesListNotExported(
 	}
 
 	neighborID := params.ByName("neighborId")
-	source := s.cfg.SourceInstanceByID(rsID)
+	source := s.Config().SourceInstanceByID(rsID)
 	if source == nil {
 		return nil, ErrSourceNotFound
 	}
@@ -227,13 +232,17 @@ func (s *Server) apiRoutesListNotExported(
 		s.logSourceError("routes_not_exported", rsID, neighborID, err)
 		return nil, err
 	}
+	s.routesMeta.set("not-exported", rsID, neighborID, result.Meta)
 
 	// Filter routes based on criteria if present
 	allRoutes := apiQueryFilterNextHopGateway(req, "q", result.NotExported)
+	if !s.canSeeNeighborRoutes(ctx, rsID, neighborID) {
+		allRoutes = api.Routes{} // Only visible for the member
+	}
 	routes := api.Routes{}
 
 	// Apply other (community) filters
-	filtersApplied, err := api.FiltersFromQuery(req.URL.Query())
+	filtersApplied, err := validateRouteFilters(req.URL.Query())
 	if err != nil {
 		return nil, err
 	}
@@ -257,7 +266,7 @@ func (s *Server) apiRoutesListNotExported(
 
 	// Paginate results
 	page := apiQueryMustInt(req, "page", 0)
-	pageSize := s.cfg.UI.Pagination.RoutesNotExportedPageSize
+	pageSize := s.Config().UI.Pagination.RoutesNotExportedPageSize
 	routes, pagination := apiPaginateRoutes(routes, page, pageSize)
 
 	// Calculate query duration
@@ -285,3 +294,71 @@ func (s *Server) apiRoutesListNotExported(
 
 	return response, nil
 }
+
+// Route changes of a neighbor between refreshes.
+// An optional since parameter (RFC3339) selects the
+// refresh to compare with. The changes are flagged as
+// incomplete if since is before the kept route history.
+func (s *Server) apiRoutesListChanges(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (response, error) {
+	rsID, err := validateSourceID(params.ByName("id"))
+	if err != nil {
+		return nil, err
+	}
+	if s.Config().SourceByID(rsID) == nil {
+		return nil, ErrSourceNotFound
+	}
+	neighborID := params.ByName("neighborId")
+
+	since := time.Time{}
+	if value := req.URL.Query().Get("since"); value != "" {
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, &ErrValidationFailed{
+				Param:  "since",
+				Reason: "since must be a RFC3339 timestamp",
+			}
+		}
+	}
+
+	neighbors, err := s.neighborsStore.GetNeighborsMapAt(ctx, rsID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := neighbors[neighborID]; !ok {
+		return nil, ErrNeighborNotFound
+	}
+
+	changes, err := s.routesStore.RouteChanges(ctx, rsID, neighborID, since)
+	if err != nil {
+		return nil, err
+	}
+	if !s.canSeeNeighborRoutes(ctx, rsID, neighborID) {
+		// Filtered routes are only visible for the member
+		changes = &api.RouteChanges{
+			From:      changes.From,
+			To:        changes.To,
+			Complete:  changes.Complete,
+			Added:     visibleRouteChanges(changes.Added),
+			Withdrawn: visibleRouteChanges(changes.Withdrawn),
+			Modified:  visibleRouteChanges(changes.Modified),
+		}
+	}
+	response := &api.RouteChangesResponse{
+		Response: api.Response{
+			Meta: &api.Meta{
+				CacheStatus: api.CacheStatus{
+					CachedAt: s.routesStore.CachedAt(ctx),
+				},
+				ResultFromCache: true,
+				TTL:             s.routesStore.CacheTTL(ctx),
+			},
+		},
+		RouteChanges: *changes,
+		NeighborID:   neighborID,
+	}
+	return response, nil
+}
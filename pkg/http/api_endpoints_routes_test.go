@@ -0,0 +1,47 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/store"
+)
+
+func TestRoutesListChanges(t *testing.T) {
+	s, _ := newTestServer(t)
+	sourceID := s.Config().Sources[0].ID
+	ctx := context.Background()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// The routes were not refreshed yet
+	res, err := s.apiRoutesListChanges(ctx, req, httprouter.Params{
+		{Key: "id", Value: sourceID},
+		{Key: "neighborId", Value: "ID163_AS31078"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := res.(*api.RouteChangesResponse)
+	if changes.Complete || len(changes.Added) != 0 {
+		t.Error("expected incomplete changes:", changes)
+	}
+
+	// Unknown neighbors are not found
+	_, err = s.apiRoutesListChanges(ctx, req, httprouter.Params{
+		{Key: "id", Value: sourceID},
+		{Key: "neighborId", Value: "ID42_AS2342"},
+	})
+	if err != ErrNeighborNotFound {
+		t.Error("expected neighbor not found, got:", err)
+	}
+
+	if _, status := apiErrorResponse(
+		sourceID, store.ErrRouteHistoryDisabled); status != http.StatusNotFound {
+		t.Error("unexpected status for disabled route changes:", status)
+	}
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"net/http"
+	"net/url"
 	"sort"
 	"time"
 
@@ -24,76 +25,12 @@ func (s *Server) apiLookupPrefixGlobal(
 		Routes:    s.routesStore.Status(ctx),
 	}
 
-	// Get prefix to query
-	q, err := validateQueryString(req, "q")
-	if err != nil {
-		return nil, err
-	}
-
-	q, filterTokens := QueryString(q).ExtractFilters()
-
-	// Get filters from query string
-	queryFilters, err := api.FiltersFromTokens(filterTokens)
-	if err != nil {
-		return nil, &ErrValidationFailed{
-			Param:  "q",
-			Reason: err.Error(),
-		}
-	}
-
-	// Get additional filter criteria
-	filtersApplied, err := api.FiltersFromQuery(req.URL.Query())
-	if err != nil {
-		return nil, err
-	}
-
-	// Merge query filters into applied filters
-	filtersApplied = filtersApplied.Combine(queryFilters)
-
-	// Select the query strategy:
-	//  Prefix -> fetch prefix
-	//       _ -> fetch neighbors and routes
-	//
-	lookupPrefix := decoders.MaybePrefix(q)
-	lookupEmptyQuery := false
-	if q == "" && (filtersApplied.HasGroup(api.SearchKeyCommunities) ||
-		filtersApplied.HasGroup(api.SearchKeyExtCommunities) ||
-		filtersApplied.HasGroup(api.SearchKeyLargeCommunities)) {
-		lookupPrefix = true
-		lookupEmptyQuery = true
-	}
-
 	// Measure response time
 	t0 := time.Now()
 
-	// Perform query
-	var routes api.LookupRoutes
-	if lookupPrefix {
-		if !lookupEmptyQuery {
-			q, err = validatePrefixQuery(q)
-			if err != nil {
-				return nil, err
-			}
-		}
-		routes, err = s.routesStore.LookupPrefix(ctx, q, filtersApplied)
-		if err != nil {
-			return nil, err
-		}
-
-	} else {
-		// Query by neighbors
-		q, err = validateNeighborsQuery(q)
-		if err != nil {
-			return nil, err
-		}
-		neighbors, err := s.neighborsStore.LookupNeighbors(ctx, q)
-		if err != nil {
-			return nil, err
-		}
-		routes, err = s.routesStore.LookupPrefixForNeighbors(ctx, neighbors, filtersApplied)
-		if err != nil {
-			return nil, err
-		}
+	routes, filtersApplied, err := s.lookupPrefixRoutes(ctx, req.URL.Query())
+	if err != nil {
+		return nil, err
 	}
 
 	// Split routes
@@ -104,7 +41,7 @@ func (s *Server) apiLookupPrefixGlobal(
 
 	// Check if we should calculate community filter
 	// cardinalities.
-	filterCutoff := s.cfg.Server.PrefixLookupCommunityFilterCutoff
+	filterCutoff := s.Config().Server.PrefixLookupCommunityFilterCutoff
 	canFilterCommunities := totalResults <= filterCutoff
 
 	// In case there is a source filter applied, we can filter communities
@@ -138,6 +75,7 @@ func (s *Server) apiLookupPrefixGlobal(
 
 		if canFilterCommunities {
 			filtersAvailable.UpdateCommunitiesFromLookupRoute(r)
+			filtersAvailable.UpdateAttributesFromRoute(r.Route)
 		}
 
 		hasIP4 = hasIP4 || r.AddrFamily == api.AddrFamilyIPv4
@@ -155,13 +93,13 @@ func (s *Server) apiLookupPrefixGlobal(
 
 	// Paginate results
 	pageImported := apiQueryMustInt(req, "page_imported", 0)
-	pageSizeImported := s.cfg.UI.Pagination.RoutesAcceptedPageSize
+	pageSizeImported := s.Config().UI.Pagination.RoutesAcceptedPageSize
 	routesImported, paginationImported := apiPaginateLookupRoutes(
 		imported, pageImported, pageSizeImported,
 	)
 
 	pageFiltered := apiQueryMustInt(req, "page_filtered", 0)
-	pageSizeFiltered := s.cfg.UI.Pagination.RoutesFilteredPageSize
+	pageSizeFiltered := s.Config().UI.Pagination.RoutesFilteredPageSize
 	routesFiltered, paginationFiltered := apiPaginateLookupRoutes(
 		filtered, pageFiltered, pageSizeFiltered,
 	)
@@ -205,6 +143,95 @@ func (s *Server) apiLookupPrefixGlobal(
 	return response, nil
 }
 
+// lookupPrefixRoutes queries the routes store for the
+// prefix or neighbors in the query and applies the
+// filters from the query string.
+func (s *Server) lookupPrefixRoutes(
+	ctx context.Context,
+	query url.Values,
+) (api.LookupRoutes, *api.SearchFilters, error) {
+	// Get prefix to query. When a search query is
+	// present, the prefix is optional.
+	var q string
+	var err error
+	hasSearchQuery := query.Get(api.SearchKeyQuery) != ""
+	if !hasSearchQuery || query.Has("q") {
+		q, err = validateQueryValue(query, "q")
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	q, filterTokens := QueryString(q).ExtractFilters()
+
+	// Get filters from query string
+	queryFilters, err := api.FiltersFromTokens(filterTokens)
+	if err != nil {
+		return nil, nil, &ErrValidationFailed{
+			Param:  "q",
+			Reason: err.Error(),
+		}
+	}
+
+	// Get additional filter criteria
+	filtersApplied, err := validateFilters(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Merge query filters into applied filters
+	filtersApplied = filtersApplied.Combine(queryFilters)
+
+	// Select the query strategy:
+	//  Prefix -> fetch prefix
+	//       _ -> fetch neighbors and routes
+	//
+	lookupPrefix := decoders.MaybePrefix(q)
+	lookupEmptyQuery := false
+	if q == "" && (filtersApplied.HasGroup(api.SearchKeyCommunities) ||
+		filtersApplied.HasGroup(api.SearchKeyExtCommunities) ||
+		filtersApplied.HasGroup(api.SearchKeyLargeCommunities) ||
+		filtersApplied.HasGroup(api.SearchKeyQuery)) {
+		lookupPrefix = true
+		lookupEmptyQuery = true
+	}
+
+	// Perform query
+	var routes api.LookupRoutes
+	if lookupPrefix {
+		if !lookupEmptyQuery {
+			q, err = validatePrefixQuery(q)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		routes, err = s.routesStore.LookupPrefix(ctx, q, filtersApplied)
+		if err != nil {
+			return nil, nil, err
+		}
+
+	} else {
+		// Query by neighbors
+		q, err = validateNeighborsQuery(q)
+		if err != nil {
+			return nil, nil, err
+		}
+		neighbors, err := s.neighborsStore.LookupNeighbors(ctx, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		routes, err = s.routesStore.LookupPrefixForNeighbors(ctx, neighbors, filtersApplied)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Hide the filtered routes of other members
+	routes = s.visibleLookupRoutes(ctx, routes)
+
+	return routes, filtersApplied, nil
+}
+
 func (s *Server) apiLookupNeighborsGlobal(
 	ctx context.Context,
 	req *http.Request,
@@ -221,6 +248,7 @@ func (s *Server) apiLookupNeighborsGlobal(
 		return nil, err
 	}
 
+	neighbors = s.visibleNeighbors(ctx, "", neighbors)
 	sort.Sort(neighbors)
 
 	// Make response
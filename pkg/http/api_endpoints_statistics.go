@@ -0,0 +1,48 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Limits for the number of top values in the statistics
+const (
+	StatisticsDefaultTop = 10
+	StatisticsMaxTop     = 1000
+)
+
+// Handle statistics: The distribution of route attributes
+// per route server and over all route servers.
+func (s *Server) apiStatisticsShow(
+	ctx context.Context,
+	req *http.Request,
+	_params httprouter.Params,
+) (response, error) {
+	top := apiQueryMustInt(req, "top", StatisticsDefaultTop)
+	if top < 0 || top > StatisticsMaxTop {
+		top = StatisticsDefaultTop
+	}
+
+	global, routeServers := s.routesStore.Statistics(ctx, top)
+	response := &api.StatisticsResponse{
+		Response: api.Response{
+			Meta: &api.Meta{
+				CacheStatus: api.CacheStatus{
+					CachedAt: s.routesStore.CachedAt(ctx),
+				},
+				StoreStatus: &api.StoreStatusMeta{
+					Routes: s.routesStore.Status(ctx),
+				},
+				ResultFromCache: true,
+				TTL:             s.routesStore.CacheTTL(ctx),
+			},
+		},
+		Global:       global,
+		RouteServers: routeServers,
+	}
+	return response, nil
+}
@@ -6,11 +6,14 @@ package http
 // to internal IP addresses.
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/store"
 )
 
 // ErrResourceNotFoundError is a 404 error
@@ -30,9 +33,33 @@ func (err ErrTimeout) Error() string {
 	return string(err)
 }
 
+// ErrRateLimited is returned when the client exceeded
+// the request rate or too many lookups are processed.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (err *ErrRateLimited) Error() string {
+	return "too many requests, retry after " + err.RetryAfter.String()
+}
+
 // Variables
 var (
-	ErrSourceNotFound = &ErrResourceNotFoundError{}
+	ErrSourceNotFound   = &ErrResourceNotFoundError{}
+	ErrNeighborNotFound = &ErrResourceNotFoundError{}
+
+	// ErrPrefixLookupDisabled is returned for lookups
+	// when the prefix lookup is not enabled.
+	ErrPrefixLookupDisabled = errors.New("prefix lookup is disabled")
+
+	// ErrUnauthorized is returned for requests with
+	// invalid credentials.
+	ErrUnauthorized = errors.New("invalid credentials")
+
+	// ErrForbidden is returned when the client is not
+	// allowed to use an administrative endpoint.
+	ErrForbidden = errors.New("access denied")
 )
 
 // Error tags
@@ -42,6 +69,9 @@ const (
 	TagConnectionTimeout = "CONNECTION_TIMEOUT"
 	TagResourceNotFound  = "NOT_FOUND"
 	TagValidationError   = "VALIDATION_ERROR"
+	TagRateLimited       = "RATE_LIMITED"
+	TagUnauthorized      = "UNAUTHORIZED"
+	TagForbidden         = "FORBIDDEN"
 )
 
 // Error codes
@@ -50,7 +80,10 @@ const (
 	CodeConnectionRefused = 100
 	CodeConnectionTimeout = 101
 	CodeValidationError   = 400
+	CodeUnauthorized      = 401
+	CodeForbidden         = 403
 	CodeResourceNotFound  = 404
+	CodeRateLimited       = 429
 )
 
 // Error status codes
@@ -59,6 +92,9 @@ const (
 	StatusResourceNotFound = http.StatusNotFound
 	StatusValidationError  = http.StatusBadRequest
 	TimeoutError           = http.StatusGatewayTimeout
+	StatusRateLimited      = http.StatusTooManyRequests
+	StatusUnauthorized     = http.StatusUnauthorized
+	StatusForbidden        = http.StatusForbidden
 )
 
 // Handle an error and create a error API response
@@ -76,6 +112,18 @@ func apiErrorResponse(
 		tag = TagValidationError
 		code = CodeValidationError
 		status = StatusValidationError
+	} else if err == ErrUnauthorized {
+		tag = TagUnauthorized
+		code = CodeUnauthorized
+		status = StatusUnauthorized
+	} else if err == ErrForbidden {
+		tag = TagForbidden
+		code = CodeForbidden
+		status = StatusForbidden
+	} else if err == store.ErrRouteHistoryDisabled {
+		tag = TagResourceNotFound
+		code = CodeResourceNotFound
+		status = StatusResourceNotFound
 	} else {
 
 		switch e := err.(type) {
@@ -97,6 +145,10 @@ func apiErrorResponse(
 				code = CodeConnectionTimeout
 				message = "Connection timed out when connecting to the backend API"
 			}
+		case *ErrRateLimited:
+			tag = TagRateLimited
+			code = CodeRateLimited
+			status = StatusRateLimited
 		case *ErrValidationFailed:
 			tag = TagValidationError
 			code = CodeValidationError
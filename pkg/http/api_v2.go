@@ -0,0 +1,364 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// Alice LG Rest API v2
+//
+// The v2 API provides the routes with cursor
+// pagination and a configurable sort order. Errors
+// are wrapped in an api.ErrorEnvelope.
+//
+// Endpoints:
+//
+//   Routes (cursor=<cursor>&limit=<n>&sort=[-]<key>)
+//     Received     /api/v2/routeservers/:id/neighbors/:neighborId/routes/received
+//     Filtered     /api/v2/routeservers/:id/neighbors/:neighborId/routes/filtered
+//     NotExported  /api/v2/routeservers/:id/neighbors/:neighborId/routes/not-exported
+//
+//   Querying (state=imported|filtered)
+//     LookupPrefix /api/v2/lookup/prefix?q=<prefix>
+
+// Wrap a v2 handler: Errors are encoded
+// as error envelope.
+func endpointV2(wrapped apiEndpoint) httprouter.Handle {
+	return serveEndpoint(wrapped, nil, apiWriteErrorV2)
+}
+
+// Wrap a v2 handler and answer conditional requests
+// without invoking the handler.
+func cachedEndpointV2(
+	wrapped apiEndpoint,
+	validate cacheValidator,
+) httprouter.Handle {
+	return serveEndpoint(wrapped, validate, apiWriteErrorV2)
+}
+
+// apiErrorEnvelope creates the v2 error response
+func apiErrorEnvelope(rsID string, err error) (*api.ErrorEnvelope, int) {
+	result, status := apiErrorResponse(rsID, err)
+	details := &api.ErrorDetails{
+		Code:          strings.ToLower(result.Tag),
+		Status:        status,
+		Message:       result.Message,
+		RouteServerID: rsID,
+	}
+	var validationErr *ErrValidationFailed
+	if errors.As(err, &validationErr) {
+		details.Param = validationErr.Param
+	}
+	return &api.ErrorEnvelope{Error: details}, status
+}
+
+// apiWriteErrorV2 responds with an error envelope
+func apiWriteErrorV2(
+	res http.ResponseWriter,
+	params httprouter.Params,
+	err error,
+) {
+	rsID := params.ByName("id")
+	if rsID != "" {
+		if _, paramErr := validateSourceID(rsID); paramErr != nil {
+			rsID = ""
+		}
+	}
+	result, status := apiErrorEnvelope(rsID, err)
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	payload, _ := json.Marshal(result)
+	res.Write(payload)
+}
+
+// Register the v2 api endpoints
+func (s *Server) apiRegisterEndpointsV2(router *httprouter.Router) {
+	router.GET("/api/v2/routeservers/:id/neighbors/:neighborId/routes/received",
+		s.rateLimited(config.RateLimitClassRoutes,
+			cachedEndpointV2(s.apiRoutesPageReceived, s.neighborRoutesCacheState("received"))))
+	router.GET("/api/v2/routeservers/:id/neighbors/:neighborId/routes/filtered",
+		s.rateLimited(config.RateLimitClassRoutes,
+			cachedEndpointV2(s.apiRoutesPageFiltered, s.neighborRoutesCacheState("filtered"))))
+	router.GET("/api/v2/routeservers/:id/neighbors/:neighborId/routes/not-exported",
+		s.rateLimited(config.RateLimitClassRoutes,
+			cachedEndpointV2(s.apiRoutesPageNotExported, s.neighborRoutesCacheState("not-exported"))))
+
+	if s.Config().Server.EnablePrefixLookup {
+		router.GET("/api/v2/lookup/prefix",
+			s.rateLimited(config.RateLimitClassLookup,
+				cachedEndpointV2(s.apiLookupPrefixPage, s.storeCacheState)))
+	}
+}
+
+// routesSelector selects the routes of a neighbor
+// from the source.
+type routesSelector func(
+	ctx context.Context,
+	s *Server,
+	rsID string,
+	neighborID string,
+) (*api.RoutesResponse, api.Routes, error)
+
+func selectRoutesReceived(
+	ctx context.Context, s *Server, rsID, neighborID string,
+) (*api.RoutesResponse, api.Routes, error) {
+	result, err := s.Config().SourceInstanceByID(rsID).RoutesReceived(ctx, neighborID)
+	if err != nil {
+		s.logSourceError("routes_received", rsID, neighborID, err)
+		return nil, nil, err
+	}
+	s.routesMeta.set("received", rsID, neighborID, result.Meta)
+	return result, result.Imported, nil
+}
+
+func selectRoutesFiltered(
+	ctx context.Context, s *Server, rsID, neighborID string,
+) (*api.RoutesResponse, api.Routes, error) {
+	result, err := s.Config().SourceInstanceByID(rsID).RoutesFiltered(ctx, neighborID)
+	if err != nil {
+		s.logSourceError("routes_filtered", rsID, neighborID, err)
+		return nil, nil, err
+	}
+	s.routesMeta.set("filtered", rsID, neighborID, result.Meta)
+	if !s.canSeeNeighborRoutes(ctx, rsID, neighborID) {
+		return result, api.Routes{}, nil // Only visible for the member
+	}
+	return result, result.Filtered, nil
+}
+
+func selectRoutesNotExported(
+	ctx context.Context, s *Server, rsID, neighborID string,
+) (*api.RoutesResponse, api.Routes, error) {
+	result, err := s.Config().SourceInstanceByID(rsID).RoutesNotExported(ctx, neighborID)
+	if err != nil {
+		s.logSourceError("routes_not_exported", rsID, neighborID, err)
+		return nil, nil, err
+	}
+	s.routesMeta.set("not-exported", rsID, neighborID, result.Meta)
+	if !s.canSeeNeighborRoutes(ctx, rsID, neighborID) {
+		return result, api.Routes{}, nil // Only visible for the member
+	}
+	return result, result.NotExported, nil
+}
+
+// Cursor paginated routes: Received routes
+func (s *Server) apiRoutesPageReceived(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (response, error) {
+	return s.apiRoutesPage(ctx, req, params, selectRoutesReceived)
+}
+
+// Cursor paginated routes: Filtered routes
+func (s *Server) apiRoutesPageFiltered(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (response, error) {
+	return s.apiRoutesPage(ctx, req, params, selectRoutesFiltered)
+}
+
+// Cursor paginated routes: Not exported routes
+func (s *Server) apiRoutesPageNotExported(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+) (response, error) {
+	return s.apiRoutesPage(ctx, req, params, selectRoutesNotExported)
+}
+
+// apiRoutesPage filters, sorts and paginates the
+// selected routes of a neighbor.
+func (s *Server) apiRoutesPage(
+	ctx context.Context,
+	req *http.Request,
+	params httprouter.Params,
+	selectRoutes routesSelector,
+) (response, error) {
+	t0 := time.Now()
+
+	rsID, err := validateSourceID(params.ByName("id"))
+	if err != nil {
+		return nil, err
+	}
+	neighborID := params.ByName("neighborId")
+	if s.Config().SourceInstanceByID(rsID) == nil {
+		return nil, ErrSourceNotFound
+	}
+
+	// Validate the query before fetching routes
+	query := req.URL.Query()
+	filtersApplied, err := validateRouteFilters(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result, allRoutes, err := selectRoutes(ctx, s, rsID, neighborID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The routes are identified by the time they were cached
+	generation := "0"
+	if result.Meta != nil {
+		generation = strconv.FormatInt(
+			result.Meta.CacheStatus.CachedAt.UnixNano(), 10)
+	}
+	page, err := parseCursorPage(query, generation)
+	if err != nil {
+		return nil, err
+	}
+
+	allRoutes = apiQueryFilterNextHopGateway(req, "q", allRoutes)
+	routes := api.Routes{}
+
+	var hasIP4, hasIP6 bool
+	filtersAvailable := api.NewSearchFilters()
+	for _, r := range allRoutes {
+		if !filtersApplied.MatchRoute(r) {
+			continue
+		}
+		routes = append(routes, r)
+		filtersAvailable.UpdateFromRoute(r)
+		hasIP4 = hasIP4 || r.AddrFamily == api.AddrFamilyIPv4
+		hasIP6 = hasIP6 || r.AddrFamily == api.AddrFamilyIPv6
+	}
+	filtersAvailable.SetFilterAddrFamilies(hasIP4, hasIP6)
+
+	// Remove applied filters from available
+	filtersApplied.MergeProperties(filtersAvailable)
+	filtersAvailable = filtersAvailable.Sub(filtersApplied)
+
+	routes, pagination := paginateCursor(page, routes, func(r *api.Route) routeKey {
+		return makeRouteKey(page.sort, r, routeIdentity(r))
+	})
+
+	return &api.RoutesPage{
+		Response: api.Response{
+			Meta: result.Meta,
+		},
+		TimedResponse: api.TimedResponse{
+			RequestDuration: DurationMs(time.Since(t0)),
+		},
+		FilteredResponse: api.FilteredResponse{
+			FiltersAvailable: filtersAvailable,
+			FiltersApplied:   filtersApplied,
+		},
+		Routes:     routes,
+		Pagination: pagination,
+	}, nil
+}
+
+// Cursor paginated global lookup. The results can
+// be limited to imported or filtered routes.
+func (s *Server) apiLookupPrefixPage(
+	ctx context.Context,
+	req *http.Request,
+	_params httprouter.Params,
+) (response, error) {
+	t0 := time.Now()
+
+	query := req.URL.Query()
+	state := query.Get("state")
+	if state != "" &&
+		state != api.RouteStateImported &&
+		state != api.RouteStateFiltered {
+		return nil, &ErrValidationFailed{
+			Param:  "state",
+			Reason: "state must be imported or filtered",
+		}
+	}
+
+	// Take the generation before the lookup: If the
+	// store is refreshed meanwhile, the next page will
+	// be flagged as changed.
+	generation := strconv.FormatUint(s.routesStore.Generation(ctx), 10)
+	page, err := parseCursorPage(query, generation)
+	if err != nil {
+		return nil, err
+	}
+
+	statusMeta := &api.StoreStatusMeta{
+		Neighbors: s.neighborsStore.Status(ctx),
+		Routes:    s.routesStore.Status(ctx),
+	}
+
+	allRoutes, filtersApplied, err := s.lookupPrefixRoutes(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make(api.LookupRoutes, 0, len(allRoutes))
+	for _, r := range allRoutes {
+		if state != "" && r.State != state {
+			continue
+		}
+		routes = append(routes, r)
+	}
+
+	// Community filter cardinalities are only calculated
+	// below the cutoff or with a source filter applied.
+	canFilterCommunities := len(routes) <= s.Config().Server.PrefixLookupCommunityFilterCutoff ||
+		filtersApplied.HasGroup(api.SearchKeySources)
+	filtersNotAvailable := []string{}
+	if !canFilterCommunities {
+		filtersNotAvailable = append(
+			filtersNotAvailable, api.SearchKeyCommunities)
+	}
+
+	filtersAvailable := api.NewSearchFilters()
+	var hasIP4, hasIP6 bool
+	for _, r := range routes {
+		filtersAvailable.UpdateSourcesFromLookupRoute(r)
+		filtersAvailable.UpdateASNSFromLookupRoute(r)
+		if canFilterCommunities {
+			filtersAvailable.UpdateCommunitiesFromLookupRoute(r)
+			filtersAvailable.UpdateAttributesFromRoute(r.Route)
+		}
+		hasIP4 = hasIP4 || r.AddrFamily == api.AddrFamilyIPv4
+		hasIP6 = hasIP6 || r.AddrFamily == api.AddrFamilyIPv6
+	}
+	filtersAvailable.SetFilterAddrFamilies(hasIP4, hasIP6)
+
+	// Remove applied filters from available
+	filtersApplied.MergeProperties(filtersAvailable)
+	filtersAvailable = filtersAvailable.Sub(filtersApplied)
+
+	routes, pagination := paginateCursor(page, routes, func(r *api.LookupRoute) routeKey {
+		return makeRouteKey(page.sort, r.Route, lookupRouteIdentity(r))
+	})
+
+	return &api.LookupRoutesPage{
+		Response: api.Response{
+			Meta: &api.Meta{
+				CacheStatus: api.CacheStatus{
+					CachedAt: s.routesStore.CachedAt(ctx),
+				},
+				StoreStatus:     statusMeta,
+				ResultFromCache: true,
+				TTL:             s.routesStore.CacheTTL(ctx),
+			},
+		},
+		TimedResponse: api.TimedResponse{
+			RequestDuration: DurationMs(time.Since(t0)),
+		},
+		FilteredResponse: api.FilteredResponse{
+			FiltersAvailable:    filtersAvailable,
+			FiltersNotAvailable: filtersNotAvailable,
+			FiltersApplied:      filtersApplied,
+		},
+		Routes:     routes,
+		Pagination: pagination,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func TestAPIv2LookupPrefix(t *testing.T) {
+	_, router := newTestServer(t)
+
+	req := httptest.NewRequest("GET",
+		"/api/v2/lookup/prefix?q=193.200&sort=-as_path_length&limit=10", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusOK {
+		t.Fatal("unexpected status:", res.Code, res.Body.String())
+	}
+
+	result := &api.LookupRoutesPage{}
+	if err := json.Unmarshal(res.Body.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Routes) != 1 {
+		t.Fatal("unexpected routes:", result.Routes)
+	}
+	if result.Routes[0].Network != "193.200.230.0/24" {
+		t.Error("unexpected route:", result.Routes[0])
+	}
+	if result.Pagination.Sort != "-as_path_length" ||
+		result.Pagination.Limit != 10 ||
+		result.Pagination.NextCursor != "" {
+		t.Error("unexpected pagination:", result.Pagination)
+	}
+
+	// Filter by state
+	req = httptest.NewRequest("GET",
+		"/api/v2/lookup/prefix?q=193.200&state=filtered", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	result = &api.LookupRoutesPage{}
+	if err := json.Unmarshal(res.Body.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Routes) != 0 {
+		t.Error("unexpected routes:", result.Routes)
+	}
+}
+
+func TestAPIv2ErrorEnvelope(t *testing.T) {
+	_, router := newTestServer(t)
+
+	req := httptest.NewRequest("GET",
+		"/api/v2/lookup/prefix?q=193.200&sort=color", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusBadRequest {
+		t.Fatal("unexpected status:", res.Code)
+	}
+	if res.Header().Get("Content-Type") != "application/json" {
+		t.Error("unexpected content type:", res.Header().Get("Content-Type"))
+	}
+
+	result := &api.ErrorEnvelope{}
+	if err := json.Unmarshal(res.Body.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Error == nil ||
+		result.Error.Code != "validation_error" ||
+		result.Error.Status != http.StatusBadRequest ||
+		result.Error.Param != "sort" {
+		t.Error("unexpected error:", result.Error)
+	}
+
+	// Unknown route server
+	req = httptest.NewRequest("GET",
+		"/api/v2/routeservers/unknown/neighbors/n1/routes/received", nil)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	if res.Code != http.StatusNotFound {
+		t.Fatal("unexpected status:", res.Code)
+	}
+	result = &api.ErrorEnvelope{}
+	if err := json.Unmarshal(res.Body.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Error.Code != "not_found" ||
+		result.Error.RouteServerID != "unknown" {
+		t.Error("unexpected error:", result.Error)
+	}
+}
@@ -1,10 +1,14 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
 	"net/http"
+	"net/url"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
 )
 
 // ErrValidationFailed indicates that a parameter validation
@@ -72,7 +76,11 @@ func validateSourceID(id string) (string, error) {
 
 // Helper: Validate query string
 func validateQueryString(req *http.Request, key string) (string, error) {
-	query := req.URL.Query()
+	return validateQueryValue(req.URL.Query(), key)
+}
+
+// Helper: Validate a single query value
+func validateQueryValue(query url.Values, key string) (string, error) {
 	values, ok := query[key]
 	if !ok {
 		return "", NewErrMissingParam(key)
@@ -114,3 +122,41 @@ func validateNeighborsQuery(value string) (string, error) {
 	}
 	return value, nil
 }
+
+// Helper: Validate the filters of the query string.
+// Invalid values of a filter are rejected as bad request.
+func validateFilters(query url.Values) (*api.SearchFilters, error) {
+	filters, err := api.FiltersFromQuery(query)
+	if err == nil {
+		return filters, nil
+	}
+	param := api.SearchKeyQuery
+	var filterErr *api.FilterError
+	if errors.As(err, &filterErr) {
+		param = filterErr.Key
+	}
+	return nil, &ErrValidationFailed{
+		Param:  param,
+		Reason: err.Error(),
+	}
+}
+
+// Helper: Validate the filters of a neighbor's routes.
+// The routes of a neighbor have no source or ASN, so
+// these terms can not be evaluated in a search query.
+func validateRouteFilters(query url.Values) (*api.SearchFilters, error) {
+	filters, err := validateFilters(query)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range filters.GetGroupByKey(api.SearchKeyQuery).Filters {
+		q, ok := f.Value.(*api.SearchQuery)
+		if ok && q.HasTerm(api.SearchKeySources, api.SearchKeyASNS) {
+			return nil, &ErrValidationFailed{
+				Param:  api.SearchKeyQuery,
+				Reason: "source and asn terms are only supported in the route lookup",
+			}
+		}
+	}
+	return filters, nil
+}
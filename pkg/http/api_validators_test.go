@@ -0,0 +1,64 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestValidateFilters(t *testing.T) {
+	tests := map[string]string{
+		"med=abc":          "med",
+		"prefix_length=>x": "prefix_length",
+		"otc=maybe":        "otc",
+		"query=asn:":       "query",
+	}
+	for qry, param := range tests {
+		values, _ := url.ParseQuery(qry)
+		_, err := validateFilters(values)
+		var validationErr *ErrValidationFailed
+		if !errors.As(err, &validationErr) {
+			t.Error(qry, "expected a validation error, got:", err)
+			continue
+		}
+		if validationErr.Param != param {
+			t.Error(qry, "unexpected param:", validationErr.Param)
+		}
+	}
+}
+
+func TestInvalidFilterBadRequest(t *testing.T) {
+	_, router := newTestServer(t)
+	for _, path := range []string{
+		"/api/v1/lookup/prefix?q=193.200&med=abc",
+		"/api/v1/lookup/prefix?q=193.200&otc=maybe",
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if res.Code != http.StatusBadRequest {
+			t.Error(path, "unexpected status:", res.Code)
+		}
+	}
+}
+
+func TestValidateRouteFilters(t *testing.T) {
+	for _, qry := range []string{
+		"query=NOT asn:64500",
+		"query=community:23:42 OR (source:rs1 AND med:0)",
+	} {
+		values, _ := url.ParseQuery(qry)
+		_, err := validateRouteFilters(values)
+		var validationErr *ErrValidationFailed
+		if !errors.As(err, &validationErr) {
+			t.Error(qry, "expected a validation error, got:", err)
+		}
+	}
+
+	values, _ := url.ParseQuery("query=NOT community:23:42")
+	if _, err := validateRouteFilters(values); err != nil {
+		t.Error(err)
+	}
+}
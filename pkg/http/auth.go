@@ -0,0 +1,226 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/auth"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// Member access
+//
+// Requests may carry a bearer token or an API key. The
+// identity of the member is added to the request context.
+//
+// For sources with members visibility, filtered and not
+// exported routes and the neighbor details are only shown
+// to members authorized for the ASN of the neighbor.
+
+// authenticated identifies the member and adds the
+// identity to the request context. Requests with
+// invalid credentials are rejected.
+func (s *Server) authenticated(next http.Handler) http.Handler {
+	if s.authenticator == nil {
+		return next
+	}
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Add("Vary", "Authorization")
+		res.Header().Add("Vary", s.Config().Auth.APIKeyHeader)
+
+		id, err := s.authenticator.Authenticate(req)
+		if err != nil {
+			writeUnauthorized(res, req, err)
+			return
+		}
+		if id != nil {
+			req = req.WithContext(auth.ContextWithIdentity(req.Context(), id))
+		}
+		next.ServeHTTP(res, req)
+	})
+}
+
+// writeUnauthorized responds with an unauthorized error
+func writeUnauthorized(
+	res http.ResponseWriter,
+	req *http.Request,
+	err error,
+) {
+	log.Println("rejected credentials:", err)
+	res.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+
+	// The request is not routed yet, there are no params.
+	if strings.HasPrefix(req.URL.Path, "/api/v2/") {
+		apiWriteErrorV2(res, nil, ErrUnauthorized)
+	} else {
+		apiWriteError(res, nil, ErrUnauthorized)
+	}
+}
+
+// canSeeNeighbor checks if the routes and details of a
+// neighbor of the source are visible for the request.
+func (s *Server) canSeeNeighbor(
+	ctx context.Context,
+	sourceID string,
+	asn int,
+) bool {
+	src := s.Config().SourceByID(sourceID)
+	if src == nil || src.Visibility != config.VisibilityMembers {
+		return true
+	}
+	return auth.IdentityFromContext(ctx).CanAccessASN(asn)
+}
+
+// canSeeNeighborRoutes looks up the neighbor and checks
+// if its routes are visible. Unknown neighbors of sources
+// with members visibility are hidden.
+func (s *Server) canSeeNeighborRoutes(
+	ctx context.Context,
+	sourceID string,
+	neighborID string,
+) bool {
+	src := s.Config().SourceByID(sourceID)
+	if src == nil || src.Visibility != config.VisibilityMembers {
+		return true
+	}
+	neighbors, err := s.neighborsStore.GetNeighborsMapAt(ctx, sourceID)
+	if err != nil {
+		return false
+	}
+	neighbor, ok := neighbors[neighborID]
+	if !ok {
+		return false
+	}
+	return auth.IdentityFromContext(ctx).CanAccessASN(neighbor.ASN)
+}
+
+// redactNeighbor copies the neighbor without the
+// details only visible to the member.
+func redactNeighbor(n *api.Neighbor) *api.Neighbor {
+	redacted := *n
+	redacted.RoutesFiltered = 0
+	redacted.LastError = ""
+	redacted.RoutesChannels = nil
+	redacted.RouteChanges = nil
+	redacted.Details = nil
+	return &redacted
+}
+
+// visibleNeighbors redacts the neighbors not visible for
+// the request. When the source id is empty, the route
+// server of the neighbor is used.
+func (s *Server) visibleNeighbors(
+	ctx context.Context,
+	sourceID string,
+	neighbors api.Neighbors,
+) api.Neighbors {
+	visible := make(api.Neighbors, 0, len(neighbors))
+	for _, n := range neighbors {
+		rsID := sourceID
+		if rsID == "" {
+			rsID = n.RouteServerID
+		}
+		if !s.canSeeNeighbor(ctx, rsID, n.ASN) {
+			n = redactNeighbor(n)
+		}
+		visible = append(visible, n)
+	}
+	return visible
+}
+
+// visibleLookupRoutes removes the filtered routes of
+// neighbors not visible for the request and redacts
+// the neighbor of the remaining routes.
+func (s *Server) visibleLookupRoutes(
+	ctx context.Context,
+	routes api.LookupRoutes,
+) api.LookupRoutes {
+	visible := make(api.LookupRoutes, 0, len(routes))
+	for _, r := range routes {
+		if r = s.visibleLookupRoute(ctx, r); r != nil {
+			visible = append(visible, r)
+		}
+	}
+	return visible
+}
+
+// visibleLookupRoute returns the route with the neighbor
+// redacted if it is not visible for the request, or nil
+// if the route was filtered.
+func (s *Server) visibleLookupRoute(
+	ctx context.Context,
+	r *api.LookupRoute,
+) *api.LookupRoute {
+	if r.Neighbor == nil || r.RouteServer == nil || r.RouteServer.ID == nil {
+		return r
+	}
+	if s.canSeeNeighbor(ctx, *r.RouteServer.ID, r.Neighbor.ASN) {
+		return r
+	}
+	if r.State == api.RouteStateFiltered {
+		return nil
+	}
+	redacted := *r
+	redacted.Neighbor = redactNeighbor(r.Neighbor)
+	return &redacted
+}
+
+// visibleRouteChanges removes the changes of routes
+// which were filtered or not exported before or after
+// the change. The other changes remain visible.
+func visibleRouteChanges(changes []*api.RouteChange) []*api.RouteChange {
+	hidden := func(state string) bool {
+		return state == api.RouteStateFiltered ||
+			state == api.RouteStateNotExported
+	}
+	visible := make([]*api.RouteChange, 0, len(changes))
+	for _, c := range changes {
+		if hidden(c.StateBefore) || hidden(c.StateAfter) {
+			continue
+		}
+		visible = append(visible, c)
+	}
+	return visible
+}
+
+// visibleAlerts removes the alerts of neighbors not
+// visible for the request. The alerts include the
+// filtered routes and matched prefixes of the neighbor.
+func (s *Server) visibleAlerts(
+	ctx context.Context,
+	alerts []*api.Alert,
+) []*api.Alert {
+	visible := make([]*api.Alert, 0, len(alerts))
+	for _, a := range alerts {
+		if s.canSeeNeighbor(ctx, a.RouteServerID, a.ASN) {
+			visible = append(visible, a)
+		}
+	}
+	return visible
+}
+
+// visibleNeighborEvent redacts the filtered routes of
+// a neighbor not visible for the request. Nil is returned
+// if only the number of filtered routes changed.
+func (s *Server) visibleNeighborEvent(
+	ctx context.Context,
+	e *api.NeighborEvent,
+) *api.NeighborEvent {
+	if s.canSeeNeighbor(ctx, e.RouteServerID, e.ASN) {
+		return e
+	}
+	redacted := *e
+	redacted.Routes.Filtered = 0
+	if e.RoutesBefore != nil {
+		before := *e.RoutesBefore
+		before.Filtered = 0
+		if before == redacted.Routes {
+			return nil
+		}
+		redacted.RoutesBefore = &before
+	}
+	return &redacted
+}
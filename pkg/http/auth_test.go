@@ -0,0 +1,267 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/alerts"
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/auth"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+func TestAuthenticatedInvalidCredentials(t *testing.T) {
+	s, router := newTestServer(t)
+	handler := s.authenticated(router)
+
+	req := httptest.NewRequest("GET", "/api/v1/config", nil)
+	req.Header.Set("X-API-Key", "guessed")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatal("expected 401, got:", rec.Code)
+	}
+	if rec.Header().Get("WWW-Authenticate") == "" {
+		t.Error("expected WWW-Authenticate header")
+	}
+	errResponse := api.ErrorResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+		t.Fatal(err)
+	}
+	if errResponse.Tag != TagUnauthorized {
+		t.Error("unexpected tag:", errResponse.Tag)
+	}
+
+	// Valid API key
+	req.Header.Set("X-API-Key", "s3cr3t")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Error("expected 200, got:", rec.Code)
+	}
+}
+
+func TestVisibleLookupRoutes(t *testing.T) {
+	s, _ := newTestServer(t)
+	src := s.Config().Sources[0]
+	src.Visibility = config.VisibilityMembers
+
+	neighbor := &api.Neighbor{
+		ID:             "ID163_AS31078",
+		ASN:            31078,
+		RoutesFiltered: 23,
+		Details:        map[string]any{"description": "Netnod"},
+	}
+	routes := api.LookupRoutes{
+		{
+			Route:       &api.Route{Network: "193.200.230.0/24"},
+			State:       api.RouteStateImported,
+			Neighbor:    neighbor,
+			RouteServer: &api.LookupRouteServer{ID: &src.ID},
+		},
+		{
+			Route:       &api.Route{Network: "193.200.231.0/24"},
+			State:       api.RouteStateFiltered,
+			Neighbor:    neighbor,
+			RouteServer: &api.LookupRouteServer{ID: &src.ID},
+		},
+	}
+
+	// Anonymous
+	visible := s.visibleLookupRoutes(context.Background(), routes)
+	if len(visible) != 1 || visible[0].State != api.RouteStateImported {
+		t.Fatal("expected only the imported route:", visible)
+	}
+	if visible[0].Neighbor.Details != nil || visible[0].Neighbor.RoutesFiltered != 0 {
+		t.Error("expected a redacted neighbor:", visible[0].Neighbor)
+	}
+	if neighbor.Details == nil {
+		t.Error("the original neighbor must not be modified")
+	}
+
+	// Member
+	ctx := auth.ContextWithIdentity(context.Background(), &auth.Identity{
+		ASNs: []int{31078},
+	})
+	visible = s.visibleLookupRoutes(ctx, routes)
+	if len(visible) != 2 || visible[1].Neighbor != neighbor {
+		t.Error("expected all routes for the member:", visible)
+	}
+
+	// Other member
+	ctx = auth.ContextWithIdentity(context.Background(), &auth.Identity{
+		ASNs: []int{2342},
+	})
+	if len(s.visibleLookupRoutes(ctx, routes)) != 1 {
+		t.Error("expected only the imported route for other members")
+	}
+
+	// Public sources
+	src.Visibility = config.VisibilityPublic
+	if len(s.visibleLookupRoutes(context.Background(), routes)) != 2 {
+		t.Error("expected all routes of public sources")
+	}
+}
+
+func TestVisibleNeighbors(t *testing.T) {
+	s, router := newTestServer(t)
+	s.Config().Sources[0].Visibility = config.VisibilityMembers
+	handler := s.authenticated(router)
+
+	neighborsFor := func(apiKey string) (api.Neighbors, http.Header) {
+		req := httptest.NewRequest("GET", "/api/v1/lookup/neighbors?asn=31078", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatal("unexpected status:", rec.Code)
+		}
+		res := api.NeighborsResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Neighbors, rec.Header()
+	}
+
+	neighbors, header := neighborsFor("")
+	if len(neighbors) != 1 || neighbors[0].Details != nil {
+		t.Error("expected a redacted neighbor:", neighbors)
+	}
+	if strings.HasPrefix(header.Get("Cache-Control"), "private") {
+		t.Error("anonymous responses may be cached:", header.Get("Cache-Control"))
+	}
+	neighbors, header = neighborsFor("s3cr3t")
+	if len(neighbors) != 1 || neighbors[0].Description != "Netnod" {
+		t.Error("unexpected neighbors:", neighbors)
+	}
+	if !strings.HasPrefix(header.Get("Cache-Control"), "private") {
+		t.Error("member responses must be private:", header.Get("Cache-Control"))
+	}
+	if !slices.Contains(header.Values("Vary"), "Authorization") {
+		t.Error("expected Vary: Authorization:", header.Values("Vary"))
+	}
+}
+
+func TestVisibleAlerts(t *testing.T) {
+	s, router := newTestServer(t)
+	src := s.Config().Sources[0]
+	src.Visibility = config.VisibilityMembers
+	handler := s.authenticated(router)
+
+	s.alerts = alerts.NewEngine(&config.Config{
+		Alerts: config.AlertsConfig{
+			HistorySize: 10,
+			Rules: []*config.AlertRuleConfig{{
+				ID:            "session_down",
+				NeighborState: "down",
+			}},
+		},
+	})
+	s.alerts.EvaluateNeighbors(context.Background(), src.ID, api.Neighbors{
+		{ID: "ID163_AS31078", ASN: 31078, State: "down", RoutesFiltered: 23},
+		{ID: "ID42_AS2342", ASN: 2342, State: "down"},
+	})
+
+	alertsFor := func(apiKey string) []*api.Alert {
+		req := httptest.NewRequest("GET", "/api/v1/alerts", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatal("unexpected status:", rec.Code)
+		}
+		res := api.AlertsResponse{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Alerts
+	}
+
+	if list := alertsFor(""); len(list) != 0 {
+		t.Error("expected no alerts for anonymous requests:", list)
+	}
+	list := alertsFor("s3cr3t")
+	if len(list) != 1 || list[0].ASN != 31078 {
+		t.Error("expected only the alert of the member:", list)
+	}
+
+	// Public sources
+	src.Visibility = config.VisibilityPublic
+	if list := alertsFor(""); len(list) != 2 {
+		t.Error("expected all alerts of public sources:", list)
+	}
+}
+
+func TestVisibleNeighborEvent(t *testing.T) {
+	s, _ := newTestServer(t)
+	src := s.Config().Sources[0]
+	src.Visibility = config.VisibilityMembers
+
+	event := &api.NeighborEvent{
+		Type:          api.NeighborEventRoutesChanged,
+		RouteServerID: src.ID,
+		ASN:           31078,
+		Routes:        api.NeighborRoutesCount{Received: 10, Filtered: 3, Accepted: 7},
+		RoutesBefore:  &api.NeighborRoutesCount{Received: 8, Filtered: 1, Accepted: 7},
+	}
+
+	// Anonymous
+	ctx := context.Background()
+	visible := s.visibleNeighborEvent(ctx, event)
+	if visible == nil || visible.Routes.Filtered != 0 ||
+		visible.RoutesBefore.Filtered != 0 {
+		t.Fatal("expected a redacted event:", visible)
+	}
+	if visible.Routes.Received != 10 || visible.RoutesBefore.Received != 8 {
+		t.Error("unexpected routes:", visible.Routes, visible.RoutesBefore)
+	}
+	if event.Routes.Filtered != 3 || event.RoutesBefore.Filtered != 1 {
+		t.Error("the original event must not be modified")
+	}
+
+	// Only the filtered routes changed
+	hidden := *event
+	hidden.RoutesBefore = &api.NeighborRoutesCount{Received: 10, Filtered: 1, Accepted: 7}
+	if s.visibleNeighborEvent(ctx, &hidden) != nil {
+		t.Error("expected the event to be hidden")
+	}
+
+	// Member
+	ctx = auth.ContextWithIdentity(ctx, &auth.Identity{ASNs: []int{31078}})
+	if s.visibleNeighborEvent(ctx, event) != event {
+		t.Error("expected the event for the member")
+	}
+}
+
+func TestVisibleRouteChanges(t *testing.T) {
+	changes := []*api.RouteChange{
+		{Network: "10.0.0.0/24", StateAfter: api.RouteStateImported},
+		{Network: "10.0.1.0/24", StateAfter: api.RouteStateFiltered},
+		{
+			Network:     "10.0.2.0/24",
+			StateBefore: api.RouteStateFiltered,
+			StateAfter:  api.RouteStateImported,
+		},
+		{
+			Network:     "10.0.3.0/24",
+			StateBefore: api.RouteStateImported,
+			StateAfter:  api.RouteStateImported,
+		},
+		{Network: "10.0.4.0/24", StateBefore: api.RouteStateNotExported},
+	}
+	visible := visibleRouteChanges(changes)
+	if len(visible) != 2 ||
+		visible[0].Network != "10.0.0.0/24" ||
+		visible[1].Network != "10.0.3.0/24" {
+		t.Error("unexpected changes:", visible)
+	}
+}
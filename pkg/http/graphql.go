@@ -0,0 +1,878 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// GraphQL API
+//
+// The GraphQL API provides route servers, neighbors,
+// their routes and filter reasons, lookups and the
+// store status in a single query.
+//
+// Resolvers use the neighbors and routes stores and
+// the source instances, just like the REST endpoints.
+// The estimated cost of a query is limited, see
+// graphql_cost.go.
+
+// graphQLNeighbor is a neighbor on a route server
+type graphQLNeighbor struct {
+	*api.Neighbor
+	sourceID string
+}
+
+// graphQLReason is a community with a filter or
+// no-export reason.
+type graphQLReason struct {
+	Community string
+	Reason    string
+}
+
+// graphQLAPI holds the schema and resolvers
+type graphQLAPI struct {
+	server  *Server
+	schema  graphql.Schema
+	maxCost int
+}
+
+// newGraphQLAPI creates the schema with resolvers
+// for the server.
+func newGraphQLAPI(s *Server) (*graphQLAPI, error) {
+	g := &graphQLAPI{
+		server:  s,
+		maxCost: s.Config().Server.GraphQLMaxCost,
+	}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: g.queryType(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	g.schema = schema
+	return g, nil
+}
+
+// Arguments
+var (
+	graphQLArgFirst = &graphql.ArgumentConfig{
+		Type: graphql.Int,
+		Description: fmt.Sprintf(
+			"Return only the first n results (default %d)",
+			GraphQLDefaultListSize),
+	}
+	graphQLArgID = &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(graphql.ID),
+	}
+)
+
+// graphQLFirst limits a list of results to the first
+// argument. Without the argument, the list is limited to
+// the default size used for estimating the query cost.
+func graphQLFirst[T any](p graphql.ResolveParams, results []T) []T {
+	first, ok := p.Args["first"].(int)
+	if !ok {
+		first = GraphQLDefaultListSize
+	}
+	first = max(first, 0)
+	if first < len(results) {
+		return results[:first]
+	}
+	return results
+}
+
+// Helper: Make a list of non null items
+func graphQLList(t graphql.Type) *graphql.NonNull {
+	return graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(t)))
+}
+
+// Helper: Convert communities to strings
+func graphQLCommunities(communities api.Communities) []string {
+	result := make([]string, 0, len(communities))
+	for _, c := range communities {
+		result = append(result, c.String())
+	}
+	return result
+}
+
+// Helper: Convert extended communities to strings
+func graphQLExtCommunities(communities api.ExtCommunities) []string {
+	result := make([]string, 0, len(communities))
+	for _, c := range communities {
+		parts := make([]string, 0, len(c))
+		for _, v := range c {
+			parts = append(parts, fmt.Sprint(v))
+		}
+		result = append(result, strings.Join(parts, ":"))
+	}
+	return result
+}
+
+// graphQLRoute gets the route from a route or
+// a lookup route.
+func graphQLRoute(source any) *api.Route {
+	switch r := source.(type) {
+	case *api.Route:
+		return r
+	case *api.LookupRoute:
+		return r.Route
+	}
+	return nil
+}
+
+// routeReasons looks up the reasons of the communities
+// of a route.
+func routeReasons(
+	r *api.Route,
+	reasons api.BGPCommunityMap,
+) []*graphQLReason {
+	result := []*graphQLReason{}
+	if r.BGP == nil || reasons == nil {
+		return result
+	}
+	communities := make(api.Communities, 0,
+		len(r.BGP.Communities)+len(r.BGP.LargeCommunities))
+	communities = append(communities, r.BGP.Communities...)
+	communities = append(communities, r.BGP.LargeCommunities...)
+	for _, c := range communities {
+		reason, err := reasons.Lookup(c.String())
+		if err != nil {
+			continue
+		}
+		result = append(result, &graphQLReason{
+			Community: c.String(),
+			Reason:    reason,
+		})
+	}
+	return result
+}
+
+func (g *graphQLAPI) reasonType() *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:        "CommunityReason",
+		Description: "A community with a filter or no-export reason",
+		Fields: graphql.Fields{
+			"community": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*graphQLReason).Community, nil
+				},
+			},
+			"reason": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*graphQLReason).Reason, nil
+				},
+			},
+		},
+	})
+}
+
+func (g *graphQLAPI) bgpType() *graphql.Object {
+	bgpField := func(
+		t graphql.Output,
+		fn func(*api.BGPInfo) any,
+	) *graphql.Field {
+		return &graphql.Field{
+			Type: t,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return fn(p.Source.(*api.BGPInfo)), nil
+			},
+		}
+	}
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "BGPInfo",
+		Fields: graphql.Fields{
+			"origin": bgpField(graphql.String, func(b *api.BGPInfo) any {
+				return b.Origin
+			}),
+			"asPath": bgpField(graphQLList(graphql.Int), func(b *api.BGPInfo) any {
+				if b.AsPath == nil {
+					return []int{}
+				}
+				return b.AsPath
+			}),
+			"nextHop": bgpField(graphql.String, func(b *api.BGPInfo) any {
+				return b.NextHop
+			}),
+			"localPref": bgpField(graphql.Int, func(b *api.BGPInfo) any {
+				return b.LocalPref
+			}),
+			"med": bgpField(graphql.Int, func(b *api.BGPInfo) any {
+				return b.Med
+			}),
+			"otc": bgpField(graphql.Int, func(b *api.BGPInfo) any {
+				return b.OTC
+			}),
+			"communities": bgpField(graphQLList(graphql.String), func(b *api.BGPInfo) any {
+				return graphQLCommunities(b.Communities)
+			}),
+			"largeCommunities": bgpField(graphQLList(graphql.String), func(b *api.BGPInfo) any {
+				return graphQLCommunities(b.LargeCommunities)
+			}),
+			"extCommunities": bgpField(graphQLList(graphql.String), func(b *api.BGPInfo) any {
+				return graphQLExtCommunities(b.ExtCommunities)
+			}),
+		},
+	})
+}
+
+// routeFields are the fields of routes and lookup routes
+func (g *graphQLAPI) routeFields(
+	bgpType *graphql.Object,
+	reasonType *graphql.Object,
+) graphql.Fields {
+	routeField := func(
+		t graphql.Output,
+		fn func(*api.Route) any,
+	) *graphql.Field {
+		return &graphql.Field{
+			Type: t,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return fn(graphQLRoute(p.Source)), nil
+			},
+		}
+	}
+	ui := g.server.Config().UI
+	return graphql.Fields{
+		"network": routeField(graphql.NewNonNull(graphql.String), func(r *api.Route) any {
+			return r.Network
+		}),
+		"gateway": routeField(graphql.String, func(r *api.Route) any {
+			return r.Gateway
+		}),
+		"interface": routeField(graphql.String, func(r *api.Route) any {
+			return r.Interface
+		}),
+		"metric": routeField(graphql.Int, func(r *api.Route) any {
+			return r.Metric
+		}),
+		"age": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "Age of the route in seconds",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return graphQLRoute(p.Source).Age.Seconds(), nil
+			},
+		},
+		"primary": routeField(graphql.Boolean, func(r *api.Route) any {
+			return r.Primary
+		}),
+		"bgp": routeField(bgpType, func(r *api.Route) any {
+			return r.BGP
+		}),
+		"filterReasons": routeField(graphQLList(reasonType), func(r *api.Route) any {
+			return routeReasons(r, ui.RoutesRejections.Reasons)
+		}),
+		"noexportReasons": routeField(graphQLList(reasonType), func(r *api.Route) any {
+			return routeReasons(r, ui.RoutesNoexports.Reasons)
+		}),
+	}
+}
+
+// routesField resolves the routes of a neighbor
+func (g *graphQLAPI) routesField(
+	routeType *graphql.Object,
+	description string,
+	fetch func(
+		ctx context.Context,
+		n *graphQLNeighbor,
+	) (api.Routes, error),
+) *graphql.Field {
+	return &graphql.Field{
+		Type:        graphQLList(routeType),
+		Description: description,
+		Args: graphql.FieldConfigArgument{
+			"first": graphQLArgFirst,
+		},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			routes, err := fetch(p.Context, p.Source.(*graphQLNeighbor))
+			if err != nil {
+				return nil, err
+			}
+			return graphQLFirst(p, routes), nil
+		},
+	}
+}
+
+// sourceRoutes fetches the routes of the neighbor
+// from the source instance.
+func (g *graphQLAPI) sourceRoutes(
+	ctx context.Context,
+	n *graphQLNeighbor,
+	method string,
+) (*api.RoutesResponse, error) {
+	source := g.server.Config().SourceInstanceByID(n.sourceID)
+	if source == nil {
+		return nil, ErrSourceNotFound
+	}
+	var (
+		res *api.RoutesResponse
+		err error
+	)
+	switch method {
+	case "routes_received":
+		res, err = source.RoutesReceived(ctx, n.ID)
+	case "routes_filtered":
+		res, err = source.RoutesFiltered(ctx, n.ID)
+	case "routes_not_exported":
+		res, err = source.RoutesNotExported(ctx, n.ID)
+	}
+	if err != nil {
+		g.server.logSourceError(method, n.sourceID, n.ID, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (g *graphQLAPI) neighborType(routeType *graphql.Object) *graphql.Object {
+	neighborField := func(
+		t graphql.Output,
+		fn func(*graphQLNeighbor) any,
+	) *graphql.Field {
+		return &graphql.Field{
+			Type: t,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return fn(p.Source.(*graphQLNeighbor)), nil
+			},
+		}
+	}
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Neighbor",
+		Fields: graphql.Fields{
+			"id": neighborField(graphql.NewNonNull(graphql.ID), func(n *graphQLNeighbor) any {
+				return n.ID
+			}),
+			"routeServerId": neighborField(graphql.NewNonNull(graphql.ID), func(n *graphQLNeighbor) any {
+				return n.sourceID
+			}),
+			"address": neighborField(graphql.String, func(n *graphQLNeighbor) any {
+				return n.Address
+			}),
+			"asn": neighborField(graphql.Int, func(n *graphQLNeighbor) any {
+				return n.ASN
+			}),
+			"state": neighborField(graphql.String, func(n *graphQLNeighbor) any {
+				return n.State
+			}),
+			"description": neighborField(graphql.String, func(n *graphQLNeighbor) any {
+				return n.Description
+			}),
+			"uptime": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "Uptime of the session in seconds",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return p.Source.(*graphQLNeighbor).Uptime.Seconds(), nil
+				},
+			},
+			"lastError": neighborField(graphql.String, func(n *graphQLNeighbor) any {
+				return n.LastError
+			}),
+			"routesReceived": neighborField(graphql.Int, func(n *graphQLNeighbor) any {
+				return n.RoutesReceived
+			}),
+			"routesFiltered": neighborField(graphql.Int, func(n *graphQLNeighbor) any {
+				return n.RoutesFiltered
+			}),
+			"routesExported": neighborField(graphql.Int, func(n *graphQLNeighbor) any {
+				return n.RoutesExported
+			}),
+			"routesPreferred": neighborField(graphql.Int, func(n *graphQLNeighbor) any {
+				return n.RoutesPreferred
+			}),
+			"routesAccepted": neighborField(graphql.Int, func(n *graphQLNeighbor) any {
+				return n.RoutesAccepted
+			}),
+			"received": g.routesField(routeType,
+				"Routes received from the neighbor",
+				func(ctx context.Context, n *graphQLNeighbor) (api.Routes, error) {
+					res, err := g.sourceRoutes(ctx, n, "routes_received")
+					if err != nil {
+						return nil, err
+					}
+					return res.Imported, nil
+				}),
+			"filtered": g.routesField(routeType,
+				"Routes of the neighbor filtered by the route server",
+				func(ctx context.Context, n *graphQLNeighbor) (api.Routes, error) {
+					if !g.server.canSeeNeighbor(ctx, n.sourceID, n.ASN) {
+						return api.Routes{}, nil // Only visible for the member
+					}
+					res, err := g.sourceRoutes(ctx, n, "routes_filtered")
+					if err != nil {
+						return nil, err
+					}
+					return res.Filtered, nil
+				}),
+			"notExported": g.routesField(routeType,
+				"Routes not exported to the neighbor",
+				func(ctx context.Context, n *graphQLNeighbor) (api.Routes, error) {
+					if !g.server.canSeeNeighbor(ctx, n.sourceID, n.ASN) {
+						return api.Routes{}, nil // Only visible for the member
+					}
+					res, err := g.sourceRoutes(ctx, n, "routes_not_exported")
+					if err != nil {
+						return nil, err
+					}
+					return res.NotExported, nil
+				}),
+		},
+	})
+}
+
+// neighbors gets the neighbors of a route server from
+// the store. The source is queried if the store is
+// not yet initialized.
+func (g *graphQLAPI) neighbors(
+	ctx context.Context,
+	sourceID string,
+) ([]*graphQLNeighbor, error) {
+	var neighbors api.Neighbors
+	if g.server.neighborsStore.IsInitialized(sourceID) {
+		result, err := g.server.neighborsStore.GetNeighborsAt(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		neighbors = result
+	} else {
+		source := g.server.Config().SourceInstanceByID(sourceID)
+		if source == nil {
+			return nil, ErrSourceNotFound
+		}
+		res, err := source.NeighborsSummary(ctx)
+		if err != nil {
+			g.server.logSourceError("neighbors", sourceID, err)
+			return nil, err
+		}
+		neighbors = res.Neighbors
+	}
+	neighbors = g.server.visibleNeighbors(ctx, sourceID, neighbors)
+
+	result := make([]*graphQLNeighbor, 0, len(neighbors))
+	for _, n := range neighbors {
+		result = append(result, &graphQLNeighbor{
+			Neighbor: n,
+			sourceID: sourceID,
+		})
+	}
+	return result, nil
+}
+
+func (g *graphQLAPI) routeServerType(neighborType *graphql.Object) *graphql.Object {
+	statusType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RouteServerStatus",
+		Fields: graphql.Fields{
+			"serverTime":   &graphql.Field{Type: graphql.DateTime},
+			"lastReboot":   &graphql.Field{Type: graphql.DateTime},
+			"lastReconfig": &graphql.Field{Type: graphql.DateTime},
+			"message":      &graphql.Field{Type: graphql.String},
+			"routerId":     &graphql.Field{Type: graphql.String},
+			"version":      &graphql.Field{Type: graphql.String},
+			"backend":      &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	rsField := func(
+		t graphql.Output,
+		fn func(*config.SourceConfig) any,
+	) *graphql.Field {
+		return &graphql.Field{
+			Type: t,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return fn(p.Source.(*config.SourceConfig)), nil
+			},
+		}
+	}
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "RouteServer",
+		Fields: graphql.Fields{
+			"id": rsField(graphql.NewNonNull(graphql.ID), func(rs *config.SourceConfig) any {
+				return rs.ID
+			}),
+			"name": rsField(graphql.String, func(rs *config.SourceConfig) any {
+				return rs.Name
+			}),
+			"group": rsField(graphql.String, func(rs *config.SourceConfig) any {
+				return rs.Group
+			}),
+			"type": rsField(graphql.String, func(rs *config.SourceConfig) any {
+				return rs.Type
+			}),
+			"blackholes": rsField(graphQLList(graphql.String), func(rs *config.SourceConfig) any {
+				if rs.Blackholes == nil {
+					return []string{}
+				}
+				return rs.Blackholes
+			}),
+			"status": &graphql.Field{
+				Type: statusType,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					rs := p.Source.(*config.SourceConfig)
+					source := g.server.Config().SourceInstanceByID(rs.ID)
+					if source == nil {
+						return nil, ErrSourceNotFound
+					}
+					res, err := source.Status(p.Context)
+					if err != nil {
+						g.server.logSourceError("status", rs.ID, err)
+						return nil, err
+					}
+					s := res.Status
+					return map[string]any{
+						"serverTime":   s.ServerTime,
+						"lastReboot":   s.LastReboot,
+						"lastReconfig": s.LastReconfig,
+						"message":      s.Message,
+						"routerId":     s.RouterID,
+						"version":      s.Version,
+						"backend":      s.Backend,
+					}, nil
+				},
+			},
+			"neighbors": &graphql.Field{
+				Type: graphQLList(neighborType),
+				Args: graphql.FieldConfigArgument{
+					"asn": &graphql.ArgumentConfig{
+						Type:        graphql.Int,
+						Description: "Only neighbors with the ASN",
+					},
+					"state": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Only neighbors in the state, e.g. up",
+					},
+					"first": graphQLArgFirst,
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					rs := p.Source.(*config.SourceConfig)
+					neighbors, err := g.neighbors(p.Context, rs.ID)
+					if err != nil {
+						return nil, err
+					}
+					asn, hasASN := p.Args["asn"].(int)
+					state, hasState := p.Args["state"].(string)
+					result := make([]*graphQLNeighbor, 0, len(neighbors))
+					for _, n := range neighbors {
+						if hasASN && n.ASN != asn {
+							continue
+						}
+						if hasState && !strings.EqualFold(n.State, state) {
+							continue
+						}
+						result = append(result, n)
+					}
+					return graphQLFirst(p, result), nil
+				},
+			},
+			"neighbor": &graphql.Field{
+				Type: neighborType,
+				Args: graphql.FieldConfigArgument{
+					"id": graphQLArgID,
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					rs := p.Source.(*config.SourceConfig)
+					neighbors, err := g.neighbors(p.Context, rs.ID)
+					if err != nil {
+						return nil, err
+					}
+					id := p.Args["id"].(string)
+					for _, n := range neighbors {
+						if n.ID == id {
+							return n, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+		},
+	})
+}
+
+func (g *graphQLAPI) lookupRouteType(
+	routeFields graphql.Fields,
+	neighborType *graphql.Object,
+) *graphql.Object {
+	fields := graphql.Fields{
+		"state": &graphql.Field{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "State of the route: imported or filtered",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				return p.Source.(*api.LookupRoute).State, nil
+			},
+		},
+		"routeServerId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				r := p.Source.(*api.LookupRoute)
+				if r.RouteServer == nil || r.RouteServer.ID == nil {
+					return "", nil
+				}
+				return *r.RouteServer.ID, nil
+			},
+		},
+		"routeServerName": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				r := p.Source.(*api.LookupRoute)
+				if r.RouteServer == nil {
+					return nil, nil
+				}
+				return r.RouteServer.Name, nil
+			},
+		},
+		"neighbor": &graphql.Field{
+			Type: neighborType,
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				r := p.Source.(*api.LookupRoute)
+				if r.Neighbor == nil || r.RouteServer == nil || r.RouteServer.ID == nil {
+					return nil, nil
+				}
+				return &graphQLNeighbor{
+					Neighbor: r.Neighbor,
+					sourceID: *r.RouteServer.ID,
+				}, nil
+			},
+		},
+	}
+	for name, field := range routeFields {
+		fields[name] = field
+	}
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name:   "LookupRoute",
+		Fields: fields,
+	})
+}
+
+func (g *graphQLAPI) storeStatusType() *graphql.Object {
+	routesStatusType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RouteServerRoutesStatus",
+		Fields: graphql.Fields{
+			"name":      &graphql.Field{Type: graphql.String},
+			"state":     &graphql.Field{Type: graphql.String},
+			"updatedAt": &graphql.Field{Type: graphql.DateTime},
+			"imported":  &graphql.Field{Type: graphql.Int},
+			"filtered":  &graphql.Field{Type: graphql.Int},
+		},
+	})
+	neighborsStatusType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RouteServerNeighborsStatus",
+		Fields: graphql.Fields{
+			"name":      &graphql.Field{Type: graphql.String},
+			"state":     &graphql.Field{Type: graphql.String},
+			"updatedAt": &graphql.Field{Type: graphql.DateTime},
+			"neighbors": &graphql.Field{Type: graphql.Int},
+		},
+	})
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "StoreStatus",
+		Fields: graphql.Fields{
+			"totalRoutesImported": &graphql.Field{Type: graphql.Int},
+			"totalRoutesFiltered": &graphql.Field{Type: graphql.Int},
+			"totalNeighbors":      &graphql.Field{Type: graphql.Int},
+			"routes":              &graphql.Field{Type: graphQLList(routesStatusType)},
+			"neighbors":           &graphql.Field{Type: graphQLList(neighborsStatusType)},
+		},
+	})
+}
+
+// storeStatus collects the status of the stores
+func (g *graphQLAPI) storeStatus(ctx context.Context) map[string]any {
+	routesStats := g.server.routesStore.Stats(ctx)
+	neighborsStats := g.server.neighborsStore.Stats(ctx)
+
+	routes := make([]map[string]any, 0, len(routesStats.RouteServers))
+	for _, rs := range routesStats.RouteServers {
+		routes = append(routes, map[string]any{
+			"name":      rs.Name,
+			"state":     rs.State,
+			"updatedAt": graphQLTime(rs.UpdatedAt),
+			"imported":  rs.Routes.Imported,
+			"filtered":  rs.Routes.Filtered,
+		})
+	}
+	neighbors := make([]map[string]any, 0, len(neighborsStats.RouteServers))
+	for _, rs := range neighborsStats.RouteServers {
+		neighbors = append(neighbors, map[string]any{
+			"name":      rs.Name,
+			"state":     rs.State,
+			"updatedAt": graphQLTime(rs.UpdatedAt),
+			"neighbors": rs.Neighbors,
+		})
+	}
+	return map[string]any{
+		"totalRoutesImported": routesStats.TotalRoutes.Imported,
+		"totalRoutesFiltered": routesStats.TotalRoutes.Filtered,
+		"totalNeighbors":      neighborsStats.TotalNeighbors,
+		"routes":              routes,
+		"neighbors":           neighbors,
+	}
+}
+
+// Helper: Unset timestamps are null
+func graphQLTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// lookupQuery creates the query for the prefix lookup
+// from the arguments.
+func lookupQuery(args map[string]any) url.Values {
+	query := url.Values{}
+	if q, ok := args["q"].(string); ok {
+		query.Set("q", q)
+	}
+	if q, ok := args["query"].(string); ok {
+		query.Set(api.SearchKeyQuery, q)
+	}
+	lists := map[string]string{
+		"sources":          api.SearchKeySources,
+		"asns":             api.SearchKeyASNS,
+		"communities":      api.SearchKeyCommunities,
+		"largeCommunities": api.SearchKeyLargeCommunities,
+		"extCommunities":   api.SearchKeyExtCommunities,
+	}
+	for arg, key := range lists {
+		values, ok := args[arg].([]any)
+		if !ok || len(values) == 0 {
+			continue
+		}
+		tokens := make([]string, 0, len(values))
+		for _, v := range values {
+			switch v := v.(type) {
+			case int:
+				tokens = append(tokens, strconv.Itoa(v))
+			default:
+				tokens = append(tokens, fmt.Sprint(v))
+			}
+		}
+		query.Set(key, strings.Join(tokens, ","))
+	}
+	return query
+}
+
+func (g *graphQLAPI) queryType() *graphql.Object {
+	reasonType := g.reasonType()
+	routeFields := g.routeFields(g.bgpType(), reasonType)
+	routeType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Route",
+		Fields: routeFields,
+	})
+	neighborType := g.neighborType(routeType)
+	routeServerType := g.routeServerType(neighborType)
+	lookupRouteType := g.lookupRouteType(routeFields, neighborType)
+
+	stringList := graphql.NewList(graphql.NewNonNull(graphql.String))
+
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"routeServers": &graphql.Field{
+				Type: graphQLList(routeServerType),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return g.server.Config().Sources, nil
+				},
+			},
+			"routeServer": &graphql.Field{
+				Type: routeServerType,
+				Args: graphql.FieldConfigArgument{
+					"id": graphQLArgID,
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					rs := g.server.Config().SourceByID(p.Args["id"].(string))
+					if rs == nil {
+						return nil, nil
+					}
+					return rs, nil
+				},
+			},
+			"lookupPrefix": &graphql.Field{
+				Type: graphQLList(lookupRouteType),
+				Description: "Routes matching the prefix, neighbor " +
+					"or search query on all route servers",
+				Args: graphql.FieldConfigArgument{
+					"q": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Prefix, neighbor ASN or description",
+					},
+					"query": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Search query",
+					},
+					"sources":          &graphql.ArgumentConfig{Type: stringList},
+					"asns":             &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(graphql.Int))},
+					"communities":      &graphql.ArgumentConfig{Type: stringList},
+					"largeCommunities": &graphql.ArgumentConfig{Type: stringList},
+					"extCommunities":   &graphql.ArgumentConfig{Type: stringList},
+					"first":            graphQLArgFirst,
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if !g.server.Config().Server.EnablePrefixLookup {
+						return nil, ErrPrefixLookupDisabled
+					}
+					routes, _, err := g.server.lookupPrefixRoutes(
+						p.Context, lookupQuery(p.Args))
+					if err != nil {
+						return nil, err
+					}
+					return graphQLFirst(p, routes), nil
+				},
+			},
+			"lookupNeighbors": &graphql.Field{
+				Type:        graphQLList(neighborType),
+				Description: "Neighbors on all route servers",
+				Args: graphql.FieldConfigArgument{
+					"asn": &graphql.ArgumentConfig{
+						Type: graphql.Int,
+					},
+					"name": &graphql.ArgumentConfig{
+						Type:        graphql.String,
+						Description: "Part of the neighbor description",
+					},
+					"first": graphQLArgFirst,
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if !g.server.Config().Server.EnablePrefixLookup {
+						return nil, ErrPrefixLookupDisabled
+					}
+					query := url.Values{}
+					if asn, ok := p.Args["asn"].(int); ok {
+						query.Set("asn", strconv.Itoa(asn))
+					}
+					if name, ok := p.Args["name"].(string); ok {
+						query.Set("name", name)
+					}
+					neighbors, err := g.server.neighborsStore.FilterNeighbors(
+						p.Context, api.NeighborFilterFromQuery(query))
+					if err != nil {
+						return nil, err
+					}
+					neighbors = g.server.visibleNeighbors(p.Context, "", neighbors)
+					result := make([]*graphQLNeighbor, 0, len(neighbors))
+					for _, n := range neighbors {
+						result = append(result, &graphQLNeighbor{
+							Neighbor: n,
+							sourceID: n.RouteServerID,
+						})
+					}
+					return graphQLFirst(p, result), nil
+				},
+			},
+			"storeStatus": &graphql.Field{
+				Type: graphql.NewNonNull(g.storeStatusType()),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					return g.storeStatus(p.Context), nil
+				},
+			},
+		},
+	})
+}
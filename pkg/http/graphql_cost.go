@@ -0,0 +1,187 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// GraphQLDefaultListSize is the estimated number of
+// items of a list without a first argument.
+const GraphQLDefaultListSize = 100
+
+// Costs of fields querying the stores or sources.
+// Other fields with an object type cost 1, scalar
+// fields are free.
+var graphQLFieldCosts = map[string]int{
+	"Query.lookupPrefix":    100,
+	"Query.lookupNeighbors": 10,
+	"RouteServer.status":    10,
+	"RouteServer.neighbors": 5,
+	"RouteServer.neighbor":  5,
+	"Neighbor.received":     10,
+	"Neighbor.filtered":     10,
+	"Neighbor.notExported":  10,
+}
+
+// ErrGraphQLCostExceeded is returned when the estimated
+// cost of a query exceeds the limit.
+type ErrGraphQLCostExceeded struct {
+	Cost    int
+	MaxCost int
+}
+
+// Error implements the error interface
+func (err *ErrGraphQLCostExceeded) Error() string {
+	return fmt.Sprintf(
+		"query cost %d exceeds the limit of %d", err.Cost, err.MaxCost)
+}
+
+// graphQLCost estimates the cost of a query: The cost
+// of each field is multiplied by the sizes of the
+// enclosing lists.
+type graphQLCost struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+	sizes     map[string]int
+}
+
+// queryCost estimates the cost of the operation in
+// a validated document.
+func (g *graphQLAPI) queryCost(
+	doc *ast.Document,
+	operationName string,
+	variables map[string]any,
+) int {
+	c := &graphQLCost{
+		schema:    &g.schema,
+		fragments: map[string]*ast.FragmentDefinition{},
+		variables: variables,
+		sizes: map[string]int{
+			"Query.routeServers": len(g.server.Config().Sources),
+		},
+	}
+
+	var operation *ast.OperationDefinition
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			c.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" && operation == nil {
+				operation = def
+			} else if def.Name != nil && def.Name.Value == operationName {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return 0
+	}
+	return c.selectionSet(g.schema.QueryType(), operation.SelectionSet, 1)
+}
+
+// Helper: Get the named type and if the type is a list
+func graphQLUnwrapType(t graphql.Type) (graphql.Type, bool) {
+	isList := false
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		case *graphql.List:
+			t = wrapped.OfType
+			isList = true
+		default:
+			return t, isList
+		}
+	}
+}
+
+func (c *graphQLCost) selectionSet(
+	parent *graphql.Object,
+	set *ast.SelectionSet,
+	multiplier int,
+) int {
+	if set == nil || parent == nil {
+		return 0
+	}
+	cost := 0
+	for _, selection := range set.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			cost += c.field(parent, sel, multiplier)
+		case *ast.InlineFragment:
+			t := parent
+			if sel.TypeCondition != nil {
+				t, _ = c.schema.Type(sel.TypeCondition.Name.Value).(*graphql.Object)
+			}
+			cost += c.selectionSet(t, sel.SelectionSet, multiplier)
+		case *ast.FragmentSpread:
+			fragment, ok := c.fragments[sel.Name.Value]
+			if !ok {
+				continue
+			}
+			t, _ := c.schema.Type(fragment.TypeCondition.Name.Value).(*graphql.Object)
+			cost += c.selectionSet(t, fragment.SelectionSet, multiplier)
+		}
+	}
+	return cost
+}
+
+func (c *graphQLCost) field(
+	parent *graphql.Object,
+	field *ast.Field,
+	multiplier int,
+) int {
+	def, ok := parent.Fields()[field.Name.Value]
+	if !ok {
+		return 0 // e.g. __typename
+	}
+	key := parent.Name() + "." + field.Name.Value
+	t, isList := graphQLUnwrapType(def.Type)
+	object, isObject := t.(*graphql.Object)
+	if !isObject {
+		return 0
+	}
+
+	cost, ok := graphQLFieldCosts[key]
+	if !ok {
+		cost = 1
+	}
+	cost *= multiplier
+
+	if isList {
+		multiplier *= c.listSize(key, field)
+	}
+	return cost + c.selectionSet(object, field.SelectionSet, multiplier)
+}
+
+// listSize estimates the size of the list from the
+// first argument or a default.
+func (c *graphQLCost) listSize(key string, field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				return max(n, 0)
+			}
+		case *ast.Variable:
+			switch n := c.variables[value.Name.Value].(type) {
+			case int:
+				return max(n, 0)
+			case float64:
+				return max(int(n), 0)
+			}
+		}
+	}
+	if size, ok := c.sizes[key]; ok {
+		return size
+	}
+	return GraphQLDefaultListSize
+}
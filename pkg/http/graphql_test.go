@@ -0,0 +1,257 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func newTestGraphQLAPI(t *testing.T) *graphQLAPI {
+	s, _ := newTestServer(t)
+	g, err := newGraphQLAPI(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// graphQLData executes the query and decodes the data
+func graphQLData(t *testing.T, g *graphQLAPI, query string) map[string]any {
+	result := g.Execute(context.Background(), &GraphQLRequest{
+		Query: query,
+	})
+	if result.HasErrors() {
+		t.Fatal(result.Errors)
+	}
+	return decodeJSON(t, result.Data).(map[string]any)
+}
+
+func TestGraphQLRouteServers(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+	data := graphQLData(t, g, `{ routeServers { id name group } }`)
+
+	rs := data["routeServers"].([]any)
+	if len(rs) != len(g.server.Config().Sources) {
+		t.Fatal("unexpected route servers:", rs)
+	}
+	first := rs[0].(map[string]any)
+	if first["id"] != g.server.Config().Sources[0].ID {
+		t.Error("unexpected route server:", first)
+	}
+}
+
+func TestGraphQLLookup(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+	data := graphQLData(t, g, `{
+		lookupPrefix(q: "193.200") {
+			network
+			state
+			routeServerId
+			bgp { asPath communities largeCommunities }
+			filterReasons { community reason }
+			neighbor { id asn }
+		}
+		lookupNeighbors(asn: 31078) { id asn routeServerId }
+		storeStatus { totalRoutesImported totalNeighbors }
+	}`)
+
+	routes := data["lookupPrefix"].([]any)
+	if len(routes) != 1 {
+		t.Fatal("unexpected routes:", routes)
+	}
+	route := routes[0].(map[string]any)
+	if route["network"] != "193.200.230.0/24" {
+		t.Error("unexpected network:", route["network"])
+	}
+	if route["state"] != api.RouteStateImported {
+		t.Error("unexpected state:", route["state"])
+	}
+	asPath := route["bgp"].(map[string]any)["asPath"].([]any)
+	if len(asPath) != 2 || asPath[0].(float64) != 31078 {
+		t.Error("unexpected as path:", asPath)
+	}
+
+	neighbors := data["lookupNeighbors"].([]any)
+	if len(neighbors) != 1 {
+		t.Fatal("unexpected neighbors:", neighbors)
+	}
+	neighbor := neighbors[0].(map[string]any)
+	if neighbor["routeServerId"] != g.server.Config().Sources[0].ID {
+		t.Error("unexpected neighbor:", neighbor)
+	}
+}
+
+func TestGraphQLLookupDisabled(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+	g.server.Config().Server.EnablePrefixLookup = false
+	result := g.Execute(context.Background(), &GraphQLRequest{
+		Query: `{ lookupPrefix(q: "193.200") { network } }`,
+	})
+	if !result.HasErrors() {
+		t.Fatal("expected an error")
+	}
+	if result.Errors[0].Message != ErrPrefixLookupDisabled.Error() {
+		t.Error("unexpected error:", result.Errors)
+	}
+}
+
+func TestGraphQLQueryCost(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+	numSources := len(g.server.Config().Sources)
+
+	tests := []struct {
+		query     string
+		variables map[string]any
+		cost      int
+	}{
+		{`{ routeServers { id name } }`, nil, 1},
+		{`{ storeStatus { totalNeighbors } }`, nil, 1},
+		{`{ lookupPrefix(q: "10.0") { network bgp { asPath } } }`, nil,
+			100 + GraphQLDefaultListSize},
+		{`{ lookupPrefix(q: "10.0", first: 10) { network } }`, nil, 100},
+		{`{ routeServers { neighbors { id } } }`, nil,
+			1 + 5*numSources},
+		{`{ routeServers { neighbors(first: 2) { received(first: 10) { network } } } }`, nil,
+			1 + 5*numSources + 10*numSources*2},
+		{`{ routeServers { neighbors { received { network } } } }`, nil,
+			1 + 5*numSources + 10*numSources*GraphQLDefaultListSize},
+		{`query($n: Int) { routeServers { neighbors(first: $n) { filtered { network } } } }`,
+			map[string]any{"n": float64(3)},
+			1 + 5*numSources + 10*numSources*3},
+		{`{ routeServers { ...rs } } fragment rs on RouteServer { status { version } }`, nil,
+			1 + 10*numSources},
+	}
+
+	for _, tt := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cost := g.queryCost(doc, "", tt.variables)
+		if cost != tt.cost {
+			t.Errorf("expected cost %d, got %d for: %s", tt.cost, cost, tt.query)
+		}
+	}
+}
+
+func TestGraphQLFirst(t *testing.T) {
+	results := make([]int, GraphQLDefaultListSize+50)
+	tests := []struct {
+		args map[string]any
+		len  int
+	}{
+		{map[string]any{}, GraphQLDefaultListSize},
+		{map[string]any{"first": 10}, 10},
+		{map[string]any{"first": 1000}, len(results)},
+		{map[string]any{"first": -1}, 0},
+	}
+	for _, tt := range tests {
+		p := graphql.ResolveParams{Args: tt.args}
+		if n := len(graphQLFirst(p, results)); n != tt.len {
+			t.Errorf("expected %d results, got %d for: %v", tt.len, n, tt.args)
+		}
+	}
+}
+
+func TestGraphQLQueryCostExceeded(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+	result := g.Execute(context.Background(), &GraphQLRequest{
+		Query: `{ routeServers { neighbors { received { network } } } }`,
+	})
+	if len(result.Errors) != 1 {
+		t.Fatal("expected an error, got:", result.Errors)
+	}
+	if result.Errors[0].Extensions["code"] != "QUERY_COST_EXCEEDED" {
+		t.Error("unexpected error:", result.Errors[0])
+	}
+	if result.Data != nil {
+		t.Error("query should not be executed")
+	}
+}
+
+func TestGraphQLValidation(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+	result := g.Execute(context.Background(), &GraphQLRequest{
+		Query: `{ routeServers { unknownField } }`,
+	})
+	if !result.HasErrors() {
+		t.Error("expected a validation error")
+	}
+	result = g.Execute(context.Background(), &GraphQLRequest{
+		Query: `{ routeServers { `,
+	})
+	if !result.HasErrors() {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestGraphQLRouteReasons(t *testing.T) {
+	reasons := api.BGPCommunityMap{
+		"23": api.BGPCommunityMap{
+			"65666": api.BGPCommunityMap{
+				"1": "An IP Bogon was detected",
+			},
+		},
+	}
+	r := &api.Route{
+		BGP: &api.BGPInfo{
+			Communities:      api.Communities{{23, 42}},
+			LargeCommunities: api.Communities{{23, 65666, 1}},
+		},
+	}
+	result := routeReasons(r, reasons)
+	if len(result) != 1 {
+		t.Fatal("unexpected reasons:", result)
+	}
+	if result[0].Community != "23:65666:1" ||
+		result[0].Reason != "An IP Bogon was detected" {
+		t.Error("unexpected reason:", result[0])
+	}
+}
+
+func TestGraphQLEndpoint(t *testing.T) {
+	g := newTestGraphQLAPI(t)
+
+	// POST
+	body := `{"query": "query($id: ID!) { routeServer(id: $id) { id } }",
+	          "variables": {"id": "` + g.server.Config().Sources[0].ID + `"}}`
+	req := httptest.NewRequest("POST", "/api/v1/graphql", strings.NewReader(body))
+	res, err := g.apiQuery(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := res.(*graphql.Result)
+	if result.HasErrors() {
+		t.Fatal(result.Errors)
+	}
+	payload, _ := json.Marshal(result)
+	if !strings.Contains(string(payload), g.server.Config().Sources[0].ID) {
+		t.Error("unexpected result:", string(payload))
+	}
+
+	// GET
+	query := url.Values{}
+	query.Set("query", "{ routeServers { id } }")
+	req = httptest.NewRequest("GET", "/api/v1/graphql?"+query.Encode(), nil)
+	res, err = g.apiQuery(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.(*graphql.Result).HasErrors() {
+		t.Error(res.(*graphql.Result).Errors)
+	}
+
+	// Missing query
+	req = httptest.NewRequest("GET", "/api/v1/graphql", nil)
+	if _, err := g.apiQuery(context.Background(), req, nil); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,199 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/store/backends/postgres"
+)
+
+// Health Endpoints
+//
+//   Liveness     /healthz
+//   Readiness    /readyz
+//
+// The liveness endpoint responds as long as the
+// process is serving requests. The readiness checks
+// are configured in the [health] section.
+
+// healthCheckTimeout limits the time for
+// reaching the database.
+const healthCheckTimeout = 5 * time.Second
+
+// HealthCheck is the result of a readiness check.
+// The sources failing the check are listed.
+type HealthCheck struct {
+	Name    string   `json:"name"`
+	Ok      bool     `json:"ok"`
+	Message string   `json:"message,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+// HealthStatus is the response of the health endpoints
+type HealthStatus struct {
+	Ok     bool           `json:"ok"`
+	Checks []*HealthCheck `json:"checks,omitempty"`
+}
+
+// registerHealthChecks adds the liveness and
+// readiness endpoints.
+func (s *Server) registerHealthChecks(router *httprouter.Router) {
+	router.GET("/healthz", s.healthLiveness)
+	router.GET("/readyz", s.healthReadiness)
+}
+
+// healthLiveness responds if the process is alive
+func (s *Server) healthLiveness(
+	res http.ResponseWriter,
+	_req *http.Request,
+	_params httprouter.Params,
+) {
+	writeHealthStatus(res, &HealthStatus{Ok: true})
+}
+
+// healthReadiness runs the readiness checks. The
+// response status is 503 if a check failed.
+func (s *Server) healthReadiness(
+	res http.ResponseWriter,
+	req *http.Request,
+	_params httprouter.Params,
+) {
+	writeHealthStatus(res, s.checkReadiness(req.Context()))
+}
+
+// checkReadiness runs the configured readiness checks
+func (s *Server) checkReadiness(ctx context.Context) *HealthStatus {
+	cfg := s.Config().Health
+	checks := []*HealthCheck{}
+
+	if cfg.CheckPostgres && s.pool != nil {
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		status := postgres.NewManager(s.pool).Status(ctx)
+		checks = append(checks, checkPostgres(status))
+	}
+
+	// The stores are only refreshed
+	// with the prefix lookup enabled.
+	if s.Config().Server.EnablePrefixLookup {
+		stores := []struct {
+			name   string
+			status *api.StoreStatus
+		}{
+			{"neighbors_store", s.neighborsStore.Status(ctx)},
+			{"routes_store", s.routesStore.Status(ctx)},
+		}
+		maxError := time.Duration(cfg.MaxSourceError) * time.Second
+		for _, store := range stores {
+			checks = append(checks, checkSourcesInitialized(
+				store.name, store.status, cfg.SourcesInitialized))
+			if maxError > 0 {
+				checks = append(checks, checkSourceErrors(
+					store.name+"_errors", store.status, maxError, time.Now()))
+			}
+		}
+	}
+
+	status := &HealthStatus{Ok: true, Checks: checks}
+	for _, check := range checks {
+		if !check.Ok {
+			status.Ok = false
+		}
+	}
+	return status
+}
+
+// checkPostgres requires a migrated database. The readiness
+// endpoint is public, so connection errors are only logged.
+func checkPostgres(status *postgres.Status) *HealthCheck {
+	check := &HealthCheck{Name: "postgres", Ok: true}
+	if status.Error != nil {
+		log.Println("readiness check: postgres:", status.Error)
+		check.Ok = false
+		check.Message = "database unreachable"
+	} else if !status.Migrated {
+		check.Ok = false
+		check.Message = fmt.Sprintf(
+			"schema version %d is not migrated", status.SchemaVersion)
+	}
+	return check
+}
+
+// checkSourcesInitialized requires the percentage of
+// sources to be initialized in the store.
+func checkSourcesInitialized(
+	name string,
+	status *api.StoreStatus,
+	percent int,
+) *HealthCheck {
+	pending := []string{}
+	for id, src := range status.Sources {
+		if !src.Initialized {
+			pending = append(pending, id)
+		}
+	}
+	slices.Sort(pending)
+
+	total := len(status.Sources)
+	initialized := total - len(pending)
+	check := &HealthCheck{
+		Name: name,
+		Ok:   initialized*100 >= total*percent,
+		Message: fmt.Sprintf(
+			"%d of %d sources initialized, %d%% required",
+			initialized, total, percent),
+	}
+	if !check.Ok {
+		check.Sources = pending
+	}
+	return check
+}
+
+// checkSourceErrors fails if the refreshes of a
+// source failed for longer than maxError.
+func checkSourceErrors(
+	name string,
+	status *api.StoreStatus,
+	maxError time.Duration,
+	now time.Time,
+) *HealthCheck {
+	failing := []string{}
+	for id, src := range status.Sources {
+		if src.ErrorSince != nil && now.Sub(*src.ErrorSince) > maxError {
+			failing = append(failing, id)
+		}
+	}
+	slices.Sort(failing)
+
+	check := &HealthCheck{Name: name, Ok: len(failing) == 0}
+	if !check.Ok {
+		check.Message = fmt.Sprintf(
+			"refreshes failing for more than %s", maxError)
+		check.Sources = failing
+	}
+	return check
+}
+
+// writeHealthStatus responds with the status. Health
+// checks must not be cached.
+func writeHealthStatus(res http.ResponseWriter, status *HealthStatus) {
+	payload, err := json.Marshal(status)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	if !status.Ok {
+		res.WriteHeader(http.StatusServiceUnavailable)
+	}
+	res.Write(payload)
+}
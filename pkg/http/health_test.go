@@ -0,0 +1,110 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/store/backends/postgres"
+)
+
+func TestCheckSourcesInitialized(t *testing.T) {
+	status := &api.StoreStatus{
+		Sources: map[string]*api.SourceStatus{
+			"rs1": {Initialized: true},
+			"rs2": {Initialized: false},
+			"rs3": {Initialized: true},
+			"rs4": {Initialized: false},
+		},
+	}
+	check := checkSourcesInitialized("routes_store", status, 50)
+	if !check.Ok {
+		t.Error("expected 50% to be sufficient:", check.Message)
+	}
+	check = checkSourcesInitialized("routes_store", status, 75)
+	if check.Ok {
+		t.Error("expected the check to fail")
+	}
+	if !slices.Equal(check.Sources, []string{"rs2", "rs4"}) {
+		t.Error("unexpected sources:", check.Sources)
+	}
+}
+
+func TestCheckSourceErrors(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-15 * time.Minute)
+	recent := now.Add(-time.Minute)
+	status := &api.StoreStatus{
+		Sources: map[string]*api.SourceStatus{
+			"rs1": {},
+			"rs2": {ErrorSince: &since},
+			"rs3": {ErrorSince: &recent},
+		},
+	}
+	check := checkSourceErrors("neighbors_store_errors", status, 10*time.Minute, now)
+	if check.Ok {
+		t.Error("expected the check to fail")
+	}
+	if !slices.Equal(check.Sources, []string{"rs2"}) {
+		t.Error("unexpected sources:", check.Sources)
+	}
+	check = checkSourceErrors("neighbors_store_errors", status, time.Hour, now)
+	if !check.Ok {
+		t.Error("expected the check to pass:", check.Sources)
+	}
+}
+
+func TestCheckPostgres(t *testing.T) {
+	if check := checkPostgres(&postgres.Status{Migrated: true}); !check.Ok {
+		t.Error("expected a migrated database to pass")
+	}
+	if check := checkPostgres(&postgres.Status{}); check.Ok {
+		t.Error("expected an unmigrated database to fail")
+	}
+	// Connection details are not exposed
+	check := checkPostgres(&postgres.Status{
+		Error: errors.New("failed to connect to `host=db user=alice`"),
+	})
+	if check.Ok || check.Message != "database unreachable" {
+		t.Error("unexpected check:", check)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	s, _ := newTestServer(t)
+	router := httprouter.New()
+	s.registerHealthChecks(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Error("unexpected liveness status:", rec.Code)
+	}
+
+	// The stores were not refreshed yet
+	req = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Error("unexpected readiness status:", rec.Code)
+	}
+	status := &HealthStatus{}
+	if err := json.Unmarshal(rec.Body.Bytes(), status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Ok || len(status.Checks) == 0 {
+		t.Fatal("unexpected status:", status)
+	}
+	check := status.Checks[0]
+	if check.Name != "neighbors_store" || check.Ok || len(check.Sources) == 0 {
+		t.Error("unexpected check:", check)
+	}
+}
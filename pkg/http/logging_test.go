@@ -19,7 +19,8 @@ func TestApiLogSourceError(t *testing.T) {
 		},
 	}
 
-	s := &Server{cfg: cfg}
+	s := &Server{}
+	s.cfg.Store(cfg)
 
 	s.logSourceError("foo.bar", "rs1v4", 23, "Test")
 	s.logSourceError("foo.bam", "rs1v4", err)
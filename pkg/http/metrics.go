@@ -3,16 +3,39 @@ package http
 import (
 	"context"
 	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var requestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests by endpoint, method and status",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"endpoint", "method", "status"},
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration)
+}
+
 func (s *Server) registerMetrics(
 	ctx context.Context,
 	router *httprouter.Router,
 ) error {
-	if !s.cfg.Server.EnableMetrics {
+	if !s.Config().Server.EnableMetrics {
 		return nil
 	}
 
@@ -21,3 +44,127 @@ func (s *Server) registerMetrics(
 
 	return nil
 }
+
+// statusRecorder keeps the status of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Flush sends the buffered data of streamed responses
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap provides the response writer
+// to the http.ResponseController.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// routeLabelKey is the context key of the route label
+type routeLabelKey struct{}
+
+// routeLabel is the route of the request, which can
+// be refined by the handlers of a catch all route.
+type routeLabel struct {
+	pattern string
+}
+
+// refineRoute replaces the catch all parameter in the
+// route of the request with the segment selected by
+// the handler, e.g. .../routes/received.
+func refineRoute(ctx context.Context, param, segment string) {
+	label, ok := ctx.Value(routeLabelKey{}).(*routeLabel)
+	if !ok {
+		return
+	}
+	label.pattern = strings.TrimSuffix(label.pattern, "*"+param) + segment
+}
+
+// instrumented records the duration and the status of
+// the requests and starts the server span, continuing
+// the trace of the client. Requests are labeled with the
+// route of the router, so the number of endpoints is bounded.
+func instrumented(router *httprouter.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		label := &routeLabel{pattern: routePattern(router, req)}
+		route := label.pattern
+		method := metricsMethod(req.Method)
+
+		ctx := otel.GetTextMapPropagator().Extract(
+			req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx = context.WithValue(ctx, routeLabelKey{}, label)
+		ctx, span := tracer.Start(ctx, method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(req.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(req.URL.Path),
+			))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: res, status: http.StatusOK}
+		next.ServeHTTP(rec, req.WithContext(ctx))
+
+		if label.pattern != route {
+			route = label.pattern
+			span.SetName(method + " " + route)
+		}
+		span.SetAttributes(
+			semconv.HTTPRoute(route),
+			semconv.HTTPResponseStatusCode(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+		requestDuration.WithLabelValues(
+			route, method, strconv.Itoa(rec.status),
+		).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routePattern reconstructs the route matching the
+// request by replacing the values of the parameters
+// with their names, e.g. /api/v1/routeservers/:id/status.
+func routePattern(router *httprouter.Router, req *http.Request) string {
+	handle, params, _ := router.Lookup(req.Method, req.URL.Path)
+	if handle == nil {
+		return "unmatched"
+	}
+	segments := strings.Split(req.URL.Path, "/")
+	next := 0
+	for _, p := range params {
+		// Catch all parameters match the rest of the path
+		if strings.HasPrefix(p.Value, "/") {
+			path := strings.Join(segments, "/")
+			return strings.TrimSuffix(path, p.Value) + "/*" + p.Key
+		}
+		for i := next; i < len(segments); i++ {
+			if segments[i] == p.Value {
+				segments[i] = ":" + p.Key
+				next = i + 1
+				break
+			}
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// metricsMethod limits the methods to the
+// ones served by the router.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+		return method
+	}
+	return "other"
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestRoutePattern(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request, httprouter.Params) {}
+	router := httprouter.New()
+	router.GET("/api/v1/routeservers/:id/status", noop)
+	router.GET("/alice/*path", noop)
+	router.GET("/", noop)
+
+	tests := map[string]string{
+		"/api/v1/routeservers/rs1/status": "/api/v1/routeservers/:id/status",
+		"/alice/routeservers/rs1":         "/alice/*path",
+		"/":                               "/",
+		"/wp-login.php":                   "unmatched",
+	}
+	for path, expected := range tests {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if pattern := routePattern(router, req); pattern != expected {
+			t.Error(path, "unexpected route:", pattern)
+		}
+	}
+}
+
+func TestInstrumented(t *testing.T) {
+	router := httprouter.New()
+	router.GET("/api/v1/routeservers/:id/status",
+		func(res http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+			res.WriteHeader(http.StatusTeapot)
+		})
+	handler := instrumented(router, router)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/routeservers/rs9/status", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	m := &dto.Metric{}
+	obs := requestDuration.WithLabelValues(
+		"/api/v1/routeservers/:id/status", "GET", "418")
+	if err := obs.(prometheus.Histogram).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if n := m.GetHistogram().GetSampleCount(); n != 1 {
+		t.Error("expected a recorded request, got:", n)
+	}
+}
+
+func TestInstrumentedNeighborRoutes(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request, httprouter.Params) {}
+	handlers := neighborRoutesHandlers{
+		"received": noop,
+		"filtered": noop,
+	}
+	router := httprouter.New()
+	router.GET("/api/v1/routeservers/:id/neighbors/:neighborId/routes/*route",
+		handlers.dispatch(noop))
+	handler := instrumented(router, router)
+
+	tests := map[string]string{
+		"/api/v1/routeservers/rs1/neighbors/n1/routes/received":    "/api/v1/routeservers/:id/neighbors/:neighborId/routes/received",
+		"/api/v1/routeservers/rs1/neighbors/n1/routes/filtered":    "/api/v1/routeservers/:id/neighbors/:neighborId/routes/filtered",
+		"/api/v1/routeservers/rs1/neighbors/n1/routes/10.0.0.0/24": "/api/v1/routeservers/:id/neighbors/:neighborId/routes/:prefix",
+	}
+	for path, endpoint := range tests {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		m := &dto.Metric{}
+		obs := requestDuration.WithLabelValues(endpoint, "GET", "200")
+		if err := obs.(prometheus.Histogram).Write(m); err != nil {
+			t.Fatal(err)
+		}
+		if n := m.GetHistogram().GetSampleCount(); n != 1 {
+			t.Error(path, "expected a recorded request, got:", n)
+		}
+	}
+}
@@ -0,0 +1,594 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+	"github.com/alice-lg/alice-lg/pkg/export"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification
+// of the generated document.
+const OpenAPIVersion = "3.1.0"
+
+// openAPIParam is a path or query parameter
+type openAPIParam struct {
+	Name        string
+	In          string
+	Description string
+	Type        string
+	Required    bool
+}
+
+func pathParam(name, description string) *openAPIParam {
+	return &openAPIParam{
+		Name:        name,
+		In:          "path",
+		Description: description,
+		Type:        "string",
+		Required:    true,
+	}
+}
+
+func queryParam(name, typ, description string) *openAPIParam {
+	return &openAPIParam{
+		Name:        name,
+		In:          "query",
+		Description: description,
+		Type:        typ,
+	}
+}
+
+var (
+	paramSourceID = pathParam(
+		"id", "ID of the route server")
+	paramNeighborID = pathParam(
+		"neighborId", "ID of the neighbor")
+	paramPage = queryParam(
+		"page", "integer", "Page of the results, starting at 0")
+	paramRoutesQ = queryParam(
+		"q", "string", "Only include routes where the network or gateway starts with q")
+	paramExportFormat = queryParam(
+		"format", "string", "Export format: ndjson, csv or mrt. "+
+			"Alternatively the format is selected by the Accept header.")
+	paramExportColumns = queryParam(
+		"columns", "string", "Comma separated list of columns of the csv export")
+	paramCursor = queryParam(
+		"cursor", "string", "Opaque cursor of the next page")
+	paramLimit = queryParam(
+		"limit", "integer", "Number of routes per page, 1-1000 (default 100)")
+	paramSort = queryParam(
+		"sort", "string", "Sort key: network, as_path_length, local_pref or age. "+
+			"A '-' prefix sorts in descending order.")
+)
+
+// openAPIQueryExample is an example of a search query.
+// It is valid for the routes of a neighbor, which do not
+// support source and asn terms.
+const openAPIQueryExample = "community:23:42 AND NOT prefix_length:25-32"
+
+// openAPIQueryTerms notes the terms limited to the lookup
+const openAPIQueryTerms = ". The source and asn terms are only supported in the route lookup."
+
+// Descriptions of the search filters
+var openAPIFilterDescriptions = map[string]string{
+	api.SearchKeySources:          "Comma separated list of route server IDs",
+	api.SearchKeyASNS:             "Comma separated list of neighbor ASNs",
+	api.SearchKeyCommunities:      "Comma separated list of communities, e.g. 23:42",
+	api.SearchKeyExtCommunities:   "Comma separated list of extended communities, e.g. rt:23:42",
+	api.SearchKeyLargeCommunities: "Comma separated list of large communities, e.g. 23:42:1",
+	api.SearchKeyAddrFamily:       "Address family: 1 (IPv4) or 2 (IPv6)",
+	api.SearchKeyPrefixLength:     "Prefix length or range, e.g. 16-24",
+	api.SearchKeyLocalPref:        "Local preference or range",
+	api.SearchKeyMED:              "Multi exit discriminator or range",
+	api.SearchKeyAge:              "Age of the route in seconds or range",
+	api.SearchKeyOTC:              "Presence of the only to customer (OTC) attribute: true or false",
+	api.SearchKeyNextHop:          "Next hop address",
+	api.SearchKeyQuery:            "Search query, e.g. '" + openAPIQueryExample + "'" + openAPIQueryTerms,
+}
+
+// filterParams creates the query parameters
+// for all search filters.
+func filterParams() []*openAPIParam {
+	params := []*openAPIParam{}
+	for _, group := range *api.NewSearchFilters() {
+		params = append(params, queryParam(
+			group.Key, "string", openAPIFilterDescriptions[group.Key]))
+	}
+	return params
+}
+
+// openAPIEndpoint describes an API endpoint
+type openAPIEndpoint struct {
+	Path    string
+	Method  string // Default: get
+	ID      string
+	Summary string
+	Tag     string
+	Params  []*openAPIParam
+
+	// RequestBody is a value of the json encoded
+	// request body type.
+	RequestBody any
+
+	// Response is a value of the response type,
+	// encoded as application/json.
+	Response any
+
+	// Content maps content types to schemas of
+	// non json responses.
+	Content map[string]map[string]any
+
+	// Error is a value of the error response
+	// type. Default: api.ErrorResponse
+	Error any
+}
+
+// openAPIEndpoints lists all endpoints of the API.
+// The paths use the httprouter notation.
+func (s *Server) openAPIEndpoints() []*openAPIEndpoint {
+	routesParams := append([]*openAPIParam{
+		paramSourceID, paramNeighborID, paramRoutesQ, paramPage,
+	}, filterParams()...)
+
+	routesPageParams := append([]*openAPIParam{
+		paramSourceID, paramNeighborID, paramRoutesQ,
+		paramCursor, paramLimit, paramSort,
+	}, filterParams()...)
+
+	endpoints := []*openAPIEndpoint{
+		{
+			Path:     "/api/v1/status",
+			ID:       "showStatus",
+			Summary:  "Status of the application and the stores",
+			Tag:      "meta",
+			Response: &AppStatus{},
+		},
+		{
+			Path:     "/api/v1/config",
+			ID:       "showConfig",
+			Summary:  "Runtime configuration of the client",
+			Tag:      "meta",
+			Response: api.ConfigResponse{},
+		},
+		{
+			Path:    "/api/v1/openapi.json",
+			ID:      "showOpenAPI",
+			Summary: "This document",
+			Tag:     "meta",
+			Content: map[string]map[string]any{
+				"application/json": {"type": "object"},
+			},
+		},
+		{
+			Path:    "/api/v1/statistics",
+			ID:      "showStatistics",
+			Summary: "Statistics of the stored routes",
+			Tag:     "meta",
+			Params: []*openAPIParam{
+				queryParam("top", "integer", "Number of entries in the top lists"),
+			},
+			Response: &api.StatisticsResponse{},
+		},
+		{
+			Path:    "/api/v1/alerts",
+			ID:      "listAlerts",
+			Summary: "History of the alerts, the most recent first",
+			Tag:     "alerts",
+			Params: []*openAPIParam{
+				queryParam("status", "string", "Alert status: firing or resolved"),
+				queryParam("rule", "string", "ID of the alerting rule"),
+				queryParam("routeserver", "string", "ID of the route server"),
+			},
+			Response: &api.AlertsResponse{},
+		},
+		{
+			Path:     "/api/v1/routeservers",
+			ID:       "listRouteServers",
+			Summary:  "All route servers",
+			Tag:      "routeservers",
+			Response: api.RouteServersResponse{},
+		},
+		{
+			Path:     "/api/v1/routeservers/:id/status",
+			ID:       "showRouteServerStatus",
+			Summary:  "Status of the route server",
+			Tag:      "routeservers",
+			Params:   []*openAPIParam{paramSourceID},
+			Response: &api.StatusResponse{},
+		},
+		{
+			Path:     "/api/v1/routeservers/:id/neighbors",
+			ID:       "listNeighbors",
+			Summary:  "Neighbors of the route server",
+			Tag:      "routeservers",
+			Params:   []*openAPIParam{paramSourceID},
+			Response: &api.NeighborsResponse{},
+		},
+		{
+			Path:     "/api/v1/routeservers/:id/neighbors/:neighborId/routes/received",
+			ID:       "listRoutesReceived",
+			Summary:  "Routes received from the neighbor",
+			Tag:      "routes",
+			Params:   routesParams,
+			Response: api.PaginatedRoutesResponse{},
+		},
+		{
+			Path:     "/api/v1/routeservers/:id/neighbors/:neighborId/routes/filtered",
+			ID:       "listRoutesFiltered",
+			Summary:  "Routes of the neighbor filtered by the route server",
+			Tag:      "routes",
+			Params:   routesParams,
+			Response: api.PaginatedRoutesResponse{},
+		},
+		{
+			Path:     "/api/v1/routeservers/:id/neighbors/:neighborId/routes/not-exported",
+			ID:       "listRoutesNotExported",
+			Summary:  "Routes not exported to the neighbor",
+			Tag:      "routes",
+			Params:   routesParams,
+			Response: api.PaginatedRoutesResponse{},
+		},
+		{
+			Path:    "/api/v1/routeservers/:id/neighbors/:neighborId/routes/changes",
+			ID:      "listRouteChanges",
+			Summary: "Changes of the routes of the neighbor",
+			Tag:     "routes",
+			Params: []*openAPIParam{
+				paramSourceID,
+				paramNeighborID,
+				queryParam("since", "string",
+					"RFC3339 timestamp selecting the refresh to compare with"),
+			},
+			Response: &api.RouteChangesResponse{},
+		},
+		{
+			Path:    "/api/v1/routeservers/:id/neighbors/:neighborId/routes/:prefix",
+			ID:      "showRouteDetails",
+			Summary: "All paths of a prefix with the details from the route server",
+			Tag:     "routes",
+			Params: []*openAPIParam{
+				paramSourceID,
+				paramNeighborID,
+				pathParam("prefix", "Network of the route, e.g. 10.0.0.0/24"),
+			},
+			Response: &api.RouteDetailsResponse{},
+		},
+		{
+			Path:     "/api/v2/routeservers/:id/neighbors/:neighborId/routes/received",
+			ID:       "pageRoutesReceived",
+			Summary:  "Routes received from the neighbor, cursor paginated",
+			Tag:      "v2",
+			Params:   routesPageParams,
+			Response: &api.RoutesPage{},
+			Error:    &api.ErrorEnvelope{},
+		},
+		{
+			Path:     "/api/v2/routeservers/:id/neighbors/:neighborId/routes/filtered",
+			ID:       "pageRoutesFiltered",
+			Summary:  "Routes of the neighbor filtered by the route server, cursor paginated",
+			Tag:      "v2",
+			Params:   routesPageParams,
+			Response: &api.RoutesPage{},
+			Error:    &api.ErrorEnvelope{},
+		},
+		{
+			Path:     "/api/v2/routeservers/:id/neighbors/:neighborId/routes/not-exported",
+			ID:       "pageRoutesNotExported",
+			Summary:  "Routes not exported to the neighbor, cursor paginated",
+			Tag:      "v2",
+			Params:   routesPageParams,
+			Response: &api.RoutesPage{},
+			Error:    &api.ErrorEnvelope{},
+		},
+		{
+			Path:    "/api/v1/events/neighbors",
+			ID:      "streamNeighborEvents",
+			Summary: "Server-sent events of neighbor state changes",
+			Tag:     "events",
+			Params: []*openAPIParam{
+				queryParam("sources", "string", "Comma separated list of route server IDs"),
+				queryParam("asn", "string", "Comma separated list of neighbor ASNs"),
+			},
+			Content: map[string]map[string]any{
+				"text/event-stream": {
+					"type": "string",
+					"description": "The data of each event is a " +
+						"JSON encoded NeighborEvent",
+				},
+			},
+		},
+	}
+
+	endpoints = append(endpoints, &openAPIEndpoint{
+		Path:     "/healthz",
+		ID:       "healthLiveness",
+		Summary:  "Liveness of the process",
+		Tag:      "health",
+		Response: &HealthStatus{},
+	}, &openAPIEndpoint{
+		Path:     "/readyz",
+		ID:       "healthReadiness",
+		Summary:  "Readiness checks, the status is 503 if a check failed",
+		Tag:      "health",
+		Response: &HealthStatus{},
+		Error:    &HealthStatus{},
+	})
+
+	if s.Config().Server.EnableMetrics {
+		endpoints = append(endpoints, &openAPIEndpoint{
+			Path:    "/metrics",
+			ID:      "metrics",
+			Summary: "Prometheus metrics",
+			Tag:     "health",
+			Content: map[string]map[string]any{
+				"text/plain": {
+					"type":        "string",
+					"description": "Prometheus text exposition format",
+				},
+			},
+		})
+	}
+
+	if s.Config().Server.EnableGraphQL {
+		graphQLContent := map[string]map[string]any{
+			"application/json": {
+				"type":        "object",
+				"description": "GraphQL response with data and errors",
+			},
+		}
+		endpoints = append(endpoints, &openAPIEndpoint{
+			Path:    "/api/v1/graphql",
+			ID:      "queryGraphQL",
+			Summary: "GraphQL query",
+			Tag:     "graphql",
+			Params: []*openAPIParam{
+				queryParam("query", "string", "GraphQL query"),
+				queryParam("operationName", "string", "Name of the operation"),
+				queryParam("variables", "string", "JSON encoded variables"),
+			},
+			Content: graphQLContent,
+		}, &openAPIEndpoint{
+			Path:        "/api/v1/graphql",
+			Method:      "post",
+			ID:          "postGraphQL",
+			Summary:     "GraphQL query",
+			Tag:         "graphql",
+			RequestBody: GraphQLRequest{},
+			Content:     graphQLContent,
+		})
+	}
+
+	if s.Config().Server.EnableConfigReload {
+		endpoints = append(endpoints, &openAPIEndpoint{
+			Path:     "/api/v1/admin/reload",
+			Method:   "post",
+			ID:       "reloadConfig",
+			Summary:  "Reload the configuration file",
+			Tag:      "admin",
+			Response: &api.ConfigReloadResponse{},
+		})
+	}
+
+	if !s.Config().Server.EnablePrefixLookup {
+		return endpoints
+	}
+
+	exportContent := map[string]map[string]any{
+		export.ContentType(export.FormatNDJSON): {
+			"type":        "string",
+			"description": "One JSON encoded LookupRoute per line",
+		},
+		export.ContentType(export.FormatCSV): {
+			"type": "string",
+		},
+		export.ContentType(export.FormatMRT): {
+			"type":   "string",
+			"format": "binary",
+		},
+	}
+	exportParams := append([]*openAPIParam{
+		paramExportFormat, paramExportColumns,
+	}, filterParams()...)
+
+	return append(endpoints, []*openAPIEndpoint{
+		{
+			Path:    "/api/v1/lookup/prefix",
+			ID:      "lookupPrefix",
+			Summary: "Routes matching the prefix or search query on all route servers",
+			Tag:     "lookup",
+			Params: append([]*openAPIParam{
+				queryParam("q", "string", "Prefix, neighbor ASN or description"),
+				queryParam("page_imported", "integer", "Page of the imported routes"),
+				queryParam("page_filtered", "integer", "Page of the filtered routes"),
+			}, filterParams()...),
+			Response: api.PaginatedRoutesLookupResponse{},
+		},
+		{
+			Path:    "/api/v1/lookup/neighbors",
+			ID:      "lookupNeighbors",
+			Summary: "Neighbors on all route servers",
+			Tag:     "lookup",
+			Params: []*openAPIParam{
+				queryParam("asn", "integer", "ASN of the neighbor"),
+				queryParam("name", "string", "Part of the neighbor description"),
+			},
+			Response: &api.NeighborsResponse{},
+		},
+		{
+			Path:    "/api/v1/lookup/diff",
+			ID:      "lookupDiff",
+			Summary: "Comparison of the routes of multiple route servers",
+			Tag:     "lookup",
+			Params: []*openAPIParam{
+				queryParam("sources", "string", "Comma separated list of route server IDs"),
+				queryParam("group", "string", "Compare all route servers of the group"),
+			},
+			Response: &api.RoutesDiffResponse{},
+		},
+		{
+			Path:    "/api/v2/lookup/prefix",
+			ID:      "pageLookupPrefix",
+			Summary: "Routes matching the prefix or search query, cursor paginated",
+			Tag:     "v2",
+			Params: append([]*openAPIParam{
+				queryParam("q", "string", "Prefix, neighbor ASN or description"),
+				queryParam("state", "string", "Only include imported or filtered routes"),
+				paramCursor, paramLimit, paramSort,
+			}, filterParams()...),
+			Response: &api.LookupRoutesPage{},
+			Error:    &api.ErrorEnvelope{},
+		},
+		{
+			Path:    "/api/v1/routeservers/:id/routes/export",
+			ID:      "exportRouteServerRoutes",
+			Summary: "All routes of the route server",
+			Tag:     "exports",
+			Params:  append([]*openAPIParam{paramSourceID}, exportParams...),
+			Content: exportContent,
+		},
+		{
+			Path:    "/api/v1/routeservers/:id/neighbors/:neighborId/routes/export",
+			ID:      "exportNeighborRoutes",
+			Summary: "All routes of the neighbor",
+			Tag:     "exports",
+			Params: append([]*openAPIParam{
+				paramSourceID, paramNeighborID,
+			}, exportParams...),
+			Content: exportContent,
+		},
+		{
+			Path:    "/api/v1/lookup/prefix/export",
+			ID:      "exportLookupPrefix",
+			Summary: "All routes matching the prefix or search query",
+			Tag:     "exports",
+			Params: append([]*openAPIParam{
+				queryParam("q", "string", "Prefix, neighbor ASN or description"),
+			}, exportParams...),
+			Content: exportContent,
+		},
+	}...)
+}
+
+// openAPIPath converts the httprouter path to
+// an OpenAPI path template.
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// openAPIDocument creates the OpenAPI document with
+// the schemas derived from the response types.
+func (s *Server) openAPIDocument() map[string]any {
+	schemas := newOpenAPISchemas()
+	errorResponse := func(e any) map[string]any {
+		if e == nil {
+			e = api.ErrorResponse{}
+		}
+		return map[string]any{
+			"description": "Error",
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": schemas.Schema(reflect.TypeOf(e)),
+				},
+			},
+		}
+	}
+
+	paths := map[string]any{}
+	for _, e := range s.openAPIEndpoints() {
+		params := make([]any, 0, len(e.Params))
+		for _, p := range e.Params {
+			params = append(params, map[string]any{
+				"name":        p.Name,
+				"in":          p.In,
+				"description": p.Description,
+				"required":    p.Required,
+				"schema":      map[string]any{"type": p.Type},
+			})
+		}
+
+		content := map[string]any{}
+		if e.Response != nil {
+			content["application/json"] = map[string]any{
+				"schema": schemas.Schema(reflect.TypeOf(e.Response)),
+			}
+		}
+		for contentType, schema := range e.Content {
+			content[contentType] = map[string]any{
+				"schema": schema,
+			}
+		}
+
+		operation := map[string]any{
+			"operationId": e.ID,
+			"summary":     e.Summary,
+			"tags":        []string{e.Tag},
+			"parameters":  params,
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content":     content,
+				},
+				"default": errorResponse(e.Error),
+			},
+		}
+		if e.RequestBody != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{
+						"schema": schemas.Schema(reflect.TypeOf(e.RequestBody)),
+					},
+				},
+			}
+		}
+
+		method := e.Method
+		if method == "" {
+			method = "get"
+		}
+		path := openAPIPath(e.Path)
+		item, ok := paths[path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[method] = operation
+	}
+
+	return map[string]any{
+		"openapi": OpenAPIVersion,
+		"info": map[string]any{
+			"title":   "Alice-LG API",
+			"version": config.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+		},
+	}
+}
+
+// Handle OpenAPI document
+func (s *Server) apiOpenAPIShow(
+	_ctx context.Context,
+	_req *http.Request,
+	_params httprouter.Params,
+) (response, error) {
+	s.openAPIOnce.Do(func() {
+		s.openAPI = s.openAPIDocument()
+	})
+	return s.openAPI, nil
+}
@@ -0,0 +1,215 @@
+package http
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Types with a custom JSON encoding or a special
+// representation in the schema.
+var openAPISchemaOverrides = map[reflect.Type]map[string]any{
+	reflect.TypeOf(time.Time{}): {
+		"type":   "string",
+		"format": "date-time",
+	},
+	reflect.TypeOf(time.Duration(0)): {
+		"type":        "integer",
+		"format":      "int64",
+		"description": "Duration in nanoseconds",
+	},
+	reflect.TypeOf(json.RawMessage{}): {},
+	reflect.TypeOf(api.IntRange{}): {
+		"type":        "string",
+		"description": "Range of integers, e.g. 16-24",
+	},
+	reflect.TypeOf(api.SearchQuery{}): {
+		"type":        "string",
+		"description": "Search query",
+	},
+}
+
+// openAPISchemas creates JSON schemas from go types.
+// Named structs are added to the components and
+// referenced.
+type openAPISchemas struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newOpenAPISchemas() *openAPISchemas {
+	return &openAPISchemas{
+		components: map[string]any{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// componentName derives the name of the component from
+// the type. Types outside of the api and http packages
+// are prefixed with the package name.
+func (s *openAPISchemas) componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if strings.HasSuffix(pkg, "/pkg/api") || strings.HasSuffix(pkg, "/pkg/http") {
+		return t.Name()
+	}
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
+
+// nullable allows null as value
+func nullable(schema map[string]any) map[string]any {
+	if t, ok := schema["type"].(string); ok {
+		schema["type"] = []string{t, "null"}
+		return schema
+	}
+	return map[string]any{
+		"anyOf": []any{schema, map[string]any{"type": "null"}},
+	}
+}
+
+// Schema returns the schema for a type
+func (s *openAPISchemas) Schema(t reflect.Type) map[string]any {
+	if override, ok := openAPISchemaOverrides[t]; ok {
+		schema := make(map[string]any, len(override))
+		for k, v := range override {
+			schema[k] = v
+		}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.Schema(t.Elem()))
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Interface:
+		return map[string]any{}
+	case reflect.Slice, reflect.Array:
+		return nullable(map[string]any{
+			"type":  "array",
+			"items": s.Schema(t.Elem()),
+		})
+	case reflect.Map:
+		return nullable(map[string]any{
+			"type":                 "object",
+			"additionalProperties": s.Schema(t.Elem()),
+		})
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return s.ref(t)
+	}
+	return map[string]any{}
+}
+
+// ref adds the struct to the components
+func (s *openAPISchemas) ref(t reflect.Type) map[string]any {
+	name, ok := s.names[t]
+	if !ok {
+		name = s.componentName(t)
+		s.names[t] = name
+		s.components[name] = nil // Reserve for recursive types
+		s.components[name] = s.structSchema(t)
+	}
+	return map[string]any{
+		"$ref": "#/components/schemas/" + name,
+	}
+}
+
+// structSchema creates an object schema with the fields
+// encoded by encoding/json. Fields of embedded structs
+// are inlined, the shallowest field wins.
+func (s *openAPISchemas) structSchema(t reflect.Type) map[string]any {
+	fields := map[string]*openAPIField{}
+	s.collectFields(t, 0, fields)
+
+	properties := map[string]any{}
+	required := []string{}
+	for name, field := range fields {
+		if field.conflict {
+			continue // Dropped by encoding/json
+		}
+		properties[name] = field.schema
+		if field.required {
+			required = append(required, name)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// openAPIField is a json encoded struct field
+type openAPIField struct {
+	depth    int
+	schema   map[string]any
+	required bool
+	conflict bool
+}
+
+func (s *openAPISchemas) collectFields(
+	t reflect.Type,
+	depth int,
+	fields map[string]*openAPIField,
+) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.collectFields(ft, depth+1, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prev, ok := fields[name]
+		if ok && prev.depth < depth {
+			continue
+		}
+		if ok && prev.depth == depth {
+			prev.conflict = true
+			continue
+		}
+		fields[name] = &openAPIField{
+			depth:    depth,
+			schema:   s.Schema(field.Type),
+			required: !strings.Contains(opts, "omitempty"),
+		}
+	}
+}
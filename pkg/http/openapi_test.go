@@ -0,0 +1,515 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-ini/ini"
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+	"github.com/alice-lg/alice-lg/pkg/sources"
+	"github.com/alice-lg/alice-lg/pkg/store"
+	"github.com/alice-lg/alice-lg/pkg/store/backends/memory"
+	"github.com/alice-lg/alice-lg/pkg/store/testdata"
+)
+
+// validateSchema is a minimal JSON schema validator,
+// supporting the keywords used by the generated schemas.
+func validateSchema(
+	doc map[string]any,
+	schema map[string]any,
+	value any,
+	path string,
+) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		components := doc["components"].(map[string]any)["schemas"]
+		target, ok := components.(map[string]any)[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown ref %s", path, ref)
+		}
+		return validateSchema(doc, target.(map[string]any), value, path)
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		for _, s := range anyOf {
+			if validateSchema(doc, s.(map[string]any), value, path) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s: %v matches none of the schemas", path, value)
+	}
+
+	types := []string{}
+	switch t := schema["type"].(type) {
+	case nil:
+		return nil // Any value
+	case string:
+		types = append(types, t)
+	case []string:
+		types = append(types, t...)
+	case []any:
+		for _, v := range t {
+			types = append(types, v.(string))
+		}
+	}
+
+	var err error
+	for _, t := range types {
+		err = validateSchemaType(doc, schema, t, value, path)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func validateSchemaType(
+	doc map[string]any,
+	schema map[string]any,
+	typ string,
+	value any,
+	path string,
+) error {
+	switch typ {
+	case "null":
+		if value != nil {
+			return fmt.Errorf("%s: expected null, got %v", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected boolean, got %v", path, value)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected string, got %v", path, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected number, got %v", path, value)
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected integer, got %v", path, value)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			return fmt.Errorf("%s: expected array, got %v", path, value)
+		}
+		itemSchema, _ := schema["items"].(map[string]any)
+		for i, item := range items {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if err := validateSchema(doc, itemSchema, item, p); err != nil {
+				return err
+			}
+		}
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: expected object, got %v", path, value)
+		}
+		return validateSchemaObject(doc, schema, obj, path)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", path, typ)
+	}
+	return nil
+}
+
+func validateSchemaObject(
+	doc map[string]any,
+	schema map[string]any,
+	obj map[string]any,
+	path string,
+) error {
+	properties, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if _, ok := obj[name.(string)]; !ok {
+			return fmt.Errorf("%s: missing property %s", path, name)
+		}
+	}
+
+	for key, value := range obj {
+		p := path + "." + key
+		if s, ok := properties[key]; ok {
+			if err := validateSchema(doc, s.(map[string]any), value, p); err != nil {
+				return err
+			}
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				return fmt.Errorf("%s: unexpected property", p)
+			}
+		case map[string]any:
+			if err := validateSchema(doc, additional, value, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeJSON encodes and decodes a value to get
+// the generic json representation.
+func decodeJSON(t *testing.T, value any) any {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result any
+	if err := json.Unmarshal(payload, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// testSource serves the routes of the testdata
+type testSource struct {
+	sources.Source
+}
+
+func (testSource) RoutesReceived(
+	context.Context, string,
+) (*api.RoutesResponse, error) {
+	res := testdata.RoutesResponse()
+	res.Filtered = api.Routes{}
+	return res, nil
+}
+
+func (testSource) RoutesFiltered(
+	context.Context, string,
+) (*api.RoutesResponse, error) {
+	res := testdata.RoutesResponse()
+	res.Imported = api.Routes{}
+	return res, nil
+}
+
+func (testSource) RoutesNotExported(
+	context.Context, string,
+) (*api.RoutesResponse, error) {
+	res := testdata.RoutesResponse()
+	res.NotExported = res.Imported
+	res.Imported = api.Routes{}
+	res.Filtered = api.Routes{}
+	return res, nil
+}
+
+func init() {
+	sources.Register(&sources.Backend{
+		Name: "http-test",
+		Type: "test",
+		Decode: func(*sources.Settings, *ini.Section) (any, error) {
+			return nil, nil
+		},
+		New: func(any) sources.Source {
+			return testSource{}
+		},
+	})
+}
+
+func newTestServer(t *testing.T) (*Server, *httprouter.Router) {
+	ctx := context.Background()
+	cfg, err := config.LoadConfig("../config/testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	neighborsBackend := memory.NewNeighborsBackend()
+	routesBackend := memory.NewRoutesBackend()
+	sourceID := cfg.Sources[0].ID
+	neighborsBackend.SetNeighbors(ctx, sourceID, api.Neighbors{
+		&api.Neighbor{
+			ID:            "ID163_AS31078",
+			RouteServerID: sourceID,
+			ASN:           31078,
+			Description:   "Netnod",
+			State:         "up",
+		},
+	})
+	routesBackend.SetRoutes(ctx, sourceID,
+		testdata.LoadTestLookupRoutes(sourceID, cfg.Sources[0].Name))
+
+	neighborsStore := store.NewNeighborsStore(cfg, neighborsBackend)
+	routesStore := store.NewRoutesStore(neighborsStore, cfg, routesBackend)
+
+	// The neighbors were stored without a refresh
+	status, err := neighborsStore.GetStatus(sourceID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status.Initialized = true
+	s := NewServer(cfg, nil, routesStore, neighborsStore, nil)
+
+	router := httprouter.New()
+	if err := s.apiRegisterEndpoints(router); err != nil {
+		t.Fatal(err)
+	}
+	return s, router
+}
+
+// openAPIResponseSchema gets the json schema of
+// the response of the operation.
+func openAPIResponseSchema(
+	t *testing.T,
+	doc map[string]any,
+	path string,
+) map[string]any {
+	item, ok := doc["paths"].(map[string]any)[path]
+	if !ok {
+		t.Fatal("path not documented:", path)
+	}
+	op := item.(map[string]any)["get"].(map[string]any)
+	res := op["responses"].(map[string]any)["200"].(map[string]any)
+	content := res["content"].(map[string]any)["application/json"]
+	return content.(map[string]any)["schema"].(map[string]any)
+}
+
+func TestOpenAPIHandlerResponses(t *testing.T) {
+	s, _ := newTestServer(t)
+	sourceID := s.Config().Sources[0].ID
+	neighborParams := httprouter.Params{
+		{Key: "id", Value: sourceID},
+		{Key: "neighborId", Value: "ID163_AS31078"},
+	}
+
+	// The routes of the neighbors are served by the source
+	s.Config().Sources[0].Backend = "http-test"
+
+	// Use the generic representation of the document
+	doc := decodeJSON(t, s.openAPIDocument()).(map[string]any)
+
+	tests := []struct {
+		path    string
+		handler apiEndpoint
+		query   string
+		params  httprouter.Params
+	}{
+		{"/api/v1/status", s.apiStatusShow, "", nil},
+		{"/api/v1/config", s.apiConfigShow, "", nil},
+		{"/api/v1/statistics", s.apiStatisticsShow, "top=5", nil},
+		{"/api/v1/alerts", s.apiAlertsList, "", nil},
+		{"/api/v1/routeservers", s.apiRouteServersList, "", nil},
+		{
+			"/api/v1/routeservers/{id}/neighbors",
+			s.apiNeighborsList, "",
+			httprouter.Params{{Key: "id", Value: sourceID}},
+		},
+		{
+			"/api/v1/routeservers/{id}/neighbors/{neighborId}/routes/received",
+			s.apiRoutesListReceived, "", neighborParams,
+		},
+		{
+			"/api/v1/routeservers/{id}/neighbors/{neighborId}/routes/filtered",
+			s.apiRoutesListFiltered, "", neighborParams,
+		},
+		{
+			"/api/v1/routeservers/{id}/neighbors/{neighborId}/routes/not-exported",
+			s.apiRoutesListNotExported, "", neighborParams,
+		},
+		{
+			"/api/v1/routeservers/{id}/neighbors/{neighborId}/routes/changes",
+			s.apiRoutesListChanges, "", neighborParams,
+		},
+		{
+			"/api/v1/lookup/prefix", s.apiLookupPrefixGlobal,
+			"q=193.200", nil,
+		},
+		{
+			"/api/v1/lookup/neighbors", s.apiLookupNeighborsGlobal,
+			"asn=31078", nil,
+		},
+		{
+			"/api/v2/lookup/prefix", s.apiLookupPrefixPage,
+			"q=193.200&sort=age", nil,
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+		result, err := tt.handler(context.Background(), req, tt.params)
+		if err != nil {
+			t.Error(tt.path, err)
+			continue
+		}
+		schema := openAPIResponseSchema(t, doc, tt.path)
+		if err := validateSchema(doc, schema, decodeJSON(t, result), "$"); err != nil {
+			t.Error(tt.path, err)
+		}
+	}
+}
+
+func TestOpenAPIErrorResponse(t *testing.T) {
+	s, _ := newTestServer(t)
+	doc := decodeJSON(t, s.openAPIDocument()).(map[string]any)
+
+	item := doc["paths"].(map[string]any)["/api/v1/config"].(map[string]any)
+	op := item["get"].(map[string]any)
+	res := op["responses"].(map[string]any)["default"].(map[string]any)
+	content := res["content"].(map[string]any)["application/json"]
+	schema := content.(map[string]any)["schema"].(map[string]any)
+
+	result, _ := apiErrorResponse("rs1", ErrSourceNotFound)
+	if err := validateSchema(doc, schema, decodeJSON(t, result), "$"); err != nil {
+		t.Error(err)
+	}
+}
+
+// registeredRoutes walks the trees of the router, as
+// httprouter does not list the registered routes.
+func registeredRoutes(router *httprouter.Router) map[string][]string {
+	routes := map[string][]string{}
+	var walk func(method, prefix string, n reflect.Value)
+	walk = func(method, prefix string, n reflect.Value) {
+		n = n.Elem()
+		path := prefix + n.FieldByName("path").String()
+		if !n.FieldByName("handle").IsNil() {
+			routes[method] = append(routes[method], path)
+		}
+		children := n.FieldByName("children")
+		for i := 0; i < children.Len(); i++ {
+			walk(method, path, children.Index(i))
+		}
+	}
+	trees := reflect.ValueOf(router).Elem().FieldByName("trees").MapRange()
+	for trees.Next() {
+		walk(trees.Key().String(), "", trees.Value())
+	}
+	return routes
+}
+
+func TestOpenAPIPathsRegistered(t *testing.T) {
+	s, router := newTestServer(t)
+	s.registerHealthChecks(router)
+	if err := s.registerMetrics(context.Background(), router); err != nil {
+		t.Fatal(err)
+	}
+	doc := s.openAPIDocument()
+
+	paths := doc["paths"].(map[string]any)
+	for path := range paths {
+		reqPath := strings.NewReplacer(
+			"{id}", "rs1",
+			"{neighborId}", "n1",
+			"{prefix}", "10.0.0.0/24",
+		).Replace(path)
+		handler, _, _ := router.Lookup(http.MethodGet, reqPath)
+		if handler == nil {
+			t.Error("documented path is not registered:", path)
+		}
+	}
+
+	// All registered routes are documented. The endpoints
+	// below a catch all route are documented separately.
+	routes := registeredRoutes(router)
+	if len(routes[http.MethodGet]) == 0 {
+		t.Fatal("no registered routes found")
+	}
+	for method, registered := range routes {
+		for _, route := range registered {
+			if prefix, _, ok := strings.Cut(route, "*"); ok {
+				documented := slices.ContainsFunc(
+					slices.Collect(maps.Keys(paths)), func(path string) bool {
+						return strings.HasPrefix(path, openAPIPath(prefix))
+					})
+				if !documented {
+					t.Error("registered route is not documented:", route)
+				}
+				continue
+			}
+			item, ok := paths[openAPIPath(route)].(map[string]any)
+			if !ok || item[strings.ToLower(method)] == nil {
+				t.Error("registered route is not documented:", method, route)
+			}
+		}
+	}
+
+	// The routes endpoints accept all search filters
+	params := s.openAPIEndpoints()
+	for _, e := range params {
+		if e.ID != "listRoutesReceived" {
+			continue
+		}
+		for _, group := range *api.NewSearchFilters() {
+			found := false
+			for _, p := range e.Params {
+				found = found || p.Name == group.Key
+			}
+			if !found {
+				t.Error("filter not documented:", group.Key)
+			}
+		}
+	}
+}
+
+func TestOpenAPIFilterExamples(t *testing.T) {
+	query := url.Values{api.SearchKeyQuery: {openAPIQueryExample}}
+	if _, err := validateRouteFilters(query); err != nil {
+		t.Error("documented query example is invalid:", err)
+	}
+	if !strings.Contains(
+		openAPIFilterDescriptions[api.SearchKeyQuery], openAPIQueryExample) {
+		t.Error("query example is not documented")
+	}
+
+	// The documented values of the otc filter are accepted
+	for _, value := range []string{"true", "false"} {
+		query := url.Values{api.SearchKeyOTC: {value}}
+		if _, err := api.FiltersFromQuery(query); err != nil {
+			t.Error("documented otc value is invalid:", value, err)
+		}
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	path := openAPIPath("/api/v1/routeservers/:id/neighbors/:neighborId/routes")
+	if path != "/api/v1/routeservers/{id}/neighbors/{neighborId}/routes" {
+		t.Error("unexpected path:", path)
+	}
+}
+
+func TestOpenAPISchemaValidation(t *testing.T) {
+	s, _ := newTestServer(t)
+	doc := decodeJSON(t, s.openAPIDocument()).(map[string]any)
+
+	schema := openAPIResponseSchema(t, doc, "/api/v1/routeservers")
+	valid := decodeJSON(t, api.RouteServersResponse{
+		RouteServers: api.RouteServers{
+			api.RouteServer{ID: "rs1", Name: "rs1"},
+		},
+	})
+	if err := validateSchema(doc, schema, valid, "$"); err != nil {
+		t.Error(err)
+	}
+
+	// Unexpected and mistyped properties must be rejected
+	invalid := []string{
+		`{"routeservers": [{"id": 1}]}`,
+		`{"routeservers": [], "unknown": true}`,
+		`{}`,
+	}
+	for _, payload := range invalid {
+		var value any
+		if err := json.Unmarshal([]byte(payload), &value); err != nil {
+			t.Fatal(err)
+		}
+		if err := validateSchema(doc, schema, value, "$"); err == nil {
+			t.Error("expected validation error for:", payload)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package http
+
+import (
+	"crypto/subtle"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/alice-lg/alice-lg/pkg/auth"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// Rate limiting
+//
+// Each client has a token bucket per endpoint class.
+// Additionally the number of lookups processed at the
+// same time is limited for all clients and the number
+// of event streams for each client.
+
+// Reasons for rejecting a request
+const (
+	rateLimitReasonRate        = "rate"
+	rateLimitReasonConcurrency = "concurrency"
+	rateLimitReasonStreams     = "streams"
+)
+
+// Idle buckets are removed after this interval
+const rateLimitSweepInterval = time.Minute
+
+var rateLimitRejections = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "api_rate_limit_rejections_total",
+		Help: "Number of requests rejected by the rate limits by endpoint class and reason",
+	},
+	[]string{"class", "reason"},
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitRejections)
+}
+
+// tokenBucket holds the tokens of a client
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter limits the requests per client and
+// the concurrency of the lookups.
+type rateLimiter struct {
+	cfg  config.RateLimitConfig
+	keys []*config.APIKeyConfig
+	now  func() time.Time
+
+	lookups chan struct{} // nil: unlimited
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	streams   map[string]int
+	lastSweep time.Time
+}
+
+// newRateLimiter creates a rate limiter. Nil is
+// returned when rate limiting is disabled. Only the
+// configured API keys identify a client.
+func newRateLimiter(
+	cfg config.RateLimitConfig,
+	keys []*config.APIKeyConfig,
+) *rateLimiter {
+	if !cfg.Enabled {
+		return nil
+	}
+	l := &rateLimiter{
+		cfg:     cfg,
+		keys:    keys,
+		now:     time.Now,
+		buckets: map[string]*tokenBucket{},
+		streams: map[string]int{},
+	}
+	if cfg.MaxConcurrentLookups > 0 {
+		l.lookups = make(chan struct{}, cfg.MaxConcurrentLookups)
+	}
+	return l
+}
+
+// allow takes a token from the bucket of the client.
+// When the bucket is empty, the time until the next
+// token is available is returned.
+func (l *rateLimiter) allow(class, client string) (bool, time.Duration) {
+	limit, ok := l.cfg.Classes[class]
+	if !ok {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key := class + "|" + client
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens: float64(limit.Burst),
+			last:   now,
+		}
+		l.buckets[key] = bucket
+	}
+
+	// Refill
+	elapsed := now.Sub(bucket.last).Seconds()
+	bucket.tokens = math.Min(
+		float64(limit.Burst), bucket.tokens+elapsed*limit.Rate)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := (1 - bucket.tokens) / limit.Rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// sweep removes the buckets which are refilled
+// completely and would be recreated as such.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		class, _, _ := strings.Cut(key, "|")
+		limit := l.cfg.Classes[class]
+		elapsed := now.Sub(bucket.last).Seconds()
+		if bucket.tokens+elapsed*limit.Rate >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// acquireLookup reserves a slot for a lookup without
+// waiting. The slot must be released.
+func (l *rateLimiter) acquireLookup() bool {
+	if l.lookups == nil {
+		return true
+	}
+	select {
+	case l.lookups <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// releaseLookup releases the slot of a lookup
+func (l *rateLimiter) releaseLookup() {
+	if l.lookups == nil {
+		return
+	}
+	<-l.lookups
+}
+
+// acquireStream reserves an event stream for the
+// client. The stream must be released.
+func (l *rateLimiter) acquireStream(client string) bool {
+	if l.cfg.MaxEventStreams == 0 {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.streams[client] >= l.cfg.MaxEventStreams {
+		return false
+	}
+	l.streams[client]++
+	return true
+}
+
+// releaseStream releases the event stream of the client
+func (l *rateLimiter) releaseStream(client string) {
+	if l.cfg.MaxEventStreams == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.streams[client]--
+	if l.streams[client] <= 0 {
+		delete(l.streams, client)
+	}
+}
+
+// client identifies the client of the request by the
+// authenticated identity, a configured API key or the
+// IP address. Unknown API keys are ignored, otherwise
+// clients could get a new bucket with each request.
+func (l *rateLimiter) client(req *http.Request) string {
+	if id := auth.IdentityFromContext(req.Context()); id != nil && id.Subject != "" {
+		return "id:" + id.Subject
+	}
+	if l.cfg.APIKeyHeader != "" {
+		if key := req.Header.Get(l.cfg.APIKeyHeader); key != "" {
+			for _, k := range l.keys {
+				if subtle.ConstantTimeCompare([]byte(k.Key), []byte(key)) == 1 {
+					return "key:" + k.ID
+				}
+			}
+		}
+	}
+	if l.cfg.TrustProxyHeaders {
+		// The rightmost address was added by the proxy,
+		// the others are provided by the client.
+		forwarded := req.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			last := forwarded[len(forwarded)-1]
+			if i := strings.LastIndex(last, ","); i >= 0 {
+				last = last[i+1:]
+			}
+			if addr := strings.TrimSpace(last); addr != "" {
+				return "ip:" + addr
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// rateLimited wraps the handler with the rate limit
+// of the endpoint class.
+func (s *Server) rateLimited(
+	class string,
+	next httprouter.Handle,
+) httprouter.Handle {
+	l := s.rateLimits
+	if l == nil {
+		return next
+	}
+	return func(
+		res http.ResponseWriter,
+		req *http.Request,
+		params httprouter.Params,
+	) {
+		if ok, wait := l.allow(class, l.client(req)); !ok {
+			rateLimitRejections.WithLabelValues(
+				class, rateLimitReasonRate).Inc()
+			writeRateLimited(res, req, params, wait)
+			return
+		}
+		if class == config.RateLimitClassLookup {
+			if !l.acquireLookup() {
+				rateLimitRejections.WithLabelValues(
+					class, rateLimitReasonConcurrency).Inc()
+				writeRateLimited(res, req, params, time.Second)
+				return
+			}
+			defer l.releaseLookup()
+		}
+		next(res, req, params)
+	}
+}
+
+// streamLimited limits the number of event streams
+// of a client at the same time.
+func (s *Server) streamLimited(next httprouter.Handle) httprouter.Handle {
+	l := s.rateLimits
+	if l == nil {
+		return next
+	}
+	return func(
+		res http.ResponseWriter,
+		req *http.Request,
+		params httprouter.Params,
+	) {
+		client := l.client(req)
+		if !l.acquireStream(client) {
+			rateLimitRejections.WithLabelValues(
+				config.RateLimitClassDefault, rateLimitReasonStreams).Inc()
+			writeRateLimited(res, req, params, EventsKeepaliveInterval)
+			return
+		}
+		defer l.releaseStream(client)
+		next(res, req, params)
+	}
+}
+
+// writeRateLimited responds with 429 Too Many Requests
+func writeRateLimited(
+	res http.ResponseWriter,
+	req *http.Request,
+	params httprouter.Params,
+	wait time.Duration,
+) {
+	seconds := max(1, int(math.Ceil(wait.Seconds())))
+	res.Header().Set("Retry-After", strconv.Itoa(seconds))
+
+	err := &ErrRateLimited{RetryAfter: time.Duration(seconds) * time.Second}
+	if strings.HasPrefix(req.URL.Path, "/api/v2/") {
+		apiWriteErrorV2(res, params, err)
+	} else {
+		apiWriteError(res, params, err)
+	}
+}
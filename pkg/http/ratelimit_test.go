@@ -0,0 +1,220 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/auth"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+func newTestRateLimiter(now *time.Time) *rateLimiter {
+	l := newRateLimiter(config.RateLimitConfig{
+		Enabled:              true,
+		APIKeyHeader:         "X-API-Key",
+		MaxConcurrentLookups: 1,
+		MaxEventStreams:      1,
+		Classes: map[string]*config.RateLimitClassConfig{
+			config.RateLimitClassLookup: {Rate: 0.5, Burst: 2},
+		},
+	}, nil)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	now := time.Date(2023, 4, 2, 12, 0, 0, 0, time.UTC)
+	l := newTestRateLimiter(&now)
+
+	for i := range 2 {
+		if ok, _ := l.allow(config.RateLimitClassLookup, "a"); !ok {
+			t.Fatal("request should be allowed:", i)
+		}
+	}
+	ok, wait := l.allow(config.RateLimitClassLookup, "a")
+	if ok || wait != 2*time.Second {
+		t.Error("request should be rejected:", ok, wait)
+	}
+
+	// Other clients and classes are not affected
+	if ok, _ := l.allow(config.RateLimitClassLookup, "b"); !ok {
+		t.Error("other client should be allowed")
+	}
+	if ok, _ := l.allow(config.RateLimitClassRoutes, "a"); !ok {
+		t.Error("routes are not limited")
+	}
+
+	// Refill
+	now = now.Add(2 * time.Second)
+	if ok, _ := l.allow(config.RateLimitClassLookup, "a"); !ok {
+		t.Error("request should be allowed after refill")
+	}
+
+	// Full buckets are removed
+	now = now.Add(time.Hour)
+	l.allow(config.RateLimitClassLookup, "c")
+	if len(l.buckets) != 1 {
+		t.Error("unexpected buckets:", l.buckets)
+	}
+}
+
+func TestRateLimiterClient(t *testing.T) {
+	l := newRateLimiter(config.RateLimitConfig{
+		Enabled:      true,
+		APIKeyHeader: "X-API-Key",
+	}, []*config.APIKeyConfig{
+		{ID: "noc", Key: "secret"},
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:4242"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.1")
+	if c := l.client(req); c != "ip:192.0.2.1" {
+		t.Error("unexpected client:", c)
+	}
+
+	// The address appended by the proxy is used
+	l.cfg.TrustProxyHeaders = true
+	if c := l.client(req); c != "ip:203.0.113.1" {
+		t.Error("unexpected client:", c)
+	}
+	req.Header.Add("X-Forwarded-For", "198.51.100.2")
+	if c := l.client(req); c != "ip:198.51.100.2" {
+		t.Error("unexpected client:", c)
+	}
+
+	// Unknown keys are ignored
+	req.Header.Set("X-API-Key", "random")
+	if c := l.client(req); c != "ip:198.51.100.2" {
+		t.Error("unexpected client:", c)
+	}
+	req.Header.Set("X-API-Key", "secret")
+	if c := l.client(req); c != "key:noc" {
+		t.Error("unexpected client:", c)
+	}
+
+	// Authenticated identity
+	req = req.WithContext(auth.ContextWithIdentity(
+		req.Context(), &auth.Identity{Subject: "member"}))
+	if c := l.client(req); c != "id:member" {
+		t.Error("unexpected client:", c)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	now := time.Now()
+	s := &Server{rateLimits: newTestRateLimiter(&now)}
+
+	release := make(chan struct{})
+	handler := s.rateLimited(config.RateLimitClassLookup,
+		func(res http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+			<-release
+			res.WriteHeader(http.StatusOK)
+		})
+
+	rejected := func(reason string) float64 {
+		return testutil.ToFloat64(rateLimitRejections.WithLabelValues(
+			config.RateLimitClassLookup, reason))
+	}
+	rejectedRate := rejected(rateLimitReasonRate)
+	rejectedConcurrency := rejected(rateLimitReasonConcurrency)
+
+	// A lookup is in progress
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(),
+			httptest.NewRequest("GET", "/api/v1/lookup/prefix", nil), nil)
+		close(done)
+	}()
+	for len(s.rateLimits.lookups) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	res := httptest.NewRecorder()
+	handler(res, httptest.NewRequest("GET", "/api/v1/lookup/prefix", nil), nil)
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatal("unexpected status:", res.Code)
+	}
+	if res.Header().Get("Retry-After") != "1" {
+		t.Error("unexpected retry after:", res.Header().Get("Retry-After"))
+	}
+	if rejected(rateLimitReasonConcurrency) != rejectedConcurrency+1 {
+		t.Error("the rejection should be counted")
+	}
+	close(release)
+	<-done
+
+	// The bucket is empty
+	res = httptest.NewRecorder()
+	handler(res, httptest.NewRequest("GET", "/api/v2/lookup/prefix", nil), nil)
+	if res.Code != http.StatusTooManyRequests {
+		t.Fatal("unexpected status:", res.Code)
+	}
+	if res.Header().Get("Retry-After") != "2" {
+		t.Error("unexpected retry after:", res.Header().Get("Retry-After"))
+	}
+	if rejected(rateLimitReasonRate) != rejectedRate+1 {
+		t.Error("the rejection should be counted")
+	}
+	result := &api.ErrorEnvelope{}
+	if err := json.Unmarshal(res.Body.Bytes(), result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Error.Code != "rate_limited" {
+		t.Error("unexpected error:", result.Error)
+	}
+}
+
+func TestStreamLimited(t *testing.T) {
+	now := time.Now()
+	s := &Server{rateLimits: newTestRateLimiter(&now)}
+
+	release := make(chan struct{})
+	handler := s.streamLimited(
+		func(res http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+			<-release
+		})
+	request := func(addr string) *http.Request {
+		req := httptest.NewRequest("GET", "/api/v1/events/neighbors", nil)
+		req.RemoteAddr = addr
+		return req
+	}
+
+	// A stream of the client is open
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(), request("192.0.2.1:1234"), nil)
+		close(done)
+	}()
+	for !func() bool {
+		s.rateLimits.mu.Lock()
+		defer s.rateLimits.mu.Unlock()
+		return s.rateLimits.streams["ip:192.0.2.1"] == 1
+	}() {
+		time.Sleep(time.Millisecond)
+	}
+
+	res := httptest.NewRecorder()
+	handler(res, request("192.0.2.1:2345"), nil)
+	if res.Code != http.StatusTooManyRequests {
+		t.Error("unexpected status:", res.Code)
+	}
+
+	// Other clients are not limited
+	if !s.rateLimits.acquireStream("ip:192.0.2.2") {
+		t.Error("expected a stream for another client")
+	}
+	s.rateLimits.releaseStream("ip:192.0.2.2")
+
+	close(release)
+	<-done
+	if !s.rateLimits.acquireStream("ip:192.0.2.1") {
+		t.Error("the stream should be released")
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/auth"
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// ReloadConfig reads the configuration file again and
+// applies the changes: Sources are added, removed or
+// reconfigured, unchanged sources keep their data.
+// The UI settings are replaced. Changes of the sections
+// listed by RestartRequired are ignored until the next
+// restart.
+func (s *Server) ReloadConfig(
+	ctx context.Context,
+) (*api.ConfigReloadResponse, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	current := s.Config()
+	next, err := config.LoadConfig(current.File)
+	if err != nil {
+		return nil, err
+	}
+
+	restart := current.RestartRequired(next)
+	if len(restart) > 0 {
+		log.Println("[reload] changes of",
+			strings.Join(restart, ", "), "require a restart")
+		next.Server = current.Server
+		next.Postgres = current.Postgres
+		next.RateLimit = current.RateLimit
+		next.Auth = current.Auth
+		next.Alerts = current.Alerts
+		next.Tracing = current.Tracing
+	}
+
+	diff := config.DiffSources(current, next)
+	if err := s.neighborsStore.Reconfigure(ctx, next, diff); err != nil {
+		return nil, err
+	}
+	if err := s.routesStore.Reconfigure(ctx, next, diff); err != nil {
+		return nil, err
+	}
+	s.cfg.Store(next)
+
+	// The filter reasons are part of the UI settings
+	if s.alerts != nil {
+		s.alerts.SetReasons(next.UI.RoutesRejections.Reasons)
+	}
+
+	log.Println("[reload] configuration reloaded from", next.File)
+
+	return &api.ConfigReloadResponse{
+		Added:           sourceIDs(diff.Added),
+		Removed:         sourceIDs(diff.Removed),
+		Changed:         sourceIDs(diff.Changed),
+		RestartRequired: restart,
+	}, nil
+}
+
+// sourceIDs returns the ids of the sources
+func sourceIDs(sources []*config.SourceConfig) []string {
+	ids := make([]string, 0, len(sources))
+	for _, src := range sources {
+		ids = append(ids, src.ID)
+	}
+	return ids
+}
+
+// Reload the configuration. The endpoint is restricted
+// to direct clients on the loopback interface and identities
+// authorized for all ASNs.
+func (s *Server) apiConfigReload(
+	ctx context.Context,
+	req *http.Request,
+	_ httprouter.Params,
+) (response, error) {
+	if !canReload(ctx, req) {
+		return nil, ErrForbidden
+	}
+	return s.ReloadConfig(ctx)
+}
+
+// Headers added by reverse proxies. Behind a proxy on the
+// same host, all clients connect from the loopback interface.
+var proxyHeaders = []string{
+	"Forwarded",
+	"X-Forwarded-For",
+	"X-Real-Ip",
+}
+
+// canReload checks if the client is allowed to
+// reload the configuration. Requests forwarded by
+// a proxy must be authenticated.
+func canReload(ctx context.Context, req *http.Request) bool {
+	if id := auth.IdentityFromContext(ctx); id != nil && id.All {
+		return true
+	}
+	for _, h := range proxyHeaders {
+		if req.Header.Get(h) != "" {
+			return false
+		}
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
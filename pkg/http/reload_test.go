@@ -0,0 +1,108 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/auth"
+)
+
+func TestReloadConfig(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestServer(t)
+
+	data, err := os.ReadFile("../config/testdata/alice.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.NewReplacer(
+		"name = rs2.example.com", "name = rs2.example.net",
+		"listen_http = 127.0.0.1:7340", "listen_http = 127.0.0.1:7341",
+	).Replace(string(data)) + "\n[tracing]\nservice_name = alice-next\n")
+	filename := filepath.Join(t.TempDir(), "alice.conf")
+	if err := os.WriteFile(filename, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := s.Config()
+	prev.File = filename
+	instance := prev.Sources[0].GetInstance()
+
+	res, err := s.ReloadConfig(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(res.Changed, []string{"rs2-example"}) {
+		t.Error("unexpected changed sources:", res.Changed)
+	}
+	if len(res.Added) != 0 || len(res.Removed) != 0 {
+		t.Error("unexpected added or removed sources:", res)
+	}
+	if !slices.Equal(res.RestartRequired, []string{"server", "tracing"}) {
+		t.Error("unexpected restart required:", res.RestartRequired)
+	}
+
+	cfg := s.Config()
+	if cfg == prev {
+		t.Fatal("expected the configuration to be replaced")
+	}
+	if cfg.Server.Listen != "127.0.0.1:7340" {
+		t.Error("server section should be kept:", cfg.Server.Listen)
+	}
+	if cfg.Tracing.ServiceName != prev.Tracing.ServiceName {
+		t.Error("tracing section should be kept:", cfg.Tracing.ServiceName)
+	}
+	if cfg.SourceByID("rs2-example").Name != "rs2.example.net" {
+		t.Error("source was not reconfigured")
+	}
+	if cfg.Sources[0].GetInstance() != instance {
+		t.Error("unchanged source should keep the instance")
+	}
+
+	// The routes of the unchanged source are kept
+	routes, _, err := s.lookupPrefixRoutes(ctx, url.Values{"q": {"193.200"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) == 0 {
+		t.Error("expected the routes to be kept")
+	}
+}
+
+func TestCanReload(t *testing.T) {
+	ctx := context.Background()
+
+	req := httptest.NewRequest("POST", "/api/v1/admin/reload", nil)
+	req.RemoteAddr = "127.0.0.1:4242"
+	if !canReload(ctx, req) {
+		t.Error("loopback clients should be allowed")
+	}
+
+	// Behind a reverse proxy on the same host
+	for _, h := range []string{"X-Forwarded-For", "Forwarded", "X-Real-IP"} {
+		proxied := req.Clone(ctx)
+		proxied.Header.Set(h, "192.0.2.1")
+		if canReload(ctx, proxied) {
+			t.Error("proxied clients should be denied:", h)
+		}
+	}
+
+	req.RemoteAddr = "192.0.2.1:4242"
+	if canReload(ctx, req) {
+		t.Error("remote clients should be denied")
+	}
+	member := auth.ContextWithIdentity(ctx, &auth.Identity{ASNs: []int{2342}})
+	if canReload(member, req) {
+		t.Error("members should be denied")
+	}
+	admin := auth.ContextWithIdentity(ctx, &auth.Identity{All: true})
+	if !canReload(admin, req) {
+		t.Error("identities authorized for all ASNs should be allowed")
+	}
+}
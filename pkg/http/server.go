@@ -6,11 +6,15 @@ import (
 	"context"
 	"log"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/julienschmidt/httprouter"
 
+	"github.com/alice-lg/alice-lg/pkg/alerts"
+	"github.com/alice-lg/alice-lg/pkg/auth"
 	"github.com/alice-lg/alice-lg/pkg/config"
 	"github.com/alice-lg/alice-lg/pkg/store"
 )
@@ -19,10 +23,23 @@ import (
 // and the assets.
 type Server struct {
 	*http.Server
-	cfg            *config.Config
+	cfg            atomic.Pointer[config.Config]
 	routesStore    *store.RoutesStore
 	neighborsStore *store.NeighborsStore
+	alerts         *alerts.Engine
 	pool           *pgxpool.Pool
+	rateLimits     *rateLimiter
+	authenticator  *auth.Authenticator
+	routesMeta     *neighborRoutesMeta
+
+	openAPI     map[string]any
+	openAPIOnce sync.Once
+
+	reloadMu sync.Mutex
+
+	// stopping is closed when the server shuts down,
+	// ending the event streams.
+	stopping chan struct{}
 }
 
 // NewServer creates a new server
@@ -31,17 +48,31 @@ func NewServer(
 	pool *pgxpool.Pool,
 	routesStore *store.RoutesStore,
 	neighborsStore *store.NeighborsStore,
+	alerts *alerts.Engine,
 ) *Server {
-	return &Server{
-		cfg:            cfg,
+	s := &Server{
 		routesStore:    routesStore,
 		neighborsStore: neighborsStore,
+		alerts:         alerts,
 		pool:           pool,
+		rateLimits:     newRateLimiter(cfg.RateLimit, cfg.Auth.APIKeys),
+		routesMeta:     newNeighborRoutesMeta(),
+		stopping:       make(chan struct{}),
 	}
+	s.cfg.Store(cfg)
+	return s
+}
+
+// Config returns the current configuration. It is
+// replaced when the configuration is reloaded.
+func (s *Server) Config() *config.Config {
+	return s.cfg.Load()
 }
 
 // Start starts a HTTP server and begins to listen
-// on the configured port.
+// on the configured port. When the context is done, the
+// server is shut down and Start returns after the requests
+// in progress are finished.
 func (s *Server) Start(ctx context.Context) {
 	router := httprouter.New()
 
@@ -55,27 +86,56 @@ func (s *Server) Start(ctx context.Context) {
 	if err := s.registerMetrics(ctx, router); err != nil {
 		log.Fatal(err)
 	}
+	s.registerHealthChecks(router)
 
-	httpTimeout := time.Duration(s.cfg.Server.HTTPTimeout) * time.Second
+	httpTimeout := time.Duration(s.Config().Server.HTTPTimeout) * time.Second
 	log.Println("Web server HTTP timeout set to:", httpTimeout)
-	log.Println("Listening on:", s.cfg.Server.Listen)
+	log.Println("Listening on:", s.Config().Server.Listen)
 
-	if s.cfg.Server.EnablePrefixLookup {
+	if s.Config().Server.EnablePrefixLookup {
 		log.Println("Prefix Lookup (Search): enabled")
 		log.Println("Prefix Lookup Community Filter Cutoff:",
-			s.cfg.Server.PrefixLookupCommunityFilterCutoff)
+			s.Config().Server.PrefixLookupCommunityFilterCutoff)
 	} else {
 		log.Println("Prefix Lookup (Search): disabled")
 	}
 
 	s.Server = &http.Server{
-		Addr:         s.cfg.Server.Listen,
-		Handler:      router,
+		Addr:         s.Config().Server.Listen,
+		Handler:      instrumented(router, s.authenticated(router)),
 		ReadTimeout:  httpTimeout,
 		WriteTimeout: httpTimeout,
 		IdleTimeout:  httpTimeout,
 	}
 
+	s.RegisterOnShutdown(func() {
+		close(s.stopping)
+	})
+
 	// Start http server
-	log.Fatal(s.ListenAndServe())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	s.drain(ctx)
+}
+
+// drain stops accepting connections and waits for the
+// requests in progress until the shutdown timeout expires.
+// Connections still open afterwards are closed.
+func (s *Server) drain(ctx context.Context) {
+	timeout := time.Duration(s.Config().Server.ShutdownTimeout) * time.Second
+	log.Println("Shutting down web server, waiting up to", timeout, "for requests")
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		log.Println("web server shutdown incomplete:", err)
+		s.Close()
+	}
 }
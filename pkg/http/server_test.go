@@ -0,0 +1,68 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+func TestServerDrain(t *testing.T) {
+	s := &Server{}
+	s.cfg.Store(&config.Config{
+		Server: config.ServerConfig{ShutdownTimeout: 5},
+	})
+
+	started := make(chan struct{})
+	s.Server = &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			io.WriteString(w, "done")
+		}),
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Serve(l)
+
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		results <- result{string(body), err}
+	}()
+
+	// The request in progress is finished
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.drain(ctx)
+
+	r := <-results
+	if r.err != nil {
+		t.Fatal(r.err)
+	}
+	if r.body != "done" {
+		t.Error("unexpected response:", r.body)
+	}
+
+	// New connections are refused
+	if _, err := http.Get("http://" + l.Addr().String()); err == nil {
+		t.Error("expected the server to be closed")
+	}
+}
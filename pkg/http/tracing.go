@@ -0,0 +1,27 @@
+package http
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+)
+
+var tracer = otel.Tracer("github.com/alice-lg/alice-lg/pkg/http")
+
+// endpointName derives the name of the endpoint span
+// from the handler, e.g. apiRoutesListReceived.
+func endpointName(wrapped apiEndpoint) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(wrapped).Pointer())
+	if fn == nil {
+		return "endpoint"
+	}
+	name := fn.Name()
+	name = name[strings.LastIndex(name, ".")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	if name == "" || strings.HasPrefix(name, "func") {
+		return "endpoint"
+	}
+	return name
+}
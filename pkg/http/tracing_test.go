@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func (s *Server) apiTestFailing(
+	_ctx context.Context,
+	_req *http.Request,
+	_params httprouter.Params,
+) (response, error) {
+	return nil, errors.New("failed")
+}
+
+func TestEndpointName(t *testing.T) {
+	s := &Server{}
+	if name := endpointName(s.apiTestFailing); name != "apiTestFailing" {
+		t.Error("unexpected name:", name)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	s := &Server{}
+	router := httprouter.New()
+	router.GET("/api/v1/routeservers/:id/status", endpoint(s.apiTestFailing))
+	handler := instrumented(router, router)
+
+	// The trace of the client is continued
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/routeservers/rs1/status", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatal("expected 2 spans, got:", len(ended))
+	}
+	endpointSpan, serverSpan := ended[0], ended[1]
+	if serverSpan.Name() != "GET /api/v1/routeservers/:id/status" {
+		t.Error("unexpected server span:", serverSpan.Name())
+	}
+	if serverSpan.SpanContext().TraceID().String() != traceID {
+		t.Error("expected the trace to be continued")
+	}
+	if serverSpan.Status().Code != codes.Error {
+		t.Error("expected the server span to fail")
+	}
+	if endpointSpan.Name() != "apiTestFailing" {
+		t.Error("unexpected endpoint span:", endpointSpan.Name())
+	}
+	if endpointSpan.Parent().SpanID() != serverSpan.SpanContext().SpanID() {
+		t.Error("expected the endpoint span to be a child of the server span")
+	}
+	if endpointSpan.Status().Description != "failed" {
+		t.Error("unexpected status:", endpointSpan.Status())
+	}
+}
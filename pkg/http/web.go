@@ -51,7 +51,7 @@ func (s *Server) webRegisterAssets(
 	}
 	indexHTML := string(indexHTMLData)
 
-	theme := NewTheme(s.cfg.UI.Theme)
+	theme := NewTheme(s.Config().UI.Theme)
 	err = theme.RegisterThemeAssets(router)
 	if err != nil {
 		log.Println("Warning:", err)
@@ -0,0 +1,78 @@
+package birdwatcher
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// Backend is the name of the source backend
+// and of the configuration section.
+const Backend = "birdwatcher"
+
+func init() {
+	sources.Register(&sources.Backend{
+		Name:     Backend,
+		Type:     "bird",
+		Decode:   decodeConfig,
+		Validate: validateConfig,
+		Keys: append(sources.IniKeys(Config{}),
+			sources.IniKeys(sources.HTTPAuth{})...),
+		New: func(config any) sources.Source {
+			return NewBirdwatcher(*config.(*Config))
+		},
+	})
+}
+
+// decodeConfig reads the birdwatcher configuration
+// and applies the defaults.
+func decodeConfig(
+	settings *sources.Settings,
+	section *ini.Section,
+) (any, error) {
+	c := &Config{
+		ID:   settings.ID,
+		Name: settings.Name,
+
+		Timezone:        "UTC",
+		ServerTime:      "2006-01-02T15:04:05.999999999Z07:00",
+		ServerTimeShort: "2006-01-02",
+		ServerTimeExt:   "Mon, 02 Jan 2006 15:04:05 -0700",
+
+		MainTable:          "master",
+		PeerTablePrefix:    "T",
+		PipeProtocolPrefix: "M",
+
+		StreamParserThrottle: settings.StreamParserThrottle,
+	}
+	if err := section.MapTo(c); err != nil {
+		return nil, err
+	}
+	if err := section.MapTo(&c.Auth); err != nil {
+		return nil, err
+	}
+
+	log.Println("Adding birdwatcher source", c.Name, "of type", c.Type)
+	if c.Type == "multi_table" {
+		log.Println("  Peer table prefix:", c.PeerTablePrefix)
+		log.Println("  Pipe protocol prefix:", c.PipeProtocolPrefix)
+		if c.AltPipeProtocolSuffix != "" {
+			log.Println("  Alternative pipe protocol prefix:", c.AltPipeProtocolPrefix)
+			log.Println("  Alternative pipe protocol suffix:", c.AltPipeProtocolSuffix)
+		}
+	}
+	return c, nil
+}
+
+// validateConfig checks the birdwatcher type
+func validateConfig(config any) error {
+	c := config.(*Config)
+	if c.Type != "single_table" && c.Type != "multi_table" {
+		return fmt.Errorf(
+			"unknown birdwatcher type: %q", c.Type)
+	}
+	return nil
+}
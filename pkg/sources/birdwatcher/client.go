@@ -8,24 +8,35 @@ import (
 	"io"
 	"net/http"
 	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
 )
 
+var tracer = otel.Tracer("github.com/alice-lg/alice-lg/pkg/sources/birdwatcher")
+
 // ClientResponse is a json key value mapping
 type ClientResponse map[string]any
 
 // A Client uses the http client to talk
 // to the birdwatcher API.
 type Client struct {
-	api string
+	api  string
+	auth sources.HTTPAuth
 }
 
 // NewClient creates a new client instance
-func NewClient(api string) *Client {
+func NewClient(api string, auth sources.HTTPAuth) *Client {
 	// Strip trailing slashes from api base
 	api = strings.TrimSuffix(api, "/")
 
 	client := &Client{
-		api: api,
+		api:  api,
+		auth: auth,
 	}
 	return client
 }
@@ -37,14 +48,14 @@ func (c *Client) GetEndpoint(
 	ctx context.Context,
 	endpoint string,
 ) (*http.Response, error) {
-	client := &http.Client{}
 	url := c.api + endpoint
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	c.auth.Apply(req)
 
-	return client.Do(req)
+	return sources.HTTPClient.Do(req)
 }
 
 // GetJSON makes an API request.
@@ -52,7 +63,17 @@ func (c *Client) GetEndpoint(
 func (c *Client) GetJSON(
 	ctx context.Context,
 	endpoint string,
-) (ClientResponse, error) {
+) (_ ClientResponse, err error) {
+	ctx, span := tracer.Start(ctx, "birdwatcher.GetJSON",
+		trace.WithAttributes(attribute.String("alice.endpoint", endpoint)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	res, err := c.GetEndpoint(ctx, endpoint)
 	if err != nil {
 		return ClientResponse{}, err
@@ -66,6 +87,8 @@ func (c *Client) GetJSON(
 	}
 
 	// Decode json payload
+	_, decode := tracer.Start(ctx, "birdwatcher.decode")
+	defer decode.End()
 	result := make(ClientResponse)
 	err = json.Unmarshal(payload, &result)
 	if err != nil {
@@ -1,5 +1,7 @@
 package birdwatcher
 
+import "github.com/alice-lg/alice-lg/pkg/sources"
+
 // Config contains all configuration attributes
 // for a birdwatcher based source.
 type Config struct {
@@ -24,5 +26,8 @@ type Config struct {
 	AltPipeProtocolSuffix   string `ini:"alt_pipe_protocol_suffix"`
 	NeighborsRefreshTimeout int    `ini:"neighbors_refresh_timeout"`
 
+	// Auth is used for requests to the API
+	Auth sources.HTTPAuth
+
 	StreamParserThrottle int
 }
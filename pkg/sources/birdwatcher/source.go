@@ -38,7 +38,7 @@ type GenericBirdwatcher struct {
 // NewBirdwatcher creates a new Birdwatcher instance.
 // This might be either a GenericBirdWatcher or a MultiTableBirdwatcher.
 func NewBirdwatcher(config Config) Birdwatcher {
-	client := NewClient(config.API)
+	client := NewClient(config.API, config.Auth)
 
 	// Cache settings:
 	// TODO: Maybe read from config file
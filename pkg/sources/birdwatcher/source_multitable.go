@@ -134,7 +134,7 @@ func (src *MultiTableBirdwatcher) fetchReceivedRoutes(
 	defer res.Body.Close()
 
 	// Parse the routes
-	meta, routes, err := parseRoutesResponseStream(res.Body, src.config, keepDetails)
+	meta, routes, err := parseRoutesResponseStream(ctx, res.Body, src.config, keepDetails)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -183,7 +183,7 @@ func (src *MultiTableBirdwatcher) fetchPipeFilteredRoutes(
 		defer res.Body.Close()
 
 		// Parse the routes
-		m, pipeFiltered, err := parseRoutesResponseStream(res.Body, src.config, keepDetails)
+		m, pipeFiltered, err := parseRoutesResponseStream(ctx, res.Body, src.config, keepDetails)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -221,7 +221,7 @@ func (src *MultiTableBirdwatcher) fetchFilteredRoutes(
 	}
 	defer res.Body.Close()
 
-	meta, filtered, err := parseRoutesResponseStream(res.Body, src.config, keepDetails)
+	meta, filtered, err := parseRoutesResponseStream(ctx, res.Body, src.config, keepDetails)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -251,7 +251,7 @@ func (src *MultiTableBirdwatcher) fetchFilteredRoutes(
 	defer res.Body.Close()
 
 	// Parse the routes
-	_, pipeFiltered, err := parseRoutesResponseStream(res.Body, src.config, keepDetails)
+	_, pipeFiltered, err := parseRoutesResponseStream(ctx, res.Body, src.config, keepDetails)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -304,7 +304,7 @@ func (src *MultiTableBirdwatcher) fetchNotExportedRoutes(
 	defer res.Body.Close()
 
 	// Parse the routes
-	meta, routes, err := parseRoutesResponseStream(res.Body, src.config, true)
+	meta, routes, err := parseRoutesResponseStream(ctx, res.Body, src.config, true)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -333,7 +333,7 @@ func (src *MultiTableBirdwatcher) fetchRequiredRoutes(
 	defer src.routesFetchMutex.Unlock(neighborID)
 
 	// Check if we have a cache hit
-	response := src.routesRequiredCache.Get(neighborID)
+	response := src.routesRequiredCache.Get(ctx, neighborID)
 	if response != nil {
 		return response, nil
 	}
@@ -620,7 +620,7 @@ func (src *MultiTableBirdwatcher) Neighbors(
 	ctx context.Context,
 ) (*api.NeighborsResponse, error) {
 	// Check if we hit the cache
-	response := src.neighborsCache.Get()
+	response := src.neighborsCache.Get(ctx)
 	if response != nil {
 		return response, nil
 	}
@@ -701,7 +701,7 @@ func (src *MultiTableBirdwatcher) RoutesReceived(
 	response := &api.RoutesResponse{}
 
 	// Check if we have a cache hit
-	cachedRoutes := src.routesRequiredCache.Get(neighborID)
+	cachedRoutes := src.routesRequiredCache.Get(ctx, neighborID)
 	if cachedRoutes != nil {
 		response.Response.Meta = cachedRoutes.Response.Meta
 		response.Imported = cachedRoutes.Imported
@@ -735,7 +735,7 @@ func (src *MultiTableBirdwatcher) RoutesFiltered(
 	response := &api.RoutesResponse{}
 
 	// Check if we have a cache hit
-	cachedRoutes := src.routesRequiredCache.Get(neighborID)
+	cachedRoutes := src.routesRequiredCache.Get(ctx, neighborID)
 	if cachedRoutes != nil {
 		response.Meta = cachedRoutes.Meta
 		response.Filtered = cachedRoutes.Filtered
@@ -767,7 +767,7 @@ func (src *MultiTableBirdwatcher) RoutesNotExported(
 	neighborID string,
 ) (*api.RoutesResponse, error) {
 	// Check if we have a cache hit
-	response := src.routesNotExportedCache.Get(neighborID)
+	response := src.routesNotExportedCache.Get(ctx, neighborID)
 	if response != nil {
 		return response, nil
 	}
@@ -878,7 +878,7 @@ func (src *MultiTableBirdwatcher) AllRoutes(
 	}
 	defer res.Body.Close()
 
-	meta, imported, err := parseRoutesResponseStream(res.Body, src.config, false)
+	meta, imported, err := parseRoutesResponseStream(ctx, res.Body, src.config, false)
 	if err != nil {
 		return nil, err
 	}
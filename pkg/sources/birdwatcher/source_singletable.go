@@ -22,7 +22,7 @@ func (src *SingleTableBirdwatcher) fetchReceivedRoutes(
 	}
 	defer res.Body.Close()
 
-	meta, routes, err := parseRoutesResponseStream(res.Body, src.config, false)
+	meta, routes, err := parseRoutesResponseStream(ctx, res.Body, src.config, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -42,7 +42,7 @@ func (src *SingleTableBirdwatcher) fetchFilteredRoutes(
 	}
 	defer res.Body.Close()
 
-	meta, routes, err := parseRoutesResponseStream(res.Body, src.config, false)
+	meta, routes, err := parseRoutesResponseStream(ctx, res.Body, src.config, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -62,7 +62,7 @@ func (src *SingleTableBirdwatcher) fetchNotExportedRoutes(
 	}
 	defer res.Body.Close()
 
-	meta, routes, err := parseRoutesResponseStream(res.Body, src.config, false)
+	meta, routes, err := parseRoutesResponseStream(ctx, res.Body, src.config, false)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -90,7 +90,7 @@ func (src *SingleTableBirdwatcher) fetchRequiredRoutes(
 	defer src.routesFetchMutex.Unlock(neighborID)
 
 	// Check if we have a cache hit
-	response := src.routesRequiredCache.Get(neighborID)
+	response := src.routesRequiredCache.Get(ctx, neighborID)
 	if response != nil {
 		return response, nil
 	}
@@ -136,7 +136,7 @@ func (src *SingleTableBirdwatcher) Neighbors(
 	ctx context.Context,
 ) (*api.NeighborsResponse, error) {
 	// Check if we hit the cache
-	response := src.neighborsCache.Get()
+	response := src.neighborsCache.Get(ctx)
 	if response != nil {
 		return response, nil
 	}
@@ -213,7 +213,7 @@ func (src *SingleTableBirdwatcher) RoutesReceived(
 	response := &api.RoutesResponse{}
 
 	// Check if we hit the cache
-	cachedRoutes := src.routesRequiredCache.Get(neighborID)
+	cachedRoutes := src.routesRequiredCache.Get(ctx, neighborID)
 	if cachedRoutes != nil {
 		response.Meta = cachedRoutes.Meta
 		response.Imported = cachedRoutes.Imported
@@ -241,7 +241,7 @@ func (src *SingleTableBirdwatcher) RoutesFiltered(
 	response := &api.RoutesResponse{}
 
 	// Check if we hit the cache
-	cachedRoutes := src.routesRequiredCache.Get(neighborID)
+	cachedRoutes := src.routesRequiredCache.Get(ctx, neighborID)
 	if cachedRoutes != nil {
 		response.Meta = cachedRoutes.Meta
 		response.Filtered = cachedRoutes.Filtered
@@ -267,7 +267,7 @@ func (src *SingleTableBirdwatcher) RoutesNotExported(
 	neighborID string,
 ) (*api.RoutesResponse, error) {
 	// Check if we hit the cache
-	response := src.routesNotExportedCache.Get(neighborID)
+	response := src.routesNotExportedCache.Get(ctx, neighborID)
 	if response != nil {
 		return response, nil
 	}
@@ -303,7 +303,7 @@ func (src *SingleTableBirdwatcher) fetchAllRoutesTable(
 	}
 	defer res.Body.Close()
 
-	meta, birdImported, err := parseRoutesResponseStream(res.Body, src.config, false)
+	meta, birdImported, err := parseRoutesResponseStream(ctx, res.Body, src.config, false)
 	if err != nil {
 		return nil, err
 	}
@@ -315,7 +315,7 @@ func (src *SingleTableBirdwatcher) fetchAllRoutesTable(
 	}
 	defer res.Body.Close()
 
-	_, birdFiltered, err := parseRoutesResponseStream(res.Body, src.config, false)
+	_, birdFiltered, err := parseRoutesResponseStream(ctx, res.Body, src.config, false)
 	if err != nil {
 		return nil, err
 	}
@@ -1,14 +1,39 @@
 package birdwatcher
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
 	"github.com/alice-lg/alice-lg/pkg/api"
 )
 
+// parseRoutesResponseStream decodes the routes while
+// reading the response of the birdwatcher.
 func parseRoutesResponseStream(
+	ctx context.Context,
+	body io.Reader,
+	config Config,
+	keepDetails bool,
+) (*api.Meta, api.Routes, error) {
+	_, span := tracer.Start(ctx, "birdwatcher.decode_routes")
+	defer span.End()
+
+	meta, routes, err := decodeRoutesStream(body, config, keepDetails)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, nil, err
+	}
+	span.SetAttributes(attribute.Int("alice.routes", len(routes)))
+	return meta, routes, nil
+}
+
+func decodeRoutesStream(
 	body io.Reader,
 	config Config,
 	keepDetails bool,
@@ -1,6 +1,7 @@
 package birdwatcher
 
 import (
+	"context"
 	"os"
 	"testing"
 )
@@ -17,7 +18,7 @@ func TestParseRoutesResponseStream(t *testing.T) {
 		ServerTime: "2006-01-02T15:04:05.999999999Z07:00",
 	}
 
-	meta, routes, err := parseRoutesResponseStream(f, cfg, false)
+	meta, routes, err := parseRoutesResponseStream(context.Background(), f, cfg, false)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,42 @@
+package gobgp
+
+import (
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// Backend is the name of the source backend
+// and of the configuration section.
+const Backend = "gobgp"
+
+func init() {
+	sources.Register(&sources.Backend{
+		Name:   Backend,
+		Type:   "gobgp",
+		Decode: decodeConfig,
+		Keys:   sources.IniKeys(Config{}),
+		New: func(config any) sources.Source {
+			return NewGoBGP(*config.(*Config))
+		},
+	})
+}
+
+// decodeConfig reads the GoBGP configuration
+// and applies the defaults.
+func decodeConfig(
+	settings *sources.Settings,
+	section *ini.Section,
+) (any, error) {
+	c := &Config{
+		ID:   settings.ID,
+		Name: settings.Name,
+	}
+	if err := section.MapTo(c); err != nil {
+		return nil, err
+	}
+	if c.ProcessingTimeout == 0 {
+		c.ProcessingTimeout = 300
+	}
+	return c, nil
+}
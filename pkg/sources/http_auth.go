@@ -0,0 +1,23 @@
+package sources
+
+import "net/http"
+
+// HTTPAuth configures the authentication of the requests
+// to the API of a source. A bearer token takes precedence
+// over basic auth credentials.
+type HTTPAuth struct {
+	Username string `ini:"username"`
+	Password string `ini:"password"`
+	Token    string `ini:"token"`
+}
+
+// Apply sets the authorization header of the request
+func (auth *HTTPAuth) Apply(req *http.Request) {
+	if auth.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+		return
+	}
+	if auth.Username != "" || auth.Password != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
@@ -0,0 +1,25 @@
+package sources
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPAuthApply(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	(&HTTPAuth{}).Apply(req)
+	if req.Header.Get("Authorization") != "" {
+		t.Error("unexpected authorization header")
+	}
+
+	(&HTTPAuth{Username: "alice", Password: "secret"}).Apply(req)
+	user, password, ok := req.BasicAuth()
+	if !ok || user != "alice" || password != "secret" {
+		t.Error("unexpected basic auth:", user, password)
+	}
+
+	(&HTTPAuth{Username: "alice", Token: "t0k3n"}).Apply(req)
+	if auth := req.Header.Get("Authorization"); auth != "Bearer t0k3n" {
+		t.Error("unexpected authorization header:", auth)
+	}
+}
@@ -0,0 +1,15 @@
+package sources
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HTTPClient is used for the requests to the APIs of
+// the sources. The requests are traced and carry the
+// trace context, so the traces can be continued by
+// the route server.
+var HTTPClient = &http.Client{
+	Transport: otelhttp.NewTransport(http.DefaultTransport),
+}
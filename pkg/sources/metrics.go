@@ -0,0 +1,188 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Outcomes of a source request
+const (
+	OutcomeSuccess  = "success"
+	OutcomeError    = "error"
+	OutcomeCanceled = "canceled"
+)
+
+var requestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name: "source_request_duration_seconds",
+		Help: "Duration of the requests to a route server by method and outcome",
+		Buckets: []float64{
+			0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300,
+		},
+	},
+	[]string{"source", "method", "outcome"},
+)
+
+var tracer = otel.Tracer("github.com/alice-lg/alice-lg/pkg/sources")
+
+func init() {
+	prometheus.MustRegister(requestDuration)
+}
+
+// Outcome classifies the error of a request.
+// A canceled request is not an error of the source.
+func Outcome(err error) string {
+	switch {
+	case err == nil:
+		return OutcomeSuccess
+	case errors.Is(err, context.Canceled):
+		return OutcomeCanceled
+	default:
+		return OutcomeError
+	}
+}
+
+// instrumentedSource records the duration and
+// the outcome of the requests to the source and
+// traces them.
+type instrumentedSource struct {
+	Source
+	id string
+}
+
+// Instrumented wraps the source, so the requests are
+// recorded in the source_request metrics and a span
+// is started for each request.
+func Instrumented(id string, src Source) Source {
+	return &instrumentedSource{Source: src, id: id}
+}
+
+// begin starts the span of a request. The returned
+// function is deferred with the returned error and
+// records the request.
+func (src *instrumentedSource) begin(
+	ctx context.Context,
+	method string,
+	attrs ...attribute.KeyValue,
+) (context.Context, func(*error)) {
+	start := time.Now()
+	attrs = append(attrs, attribute.String("alice.source.id", src.id))
+	ctx, span := tracer.Start(ctx, "source."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+
+	return ctx, func(err *error) {
+		outcome := Outcome(*err)
+		requestDuration.WithLabelValues(
+			src.id, method, outcome,
+		).Observe(time.Since(start).Seconds())
+
+		span.SetAttributes(attribute.String("alice.outcome", outcome))
+		if outcome == OutcomeError {
+			span.RecordError(*err)
+			span.SetStatus(codes.Error, (*err).Error())
+		}
+		span.End()
+	}
+}
+
+// neighborAttr identifies the neighbor in a span
+func neighborAttr(neighborID string) attribute.KeyValue {
+	return attribute.String("alice.neighbor.id", neighborID)
+}
+
+// Status retrieves the status of the route server
+func (src *instrumentedSource) Status(
+	ctx context.Context,
+) (res *api.StatusResponse, err error) {
+	ctx, end := src.begin(ctx, "status")
+	defer end(&err)
+	return src.Source.Status(ctx)
+}
+
+// Neighbors retrieves the neighbors
+func (src *instrumentedSource) Neighbors(
+	ctx context.Context,
+) (res *api.NeighborsResponse, err error) {
+	ctx, end := src.begin(ctx, "neighbors")
+	defer end(&err)
+	return src.Source.Neighbors(ctx)
+}
+
+// NeighborsSummary retrieves the neighbors without
+// the route counts
+func (src *instrumentedSource) NeighborsSummary(
+	ctx context.Context,
+) (res *api.NeighborsResponse, err error) {
+	ctx, end := src.begin(ctx, "neighbors_summary")
+	defer end(&err)
+	return src.Source.NeighborsSummary(ctx)
+}
+
+// NeighborsStatus retrieves the state of the neighbors
+func (src *instrumentedSource) NeighborsStatus(
+	ctx context.Context,
+) (res *api.NeighborsStatusResponse, err error) {
+	ctx, end := src.begin(ctx, "neighbors_status")
+	defer end(&err)
+	return src.Source.NeighborsStatus(ctx)
+}
+
+// Routes retrieves the routes of a neighbor
+func (src *instrumentedSource) Routes(
+	ctx context.Context,
+	neighborID string,
+) (res *api.RoutesResponse, err error) {
+	ctx, end := src.begin(ctx, "routes", neighborAttr(neighborID))
+	defer end(&err)
+	return src.Source.Routes(ctx, neighborID)
+}
+
+// RoutesReceived retrieves the received routes of a neighbor
+func (src *instrumentedSource) RoutesReceived(
+	ctx context.Context,
+	neighborID string,
+) (res *api.RoutesResponse, err error) {
+	ctx, end := src.begin(ctx, "routes_received", neighborAttr(neighborID))
+	defer end(&err)
+	return src.Source.RoutesReceived(ctx, neighborID)
+}
+
+// RoutesFiltered retrieves the filtered routes of a neighbor
+func (src *instrumentedSource) RoutesFiltered(
+	ctx context.Context,
+	neighborID string,
+) (res *api.RoutesResponse, err error) {
+	ctx, end := src.begin(ctx, "routes_filtered", neighborAttr(neighborID))
+	defer end(&err)
+	return src.Source.RoutesFiltered(ctx, neighborID)
+}
+
+// RoutesNotExported retrieves the routes of a neighbor
+// not exported to other neighbors
+func (src *instrumentedSource) RoutesNotExported(
+	ctx context.Context,
+	neighborID string,
+) (res *api.RoutesResponse, err error) {
+	ctx, end := src.begin(ctx, "routes_not_exported", neighborAttr(neighborID))
+	defer end(&err)
+	return src.Source.RoutesNotExported(ctx, neighborID)
+}
+
+// AllRoutes retrieves the routes of all neighbors
+func (src *instrumentedSource) AllRoutes(
+	ctx context.Context,
+) (res *api.RoutesResponse, err error) {
+	ctx, end := src.begin(ctx, "all_routes")
+	defer end(&err)
+	return src.Source.AllRoutes(ctx)
+}
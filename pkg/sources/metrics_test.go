@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+type failingSource struct {
+	Source
+	err error
+}
+
+func (s *failingSource) Neighbors(context.Context) (*api.NeighborsResponse, error) {
+	return nil, s.err
+}
+
+func TestOutcome(t *testing.T) {
+	if o := Outcome(nil); o != OutcomeSuccess {
+		t.Error("unexpected outcome:", o)
+	}
+	if o := Outcome(fmt.Errorf("get: %w", context.Canceled)); o != OutcomeCanceled {
+		t.Error("unexpected outcome:", o)
+	}
+	if o := Outcome(ErrSourceBusy); o != OutcomeError {
+		t.Error("unexpected outcome:", o)
+	}
+}
+
+func TestInstrumented(t *testing.T) {
+	src := Instrumented("rs-metrics", &testSource{})
+	if _, err := src.Status(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	src = Instrumented("rs-metrics", &failingSource{err: ErrSourceBusy})
+	if _, err := src.Neighbors(context.Background()); err != ErrSourceBusy {
+		t.Error("expected the error of the source, got:", err)
+	}
+
+	if n := sampleCount(t, "rs-metrics", "status", OutcomeSuccess); n != 1 {
+		t.Error("expected a successful status request, got:", n)
+	}
+	if n := sampleCount(t, "rs-metrics", "neighbors", OutcomeError); n != 1 {
+		t.Error("expected a failed neighbors request, got:", n)
+	}
+}
+
+// sampleCount gets the number of recorded requests
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	m := &dto.Metric{}
+	obs := requestDuration.WithLabelValues(labels...)
+	if err := obs.(prometheus.Histogram).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,70 @@
+package openbgpd
+
+import (
+	"time"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// Backend names of the openbgpd sources and
+// of the configuration sections.
+const (
+	// BackendStateServer is used when the openbgpd
+	// is exported using the openbgpd-state-server.
+	BackendStateServer = "openbgpd-state-server"
+
+	// BackendBgplgd is used when the openbgpd
+	// state is exported through the bgplgd.
+	BackendBgplgd = "openbgpd-bgplgd"
+)
+
+// configKeys are the keys of the openbgpd sections
+var configKeys = append(sources.IniKeys(Config{}),
+	append(sources.IniKeys(sources.HTTPAuth{}),
+		"cache_ttl", "routes_cache_size")...)
+
+func init() {
+	sources.Register(&sources.Backend{
+		Name:   BackendStateServer,
+		Type:   "openbgpd",
+		Decode: decodeConfig,
+		Keys:   configKeys,
+		New: func(config any) sources.Source {
+			return NewStateServerSource(config.(*Config))
+		},
+	})
+	sources.Register(&sources.Backend{
+		Name:   BackendBgplgd,
+		Type:   "openbgpd",
+		Decode: decodeConfig,
+		Keys:   configKeys,
+		New: func(config any) sources.Source {
+			return NewBgplgdSource(config.(*Config))
+		},
+	})
+}
+
+// decodeConfig reads the openbgpd configuration
+// and applies the defaults.
+func decodeConfig(
+	settings *sources.Settings,
+	section *ini.Section,
+) (any, error) {
+	cacheTTL := section.Key("cache_ttl").MustInt(300)
+	c := &Config{
+		ID:                settings.ID,
+		Name:              settings.Name,
+		CacheTTL:          time.Second * time.Duration(cacheTTL),
+		RoutesCacheSize:   section.Key("routes_cache_size").MustInt(1024),
+		RejectCommunities: settings.RejectCommunities,
+	}
+	if err := section.MapTo(c); err != nil {
+		return nil, err
+	}
+	if err := section.MapTo(&c.Auth); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
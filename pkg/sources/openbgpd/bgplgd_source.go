@@ -8,6 +8,7 @@ import (
 	"github.com/alice-lg/alice-lg/pkg/api"
 	"github.com/alice-lg/alice-lg/pkg/caches"
 	"github.com/alice-lg/alice-lg/pkg/decoders"
+	"github.com/alice-lg/alice-lg/pkg/sources"
 )
 
 const (
@@ -66,7 +67,7 @@ func (src *BgplgdSource) ExpireCaches() int {
 // ShowNeighborsRequest makes an all neighbors request
 func (src *BgplgdSource) ShowNeighborsRequest(ctx context.Context) (*http.Request, error) {
 	url := src.cfg.APIURL("/neighbors")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowNeighborsSummaryRequest builds an neighbors status request
@@ -74,7 +75,7 @@ func (src *BgplgdSource) ShowNeighborsSummaryRequest(
 	ctx context.Context,
 ) (*http.Request, error) {
 	url := src.cfg.APIURL("/summary")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowNeighborRIBRequest retrieves the routes accepted from the neighbor
@@ -84,14 +85,14 @@ func (src *BgplgdSource) ShowNeighborRIBRequest(
 	neighborID string,
 ) (*http.Request, error) {
 	url := src.cfg.APIURL("/rib?neighbor=%s", neighborID)
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowRIBRequest makes a request for retrieving all routes imported
 // from all peers
 func (src *BgplgdSource) ShowRIBRequest(ctx context.Context) (*http.Request, error) {
 	url := src.cfg.APIURL("/rib")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // Datasource
@@ -130,7 +131,7 @@ func (src *BgplgdSource) Neighbors(
 	ctx context.Context,
 ) (*api.NeighborsResponse, error) {
 	// Query cache and see if we have a hit
-	response := src.neighborsCache.Get()
+	response := src.neighborsCache.Get(ctx)
 	if response != nil {
 		response.Meta.ResultFromCache = true
 		return response, nil
@@ -141,7 +142,7 @@ func (src *BgplgdSource) Neighbors(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +184,7 @@ func (src *BgplgdSource) NeighborsSummary(
 	ctx context.Context,
 ) (*api.NeighborsResponse, error) {
 	// Query cache and see if we have a hit
-	response := src.neighborsSummaryCache.Get()
+	response := src.neighborsSummaryCache.Get(ctx)
 	if response != nil {
 		response.Meta.ResultFromCache = true
 		return response, nil
@@ -194,7 +195,7 @@ func (src *BgplgdSource) NeighborsSummary(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -233,7 +234,7 @@ func (src *BgplgdSource) NeighborsStatus(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -264,7 +265,7 @@ func (src *BgplgdSource) Routes(
 	ctx context.Context,
 	neighborID string,
 ) (*api.RoutesResponse, error) {
-	response := src.routesCache.Get(neighborID)
+	response := src.routesCache.Get(ctx, neighborID)
 	if response != nil {
 		response.Meta.ResultFromCache = true
 		return response, nil
@@ -275,7 +276,7 @@ func (src *BgplgdSource) Routes(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -314,7 +315,7 @@ func (src *BgplgdSource) RoutesReceived(
 	ctx context.Context,
 	neighborID string,
 ) (*api.RoutesResponse, error) {
-	response := src.routesReceivedCache.Get(neighborID)
+	response := src.routesReceivedCache.Get(ctx, neighborID)
 	if response != nil {
 		response.Meta.ResultFromCache = true
 		return response, nil
@@ -325,7 +326,7 @@ func (src *BgplgdSource) RoutesReceived(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -363,7 +364,7 @@ func (src *BgplgdSource) RoutesFiltered(
 	ctx context.Context,
 	neighborID string,
 ) (*api.RoutesResponse, error) {
-	response := src.routesFilteredCache.Get(neighborID)
+	response := src.routesFilteredCache.Get(ctx, neighborID)
 	if response != nil {
 		response.Meta.ResultFromCache = true
 		return response, nil
@@ -374,7 +375,7 @@ func (src *BgplgdSource) RoutesFiltered(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -433,7 +434,7 @@ func (src *BgplgdSource) AllRoutes(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
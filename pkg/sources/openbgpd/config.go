@@ -1,11 +1,14 @@
 package openbgpd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/sources"
 )
 
 // Config is a OpenBGPD source config
@@ -18,6 +21,9 @@ type Config struct {
 
 	API string `ini:"api"`
 
+	// Auth is used for requests to the API
+	Auth sources.HTTPAuth
+
 	RejectCommunities api.Communities
 }
 
@@ -27,3 +33,16 @@ func (cfg *Config) APIURL(path string, params ...any) string {
 	u += fmt.Sprintf(path, params...)
 	return u
 }
+
+// NewRequest creates an authenticated GET request
+func (cfg *Config) NewRequest(
+	ctx context.Context,
+	url string,
+) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Auth.Apply(req)
+	return req, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/alice-lg/alice-lg/pkg/api"
 	"github.com/alice-lg/alice-lg/pkg/caches"
 	"github.com/alice-lg/alice-lg/pkg/decoders"
+	"github.com/alice-lg/alice-lg/pkg/sources"
 )
 
 const (
@@ -72,13 +73,13 @@ func (src *StateServerSource) ExpireCaches() int {
 // StatusRequest makes status request from source
 func (src *StateServerSource) StatusRequest(ctx context.Context) (*http.Request, error) {
 	url := src.cfg.APIURL("/v1/status")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowNeighborsRequest makes an all neighbors request
 func (src *StateServerSource) ShowNeighborsRequest(ctx context.Context) (*http.Request, error) {
 	url := src.cfg.APIURL("/v1/bgpd/show/neighbor")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowNeighborsSummaryRequest builds an neighbors status request
@@ -86,7 +87,7 @@ func (src *StateServerSource) ShowNeighborsSummaryRequest(
 	ctx context.Context,
 ) (*http.Request, error) {
 	url := src.cfg.APIURL("/v1/bgpd/show/summary")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowNeighborRIBRequest retrieves the routes accepted from the neighbor
@@ -96,14 +97,14 @@ func (src *StateServerSource) ShowNeighborRIBRequest(
 	neighborID string,
 ) (*http.Request, error) {
 	url := src.cfg.APIURL("/v1/bgpd/show/rib/neighbor/%s/detail", neighborID)
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // ShowRIBRequest makes a request for retrieving all routes imported
 // from all peers
 func (src *StateServerSource) ShowRIBRequest(ctx context.Context) (*http.Request, error) {
 	url := src.cfg.APIURL("/v1/bgpd/show/rib/detail")
-	return http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	return src.cfg.NewRequest(ctx, url)
 }
 
 // Datasource
@@ -131,7 +132,7 @@ func (src *StateServerSource) Status(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -154,7 +155,7 @@ func (src *StateServerSource) Neighbors(
 	ctx context.Context,
 ) (*api.NeighborsResponse, error) {
 	// Query cache and see if we have a hit
-	response := src.neighborsCache.Get()
+	response := src.neighborsCache.Get(ctx)
 	if response != nil {
 		response.Response.Meta.ResultFromCache = true
 		return response, nil
@@ -165,7 +166,7 @@ func (src *StateServerSource) Neighbors(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -207,7 +208,7 @@ func (src *StateServerSource) Neighbors(
 func (src *StateServerSource) NeighborsSummary(
 	ctx context.Context,
 ) (*api.NeighborsResponse, error) {
-	response := src.neighborsSummaryCache.Get()
+	response := src.neighborsSummaryCache.Get(ctx)
 	if response != nil {
 		response.Meta.ResultFromCache = true
 		return response, nil
@@ -218,7 +219,7 @@ func (src *StateServerSource) NeighborsSummary(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -256,7 +257,7 @@ func (src *StateServerSource) NeighborsStatus(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -287,7 +288,7 @@ func (src *StateServerSource) Routes(
 	ctx context.Context,
 	neighborID string,
 ) (*api.RoutesResponse, error) {
-	response := src.routesCache.Get(neighborID)
+	response := src.routesCache.Get(ctx, neighborID)
 	if response != nil {
 		response.Response.Meta.ResultFromCache = true
 		return response, nil
@@ -298,7 +299,7 @@ func (src *StateServerSource) Routes(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -337,7 +338,7 @@ func (src *StateServerSource) RoutesReceived(
 	ctx context.Context,
 	neighborID string,
 ) (*api.RoutesResponse, error) {
-	response := src.routesReceivedCache.Get(neighborID)
+	response := src.routesReceivedCache.Get(ctx, neighborID)
 	if response != nil {
 		response.Response.Meta.ResultFromCache = true
 		return response, nil
@@ -348,7 +349,7 @@ func (src *StateServerSource) RoutesReceived(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -384,7 +385,7 @@ func (src *StateServerSource) RoutesFiltered(
 	ctx context.Context,
 	neighborID string,
 ) (*api.RoutesResponse, error) {
-	response := src.routesFilteredCache.Get(neighborID)
+	response := src.routesFilteredCache.Get(ctx, neighborID)
 	if response != nil {
 		response.Response.Meta.ResultFromCache = true
 		return response, nil
@@ -395,7 +396,7 @@ func (src *StateServerSource) RoutesFiltered(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -452,7 +453,7 @@ func (src *StateServerSource) AllRoutes(
 	if err != nil {
 		return nil, err
 	}
-	res, err := http.DefaultClient.Do(req)
+	res, err := sources.HTTPClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
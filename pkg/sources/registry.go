@@ -0,0 +1,135 @@
+package sources
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Settings are the common settings of a source
+// passed to the config decoder of a backend.
+type Settings struct {
+	ID   string
+	Name string
+
+	// RejectCommunities are the communities of
+	// the configured rejection reasons.
+	RejectCommunities api.Communities
+
+	// StreamParserThrottle is the delay in nanoseconds
+	// applied while parsing streamed responses.
+	StreamParserThrottle int
+}
+
+// A Backend creates sources of a kind from the
+// configuration. The backend is selected by the name
+// of the configuration section: [source.<id>.<name>].
+type Backend struct {
+	// Name of the backend, e.g. birdwatcher
+	Name string
+
+	// Type of the route server, e.g. bird
+	Type string
+
+	// Decode reads the backend configuration
+	// from the ini section.
+	Decode func(settings *Settings, section *ini.Section) (any, error)
+
+	// Validate checks the decoded configuration.
+	// Validate is optional.
+	Validate func(config any) error
+
+	// New creates a source from the
+	// decoded configuration.
+	New func(config any) Source
+
+	// Keys lists the keys of the configuration section.
+	// It is used for reporting unknown keys.
+	Keys []string
+}
+
+var (
+	backends   = map[string]*Backend{}
+	backendsMu sync.RWMutex
+)
+
+// Register makes a source backend available by its name.
+// Backends are registered from the init function of the
+// source package. Registering a name twice panics.
+func Register(backend *Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if backend.Decode == nil || backend.New == nil {
+		panic(fmt.Sprintf(
+			"sources: backend %s requires a decoder and a factory",
+			backend.Name))
+	}
+	if _, ok := backends[backend.Name]; ok {
+		panic(fmt.Sprintf(
+			"sources: backend %s is already registered", backend.Name))
+	}
+	backends[backend.Name] = backend
+}
+
+// GetBackend retrieves a registered backend by name.
+func GetBackend(name string) (*Backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	backend, ok := backends[name]
+	return backend, ok
+}
+
+// Backends lists the names of all registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DecodeConfig reads and validates the configuration
+// of a source with the backend.
+func (b *Backend) DecodeConfig(
+	settings *Settings,
+	section *ini.Section,
+) (any, error) {
+	config, err := b.Decode(settings, section)
+	if err != nil {
+		return nil, err
+	}
+	if b.Validate != nil {
+		if err := b.Validate(config); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// IniKeys lists the keys mapped by the ini
+// tags of the fields of a config struct.
+func IniKeys(config any) []string {
+	t := reflect.TypeOf(config)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	keys := []string{}
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag.Get("ini")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-ini/ini"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+type testConfig struct {
+	ID  string
+	API string `ini:"api"`
+}
+
+type testSource struct {
+	Source
+	config *testConfig
+}
+
+func (s *testSource) Status(context.Context) (*api.StatusResponse, error) {
+	return &api.StatusResponse{}, nil
+}
+
+func TestRegister(t *testing.T) {
+	Register(&Backend{
+		Name: "test-registry",
+		Type: "test",
+		Decode: func(settings *Settings, section *ini.Section) (any, error) {
+			c := &testConfig{ID: settings.ID}
+			return c, section.MapTo(c)
+		},
+		Validate: func(config any) error {
+			if config.(*testConfig).API == "" {
+				return errors.New("api is required")
+			}
+			return nil
+		},
+		New: func(config any) Source {
+			return &testSource{config: config.(*testConfig)}
+		},
+	})
+
+	backend, ok := GetBackend("test-registry")
+	if !ok {
+		t.Fatal("expected backend to be registered")
+	}
+	if !slices.Contains(Backends(), "test-registry") {
+		t.Error("backend is not listed:", Backends())
+	}
+
+	cfg, err := ini.Load([]byte("[test]\napi = http://rs1.example.net\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := backend.DecodeConfig(
+		&Settings{ID: "rs1"}, cfg.Section("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := backend.New(config).(*testSource)
+	if src.config.ID != "rs1" || src.config.API != "http://rs1.example.net" {
+		t.Error("unexpected config:", src.config)
+	}
+
+	// Validation
+	if _, err := backend.DecodeConfig(
+		&Settings{ID: "rs2"}, cfg.Section("empty")); err == nil {
+		t.Error("expected a validation error")
+	}
+
+	// Registering a name twice panics
+	defer func() {
+		if recover() == nil {
+			t.Error("expected duplicate registration to panic")
+		}
+	}()
+	Register(backend)
+}
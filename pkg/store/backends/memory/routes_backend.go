@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 
@@ -25,11 +27,16 @@ func NewRoutesBackend() *RoutesBackend {
 // SetRoutes implements the RoutesStoreBackend interface
 // function for setting all routes of a source identified
 // by ID.
+//
+// The routes are stored ordered by network, the slice
+// passed in is not modified.
 func (r *RoutesBackend) SetRoutes(
 	ctx context.Context,
 	sourceID string,
 	routes api.LookupRoutes,
 ) error {
+	routes = slices.Clone(routes)
+	sort.Stable(routes)
 	r.routes.Store(sourceID, routes)
 	return nil
 }
@@ -90,6 +97,47 @@ func (r *RoutesBackend) FindByNeighbors(
 	return result, nil
 }
 
+// StreamByNeighbors calls fn for each route of the
+// neighbors. The routes of a source are ordered by network.
+func (r *RoutesBackend) StreamByNeighbors(
+	ctx context.Context,
+	query []*api.NeighborQuery,
+	filters *api.SearchFilters,
+	fn func(*api.LookupRoute) error,
+) error {
+	var err error
+	r.routes.Range(func(k, rs any) bool {
+		for _, route := range rs.(api.LookupRoutes) {
+			if !matchNeighborQueries(route, query) {
+				continue
+			}
+			if !filters.MatchRoute(route) {
+				continue
+			}
+			if err = fn(route); err != nil {
+				return false
+			}
+		}
+		err = ctx.Err()
+		return err == nil
+	})
+	return err
+}
+
+// matchNeighborQueries checks if the route is
+// announced by any of the queried neighbors
+func matchNeighborQueries(
+	route *api.LookupRoute,
+	query []*api.NeighborQuery,
+) bool {
+	for _, q := range query {
+		if route.MatchNeighborQuery(q) {
+			return true
+		}
+	}
+	return false
+}
+
 // FindByPrefix will return the prefixes matching a pattern
 func (r *RoutesBackend) FindByPrefix(
 	ctx context.Context,
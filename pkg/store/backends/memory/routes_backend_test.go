@@ -2,7 +2,9 @@ package memory
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"testing"
 	"time"
@@ -45,6 +47,56 @@ func TestFindByNeighbors(t *testing.T) {
 	}
 }
 
+func TestStreamByNeighbors(t *testing.T) {
+	ctx := context.Background()
+
+	rs1 := testdata.LoadTestLookupRoutes("rs1", "routeserver1")
+	rs1 = append(rs1, rs1...)
+	first := rs1[0]
+
+	b := NewRoutesBackend()
+	b.SetRoutes(ctx, "rs1", rs1)
+	if rs1[0] != first {
+		t.Error("routes passed to the backend were reordered")
+	}
+
+	query := []*api.NeighborQuery{}
+	for _, r := range rs1 {
+		query = append(query, &api.NeighborQuery{
+			NeighborID: r.NeighborID,
+			SourceID:   pools.RouteServers.Get("rs1"),
+		})
+	}
+
+	routes := api.LookupRoutes{}
+	err := b.StreamByNeighbors(ctx, query, api.NewSearchFilters(),
+		func(r *api.LookupRoute) error {
+			routes = append(routes, r)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != len(rs1) {
+		t.Fatal("unexpected number of routes:", len(routes))
+	}
+	if !sort.IsSorted(routes) {
+		t.Error("routes are not ordered by network")
+	}
+
+	// Stop the iteration
+	errStop := errors.New("stop")
+	count := 0
+	err = b.StreamByNeighbors(ctx, query, api.NewSearchFilters(),
+		func(r *api.LookupRoute) error {
+			count++
+			return errStop
+		})
+	if err != errStop || count != 1 {
+		t.Error("unexpected result:", err, count)
+	}
+}
+
 func TestConcurrentRoutesAccess(t *testing.T) {
 	ctx := context.Background()
 
@@ -26,6 +26,12 @@ func Connect(ctx context.Context, opts *config.PostgresConfig) (*pgxpool.Pool, e
 	}
 
 	cfg.ConnConfig.RuntimeParams["application_name"] = filepath.Base(os.Args[0])
+	if opts.User != "" {
+		cfg.ConnConfig.User = opts.User
+	}
+	if opts.Password != "" {
+		cfg.ConnConfig.Password = opts.Password
+	}
 	if opts.MaxConns == 0 {
 		return nil, ErrMaxConnsUnconfigured
 	}
@@ -0,0 +1,272 @@
+package postgres
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Route attributes which can be queried directly
+// in the database. All other filters are applied
+// when fetching the routes.
+var filterAttributeExpressions = map[string]string{
+	api.SearchKeyPrefixLength: `masklen(network::inet)`,
+	api.SearchKeyLocalPref:    `(route -> 'bgp' ->> 'local_pref')::bigint`,
+	api.SearchKeyMED:          `(route -> 'bgp' ->> 'med')::bigint`,
+	api.SearchKeyAge:          `((route ->> 'age')::bigint / 1000000000)`,
+}
+
+// Private queryParams collects query parameters
+// and generates the placeholders.
+type queryParams struct {
+	values []any
+	offset int
+}
+
+// add a value and return the placeholder
+func (p *queryParams) add(v any) string {
+	p.values = append(p.values, v)
+	return fmt.Sprintf("$%d", p.offset+len(p.values))
+}
+
+// Private filtersCondition creates an SQL condition from
+// the route attribute search filters. Filters within a group
+// are combined with OR, groups are combined with AND.
+// The placeholders start after offset.
+//
+// An empty condition is returned if there is nothing
+// to filter.
+func filtersCondition(
+	filters *api.SearchFilters,
+	offset int,
+) (string, []any) {
+	if filters == nil {
+		return "", nil
+	}
+	params := &queryParams{offset: offset}
+	groups := []string{}
+
+	for _, key := range []string{
+		api.SearchKeyPrefixLength,
+		api.SearchKeyLocalPref,
+		api.SearchKeyMED,
+		api.SearchKeyAge,
+	} {
+		expr := filterAttributeExpressions[key]
+		conds := []string{}
+		for _, f := range filters.GetGroupByKey(key).Filters {
+			rng, ok := f.Value.(api.IntRange)
+			if !ok {
+				continue
+			}
+			conds = append(conds, rangeCondition(expr, rng, params))
+		}
+		if len(conds) > 0 {
+			groups = append(groups, "("+strings.Join(conds, " OR ")+")")
+		}
+	}
+
+	// OTC presence
+	conds := []string{}
+	for _, f := range filters.GetGroupByKey(api.SearchKeyOTC).Filters {
+		present, ok := f.Value.(bool)
+		if !ok {
+			continue
+		}
+		conds = append(conds, otcCondition(present))
+	}
+	if len(conds) > 0 {
+		groups = append(groups, "("+strings.Join(conds, " OR ")+")")
+	}
+
+	// Search queries
+	for _, f := range filters.GetGroupByKey(api.SearchKeyQuery).Filters {
+		q, ok := f.Value.(*api.SearchQuery)
+		if !ok {
+			continue
+		}
+		if cond, _ := queryCondition(q.Expr, params); cond != "" {
+			groups = append(groups, "("+cond+")")
+		}
+	}
+
+	return strings.Join(groups, " AND "), params.values
+}
+
+// Private otcCondition checks for the presence or
+// absence of the only to customer attribute.
+func otcCondition(present bool) string {
+	if present {
+		return `jsonb_typeof(route -> 'bgp' -> 'otc') = 'number'`
+	}
+	return `COALESCE(jsonb_typeof(route -> 'bgp' -> 'otc'), 'null') = 'null'`
+}
+
+// Private queryCondition translates a search query into
+// an SQL condition. Not all terms can be expressed in SQL,
+// in this case the condition is relaxed and exact is false.
+// An empty condition does not restrict the result.
+//
+// The routes are matched against the query after fetching,
+// so the condition only needs to include all matching routes.
+func queryCondition(
+	expr api.SearchQueryExpr,
+	params *queryParams,
+) (cond string, exact bool) {
+	// The values of a discarded condition are dropped,
+	// so the parameters match the placeholders.
+	n := len(params.values)
+	defer func() {
+		if cond == "" {
+			params.values = params.values[:n]
+		}
+	}()
+
+	switch e := expr.(type) {
+	case *api.SearchQueryTerm:
+		return termCondition(e, params)
+
+	case *api.SearchQueryAnd:
+		// Skipping a condition will only widen the result.
+		conds := []string{}
+		exact = true
+		for _, sub := range e.Exprs {
+			c, x := queryCondition(sub, params)
+			exact = exact && x
+			if c != "" {
+				conds = append(conds, c)
+			}
+		}
+		if len(conds) == 0 {
+			return "", false
+		}
+		return "(" + strings.Join(conds, " AND ") + ")", exact
+
+	case *api.SearchQueryOr:
+		conds := []string{}
+		exact = true
+		for _, sub := range e.Exprs {
+			c, x := queryCondition(sub, params)
+			if c == "" {
+				return "", false // Anything could match
+			}
+			exact = exact && x
+			conds = append(conds, c)
+		}
+		return "(" + strings.Join(conds, " OR ") + ")", exact
+
+	case *api.SearchQueryNot:
+		// Only exact conditions can be negated
+		c, x := queryCondition(e.Expr, params)
+		if c == "" || !x {
+			return "", false
+		}
+		return "NOT " + c, true
+	}
+	return "", false
+}
+
+// Private termCondition creates the condition for a
+// single search query term. Terms are wrapped with COALESCE,
+// so a route without the attribute does not match the term
+// but matches its negation, like in the memory store.
+func termCondition(
+	term *api.SearchQueryTerm,
+	params *queryParams,
+) (string, bool) {
+	var cond string
+	switch term.Key {
+	case api.SearchKeySources:
+		cond = "rs_id = " + params.add(fmt.Sprintf("%v", term.Value))
+	case api.SearchKeyASNS:
+		cond = "(route -> 'neighbor' ->> 'asn')::bigint = " +
+			params.add(term.Value)
+	case api.SearchKeyAddrFamily:
+		cond = "(route ->> 'address_family')::bigint = " +
+			params.add(term.Value)
+	case api.SearchKeyCommunities:
+		cond = communityCondition("communities", term.Value, params)
+	case api.SearchKeyLargeCommunities:
+		cond = communityCondition("large_communities", term.Value, params)
+	case api.SearchKeyExtCommunities:
+		cond = communityCondition("ext_communities", term.Value, params)
+	case api.SearchKeyOTC:
+		present, ok := term.Value.(bool)
+		if !ok {
+			return "", false
+		}
+		cond = otcCondition(present)
+	default:
+		expr, ok := filterAttributeExpressions[term.Key]
+		if !ok {
+			return "", false // e.g. the next hop
+		}
+		rng, ok := term.Value.(api.IntRange)
+		if !ok {
+			return "", false
+		}
+		cond = rangeCondition(expr, rng, params)
+	}
+	if cond == "" {
+		return "", false
+	}
+	return "COALESCE(" + cond + ", FALSE)", true
+}
+
+// Private communityCondition checks if any community in the
+// attribute matches. Wildcard components are skipped.
+func communityCondition(
+	attr string,
+	value any,
+	params *queryParams,
+) string {
+	var components []any
+	switch c := value.(type) {
+	case api.Community:
+		for _, v := range c {
+			components = append(components, v)
+		}
+	case api.ExtCommunity:
+		components = c
+	default:
+		return ""
+	}
+
+	list := `route -> 'bgp' -> '` + attr + `'`
+	conds := []string{
+		fmt.Sprintf("jsonb_array_length(c) = %d", len(components)),
+	}
+	for i, v := range components {
+		if v == api.CommunityWildcard || v == "*" {
+			continue
+		}
+		conds = append(conds, fmt.Sprintf("c ->> %d = ", i)+
+			params.add(fmt.Sprintf("%v", v)))
+	}
+	return `EXISTS (SELECT 1 FROM jsonb_array_elements(
+		CASE jsonb_typeof(` + list + `)
+		WHEN 'array' THEN ` + list + ` ELSE '[]' END) AS c
+		WHERE ` + strings.Join(conds, " AND ") + `)`
+}
+
+// Private rangeCondition creates a condition for an
+// expression within an integer range. Open ends are omitted.
+func rangeCondition(
+	expr string,
+	rng api.IntRange,
+	params *queryParams,
+) string {
+	conds := []string{}
+	if rng.Min != math.MinInt {
+		conds = append(conds, expr+" >= "+params.add(rng.Min))
+	}
+	if rng.Max != math.MaxInt {
+		conds = append(conds, expr+" <= "+params.add(rng.Max))
+	}
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return "(" + strings.Join(conds, " AND ") + ")"
+}
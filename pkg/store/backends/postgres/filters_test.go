@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func TestFiltersCondition(t *testing.T) {
+	values, _ := url.ParseQuery("prefix_length=25-32&med=>0,0&otc=false&asns=2342")
+	filters, err := api.FiltersFromQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cond, params := filtersCondition(filters, 1)
+	expected := "((masklen(network::inet) >= $2 AND masklen(network::inet) <= $3))" +
+		" AND (((route -> 'bgp' ->> 'med')::bigint >= $4) OR" +
+		" ((route -> 'bgp' ->> 'med')::bigint >= $5 AND (route -> 'bgp' ->> 'med')::bigint <= $6))" +
+		" AND (COALESCE(jsonb_typeof(route -> 'bgp' -> 'otc'), 'null') = 'null')"
+	if cond != expected {
+		t.Error("unexpected condition:", cond)
+	}
+	if len(params) != 5 {
+		t.Error("unexpected params:", params)
+	}
+
+	cond, params = filtersCondition(api.NewSearchFilters(), 0)
+	if cond != "" || len(params) != 0 {
+		t.Error("expected empty condition, got:", cond, params)
+	}
+}
+
+func TestQueryCondition(t *testing.T) {
+	q, err := api.ParseSearchQuery(
+		"community:65000:* AND NOT asn:64500 OR local_pref:>100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := &queryParams{offset: 1}
+	cond, exact := queryCondition(q.Expr, params)
+	if !exact {
+		t.Error("condition should be exact")
+	}
+	if !strings.Contains(cond, "NOT COALESCE((route -> 'neighbor' ->> 'asn')::bigint = $3, FALSE)") {
+		t.Error("unexpected condition:", cond)
+	}
+	if !strings.Contains(cond, "c ->> 0 = $2") {
+		t.Error("unexpected condition:", cond)
+	}
+	if len(params.values) != 3 || params.values[0] != "65000" {
+		t.Error("unexpected params:", params.values)
+	}
+
+	// The next hop can not be queried; it is dropped from
+	// conjunctions but an alternative makes the query unrestricted.
+	q, _ = api.ParseSearchQuery("asn:1 AND next_hop:10.0.0.0/8")
+	cond, exact = queryCondition(q.Expr, &queryParams{})
+	if exact || cond != "(COALESCE((route -> 'neighbor' ->> 'asn')::bigint = $1, FALSE))" {
+		t.Error("unexpected condition:", cond, exact)
+	}
+	q, _ = api.ParseSearchQuery("asn:1 OR next_hop:10.0.0.0/8")
+	if cond, _ := queryCondition(q.Expr, &queryParams{}); cond != "" {
+		t.Error("expected empty condition, got:", cond)
+	}
+	q, _ = api.ParseSearchQuery("NOT (asn:1 AND next_hop:10.0.0.0/8)")
+	if cond, _ := queryCondition(q.Expr, &queryParams{}); cond != "" {
+		t.Error("expected empty condition, got:", cond)
+	}
+}
+
+// maxPlaceholder returns the highest $n of the condition
+func maxPlaceholder(t *testing.T, cond string) int {
+	max := 0
+	for _, m := range regexp.MustCompile(`\$(\d+)`).FindAllStringSubmatch(cond, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > max {
+			max = n
+		}
+	}
+	return max
+}
+
+func TestFiltersConditionParams(t *testing.T) {
+	queries := []string{
+		"asn:1 OR next_hop:10.0.0.0/8",
+		"NOT (asn:1 AND next_hop:10.0.0.0/8)",
+		"asn:2 AND (asn:1 OR next_hop:10.0.0.0/8)",
+		"(community:65000:1 OR next_hop:10.0.0.1) AND med:<10",
+		"NOT (asn:1 AND next_hop:10.0.0.0/8) AND local_pref:>100",
+	}
+	for _, qry := range queries {
+		values := url.Values{}
+		values.Set(api.SearchKeyQuery, qry)
+		values.Set(api.SearchKeyMED, ">5")
+		filters, err := api.FiltersFromQuery(values)
+		if err != nil {
+			t.Fatal(qry, err)
+		}
+		cond, params := filtersCondition(filters, 1)
+		if max := maxPlaceholder(t, cond); len(params)+1 != max {
+			t.Error(qry, fmt.Sprintf(
+				"%d params for placeholders up to $%d: %s", len(params), max, cond))
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	pgx "github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // NeighborsBackend implements a neighbors store
@@ -31,7 +32,10 @@ func (b *NeighborsBackend) SetNeighbors(
 	ctx context.Context,
 	sourceID string,
 	neighbors api.Neighbors,
-) error {
+) (err error) {
+	ctx, span := startSpan(ctx, "SetNeighbors", sourceAttr(sourceID))
+	defer func() { endSpan(span, err) }()
+
 	now := time.Now().UTC()
 
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
@@ -47,16 +51,27 @@ func (b *NeighborsBackend) SetNeighbors(
 		return err
 	}
 
-	// Set neighbors
+	// Set neighbors, the inserts are traced in a single span
+	_, insertSpan := startQuerySpan(ctx, neighborsInsertQuery,
+		attribute.Int("alice.neighbors", len(neighbors)))
 	for _, n := range neighbors {
 		if err := b.persist(ctx, tx, sourceID, n, now); err != nil {
+			endSpan(insertSpan, err)
 			return err
 		}
 	}
+	endSpan(insertSpan, nil)
 
 	return tx.Commit(ctx)
 }
 
+// neighborsInsertQuery is the statement for inserting a neighbor
+const neighborsInsertQuery = `
+	  INSERT INTO neighbors (
+	  		id, rs_id, neighbor, updated_at
+		) VALUES ( $1, $2, $3, $4 )
+	`
+
 // Private persist saves a neighbor to the database
 func (b *NeighborsBackend) persist(
 	ctx context.Context,
@@ -65,12 +80,8 @@ func (b *NeighborsBackend) persist(
 	neighbor *api.Neighbor,
 	now time.Time,
 ) error {
-	qry := `
-	  INSERT INTO neighbors (
-	  		id, rs_id, neighbor, updated_at
-		) VALUES ( $1, $2, $3, $4 )
-	`
-	_, err := tx.Exec(ctx, qry, neighbor.ID, sourceID, neighbor, now)
+	_, err := tx.Exec(
+		ctx, neighborsInsertQuery, neighbor.ID, sourceID, neighbor, now)
 	return err
 }
 
@@ -83,8 +94,7 @@ func (b *NeighborsBackend) clear(
 	qry := `
 	  DELETE FROM neighbors WHERE rs_id = $1 
 	`
-	_, err := tx.Exec(ctx, qry, sourceID)
-	return err
+	return queryExec(ctx, tx, qry, sourceID)
 }
 
 // Private queryNeighborsAt selects all neighbors
@@ -99,7 +109,7 @@ func (b *NeighborsBackend) queryNeighborsAt(
 		  FROM neighbors
 		 WHERE rs_id = $1
 	`
-	return tx.Query(ctx, qry, sourceID)
+	return queryRows(ctx, tx, qry, sourceID)
 }
 
 // GetNeighborsAt retrieves all neighbors associated
@@ -107,7 +117,10 @@ func (b *NeighborsBackend) queryNeighborsAt(
 func (b *NeighborsBackend) GetNeighborsAt(
 	ctx context.Context,
 	sourceID string,
-) (api.Neighbors, error) {
+) (_ api.Neighbors, err error) {
+	ctx, span := startSpan(ctx, "GetNeighborsAt", sourceAttr(sourceID))
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -120,6 +133,7 @@ func (b *NeighborsBackend) GetNeighborsAt(
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	cmd := rows.CommandTag()
 	results := make(api.Neighbors, 0, cmd.RowsAffected())
 	for rows.Next() {
@@ -137,7 +151,10 @@ func (b *NeighborsBackend) GetNeighborsAt(
 func (b *NeighborsBackend) GetNeighborsMapAt(
 	ctx context.Context,
 	sourceID string,
-) (map[string]*api.Neighbor, error) {
+) (_ map[string]*api.Neighbor, err error) {
+	ctx, span := startSpan(ctx, "GetNeighborsMapAt", sourceAttr(sourceID))
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -149,6 +166,7 @@ func (b *NeighborsBackend) GetNeighborsMapAt(
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	results := make(map[string]*api.Neighbor)
 	for rows.Next() {
 		neighbor := &api.Neighbor{}
@@ -165,7 +183,10 @@ func (b *NeighborsBackend) GetNeighborsMapAt(
 func (b *NeighborsBackend) CountNeighborsAt(
 	ctx context.Context,
 	sourceID string,
-) (int, error) {
+) (_ int, err error) {
+	ctx, span := startSpan(ctx, "CountNeighborsAt", sourceAttr(sourceID))
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -177,7 +198,7 @@ func (b *NeighborsBackend) CountNeighborsAt(
 		SELECT COUNT(1) FROM neighbors WHERE rs_id = $1
 	`
 	count := 0
-	err = tx.QueryRow(ctx, qry, sourceID).Scan(&count)
+	err = queryRow(ctx, tx, qry, sourceID).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alice-lg/alice-lg/pkg/api"
@@ -12,6 +13,7 @@ import (
 
 	pgx "github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -24,6 +26,7 @@ var (
 type RoutesBackend struct {
 	pool    *pgxpool.Pool
 	sources []*config.SourceConfig
+	sync.RWMutex
 }
 
 // NewRoutesBackend creates a new instance with a postgres
@@ -39,7 +42,10 @@ func NewRoutesBackend(
 }
 
 // Init will initialize all the route tables
-func (b *RoutesBackend) Init(ctx context.Context) error {
+func (b *RoutesBackend) Init(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "Init")
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -48,7 +54,7 @@ func (b *RoutesBackend) Init(ctx context.Context) error {
 	}
 	defer tx.Rollback(ctx)
 
-	for _, src := range b.sources {
+	for _, src := range b.getSources() {
 		if err := b.initTable(ctx, tx, src.ID); err != nil {
 			return err
 		}
@@ -56,6 +62,57 @@ func (b *RoutesBackend) Init(ctx context.Context) error {
 	return tx.Commit(ctx)
 }
 
+// SetSources updates the sources after the configuration
+// was reloaded. Tables of new sources are created, the
+// tables of removed sources are dropped.
+func (b *RoutesBackend) SetSources(
+	ctx context.Context,
+	sources []*config.SourceConfig,
+) error {
+	b.Lock()
+	defer b.Unlock()
+
+	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.ReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current := make(map[string]bool)
+	for _, src := range b.sources {
+		current[src.ID] = true
+	}
+	for _, src := range sources {
+		if current[src.ID] {
+			delete(current, src.ID)
+			continue
+		}
+		if err := b.initTable(ctx, tx, src.ID); err != nil {
+			return err
+		}
+	}
+	for sourceID := range current {
+		qry := `DROP TABLE IF EXISTS ` + b.routesTable(sourceID)
+		if err := queryExec(ctx, tx, qry); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	b.sources = sources
+	return nil
+}
+
+// Private getSources returns the configured sources
+func (b *RoutesBackend) getSources() []*config.SourceConfig {
+	b.RLock()
+	defer b.RUnlock()
+	return b.sources
+}
+
 // SetRoutes implements the RoutesStoreBackend interface
 // function for setting all routes of a source identified
 // by ID.
@@ -63,7 +120,10 @@ func (b *RoutesBackend) SetRoutes(
 	ctx context.Context,
 	sourceID string,
 	routes api.LookupRoutes,
-) error {
+) (err error) {
+	ctx, span := startSpan(ctx, "SetRoutes", sourceAttr(sourceID))
+	defer func() { endSpan(span, err) }()
+
 	now := time.Now().UTC()
 
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
@@ -79,12 +139,17 @@ func (b *RoutesBackend) SetRoutes(
 		return err
 	}
 
-	// persist all routes
+	// persist all routes, the inserts are
+	// traced in a single span.
+	_, insertSpan := startQuerySpan(ctx, b.insertQuery(sourceID),
+		attribute.Int("alice.routes", len(routes)))
 	for _, r := range routes {
 		if err := b.persist(ctx, tx, sourceID, r, now); err != nil {
+			endSpan(insertSpan, err)
 			return err
 		}
 	}
+	endSpan(insertSpan, nil)
 
 	if err := tx.Commit(ctx); err != nil {
 		return err
@@ -111,20 +176,14 @@ func (b *RoutesBackend) initTable(
 		DROP TABLE IF EXISTS ` + tbl + `;
 		CREATE TABLE ` + tbl + ` ( LIKE routes INCLUDING ALL )
 	`
-	_, err := tx.Exec(ctx, qry)
-	return err
+	return queryExec(ctx, tx, qry)
 }
 
-// Private persist route in database
-func (b *RoutesBackend) persist(
-	ctx context.Context,
-	tx pgx.Tx,
-	sourceID string,
-	route *api.LookupRoute,
-	now time.Time,
-) error {
+// Private insertQuery is the statement for
+// inserting a route of the source
+func (b *RoutesBackend) insertQuery(sourceID string) string {
 	tbl := b.routesTable(sourceID)
-	qry := `
+	return `
 		INSERT INTO ` + tbl + ` (
 				id,
 				rs_id,
@@ -136,9 +195,19 @@ func (b *RoutesBackend) persist(
 				$1, $2, $3, $4, $5, $6
 			)
 	`
+}
+
+// Private persist route in database
+func (b *RoutesBackend) persist(
+	ctx context.Context,
+	tx pgx.Tx,
+	sourceID string,
+	route *api.LookupRoute,
+	now time.Time,
+) error {
 	_, err := tx.Exec(
 		ctx,
-		qry,
+		b.insertQuery(sourceID),
 		route.Route.Network,
 		sourceID,
 		route.Neighbor.ID,
@@ -175,7 +244,7 @@ func (b *RoutesBackend) queryCountByState(
 	qry := `SELECT COUNT(1) FROM ` + tbl + ` 
 			 WHERE route -> 'state' = $1`
 
-	return tx.QueryRow(ctx, qry, "\""+state+"\"")
+	return queryRow(ctx, tx, qry, "\""+state+"\"")
 }
 
 // CountRoutesAt returns the number of filtered and imported
@@ -183,7 +252,10 @@ func (b *RoutesBackend) queryCountByState(
 func (b *RoutesBackend) CountRoutesAt(
 	ctx context.Context,
 	sourceID string,
-) (uint, uint, error) {
+) (_ uint, _ uint, err error) {
+	ctx, span := startSpan(ctx, "CountRoutesAt", sourceAttr(sourceID))
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -215,7 +287,10 @@ func (b *RoutesBackend) FindByNeighbors(
 	ctx context.Context,
 	neighbors []*api.NeighborQuery,
 	filters *api.SearchFilters,
-) (api.LookupRoutes, error) {
+) (_ api.LookupRoutes, err error) {
+	ctx, span := startSpan(ctx, "FindByNeighbors", attribute.Int("alice.neighbors", len(neighbors)))
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -224,32 +299,85 @@ func (b *RoutesBackend) FindByNeighbors(
 	}
 	defer tx.Rollback(ctx)
 
-	vals := make([]any, 0, len(neighbors))
-	vars := 0
+	qry, vals := b.neighborsQuery("route", neighbors, filters)
+	rows, err := queryRows(ctx, tx, qry, vals...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	qrys := []string{}
+	return fetchRoutes(rows, filters, 0)
+}
 
-	for _, neighborQuery := range neighbors {
-		tbl := b.routesTable(*neighborQuery.SourceID)
-		param := fmt.Sprintf("$%d", vars+1)
-		vals = append(vals, *neighborQuery.NeighborID)
+// StreamByNeighbors calls fn for each row of the
+// neighbors routes. The routes are ordered by network.
+func (b *RoutesBackend) StreamByNeighbors(
+	ctx context.Context,
+	neighbors []*api.NeighborQuery,
+	filters *api.SearchFilters,
+	fn func(*api.LookupRoute) error,
+) (err error) {
+	ctx, span := startSpan(ctx, "StreamByNeighbors", attribute.Int("alice.neighbors", len(neighbors)))
+	defer func() { endSpan(span, err) }()
 
-		qry := `
-			SELECT route FROM ` + tbl + `
-			 WHERE neighbor_id = ` + param
-		qrys = append(qrys, qry)
+	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
+		IsoLevel: pgx.ReadCommitted,
+	})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qry, vals := b.neighborsQuery("route, network", neighbors, filters)
+	rows, err := queryRows(ctx, tx, qry+" ORDER BY network", vals...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
 
-		vars++
+	for rows.Next() {
+		var network string
+		route := &api.LookupRoute{}
+		if err := rows.Scan(&route, &network); err != nil {
+			return err
+		}
+		if !filters.MatchRoute(route) {
+			continue
+		}
+		if err := fn(route); err != nil {
+			return err
+		}
 	}
+	return rows.Err()
+}
 
-	qry := strings.Join(qrys, " UNION ")
+// neighborsQuery builds the query selecting the columns
+// of the neighbors routes. The route attribute filters are
+// applied in the query, the parameters are shared by all
+// subqueries.
+func (b *RoutesBackend) neighborsQuery(
+	columns string,
+	neighbors []*api.NeighborQuery,
+	filters *api.SearchFilters,
+) (string, []any) {
+	vals := make([]any, 0, len(neighbors))
+	qrys := make([]string, 0, len(neighbors))
 
-	rows, err := tx.Query(ctx, qry, vals...)
-	if err != nil {
-		return nil, err
+	cond, condVals := filtersCondition(filters, len(neighbors))
+	if cond != "" {
+		cond = " AND " + cond
 	}
 
-	return fetchRoutes(rows, filters, 0)
+	for i, neighborQuery := range neighbors {
+		tbl := b.routesTable(*neighborQuery.SourceID)
+		vals = append(vals, *neighborQuery.NeighborID)
+		qrys = append(qrys, `
+			SELECT `+columns+` FROM `+tbl+`
+			 WHERE neighbor_id = `+fmt.Sprintf("$%d", i+1)+cond)
+	}
+	vals = append(vals, condVals...)
+
+	return strings.Join(qrys, " UNION "), vals
 }
 
 // FindByPrefix will return the prefixes matching a pattern
@@ -258,7 +386,10 @@ func (b *RoutesBackend) FindByPrefix(
 	prefix string,
 	filters *api.SearchFilters,
 	limit uint,
-) (api.LookupRoutes, error) {
+) (_ api.LookupRoutes, err error) {
+	ctx, span := startSpan(ctx, "FindByPrefix")
+	defer func() { endSpan(span, err) }()
+
 	tx, err := b.pool.BeginTx(ctx, pgx.TxOptions{
 		IsoLevel: pgx.ReadCommitted,
 	})
@@ -267,20 +398,25 @@ func (b *RoutesBackend) FindByPrefix(
 	}
 	defer tx.Rollback(ctx)
 	// We are searching route.Network
+	cond, condVals := filtersCondition(filters, 1)
+	if cond != "" {
+		cond = " AND " + cond
+	}
 	qrys := []string{}
-	for _, src := range b.sources {
+	for _, src := range b.getSources() {
 		tbl := b.routesTable(src.ID)
 		qry := `
 			SELECT route FROM ` + tbl + `
-			 WHERE network ILIKE $1
-		`
+			 WHERE network ILIKE $1` + cond
 		qrys = append(qrys, qry)
 	}
 	qry := strings.Join(qrys, " UNION ")
-	rows, err := tx.Query(ctx, qry, prefix+"%")
+	vals := append([]any{prefix + "%"}, condVals...)
+	rows, err := queryRows(ctx, tx, qry, vals...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	return fetchRoutes(rows, filters, limit)
 }
 
@@ -138,6 +138,65 @@ func TestFindByNeighbors(t *testing.T) {
 	t.Log(routes)
 }
 
+func TestStreamByNeighbors(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	pool := ConnectTest()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback(ctx)
+	b := &RoutesBackend{
+		pool: pool,
+		sources: []*config.SourceConfig{
+			{ID: "rs1"},
+		},
+	}
+	r := &api.LookupRoute{
+		State: "imported",
+		Neighbor: &api.Neighbor{
+			ID: "n23",
+		},
+		Route: &api.Route{
+			Network:    "1.4.5.0/24",
+			NeighborID: pools.Neighbors.Acquire("n23"),
+		},
+	}
+	b.initTable(ctx, tx, "rs1")
+	b.persist(ctx, tx, "rs1", r, now)
+
+	r.Network = "1.2.3.0/24"
+	b.persist(ctx, tx, "rs1", r, now)
+
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	nq := &api.NeighborQuery{
+		NeighborID: pools.Neighbors.Acquire("n23"),
+		SourceID:   pools.RouteServers.Acquire("rs1"),
+	}
+
+	networks := []string{}
+	err = b.StreamByNeighbors(
+		ctx,
+		[]*api.NeighborQuery{nq},
+		api.NewSearchFilters(),
+		func(r *api.LookupRoute) error {
+			networks = append(networks, r.Network)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 2 ||
+		networks[0] != "1.2.3.0/24" ||
+		networks[1] != "1.4.5.0/24" {
+		t.Error("unexpected routes:", networks)
+	}
+}
+
 func TestFindByPrefix(t *testing.T) {
 	ctx := context.Background()
 	now := time.Now().UTC()
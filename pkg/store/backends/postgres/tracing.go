@@ -0,0 +1,163 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	pgx "github.com/jackc/pgx/v4"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/alice-lg/alice-lg/pkg/store/backends/postgres")
+
+// startSpan starts the span of an operation of the
+// backend. The span covers the transaction of the
+// operation, the statements are traced as db.query
+// spans within.
+func startSpan(
+	ctx context.Context,
+	operation string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationName(operation))
+	return tracer.Start(ctx, "postgres."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// endSpan records the error of the operation
+// and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// sourceAttr identifies the route server in a span
+func sourceAttr(sourceID string) attribute.KeyValue {
+	return attribute.String("alice.source.id", sourceID)
+}
+
+// startQuerySpan starts the span of a statement
+// executed in the transaction of an operation.
+func startQuerySpan(
+	ctx context.Context,
+	qry string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBQueryText(strings.TrimSpace(qry)))
+	return tracer.Start(ctx, "db.query",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...))
+}
+
+// queryExec executes the statement in a db.query span
+func queryExec(
+	ctx context.Context,
+	tx pgx.Tx,
+	qry string,
+	args ...any,
+) error {
+	ctx, span := startQuerySpan(ctx, qry)
+	_, err := tx.Exec(ctx, qry, args...)
+	endSpan(span, err)
+	return err
+}
+
+// queryRows runs the query in a db.query span. The
+// span ends when the rows are read or closed.
+func queryRows(
+	ctx context.Context,
+	tx pgx.Tx,
+	qry string,
+	args ...any,
+) (pgx.Rows, error) {
+	ctx, span := startQuerySpan(ctx, qry)
+	rows, err := tx.Query(ctx, qry, args...)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+// queryRow runs the query for a single row
+// in a db.query span.
+func queryRow(
+	ctx context.Context,
+	tx pgx.Tx,
+	qry string,
+	args ...any,
+) pgx.Row {
+	rows, err := queryRows(ctx, tx, qry, args...)
+	return &tracedRow{rows: rows, err: err}
+}
+
+// tracedRows ends the span of the query
+// after the last row.
+type tracedRows struct {
+	pgx.Rows
+	span  trace.Span
+	ended bool
+}
+
+// Next advances to the next row
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+// Close closes the rows and ends the span
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	endSpan(r.span, r.Rows.Err())
+}
+
+// tracedRow scans the first row of traced rows
+// like the row returned by QueryRow.
+type tracedRow struct {
+	rows pgx.Rows
+	err  error
+}
+
+// Scan reads the values of the row. If there
+// is no row, pgx.ErrNoRows is returned.
+func (r *tracedRow) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return pgx.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	r.rows.Close()
+	return r.rows.Err()
+}
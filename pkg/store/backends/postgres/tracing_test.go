@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	pgx "github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// testRows returns the values as single column rows
+type testRows struct {
+	pgx.Rows
+	values []int
+	closed bool
+}
+
+func (r *testRows) Next() bool {
+	if r.closed || len(r.values) == 0 {
+		r.closed = true
+		return false
+	}
+	return true
+}
+
+func (r *testRows) Scan(dest ...any) error {
+	*dest[0].(*int) = r.values[0]
+	r.values = r.values[1:]
+	return nil
+}
+
+func (r *testRows) Close() {
+	r.closed = true
+}
+
+func (r *testRows) Err() error {
+	return nil
+}
+
+// testTx responds to all queries with the rows
+type testTx struct {
+	pgx.Tx
+	rows *testRows
+}
+
+func (tx *testTx) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	return tx.rows, nil
+}
+
+func TestQuerySpans(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(spans)))
+
+	ctx := context.Background()
+	qry := `
+		SELECT n FROM numbers
+	`
+
+	// The span ends after the last row
+	tx := &testTx{rows: &testRows{values: []int{23, 42}}}
+	rows, err := queryRows(ctx, tx, qry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for rows.Next() {
+		n := 0
+		if err := rows.Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		count++
+		if len(spans.Ended()) != 0 {
+			t.Fatal("span ended before the last row")
+		}
+	}
+	rows.Close()
+	if count != 2 {
+		t.Error("unexpected rows:", count)
+	}
+	ended := spans.Ended()
+	if len(ended) != 1 || ended[0].Name() != "db.query" {
+		t.Fatal("expected a query span:", ended)
+	}
+	text := ""
+	for _, attr := range ended[0].Attributes() {
+		if attr.Key == semconv.DBQueryTextKey {
+			text = attr.Value.AsString()
+		}
+	}
+	if text != "SELECT n FROM numbers" {
+		t.Error("unexpected query text:", text)
+	}
+
+	// Single row
+	tx = &testTx{rows: &testRows{values: []int{23}}}
+	n := 0
+	if err := queryRow(ctx, tx, qry).Scan(&n); err != nil || n != 23 {
+		t.Error("unexpected row:", n, err)
+	}
+	if len(spans.Ended()) != 2 {
+		t.Error("expected the span of the row to end")
+	}
+
+	tx = &testTx{rows: &testRows{}}
+	if err := queryRow(ctx, tx, qry).Scan(&n); err != pgx.ErrNoRows {
+		t.Error("expected no rows, got:", err)
+	}
+}
@@ -2,7 +2,6 @@ package store
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"runtime/debug"
 	"time"
@@ -11,16 +10,22 @@ import (
 )
 
 // StartHousekeeping is a background task flushing
-// memory and expiring caches.
-func StartHousekeeping(ctx context.Context, cfg *config.Config) {
+// memory and expiring caches. The configuration is
+// retrieved for each run, as it might be reloaded.
+// The housekeeping stops when the context is done.
+func StartHousekeeping(ctx context.Context, getConfig func() *config.Config) {
 
 	for {
-		if cfg.Housekeeping.Interval > 0 {
-			time.Sleep(time.Duration(cfg.Housekeeping.Interval) * time.Minute)
-		} else {
-			time.Sleep(5 * time.Minute)
+		interval := 5 * time.Minute
+		if minutes := getConfig().Housekeeping.Interval; minutes > 0 {
+			interval = time.Duration(minutes) * time.Minute
+		}
+		if err := sleep(ctx, interval); err != nil {
+			log.Println("Housekeeping stopped")
+			return
 		}
 
+		cfg := getConfig()
 		log.Println("Housekeeping started")
 
 		// Expire the caches
@@ -35,13 +40,5 @@ func StartHousekeeping(ctx context.Context, cfg *config.Config) {
 			log.Println("Freeing memory")
 			debug.FreeOSMemory()
 		}
-
-		// Check if our services are still required
-		select {
-		case <-ctx.Done():
-			fmt.Println("shutting down Housekeeping...")
-			return
-		default:
-		}
 	}
 }
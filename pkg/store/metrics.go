@@ -2,15 +2,123 @@ package store
 
 import (
 	"context"
+	"errors"
 	"log"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/alice-lg/alice-lg/pkg/sources"
 )
 
+// Number of top values exported for the
+// routes statistics.
+const metricsStatisticsTop = 10
+
+// The refresh metrics are recorded by the stores,
+// independent of the periodically updated metrics.
+var (
+	refreshDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "store_refresh_duration_seconds",
+			Help: "Duration of the refreshes of a source in a store by outcome",
+			Buckets: []float64{
+				0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600,
+			},
+		},
+		[]string{"store", "source", "outcome"},
+	)
+
+	refreshRoutes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "store_refresh_routes",
+			Help: "Number of imported and filtered routes of the last refresh of a source",
+		},
+		[]string{"source", "type"},
+	)
+
+	refreshNeighbors = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "store_refresh_neighbors",
+			Help: "Number of neighbors of the last refresh of a source",
+		},
+		[]string{"source"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(refreshDuration)
+	prometheus.MustRegister(refreshRoutes)
+	prometheus.MustRegister(refreshNeighbors)
+}
+
+// observeRefresh records the duration of a refresh.
+// Discarded refreshes are not failures of the source.
+func observeRefresh(store, sourceID string, start time.Time, err error) {
+	outcome := sources.Outcome(err)
+	if errors.Is(err, ErrSourceReconfigured) {
+		outcome = "discarded"
+	}
+	refreshDuration.WithLabelValues(
+		store, sourceID, outcome,
+	).Observe(time.Since(start).Seconds())
+}
+
+// statsGauges are the gauges of a statistic. The top
+// values change, so the label sets which were not set
+// in an update are deleted after the update. The gauges
+// are not reset, so a scrape always sees all series.
+type statsGauges struct {
+	vec      *prometheus.GaugeVec
+	current  map[string]prometheus.Labels
+	previous map[string]prometheus.Labels
+}
+
+func newStatsGauges(vec *prometheus.GaugeVec) *statsGauges {
+	return &statsGauges{
+		vec:      vec,
+		current:  map[string]prometheus.Labels{},
+		previous: map[string]prometheus.Labels{},
+	}
+}
+
+// set updates the gauge of the label set
+func (g *statsGauges) set(labels prometheus.Labels, value float64) {
+	g.vec.With(labels).Set(value)
+	g.current[labelsKey(labels)] = labels
+}
+
+// sweep deletes the label sets not set since the last sweep
+func (g *statsGauges) sweep() {
+	for key, labels := range g.previous {
+		if _, ok := g.current[key]; !ok {
+			g.vec.Delete(labels)
+		}
+	}
+	g.previous = g.current
+	g.current = map[string]prometheus.Labels{}
+}
+
+// labelsKey identifies a label set
+func labelsKey(labels prometheus.Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	key := strings.Builder{}
+	for _, name := range names {
+		key.WriteString(name + "=" + labels[name] + "\x00")
+	}
+	return key.String()
+}
+
 type metrics struct {
 	neighborsStore *NeighborsStore
+	routesStore    *RoutesStore
 
 	neighborInfo   *prometheus.GaugeVec
 	neighborUptime *prometheus.GaugeVec
@@ -19,10 +127,16 @@ type metrics struct {
 	routesFiltered  *prometheus.GaugeVec
 	routesPreferred *prometheus.GaugeVec
 	routesAccepted  *prometheus.GaugeVec
+
+	statsPrefixLength *statsGauges
+	statsASPathLength *statsGauges
+	statsOriginASN    *statsGauges
+	statsCommunity    *statsGauges
+	statsFilterReason *statsGauges
 }
 
 // Initialize
-func initMetrics(s *NeighborsStore) *metrics {
+func initMetrics(s *NeighborsStore, rs *RoutesStore) *metrics {
 	log.Println("[metrics] Initializing export.")
 
 	labels := []string{
@@ -84,15 +198,61 @@ func initMetrics(s *NeighborsStore) *metrics {
 		labels,
 	)
 
+	statsPrefixLength := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "routes_prefix_length",
+			Help: "Number of routes on a route server by address family and prefix length",
+		},
+		[]string{"route_server_id", "address_family", "prefix_length"},
+	)
+
+	statsASPathLength := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "routes_as_path_length",
+			Help: "Number of routes on a route server by AS path length",
+		},
+		[]string{"route_server_id", "as_path_length"},
+	)
+
+	statsOriginASN := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "routes_top_origin_asn",
+			Help: "Number of routes on a route server for the most common origin ASNs",
+		},
+		[]string{"route_server_id", "asn"},
+	)
+
+	statsCommunity := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "routes_top_community",
+			Help: "Number of routes on a route server for the most common BGP communities",
+		},
+		[]string{"route_server_id", "community", "name"},
+	)
+
+	statsFilterReason := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "routes_top_filter_reason",
+			Help: "Number of filtered routes on a route server for the most common filter reasons",
+		},
+		[]string{"route_server_id", "community", "reason"},
+	)
+
 	prometheus.MustRegister(neighborInfo)
 	prometheus.MustRegister(neighborUptime)
 	prometheus.MustRegister(routesReceived)
 	prometheus.MustRegister(routesFiltered)
 	prometheus.MustRegister(routesPreferred)
 	prometheus.MustRegister(routesAccepted)
+	prometheus.MustRegister(statsPrefixLength)
+	prometheus.MustRegister(statsASPathLength)
+	prometheus.MustRegister(statsOriginASN)
+	prometheus.MustRegister(statsCommunity)
+	prometheus.MustRegister(statsFilterReason)
 
 	return &metrics{
 		neighborsStore: s,
+		routesStore:    rs,
 
 		neighborInfo:   neighborInfo,
 		neighborUptime: neighborUptime,
@@ -101,6 +261,12 @@ func initMetrics(s *NeighborsStore) *metrics {
 		routesFiltered:  routesFiltered,
 		routesPreferred: routesPreferred,
 		routesAccepted:  routesAccepted,
+
+		statsPrefixLength: newStatsGauges(statsPrefixLength),
+		statsASPathLength: newStatsGauges(statsASPathLength),
+		statsOriginASN:    newStatsGauges(statsOriginASN),
+		statsCommunity:    newStatsGauges(statsCommunity),
+		statsFilterReason: newStatsGauges(statsFilterReason),
 	}
 }
 
@@ -162,16 +328,69 @@ func (m *metrics) update(ctx context.Context) error {
 		}
 	}
 
+	m.updateStatistics(ctx)
+
 	return nil
 }
 
+// Update the metrics with the routes statistics.
+// Series of values no longer in the top are deleted.
+func (m *metrics) updateStatistics(ctx context.Context) {
+	_, rsStats := m.routesStore.Statistics(ctx, metricsStatisticsTop)
+
+	for _, rs := range rsStats {
+		for family, lengths := range rs.PrefixLengths {
+			for length, count := range lengths {
+				m.statsPrefixLength.set(prometheus.Labels{
+					"route_server_id": rs.ID,
+					"address_family":  family,
+					"prefix_length":   strconv.Itoa(length),
+				}, float64(count))
+			}
+		}
+		for length, count := range rs.ASPathLengths {
+			m.statsASPathLength.set(prometheus.Labels{
+				"route_server_id": rs.ID,
+				"as_path_length":  strconv.Itoa(length),
+			}, float64(count))
+		}
+		for _, c := range rs.TopOriginASNs {
+			m.statsOriginASN.set(prometheus.Labels{
+				"route_server_id": rs.ID,
+				"asn":             c.Value,
+			}, float64(c.Count))
+		}
+		for _, c := range append(rs.TopCommunities, rs.TopLargeCommunities...) {
+			m.statsCommunity.set(prometheus.Labels{
+				"route_server_id": rs.ID,
+				"community":       c.Value,
+				"name":            c.Name,
+			}, float64(c.Count))
+		}
+		for _, c := range rs.TopFilterReasons {
+			m.statsFilterReason.set(prometheus.Labels{
+				"route_server_id": rs.ID,
+				"community":       c.Value,
+				"reason":          c.Name,
+			}, float64(c.Count))
+		}
+	}
+
+	m.statsPrefixLength.sweep()
+	m.statsASPathLength.sweep()
+	m.statsOriginASN.sweep()
+	m.statsCommunity.sweep()
+	m.statsFilterReason.sweep()
+}
+
 // StartMetrics registers the metrics and starts a
 // periodical refresh.
 func StartMetrics(
 	ctx context.Context,
 	neighborsStore *NeighborsStore,
+	routesStore *RoutesStore,
 ) {
-	m := initMetrics(neighborsStore)
+	m := initMetrics(neighborsStore, routesStore)
 
 	// Every 5 second, update the metrics
 	log.Println("[metrics] Starting refresh.")
@@ -0,0 +1,174 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// NeighborEventsBufferSize is the number of events
+// buffered for each subscriber. Events are dropped for
+// subscribers not keeping up.
+const NeighborEventsBufferSize = 256
+
+// neighborEventsSubscriber receives the events
+// matching the filter.
+type neighborEventsSubscriber struct {
+	filter *api.NeighborEventsFilter
+	events chan *api.NeighborEvent
+}
+
+// NeighborEvents distributes neighbor events
+// to the subscribers.
+type NeighborEvents struct {
+	sync.RWMutex
+
+	seq         uint64
+	subscribers map[*neighborEventsSubscriber]struct{}
+}
+
+// NewNeighborEvents creates a new event distribution
+func NewNeighborEvents() *NeighborEvents {
+	return &NeighborEvents{
+		subscribers: make(map[*neighborEventsSubscriber]struct{}),
+	}
+}
+
+// Subscribe to the events matching the filter. The
+// returned function cancels the subscription and
+// closes the channel.
+func (e *NeighborEvents) Subscribe(
+	filter *api.NeighborEventsFilter,
+) (<-chan *api.NeighborEvent, func()) {
+	sub := &neighborEventsSubscriber{
+		filter: filter,
+		events: make(chan *api.NeighborEvent, NeighborEventsBufferSize),
+	}
+
+	e.Lock()
+	e.subscribers[sub] = struct{}{}
+	e.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			e.Lock()
+			delete(e.subscribers, sub)
+			e.Unlock()
+			close(sub.events)
+		})
+	}
+	return sub.events, cancel
+}
+
+// HasSubscribers checks if anyone is listening
+func (e *NeighborEvents) HasSubscribers() bool {
+	e.RLock()
+	defer e.RUnlock()
+	return len(e.subscribers) > 0
+}
+
+// Publish assigns sequence numbers to the events
+// and sends them to the subscribers.
+func (e *NeighborEvents) Publish(events []*api.NeighborEvent) {
+	e.Lock()
+	defer e.Unlock()
+	for _, event := range events {
+		e.seq++
+		event.ID = e.seq
+		for sub := range e.subscribers {
+			if !sub.filter.Match(event) {
+				continue
+			}
+			select {
+			case sub.events <- event:
+			default: // Subscriber is not keeping up
+			}
+		}
+	}
+}
+
+// diffNeighbors creates events for neighbors going
+// up or down and for changes of the route counts.
+// Neighbors which are no longer present are considered
+// down.
+func diffNeighbors(
+	sourceID string,
+	prev map[string]*api.Neighbor,
+	current api.Neighbors,
+	now time.Time,
+) []*api.NeighborEvent {
+	events := []*api.NeighborEvent{}
+	seen := make(map[string]bool, len(current))
+
+	for _, n := range current {
+		seen[n.ID] = true
+		before, ok := prev[n.ID]
+		stateBefore := ""
+		if ok {
+			stateBefore = before.State
+		}
+		up := api.IsNeighborStateUp(n.State)
+		wasUp := api.IsNeighborStateUp(stateBefore)
+
+		switch {
+		case up && !wasUp:
+			events = append(events, newNeighborEvent(
+				api.NeighborEventUp, sourceID, n, stateBefore, now))
+		case !up && wasUp:
+			events = append(events, newNeighborEvent(
+				api.NeighborEventDown, sourceID, n, stateBefore, now))
+		case ok && neighborRoutesCount(n) != neighborRoutesCount(before):
+			event := newNeighborEvent(
+				api.NeighborEventRoutesChanged, sourceID, n, stateBefore, now)
+			routesBefore := neighborRoutesCount(before)
+			event.RoutesBefore = &routesBefore
+			events = append(events, event)
+		}
+	}
+
+	for id, n := range prev {
+		if seen[id] || !api.IsNeighborStateUp(n.State) {
+			continue
+		}
+		gone := *n
+		gone.State = ""
+		gone.RoutesReceived = 0
+		gone.RoutesFiltered = 0
+		gone.RoutesAccepted = 0
+		events = append(events, newNeighborEvent(
+			api.NeighborEventDown, sourceID, &gone, n.State, now))
+	}
+
+	return events
+}
+
+func neighborRoutesCount(n *api.Neighbor) api.NeighborRoutesCount {
+	return api.NeighborRoutesCount{
+		Received: n.RoutesReceived,
+		Filtered: n.RoutesFiltered,
+		Accepted: n.RoutesAccepted,
+	}
+}
+
+func newNeighborEvent(
+	eventType string,
+	sourceID string,
+	n *api.Neighbor,
+	stateBefore string,
+	now time.Time,
+) *api.NeighborEvent {
+	return &api.NeighborEvent{
+		Type:          eventType,
+		Timestamp:     now,
+		RouteServerID: sourceID,
+		NeighborID:    n.ID,
+		Address:       n.Address,
+		ASN:           n.ASN,
+		Description:   n.Description,
+		State:         n.State,
+		StateBefore:   stateBefore,
+		Routes:        neighborRoutesCount(n),
+	}
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// testNeighborsSource only provides neighbors
+type testNeighborsSource struct {
+	sources.Source
+	neighbors api.Neighbors
+}
+
+func (src *testNeighborsSource) Neighbors(
+	context.Context,
+) (*api.NeighborsResponse, error) {
+	return &api.NeighborsResponse{Neighbors: src.neighbors}, nil
+}
+
+func TestDiffNeighbors(t *testing.T) {
+	now := time.Now()
+	prev := map[string]*api.Neighbor{
+		"n1": {ID: "n1", ASN: 2342, State: "up", RoutesReceived: 10},
+		"n2": {ID: "n2", ASN: 2343, State: "down"},
+		"n3": {ID: "n3", ASN: 2344, State: "up", RoutesReceived: 5},
+		"n4": {ID: "n4", ASN: 2345, State: "up"},
+	}
+	current := api.Neighbors{
+		{ID: "n1", ASN: 2342, State: "up", RoutesReceived: 12},
+		{ID: "n2", ASN: 2343, State: "up"},
+		{ID: "n3", ASN: 2344, State: "start", RoutesReceived: 0},
+		{ID: "n5", ASN: 2346, State: "up"},
+	}
+
+	events := map[string]*api.NeighborEvent{}
+	for _, e := range diffNeighbors("rs1", prev, current, now) {
+		events[e.NeighborID] = e
+	}
+	if len(events) != 5 {
+		t.Fatal("expected 5 events, got:", len(events))
+	}
+	if e := events["n1"]; e.Type != api.NeighborEventRoutesChanged ||
+		e.Routes.Received != 12 || e.RoutesBefore.Received != 10 {
+		t.Error("unexpected event:", e)
+	}
+	if e := events["n2"]; e.Type != api.NeighborEventUp || e.StateBefore != "down" {
+		t.Error("unexpected event:", e)
+	}
+	if e := events["n3"]; e.Type != api.NeighborEventDown || e.State != "start" {
+		t.Error("unexpected event:", e)
+	}
+	if e := events["n4"]; e.Type != api.NeighborEventDown || e.State != "" {
+		t.Error("unexpected event:", e)
+	}
+	if e := events["n5"]; e.Type != api.NeighborEventUp || e.RouteServerID != "rs1" {
+		t.Error("unexpected event:", e)
+	}
+}
+
+func TestNeighborsStoreEvents(t *testing.T) {
+	ctx := context.Background()
+	s := makeTestNeighborsStore()
+
+	events, cancel := s.SubscribeEvents(&api.NeighborEventsFilter{
+		ASNs: []int{2342},
+	})
+	defer cancel()
+
+	src := &testNeighborsSource{
+		neighbors: api.Neighbors{
+			{ID: "ID2233_AS2342", ASN: 2342, State: "up"},
+			{ID: "ID2233_AS2343", ASN: 2343, State: "up"},
+		},
+	}
+	if err := s.updateSource(ctx, src, "rs1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.updateSource(ctx, src, "rs2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the first neighbor matches the filter
+	for _, sourceID := range []string{"rs1", "rs2"} {
+		select {
+		case e := <-events:
+			if e.Type != api.NeighborEventUp || e.ASN != 2342 ||
+				e.RouteServerID != sourceID {
+				t.Error("unexpected event:", e)
+			}
+		default:
+			t.Fatal("expected an event for", sourceID)
+		}
+	}
+	select {
+	case e := <-events:
+		t.Error("unexpected event:", e)
+	default:
+	}
+
+	cancel()
+	if s.events.HasSubscribers() {
+		t.Error("subscription should be canceled")
+	}
+	if _, ok := <-events; ok {
+		t.Error("channel should be closed")
+	}
+}
+
+func TestNeighborEventsFilterFromQuery(t *testing.T) {
+	filter, err := api.NeighborEventsFilterFromQuery(map[string][]string{
+		"sources": {"rs1, rs2"},
+		"asn":     {"AS2342,23"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filter.SourceIDs) != 2 || filter.ASNs[0] != 2342 || filter.ASNs[1] != 23 {
+		t.Error("unexpected filter:", filter)
+	}
+	if !filter.Match(&api.NeighborEvent{RouteServerID: "rs2", ASN: 23}) {
+		t.Error("expected event to match")
+	}
+	if filter.Match(&api.NeighborEvent{RouteServerID: "rs3", ASN: 23}) {
+		t.Error("expected event not to match")
+	}
+	if _, err := api.NeighborEventsFilterFromQuery(map[string][]string{
+		"asn": {"foo"},
+	}); err == nil {
+		t.Error("expected error")
+	}
+}
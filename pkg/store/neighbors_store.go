@@ -7,6 +7,7 @@ import (
 	"math/rand"
 	"regexp"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/alice-lg/alice-lg/pkg/api"
@@ -50,10 +51,23 @@ type NeighborsStoreBackend interface {
 	) (int, error)
 }
 
+// NeighborsRefreshHook is called after the neighbors
+// of a source were refreshed.
+type NeighborsRefreshHook func(
+	ctx context.Context,
+	sourceID string,
+	neighbors api.Neighbors,
+)
+
 // NeighborsStore is queryable for neighbor information
 type NeighborsStore struct {
 	backend NeighborsStoreBackend
 	sources *SourcesStore
+	events  *NeighborEvents
+	hooks   []NeighborsRefreshHook
+
+	// Refreshes in progress
+	refreshes sync.WaitGroup
 
 	forceNeighborRefresh bool
 }
@@ -91,21 +105,43 @@ func NewNeighborsStore(
 	store := &NeighborsStore{
 		backend:              backend,
 		sources:              sources,
+		events:               NewNeighborEvents(),
 		forceNeighborRefresh: forceNeighborRefresh,
 	}
 	return store
 }
 
 // Start the store's housekeeping.
+// The store stops when the context is done. Start
+// returns when the refreshes in progress are finished.
 func (s *NeighborsStore) Start(ctx context.Context) {
 	log.Println("Starting local neighbors store")
 	for {
-		if err := ctx.Err(); err != nil {
-			return // Context invalid
-		}
 		s.update(ctx)
-		time.Sleep(time.Second)
+		if err := sleep(ctx, time.Second); err != nil {
+			break // Context invalid
+		}
 	}
+	s.refreshes.Wait()
+	log.Println("Stopped local neighbors store")
+}
+
+// SubscribeEvents subscribes to neighbor events
+// matching the filter. The events are detected when
+// the neighbors of a source are refreshed.
+// The subscription must be canceled by calling
+// the returned function.
+func (s *NeighborsStore) SubscribeEvents(
+	filter *api.NeighborEventsFilter,
+) (<-chan *api.NeighborEvent, func()) {
+	return s.events.Subscribe(filter)
+}
+
+// AddRefreshHook registers a hook called after each
+// successful refresh. Hooks must be added before
+// the store is started.
+func (s *NeighborsStore) AddRefreshHook(hook NeighborsRefreshHook) {
+	s.hooks = append(s.hooks, hook)
 }
 
 // GetStatus retrieves the status for a route server
@@ -127,16 +163,52 @@ func (s *NeighborsStore) updateSource(
 	ctx context.Context,
 	src sources.Source,
 	srcID string,
-) error {
+) (err error) {
+	defer func(start time.Time) {
+		observeRefresh("neighbors", srcID, start, err)
+	}(time.Now())
+
 	// Get neighbors form source instance and update backend
 	res, err := src.Neighbors(ctx)
 	if err != nil {
 		return err
 	}
 
+	// Keep the previous neighbors for detecting changes
+	// if someone is interested.
+	var prev map[string]*api.Neighbor
+	if s.events.HasSubscribers() && s.IsInitialized(srcID) {
+		prev, err = s.backend.GetNeighborsMapAt(ctx, srcID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !s.sources.IsCurrent(srcID, src) {
+		return ErrSourceReconfigured
+	}
+
+	// Nothing is stored if the refresh was aborted. Once
+	// started, the update of the backend is completed even
+	// when shutting down, so no transaction is cut off.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ctx = context.WithoutCancel(ctx)
+
 	if err = s.backend.SetNeighbors(ctx, srcID, res.Neighbors); err != nil {
 		return err
 	}
+	refreshNeighbors.WithLabelValues(srcID).Set(float64(len(res.Neighbors)))
+
+	if prev != nil {
+		s.events.Publish(
+			diffNeighbors(srcID, prev, res.Neighbors, time.Now().UTC()))
+	}
+
+	for _, hook := range s.hooks {
+		hook(ctx, srcID, res.Neighbors)
+	}
 
 	return s.sources.RefreshSuccess(srcID)
 }
@@ -156,9 +228,16 @@ func (s *NeighborsStore) safeUpdateSource(ctx context.Context, id string) {
 
 	// Apply jitter so, we do not hit everything at once.
 	// TODO: Make configurable
-	time.Sleep(time.Duration(rand.Intn(30)) * time.Second)
+	jitter := time.Duration(rand.Intn(30)) * time.Second
+	if err := sleep(ctx, jitter); err != nil {
+		s.sources.RefreshError(id, err)
+		return // Shutting down
+	}
 
 	src := s.sources.GetInstance(id)
+	if src == nil {
+		return // The source was removed
+	}
 	srcName := s.sources.GetName(id)
 
 	// Prepare for impact.
@@ -171,7 +250,14 @@ func (s *NeighborsStore) safeUpdateSource(ctx context.Context, id string) {
 		}
 	}()
 
-	if err := s.updateSource(ctx, src, id); err != nil {
+	if err := s.updateSource(ctx, src, id); errors.Is(err, ErrSourceReconfigured) {
+		log.Println("[neighbors store] discarding neighbors refresh from", srcName+":", err)
+		return
+	} else if err != nil && ctx.Err() != nil {
+		log.Println("[neighbors store] aborted neighbors refresh from", srcName+":", err)
+		s.sources.RefreshError(id, err)
+		return
+	} else if err != nil {
 		log.Println(
 			"[neighbors store] refreshing neighbors from", srcName, "failed:", err)
 		s.sources.RefreshError(id, err)
@@ -190,7 +276,11 @@ func (s *NeighborsStore) safeUpdateSource(ctx context.Context, id string) {
 // than the configured refresh period.
 func (s *NeighborsStore) update(ctx context.Context) {
 	for _, id := range s.sources.GetSourceIDsForRefresh() {
-		go s.safeUpdateSource(ctx, id)
+		s.refreshes.Add(1)
+		go func() {
+			defer s.refreshes.Done()
+			s.safeUpdateSource(ctx, id)
+		}()
 	}
 }
 
@@ -202,6 +292,18 @@ func (s *NeighborsStore) CachedAt(
 	return s.sources.CachedAt(ctx)
 }
 
+// Generation returns the refresh generation of the
+// stored neighbors.
+func (s *NeighborsStore) Generation(ctx context.Context) uint64 {
+	return s.sources.Generation()
+}
+
+// LastRefresh returns the time of the most recent
+// refresh of the neighbors of a source.
+func (s *NeighborsStore) LastRefresh(ctx context.Context) time.Time {
+	return s.sources.LastRefresh()
+}
+
 // CacheTTL returns the TTL time
 func (s *NeighborsStore) CacheTTL(
 	ctx context.Context,
@@ -367,6 +469,9 @@ func (s *NeighborsStore) Status(ctx context.Context) *api.StoreStatus {
 			State:           s.State.String(),
 			Initialized:     s.Initialized,
 		}
+		if errorSince := s.ErrorSince; !errorSince.IsZero() {
+			status[s.SourceID].ErrorSince = &errorSince
+		}
 	}
 
 	meta := &api.StoreStatus{
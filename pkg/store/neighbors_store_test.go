@@ -4,6 +4,11 @@ import (
 	"context"
 	"sort"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 
 	"github.com/alice-lg/alice-lg/pkg/api"
 	"github.com/alice-lg/alice-lg/pkg/config"
@@ -119,6 +124,78 @@ func TestGetNeighbors(t *testing.T) {
 
 }
 
+func TestNeighborsStoreStop(t *testing.T) {
+	cfg := &config.Config{
+		Sources: []*config.SourceConfig{
+			{ID: "rs1", Name: "rs1"},
+		},
+	}
+	store := NewNeighborsStore(cfg, memory.NewNeighborsBackend())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		store.Start(ctx)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("store did not stop")
+	}
+}
+
+func TestNeighborsStoreAbortedRefresh(t *testing.T) {
+	store := makeTestNeighborsStore()
+	src := &testNeighborsSource{
+		neighbors: api.Neighbors{
+			{ID: "ID2233_AS2342", ASN: 2342, State: "up"},
+		},
+	}
+
+	// The neighbors of an aborted refresh are not stored
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := store.updateSource(ctx, src, "rs1"); err == nil {
+		t.Error("expected an error")
+	}
+	neighbors, err := store.GetNeighborsAt(context.Background(), "rs1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighbors) != 5 {
+		t.Error("expected 5 neighbors, got:", len(neighbors))
+	}
+}
+
+func TestNeighborsStoreRefreshMetrics(t *testing.T) {
+	store := makeTestNeighborsStore()
+	src := &testNeighborsSource{
+		neighbors: api.Neighbors{
+			{ID: "ID2233_AS2342", ASN: 2342, State: "up"},
+			{ID: "ID2233_AS2343", ASN: 2343, State: "up"},
+		},
+	}
+	if err := store.updateSource(context.Background(), src, "rs2"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.ToFloat64(refreshNeighbors.WithLabelValues("rs2")); n != 2 {
+		t.Error("expected 2 neighbors, got:", n)
+	}
+
+	m := &dto.Metric{}
+	obs := refreshDuration.WithLabelValues("neighbors", "rs2", "success")
+	if err := obs.(prometheus.Histogram).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if m.GetHistogram().GetSampleCount() == 0 {
+		t.Error("expected the refresh to be recorded")
+	}
+}
+
 func TestNeighborLookup(t *testing.T) {
 	store := makeTestNeighborsStore()
 
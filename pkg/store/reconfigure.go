@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+	"github.com/alice-lg/alice-lg/pkg/sources"
+)
+
+// ErrSourceReconfigured is returned when a refresh was
+// started before the source was reconfigured or removed.
+// The result of the refresh is discarded.
+var ErrSourceReconfigured = errors.New(
+	"source was reconfigured during the refresh")
+
+// SourcesBackend is implemented by store backends
+// which keep resources for each configured source.
+type SourcesBackend interface {
+	// SetSources updates the configured sources.
+	SetSources(
+		ctx context.Context,
+		sources []*config.SourceConfig,
+	) error
+}
+
+// routeLabels are the community labels and reasons
+// used by the routes statistics. They are replaced
+// when the configuration is reloaded.
+type routeLabels struct {
+	communities   api.BGPCommunityMap
+	rejectReasons api.BGPCommunityMap
+}
+
+func newRouteLabels(cfg *config.Config) *routeLabels {
+	return &routeLabels{
+		communities:   cfg.UI.BGPCommunities,
+		rejectReasons: cfg.UI.RoutesRejections.Reasons,
+	}
+}
+
+// Reconfigure updates the sources after the configuration
+// was reloaded. Removed sources are dropped, added and
+// reconfigured sources start over uninitialized.
+func (s *SourcesStore) Reconfigure(
+	cfg *config.Config,
+	diff *config.SourcesDiff,
+) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, src := range diff.Removed {
+		delete(s.sources, src.ID)
+		delete(s.status, src.ID)
+		delete(s.retired, src.ID)
+	}
+	// Refreshes of the previous instance might still
+	// be running and must not be stored.
+	for _, src := range diff.Changed {
+		if prev, ok := s.sources[src.ID]; ok {
+			s.retired[src.ID] = prev
+		}
+	}
+	for _, src := range slices.Concat(diff.Added, diff.Changed) {
+		s.status[src.ID] = &Status{
+			RefreshInterval: s.refreshInterval,
+			SourceID:        src.ID,
+		}
+	}
+	for _, src := range cfg.Sources {
+		s.sources[src.ID] = src
+	}
+	s.generation++
+}
+
+// IsCurrent checks if the source is still configured
+// and the instance was not replaced by a reload.
+func (s *SourcesStore) IsCurrent(
+	sourceID string,
+	instance sources.Source,
+) bool {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.sources[sourceID]; !ok {
+		return false
+	}
+	prev, ok := s.retired[sourceID]
+	return !ok || !prev.IsInstance(instance)
+}
+
+// Reconfigure applies the reloaded configuration. The
+// neighbors of removed and reconfigured sources are
+// discarded, unchanged sources keep their neighbors.
+func (s *NeighborsStore) Reconfigure(
+	ctx context.Context,
+	cfg *config.Config,
+	diff *config.SourcesDiff,
+) error {
+	s.sources.Reconfigure(cfg, diff)
+	for _, id := range diff.Reset() {
+		if err := s.backend.SetNeighbors(ctx, id, api.Neighbors{}); err != nil {
+			return err
+		}
+	}
+	log.Println("[neighbors store] reconfigured sources:",
+		len(diff.Added), "added,",
+		len(diff.Removed), "removed,",
+		len(diff.Changed), "changed")
+	return nil
+}
+
+// Reconfigure applies the reloaded configuration. The
+// routes, route history and statistics of removed and
+// reconfigured sources are discarded. The community
+// labels are replaced.
+func (s *RoutesStore) Reconfigure(
+	ctx context.Context,
+	cfg *config.Config,
+	diff *config.SourcesDiff,
+) error {
+	s.labels.Store(newRouteLabels(cfg))
+	s.sources.Reconfigure(cfg, diff)
+
+	if b, ok := s.backend.(SourcesBackend); ok {
+		if err := b.SetSources(ctx, cfg.Sources); err != nil {
+			return err
+		}
+	}
+	for _, id := range diff.Reset() {
+		if err := s.backend.SetRoutes(ctx, id, api.LookupRoutes{}); err != nil {
+			return err
+		}
+		s.history.Remove(id)
+		s.statistics.remove(id)
+	}
+	log.Println("[routes store] reconfigured sources:",
+		len(diff.Added), "added,",
+		len(diff.Removed), "removed,",
+		len(diff.Changed), "changed")
+	return nil
+}
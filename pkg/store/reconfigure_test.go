@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+	"github.com/alice-lg/alice-lg/pkg/sources/openbgpd"
+)
+
+func TestRoutesStoreReconfigure(t *testing.T) {
+	ctx := context.Background()
+	s := makeTestRoutesStore()
+	current := &config.Config{
+		Sources: []*config.SourceConfig{
+			s.sources.Get("rs1"),
+			s.sources.Get("rs2"),
+		},
+	}
+	s.updateStatistics("rs1", nil)
+
+	// Reconfigure rs2 and add rs3
+	next := &config.Config{
+		Sources: []*config.SourceConfig{
+			{ID: "rs1", Name: "rs1"},
+			{ID: "rs2", Name: "rs2.example.net"},
+			{ID: "rs3", Name: "rs3"},
+		},
+	}
+	diff := config.DiffSources(current, next)
+	if err := s.Reconfigure(ctx, next, diff); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := s.sources.GetSourceIDs()
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"rs1", "rs2", "rs3"}) {
+		t.Error("unexpected sources:", ids)
+	}
+	if s.sources.GetName("rs2") != "rs2.example.net" {
+		t.Error("source rs2 was not reconfigured")
+	}
+	imported, _, err := s.backend.CountRoutesAt(ctx, "rs1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported == 0 {
+		t.Error("routes of the unchanged source should be kept")
+	}
+
+	// Remove rs1
+	current, next = next, &config.Config{
+		Sources: []*config.SourceConfig{
+			{ID: "rs2", Name: "rs2.example.net"},
+			{ID: "rs3", Name: "rs3"},
+		},
+	}
+	diff = config.DiffSources(current, next)
+	if err := s.Reconfigure(ctx, next, diff); err != nil {
+		t.Fatal(err)
+	}
+	if s.sources.Get("rs1") != nil {
+		t.Error("source rs1 should be removed")
+	}
+	imported, _, _ = s.backend.CountRoutesAt(ctx, "rs1")
+	if imported != 0 {
+		t.Error("routes of the removed source should be discarded")
+	}
+	if s.statistics.get("rs1") != nil {
+		t.Error("statistics of the removed source should be discarded")
+	}
+}
+
+func TestSourcesStoreIsCurrent(t *testing.T) {
+	prev := &config.SourceConfig{
+		ID:            "rs1",
+		Backend:       config.SourceBackendOpenBGPDStateServer,
+		BackendConfig: &openbgpd.Config{},
+	}
+	current := &config.Config{Sources: []*config.SourceConfig{prev}}
+	s := NewSourcesStore(current, 1, 1)
+	instance := prev.GetInstance()
+	if !s.IsCurrent("rs1", instance) {
+		t.Error("expected instance to be current")
+	}
+
+	next := &config.Config{
+		Sources: []*config.SourceConfig{{
+			ID:            "rs1",
+			Name:          "rs1.example.net",
+			Backend:       config.SourceBackendOpenBGPDStateServer,
+			BackendConfig: &openbgpd.Config{},
+		}},
+	}
+	s.Reconfigure(next, config.DiffSources(current, next))
+	if s.IsCurrent("rs1", instance) {
+		t.Error("instance of the previous configuration is not current")
+	}
+	if !s.IsCurrent("rs1", next.Sources[0].GetInstance()) {
+		t.Error("expected the new instance to be current")
+	}
+	if s.IsCurrent("rs2", nil) {
+		t.Error("unknown source should not be current")
+	}
+}
@@ -0,0 +1,345 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// ErrRouteHistoryDisabled is returned for route changes
+// if no refreshes are kept.
+var ErrRouteHistoryDisabled = errors.New(
+	"route changes are disabled")
+
+// routeEntry is the state of a single route
+// after a refresh.
+type routeEntry struct {
+	state string
+	bgp   *api.BGPInfo
+}
+
+// routeDelta is a change of a route in a refresh.
+// Before is nil for an added and after is nil for
+// a withdrawn route.
+type routeDelta struct {
+	before *routeEntry
+	after  *routeEntry
+}
+
+// routeDeltas are the changed routes of a neighbor
+// by network in a single refresh.
+type routeDeltas struct {
+	refreshedAt time.Time
+	changes     map[string]*routeDelta
+}
+
+// routeSourceHistory are the routes of all neighbors of
+// a source after the last refresh and the changes
+// of the kept refreshes.
+type routeSourceHistory struct {
+	// The times of the kept refreshes, oldest first
+	refreshes []time.Time
+
+	// neighborID -> network -> entry
+	routes map[string]map[string]*routeEntry
+
+	// neighborID -> deltas, oldest first
+	deltas    map[string][]*routeDeltas
+	summaries map[string]*api.RouteChangesSummary
+}
+
+// RouteHistory keeps the changes of the routes of each
+// neighbor of a source for the last refreshes. Only the
+// current routes and the changes between refreshes are
+// kept, so the memory depends on the number of changes.
+type RouteHistory struct {
+	sync.RWMutex
+
+	size    int
+	sources map[string]*routeSourceHistory
+}
+
+// NewRouteHistory creates a new route history
+// keeping the changes of size refreshes.
+func NewRouteHistory(size int) *RouteHistory {
+	return &RouteHistory{
+		size:    size,
+		sources: make(map[string]*routeSourceHistory),
+	}
+}
+
+// Remove drops the history of a source
+func (h *RouteHistory) Remove(sourceID string) {
+	h.Lock()
+	defer h.Unlock()
+	delete(h.sources, sourceID)
+}
+
+// Add records the changes of the routes of a source
+// for each neighbor. The routes of the first refresh
+// are the base for the following changes.
+func (h *RouteHistory) Add(
+	sourceID string,
+	routes api.LookupRoutes,
+	now time.Time,
+) {
+	if h.size <= 0 {
+		return
+	}
+
+	// Group routes by neighbor and network. With multiple
+	// paths, the imported route is preferred.
+	current := map[string]map[string]*routeEntry{}
+	for _, r := range routes {
+		if r.NeighborID == nil {
+			continue
+		}
+		neighborID := *r.NeighborID
+		byNetwork, ok := current[neighborID]
+		if !ok {
+			byNetwork = map[string]*routeEntry{}
+			current[neighborID] = byNetwork
+		}
+		if prev, ok := byNetwork[r.Network]; ok &&
+			prev.state == api.RouteStateImported {
+			continue
+		}
+		byNetwork[r.Network] = &routeEntry{
+			state: r.State,
+			bgp:   r.BGP,
+		}
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	src, ok := h.sources[sourceID]
+	if !ok {
+		src = &routeSourceHistory{
+			refreshes: []time.Time{now},
+			routes:    current,
+			deltas:    map[string][]*routeDeltas{},
+		}
+		src.updateSummaries()
+		h.sources[sourceID] = src
+		return
+	}
+
+	// Neighbors without routes withdrew all routes
+	for neighborID := range src.routes {
+		if _, ok := current[neighborID]; !ok {
+			current[neighborID] = map[string]*routeEntry{}
+		}
+	}
+	for neighborID, byNetwork := range current {
+		changes := diffRouteEntries(src.routes[neighborID], byNetwork)
+		if len(changes) > 0 {
+			src.deltas[neighborID] = append(
+				src.deltas[neighborID], &routeDeltas{
+					refreshedAt: now,
+					changes:     changes,
+				})
+		}
+		if len(byNetwork) == 0 {
+			delete(current, neighborID)
+		}
+	}
+	src.routes = current
+
+	src.refreshes = append(src.refreshes, now)
+	if len(src.refreshes) > h.size {
+		src.refreshes = src.refreshes[len(src.refreshes)-h.size:]
+	}
+
+	// Drop the changes before the oldest refresh
+	oldest := src.refreshes[0]
+	for neighborID, deltas := range src.deltas {
+		i := 0
+		for i < len(deltas) && !deltas[i].refreshedAt.After(oldest) {
+			i++
+		}
+		if i == len(deltas) {
+			delete(src.deltas, neighborID)
+			continue
+		}
+		src.deltas[neighborID] = deltas[i:]
+	}
+	src.updateSummaries()
+}
+
+// updateSummaries counts the changes of the neighbors
+// with routes or changes within the kept refreshes.
+func (src *routeSourceHistory) updateSummaries() {
+	oldest := src.refreshes[0]
+	latest := src.refreshes[len(src.refreshes)-1]
+	summaries := make(map[string]*api.RouteChangesSummary)
+	for neighborID := range src.routes {
+		summaries[neighborID] = &api.RouteChangesSummary{Since: oldest}
+	}
+	for neighborID, deltas := range src.deltas {
+		summaries[neighborID] = composeRouteDeltas(
+			deltas, oldest, latest).Summary()
+	}
+	src.summaries = summaries
+}
+
+// Changes combines the changes of the refreshes after
+// the refresh at or before since. If since is zero, the
+// changes since the oldest kept refresh are returned.
+// The changes are not complete if since is before the
+// oldest kept refresh or the routes were not refreshed yet.
+func (h *RouteHistory) Changes(
+	sourceID string,
+	neighborID string,
+	since time.Time,
+) (*api.RouteChanges, error) {
+	if h.size <= 0 {
+		return nil, ErrRouteHistoryDisabled
+	}
+
+	h.RLock()
+	defer h.RUnlock()
+
+	src, ok := h.sources[sourceID]
+	if !ok {
+		return composeRouteDeltas(nil, time.Time{}, time.Time{}), nil
+	}
+	oldest := src.refreshes[0]
+	if since.IsZero() {
+		since = oldest
+	}
+
+	base := oldest
+	for _, t := range src.refreshes {
+		if t.After(since) {
+			break
+		}
+		base = t
+	}
+	latest := src.refreshes[len(src.refreshes)-1]
+	changes := composeRouteDeltas(src.deltas[neighborID], base, latest)
+	changes.Complete = !since.Before(oldest)
+	return changes, nil
+}
+
+// Summaries returns the number of changes for all
+// neighbors of a source within the kept refreshes.
+func (h *RouteHistory) Summaries(
+	sourceID string,
+) map[string]*api.RouteChangesSummary {
+	h.RLock()
+	defer h.RUnlock()
+	src, ok := h.sources[sourceID]
+	if !ok {
+		return nil
+	}
+	return src.summaries
+}
+
+// diffRouteEntries lists the changed routes of a neighbor
+// between two refreshes. Unchanged entries of the current
+// refresh are replaced with the previous entries.
+func diffRouteEntries(
+	prev map[string]*routeEntry,
+	current map[string]*routeEntry,
+) map[string]*routeDelta {
+	changes := map[string]*routeDelta{}
+	for network, entry := range current {
+		p, ok := prev[network]
+		if !ok {
+			changes[network] = &routeDelta{after: entry}
+			continue
+		}
+		if p.state == entry.state &&
+			len(p.bgp.DiffAttributes(entry.bgp)) == 0 {
+			current[network] = p
+			continue
+		}
+		changes[network] = &routeDelta{before: p, after: entry}
+	}
+	for network, p := range prev {
+		if _, ok := current[network]; !ok {
+			changes[network] = &routeDelta{before: p}
+		}
+	}
+	return changes
+}
+
+// composeRouteDeltas combines the changes of the refreshes
+// after from into the added, withdrawn and modified routes.
+func composeRouteDeltas(
+	deltas []*routeDeltas,
+	from time.Time,
+	to time.Time,
+) *api.RouteChanges {
+	combined := map[string]*routeDelta{}
+	for _, d := range deltas {
+		if !d.refreshedAt.After(from) {
+			continue
+		}
+		for network, change := range d.changes {
+			c, ok := combined[network]
+			if !ok {
+				combined[network] = &routeDelta{
+					before: change.before,
+					after:  change.after,
+				}
+				continue
+			}
+			c.after = change.after
+		}
+	}
+
+	changes := &api.RouteChanges{
+		From:      from,
+		To:        to,
+		Added:     []*api.RouteChange{},
+		Withdrawn: []*api.RouteChange{},
+		Modified:  []*api.RouteChange{},
+	}
+	for network, c := range combined {
+		switch {
+		case c.before == nil && c.after == nil:
+			continue // Added and withdrawn again
+		case c.before == nil:
+			changes.Added = append(changes.Added, &api.RouteChange{
+				Network:    network,
+				StateAfter: c.after.state,
+				After:      c.after.bgp,
+			})
+		case c.after == nil:
+			changes.Withdrawn = append(changes.Withdrawn, &api.RouteChange{
+				Network:     network,
+				StateBefore: c.before.state,
+				Before:      c.before.bgp,
+			})
+		default:
+			attrs := c.before.bgp.DiffAttributes(c.after.bgp)
+			if c.before.state == c.after.state && len(attrs) == 0 {
+				continue // Changed back
+			}
+			changes.Modified = append(changes.Modified, &api.RouteChange{
+				Network:     network,
+				StateBefore: c.before.state,
+				StateAfter:  c.after.state,
+				Before:      c.before.bgp,
+				After:       c.after.bgp,
+				Attributes:  attrs,
+			})
+		}
+	}
+
+	sortRouteChanges(changes.Added)
+	sortRouteChanges(changes.Withdrawn)
+	sortRouteChanges(changes.Modified)
+	return changes
+}
+
+func sortRouteChanges(changes []*api.RouteChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Network < changes[j].Network
+	})
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func makeTestHistoryRoute(
+	neighborID string,
+	network string,
+	state string,
+	localPref int,
+) *api.LookupRoute {
+	return &api.LookupRoute{
+		Route: &api.Route{
+			NeighborID: &neighborID,
+			Network:    network,
+			BGP: &api.BGPInfo{
+				AsPath:    []int{2342},
+				LocalPref: localPref,
+			},
+		},
+		State: state,
+	}
+}
+
+func TestRouteHistoryChanges(t *testing.T) {
+	h := NewRouteHistory(3)
+	t0 := time.Date(2023, 1, 1, 8, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	t2 := t1.Add(time.Hour)
+
+	h.Add("rs1", api.LookupRoutes{
+		makeTestHistoryRoute("n1", "10.0.0.0/8", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n1", "10.1.0.0/16", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n1", "10.2.0.0/16", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n1", "10.4.0.0/16", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n2", "10.9.0.0/16", api.RouteStateImported, 100),
+	}, t0)
+	h.Add("rs1", api.LookupRoutes{
+		makeTestHistoryRoute("n1", "10.0.0.0/8", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n1", "10.1.0.0/16", api.RouteStateFiltered, 100),
+		makeTestHistoryRoute("n1", "10.2.0.0/16", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n1", "10.4.0.0/16", api.RouteStateImported, 200),
+		makeTestHistoryRoute("n1", "10.5.0.0/16", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n2", "10.9.0.0/16", api.RouteStateImported, 100),
+	}, t1)
+	h.Add("rs1", api.LookupRoutes{
+		makeTestHistoryRoute("n1", "10.0.0.0/8", api.RouteStateImported, 200),
+		makeTestHistoryRoute("n1", "10.1.0.0/16", api.RouteStateFiltered, 100),
+		makeTestHistoryRoute("n1", "10.3.0.0/16", api.RouteStateImported, 100),
+		makeTestHistoryRoute("n1", "10.4.0.0/16", api.RouteStateImported, 100),
+	}, t2)
+
+	// All changes since the first refresh
+	changes, err := h.Changes("rs1", "n1", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.From.Equal(t0) || !changes.To.Equal(t2) || !changes.Complete {
+		t.Error("unexpected range:", changes.From, changes.To)
+	}
+	if len(changes.Added) != 1 || changes.Added[0].Network != "10.3.0.0/16" {
+		t.Error("unexpected added:", changes.Added)
+	}
+	if len(changes.Withdrawn) != 1 || changes.Withdrawn[0].Network != "10.2.0.0/16" {
+		t.Error("unexpected withdrawn:", changes.Withdrawn)
+	}
+	if len(changes.Modified) != 2 {
+		t.Fatal("unexpected modified:", changes.Modified)
+	}
+	mod := changes.Modified[0]
+	if mod.Network != "10.0.0.0/8" || mod.Before.LocalPref != 100 ||
+		mod.After.LocalPref != 200 || mod.Attributes[0] != "local_pref" {
+		t.Error("unexpected modification:", mod)
+	}
+	mod = changes.Modified[1]
+	if mod.StateBefore != api.RouteStateImported ||
+		mod.StateAfter != api.RouteStateFiltered {
+		t.Error("unexpected modification:", mod)
+	}
+
+	// Changes since the second refresh
+	changes, err = h.Changes("rs1", "n1", t1.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changes.From.Equal(t1) || len(changes.Modified) != 2 ||
+		len(changes.Added) != 1 || len(changes.Withdrawn) != 2 {
+		t.Error("unexpected changes:", changes)
+	}
+
+	// Only the changes are kept
+	deltas := h.sources["rs1"].deltas["n1"]
+	if len(deltas) != 2 || len(deltas[0].changes) != 3 {
+		t.Error("unexpected deltas:", deltas)
+	}
+
+	// The second neighbor withdrew all routes
+	summary := h.Summaries("rs1")["n2"]
+	if summary.Withdrawn != 1 || !summary.Since.Equal(t0) {
+		t.Error("unexpected summary:", summary)
+	}
+
+	// Unknown neighbors have no changes
+	changes, err = h.Changes("rs1", "n3", time.Time{})
+	if err != nil || len(changes.Added) != 0 || !changes.To.Equal(t2) {
+		t.Error("expected no changes for unknown neighbor:", changes, err)
+	}
+
+	// Since is before the history
+	changes, err = h.Changes("rs1", "n1", t0.Add(-time.Minute))
+	if err != nil || changes.Complete || !changes.From.Equal(t0) {
+		t.Error("expected incomplete changes:", changes, err)
+	}
+
+	// The routes were not refreshed yet
+	changes, err = h.Changes("rs2", "n1", time.Time{})
+	if err != nil || changes.Complete || len(changes.Added) != 0 {
+		t.Error("expected incomplete changes:", changes, err)
+	}
+}
+
+func TestRouteHistorySize(t *testing.T) {
+	h := NewRouteHistory(2)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		h.Add("rs1", api.LookupRoutes{
+			makeTestHistoryRoute("n1", "10.0.0.0/8", api.RouteStateImported, i),
+		}, now.Add(time.Duration(i)*time.Minute))
+	}
+	src := h.sources["rs1"]
+	if len(src.refreshes) != 2 || len(src.deltas["n1"]) != 1 {
+		t.Error("expected 2 refreshes with 1 change:", src.refreshes, src.deltas)
+	}
+	changes, err := h.Changes("rs1", "n1", time.Time{})
+	if err != nil || len(changes.Modified) != 1 ||
+		changes.Modified[0].Before.LocalPref != 3 {
+		t.Error("unexpected changes:", changes, err)
+	}
+
+	// Disabled
+	h = NewRouteHistory(0)
+	h.Add("rs1", api.LookupRoutes{
+		makeTestHistoryRoute("n1", "10.0.0.0/8", api.RouteStateImported, 1),
+	}, now)
+	if _, err := h.Changes("rs1", "n1", time.Time{}); err != ErrRouteHistoryDisabled {
+		t.Error("route history should be disabled:", err)
+	}
+}
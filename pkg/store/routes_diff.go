@@ -0,0 +1,219 @@
+package store
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// neighborDiffKey identifies the same neighbor on
+// different sources. The neighbor address is used if
+// available, otherwise the ASN.
+func neighborDiffKey(n *api.Neighbor) string {
+	if n.Address != "" {
+		return n.Address
+	}
+	return "AS" + strconv.Itoa(n.ASN)
+}
+
+// neighborRoutes collects the routes of a neighbor
+// per source and network.
+type neighborRoutes struct {
+	neighbor *api.Neighbor
+	ids      map[string]string
+	routes   map[string]map[string]*api.LookupRoute
+}
+
+// DiffSources compares the routes of the neighbors present
+// on multiple sources. Only neighbors with differences
+// are included in the result.
+// The routes are retrieved from the store, the sources
+// are not queried.
+//
+// The visible func returns the route as it may be seen
+// by the client or nil if the route is hidden. Hidden
+// routes are not compared. With nil, all routes are used.
+func (s *RoutesStore) DiffSources(
+	ctx context.Context,
+	sourceIDs []string,
+	visible func(*api.LookupRoute) *api.LookupRoute,
+) ([]*api.NeighborRoutesDiff, error) {
+	neighbors := map[string]*neighborRoutes{}
+
+	for _, sourceID := range sourceIDs {
+		sourceNeighbors, err := s.neighbors.GetNeighborsMapAt(ctx, sourceID)
+		if err != nil {
+			return nil, err
+		}
+		query := make([]*api.NeighborQuery, 0, len(sourceNeighbors))
+		for _, n := range sourceNeighbors {
+			key := neighborDiffKey(n)
+			nr, ok := neighbors[key]
+			if !ok {
+				nr = &neighborRoutes{
+					neighbor: n,
+					ids:      map[string]string{},
+					routes:   map[string]map[string]*api.LookupRoute{},
+				}
+				neighbors[key] = nr
+			}
+			nr.ids[sourceID] = n.ID
+			nr.routes[sourceID] = map[string]*api.LookupRoute{}
+
+			if q := newNeighborQuery(n.ID, sourceID); q != nil {
+				query = append(query, q)
+			}
+		}
+		if len(query) == 0 {
+			continue
+		}
+
+		routes, err := s.backend.FindByNeighbors(
+			ctx, query, api.NewSearchFilters())
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range routes {
+			if visible != nil {
+				if r = visible(r); r == nil {
+					continue
+				}
+			}
+			if r.Neighbor == nil {
+				continue
+			}
+			nr, ok := neighbors[neighborDiffKey(r.Neighbor)]
+			if !ok {
+				continue
+			}
+			// With multiple paths, prefer the imported route.
+			byNetwork := nr.routes[sourceID]
+			if prev, ok := byNetwork[r.Network]; ok &&
+				prev.State == api.RouteStateImported {
+				continue
+			}
+			byNetwork[r.Network] = r
+		}
+	}
+
+	keys := make([]string, 0, len(neighbors))
+	for key := range neighbors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := []*api.NeighborRoutesDiff{}
+	for _, key := range keys {
+		diff := diffNeighborRoutes(neighbors[key], sourceIDs)
+		if diff != nil {
+			result = append(result, diff)
+		}
+	}
+	return result, nil
+}
+
+// diffNeighborRoutes compares the routes of a neighbor.
+// Nil is returned if the routes are the same on all sources.
+func diffNeighborRoutes(
+	nr *neighborRoutes,
+	sourceIDs []string,
+) *api.NeighborRoutesDiff {
+	present := []string{}
+	missing := []string{}
+	for _, id := range sourceIDs {
+		if _, ok := nr.ids[id]; ok {
+			present = append(present, id)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+
+	// Collect all networks
+	networks := []string{}
+	seen := map[string]bool{}
+	for _, id := range present {
+		for network := range nr.routes[id] {
+			if !seen[network] {
+				seen[network] = true
+				networks = append(networks, network)
+			}
+		}
+	}
+	sort.Strings(networks)
+
+	diffs := []*api.RouteDiff{}
+	for _, network := range networks {
+		if d := diffRoute(nr, network, present); d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+
+	if len(missing) == 0 && len(diffs) == 0 {
+		return nil
+	}
+
+	return &api.NeighborRoutesDiff{
+		Address:     nr.neighbor.Address,
+		ASN:         nr.neighbor.ASN,
+		Description: nr.neighbor.Description,
+		NeighborIDs: nr.ids,
+		MissingOn:   missing,
+		Routes:      diffs,
+	}
+}
+
+// diffRoute compares a single network on all sources
+// where the neighbor is present.
+func diffRoute(
+	nr *neighborRoutes,
+	network string,
+	sourceIDs []string,
+) *api.RouteDiff {
+	diff := &api.RouteDiff{
+		Network: network,
+		Paths:   map[string]*api.RouteDiffPath{},
+	}
+
+	var ref *api.LookupRoute
+	states := map[string]bool{}
+	attrs := []string{}
+	for _, id := range sourceIDs {
+		r, ok := nr.routes[id][network]
+		if !ok {
+			diff.MissingOn = append(diff.MissingOn, id)
+			continue
+		}
+		diff.Paths[id] = &api.RouteDiffPath{
+			NeighborID: nr.ids[id],
+			State:      r.State,
+			BGP:        r.BGP,
+		}
+		states[r.State] = true
+
+		if ref == nil {
+			ref = r
+			continue
+		}
+		for _, attr := range ref.BGP.DiffAttributes(r.BGP) {
+			if !slices.Contains(attrs, attr) {
+				attrs = append(attrs, attr)
+			}
+		}
+	}
+
+	switch {
+	case len(diff.MissingOn) > 0:
+		diff.Kind = api.RouteDiffMissing
+	case len(states) > 1:
+		diff.Kind = api.RouteDiffState
+	case len(attrs) > 0:
+		diff.Kind = api.RouteDiffAttributes
+	default:
+		return nil
+	}
+	diff.Attributes = attrs
+	return diff
+}
@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+	"github.com/alice-lg/alice-lg/pkg/config"
+	"github.com/alice-lg/alice-lg/pkg/pools"
+	"github.com/alice-lg/alice-lg/pkg/store/backends/memory"
+)
+
+func makeTestDiffRoute(
+	sourceID string,
+	neighbor *api.Neighbor,
+	network string,
+	state string,
+	localPref int,
+) *api.LookupRoute {
+	return &api.LookupRoute{
+		Route: &api.Route{
+			NeighborID: pools.Neighbors.Acquire(neighbor.ID),
+			Network:    network,
+			BGP: &api.BGPInfo{
+				AsPath:      []int{neighbor.ASN},
+				LocalPref:   localPref,
+				Communities: api.Communities{{65000, 1}},
+			},
+		},
+		State:    state,
+		Neighbor: neighbor,
+		RouteServer: &api.LookupRouteServer{
+			ID: pools.RouteServers.Acquire(sourceID),
+		},
+	}
+}
+
+func makeTestDiffRoutesStore() *RoutesStore {
+	ctx := context.Background()
+	cfg := &config.Config{
+		Server: config.ServerConfig{
+			NeighborsStoreRefreshInterval: 1,
+			RoutesStoreRefreshInterval:    1,
+		},
+		Sources: []*config.SourceConfig{
+			{ID: "rs1", Name: "rs1"},
+			{ID: "rs2", Name: "rs2"},
+		},
+	}
+
+	n1a := &api.Neighbor{ID: "n1_rs1", Address: "10.0.0.1", ASN: 2342}
+	n1b := &api.Neighbor{ID: "n1_rs2", Address: "10.0.0.1", ASN: 2342}
+	n2a := &api.Neighbor{ID: "n2_rs1", Address: "10.0.0.2", ASN: 4242}
+	n2b := &api.Neighbor{ID: "n2_rs2", Address: "10.0.0.2", ASN: 4242}
+	n3a := &api.Neighbor{ID: "n3_rs1", Address: "10.0.0.3", ASN: 2323}
+
+	nb := memory.NewNeighborsBackend()
+	nb.SetNeighbors(ctx, "rs1", api.Neighbors{n1a, n2a, n3a})
+	nb.SetNeighbors(ctx, "rs2", api.Neighbors{n1b, n2b})
+	neighbors := NewNeighborsStore(cfg, nb)
+	neighbors.sources.RefreshSuccess("rs1")
+	neighbors.sources.RefreshSuccess("rs2")
+
+	rb := memory.NewRoutesBackend()
+	rb.SetRoutes(ctx, "rs1", api.LookupRoutes{
+		makeTestDiffRoute("rs1", n1a, "10.23.0.0/16", api.RouteStateImported, 100),
+		makeTestDiffRoute("rs1", n1a, "10.42.0.0/16", api.RouteStateImported, 100),
+		makeTestDiffRoute("rs1", n1a, "10.66.0.0/16", api.RouteStateImported, 100),
+		makeTestDiffRoute("rs1", n1a, "10.99.0.0/16", api.RouteStateImported, 100),
+		makeTestDiffRoute("rs1", n2a, "10.50.0.0/16", api.RouteStateImported, 100),
+		makeTestDiffRoute("rs1", n3a, "10.70.0.0/16", api.RouteStateImported, 100),
+	})
+	rb.SetRoutes(ctx, "rs2", api.LookupRoutes{
+		makeTestDiffRoute("rs2", n1b, "10.23.0.0/16", api.RouteStateImported, 100),
+		makeTestDiffRoute("rs2", n1b, "10.42.0.0/16", api.RouteStateFiltered, 100),
+		makeTestDiffRoute("rs2", n1b, "10.66.0.0/16", api.RouteStateImported, 200),
+		makeTestDiffRoute("rs2", n2b, "10.50.0.0/16", api.RouteStateImported, 100),
+	})
+
+	return NewRoutesStore(neighbors, cfg, rb)
+}
+
+func TestRoutesStoreDiffSources(t *testing.T) {
+	s := makeTestDiffRoutesStore()
+	diff, err := s.DiffSources(context.Background(), []string{"rs1", "rs2"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Neighbor 2 is the same on both sources.
+	if len(diff) != 2 {
+		t.Fatal("expected 2 neighbors, got:", len(diff))
+	}
+
+	n1 := diff[0]
+	if n1.Address != "10.0.0.1" {
+		t.Error("unexpected neighbor:", n1.Address)
+	}
+	if n1.NeighborIDs["rs2"] != "n1_rs2" {
+		t.Error("unexpected neighbor ids:", n1.NeighborIDs)
+	}
+	if len(n1.Routes) != 3 {
+		t.Fatal("expected 3 route diffs, got:", len(n1.Routes))
+	}
+
+	expected := []struct {
+		network string
+		kind    string
+	}{
+		{"10.42.0.0/16", api.RouteDiffState},
+		{"10.66.0.0/16", api.RouteDiffAttributes},
+		{"10.99.0.0/16", api.RouteDiffMissing},
+	}
+	for i, e := range expected {
+		r := n1.Routes[i]
+		if r.Network != e.network || r.Kind != e.kind {
+			t.Error("unexpected diff:", r.Network, r.Kind)
+		}
+	}
+	if attrs := n1.Routes[1].Attributes; len(attrs) != 1 || attrs[0] != "local_pref" {
+		t.Error("unexpected attributes:", attrs)
+	}
+	if missing := n1.Routes[2].MissingOn; len(missing) != 1 || missing[0] != "rs2" {
+		t.Error("unexpected missing on:", missing)
+	}
+
+	// Neighbor 3 is only present on rs1
+	n3 := diff[1]
+	if len(n3.MissingOn) != 1 || n3.MissingOn[0] != "rs2" {
+		t.Error("unexpected missing on:", n3.MissingOn)
+	}
+}
+
+func TestRoutesStoreDiffSourcesVisible(t *testing.T) {
+	s := makeTestDiffRoutesStore()
+
+	// Hide the filtered routes
+	visible := func(r *api.LookupRoute) *api.LookupRoute {
+		if r.State == api.RouteStateFiltered {
+			return nil
+		}
+		return r
+	}
+	diff, err := s.DiffSources(
+		context.Background(), []string{"rs1", "rs2"}, visible)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range diff[0].Routes {
+		for _, p := range r.Paths {
+			if p.State == api.RouteStateFiltered {
+				t.Error("unexpected filtered path:", r.Network)
+			}
+		}
+	}
+	if r := diff[0].Routes[0]; r.Network != "10.42.0.0/16" ||
+		r.Kind != api.RouteDiffMissing {
+		t.Error("expected the hidden route to be missing:", r.Network, r.Kind)
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"log"
 	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/alice-lg/alice-lg/pkg/api"
@@ -30,6 +33,24 @@ func newNeighborQuery(neighborID string, sourceID string) *api.NeighborQuery {
 	}
 }
 
+// neighborQueries creates the backend queries
+// for a set of neighbors
+func neighborQueries(
+	neighbors api.NeighborsLookupResults,
+) []*api.NeighborQuery {
+	query := make([]*api.NeighborQuery, 0, len(neighbors))
+	for sourceID, sourceNeighbors := range neighbors {
+		for _, neighbor := range sourceNeighbors {
+			q := newNeighborQuery(neighbor.ID, sourceID)
+			if q == nil {
+				continue
+			}
+			query = append(query, q)
+		}
+	}
+	return query
+}
+
 // RoutesStoreBackend interface
 type RoutesStoreBackend interface {
 	// SetRoutes updates the routes in the store after a refresh.
@@ -55,6 +76,17 @@ type RoutesStoreBackend interface {
 		filters *api.SearchFilters,
 	) (api.LookupRoutes, error)
 
+	// StreamByNeighbors calls fn for each route of the
+	// neighbors, without collecting the result set.
+	// The routes of a source are ordered by network.
+	// An error returned by fn stops the iteration.
+	StreamByNeighbors(
+		ctx context.Context,
+		neighbors []*api.NeighborQuery,
+		filters *api.SearchFilters,
+		fn func(*api.LookupRoute) error,
+	) error
+
 	// FindByPrefix
 	FindByPrefix(
 		ctx context.Context,
@@ -64,6 +96,14 @@ type RoutesStoreBackend interface {
 	) (api.LookupRoutes, error)
 }
 
+// RoutesRefreshHook is called after the routes
+// of a source were refreshed.
+type RoutesRefreshHook func(
+	ctx context.Context,
+	sourceID string,
+	routes api.LookupRoutes,
+)
+
 // The RoutesStore holds a mapping of routes,
 // status and cfgs and will be queried instead
 // of a backend by the API
@@ -71,7 +111,16 @@ type RoutesStore struct {
 	backend   RoutesStoreBackend
 	sources   *SourcesStore
 	neighbors *NeighborsStore
+	history   *RouteHistory
 	limit     uint
+
+	statistics *Statistics
+	labels     atomic.Pointer[routeLabels]
+
+	hooks []RoutesRefreshHook
+
+	// Refreshes in progress
+	refreshes sync.WaitGroup
 }
 
 // NewRoutesStore makes a new store instance
@@ -103,23 +152,29 @@ func NewRoutesStore(
 		backend:   backend,
 		sources:   sources,
 		neighbors: neighbors,
+		history:   NewRouteHistory(cfg.Server.RoutesStoreHistory),
 		limit:     cfg.Server.RoutesStoreQueryLimit,
+
+		statistics: NewStatistics(),
 	}
+	store.labels.Store(newRouteLabels(cfg))
 	return store
 }
 
-// Start starts the routes store
+// Start starts the routes store. It returns when the
+// context is done and the refreshes are finished.
 func (s *RoutesStore) Start(ctx context.Context) {
 	log.Println("Starting local routes store")
 
 	// Periodically trigger updates
 	for {
-		if err := ctx.Err(); err != nil {
-			return // context is done
-		}
 		s.update(ctx)
-		time.Sleep(time.Second)
+		if err := sleep(ctx, time.Second); err != nil {
+			break // context is done
+		}
 	}
+	s.refreshes.Wait()
+	log.Println("Stopped local routes store")
 }
 
 // Update all routes from all sources, where the
@@ -128,7 +183,11 @@ func (s *RoutesStore) Start(ctx context.Context) {
 // NeighborsStore.update and maybe these functions can be merged (TODO)
 func (s *RoutesStore) update(ctx context.Context) {
 	for _, id := range s.sources.GetSourceIDsForRefresh() {
-		go s.safeUpdateSource(ctx, id)
+		s.refreshes.Add(1)
+		go func() {
+			defer s.refreshes.Done()
+			s.safeUpdateSource(ctx, id)
+		}()
 	}
 }
 
@@ -148,9 +207,16 @@ func (s *RoutesStore) safeUpdateSource(ctx context.Context, id string) {
 
 	// Apply jitter so, we do not hit everything at once.
 	// TODO: Make configurable
-	time.Sleep(time.Duration(rand.Intn(30)) * time.Second)
+	jitter := time.Duration(rand.Intn(30)) * time.Second
+	if err := sleep(ctx, jitter); err != nil {
+		s.sources.RefreshError(id, err)
+		return // Shutting down
+	}
 
 	src := s.sources.Get(id)
+	if src == nil {
+		return // The source was removed
+	}
 	srcName := s.sources.GetName(id)
 
 	log.Println("[routes store] begin routes refresh from", srcName)
@@ -165,7 +231,12 @@ func (s *RoutesStore) safeUpdateSource(ctx context.Context, id string) {
 		}
 	}()
 
-	if err := s.updateSource(ctx, src); err != nil {
+	if err := s.updateSource(ctx, src); errors.Is(err, ErrSourceReconfigured) {
+		log.Println("[routes store] discarding routes refresh from", src.Name+":", err)
+	} else if err != nil && ctx.Err() != nil {
+		log.Println("[routes store] aborted routes refresh from", src.Name+":", err)
+		s.sources.RefreshError(id, err)
+	} else if err != nil {
 		log.Println(
 			"[routes store] refreshing routes from", src.Name, "failed:", err)
 		s.sources.RefreshError(id, err)
@@ -179,11 +250,22 @@ func (s *RoutesStore) safeUpdateSource(ctx context.Context, id string) {
 	}
 }
 
+// AddRefreshHook registers a hook called after each
+// successful refresh. Hooks must be added before
+// the store is started.
+func (s *RoutesStore) AddRefreshHook(hook RoutesRefreshHook) {
+	s.hooks = append(s.hooks, hook)
+}
+
 // Update all routes
 func (s *RoutesStore) updateSource(
 	ctx context.Context,
 	src *config.SourceConfig,
-) error {
+) (err error) {
+	defer func(start time.Time) {
+		observeRefresh("routes", src.ID, start, err)
+	}(time.Now())
+
 	if err := s.awaitNeighborStore(ctx, src.ID); err != nil {
 		return err
 	}
@@ -214,11 +296,31 @@ func (s *RoutesStore) updateSource(
 	filtered := res.Filtered.ToLookupRoutes("filtered", srcRS, neighbors)
 	lookupRoutes := append(imported, filtered...)
 
+	if !s.sources.IsCurrent(src.ID, rs) {
+		return ErrSourceReconfigured
+	}
+
+	// Routes of an aborted refresh are not imported,
+	// an import in progress is not canceled.
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ctx = context.WithoutCancel(ctx)
+
 	log.Println("[routes store] importing", len(lookupRoutes), "routes into store from", src.Name)
 	if err = s.backend.SetRoutes(ctx, src.ID, lookupRoutes); err != nil {
 		return err
 	}
 	log.Println("[routes store] successfully imported", len(lookupRoutes), "routes into store from", src.Name)
+	refreshRoutes.WithLabelValues(src.ID, "imported").Set(float64(len(imported)))
+	refreshRoutes.WithLabelValues(src.ID, "filtered").Set(float64(len(filtered)))
+
+	s.history.Add(src.ID, lookupRoutes, time.Now().UTC())
+	s.updateStatistics(src.ID, lookupRoutes)
+
+	for _, hook := range s.hooks {
+		hook(ctx, src.ID, lookupRoutes)
+	}
 
 	return s.sources.RefreshSuccess(src.ID)
 }
@@ -238,7 +340,9 @@ func (s *RoutesStore) awaitNeighborStore(
 			return nil
 		}
 
-		time.Sleep(100 * time.Millisecond)
+		if err := sleep(ctx, 100*time.Millisecond); err != nil {
+			return err
+		}
 	}
 }
 
@@ -258,6 +362,9 @@ func (s *RoutesStore) Status(ctx context.Context) *api.StoreStatus {
 			State:           s.State.String(),
 			Initialized:     s.Initialized,
 		}
+		if errorSince := s.ErrorSince; !errorSince.IsZero() {
+			status[s.SourceID].ErrorSince = &errorSince
+		}
 	}
 
 	meta := &api.StoreStatus{
@@ -323,6 +430,19 @@ func (s *RoutesStore) CachedAt(
 	return s.sources.CachedAt(ctx)
 }
 
+// Generation returns the refresh generation of the
+// stored routes. It changes when routes of any source
+// were refreshed.
+func (s *RoutesStore) Generation(ctx context.Context) uint64 {
+	return s.sources.Generation()
+}
+
+// LastRefresh returns the time of the most recent
+// refresh of the routes of a source.
+func (s *RoutesStore) LastRefresh(ctx context.Context) time.Time {
+	return s.sources.LastRefresh()
+}
+
 // CacheTTL returns the TTL time
 func (s *RoutesStore) CacheTTL(
 	ctx context.Context,
@@ -346,16 +466,87 @@ func (s *RoutesStore) LookupPrefixForNeighbors(
 	neighbors api.NeighborsLookupResults,
 	filters *api.SearchFilters,
 ) (api.LookupRoutes, error) {
-	query := make([]*api.NeighborQuery, 0, len(neighbors))
+	query := neighborQueries(neighbors)
+	return s.backend.FindByNeighbors(ctx, query, filters)
+}
 
-	for sourceID, sourceNeighbors := range neighbors {
-		for _, neighbor := range sourceNeighbors {
-			q := newNeighborQuery(neighbor.ID, sourceID)
-			if q == nil {
-				continue
-			}
-			query = append(query, q)
+// StreamPrefixForNeighbors calls fn for each route of
+// a set of neighbors as it is read from the backend.
+func (s *RoutesStore) StreamPrefixForNeighbors(
+	ctx context.Context,
+	neighbors api.NeighborsLookupResults,
+	filters *api.SearchFilters,
+	fn func(*api.LookupRoute) error,
+) error {
+	query := neighborQueries(neighbors)
+	if len(query) == 0 {
+		return nil
+	}
+	return s.backend.StreamByNeighbors(ctx, query, filters, fn)
+}
+
+// RouteChanges returns the changes of the routes of a
+// neighbor since a point in time, see RouteHistory.Changes.
+func (s *RoutesStore) RouteChanges(
+	ctx context.Context,
+	sourceID string,
+	neighborID string,
+	since time.Time,
+) (*api.RouteChanges, error) {
+	return s.history.Changes(sourceID, neighborID, since)
+}
+
+// RouteChangesSummaries returns the number of changed
+// routes for each neighbor of a source.
+func (s *RoutesStore) RouteChangesSummaries(
+	ctx context.Context,
+	sourceID string,
+) map[string]*api.RouteChangesSummary {
+	return s.history.Summaries(sourceID)
+}
+
+// updateStatistics replaces the routes statistics
+// of a source.
+func (s *RoutesStore) updateStatistics(
+	sourceID string,
+	routes api.LookupRoutes,
+) {
+	stats := newRoutesStatistics()
+	for _, r := range routes {
+		stats.add(r, s.labels.Load().rejectReasons)
+	}
+	stats.updatedAt = time.Now().UTC()
+	s.statistics.set(sourceID, stats)
+}
+
+// Statistics returns the distribution of route attributes
+// for all route servers and the aggregate over all
+// route servers. For origin ASNs, communities and filter
+// reasons only the top n values are included.
+func (s *RoutesStore) Statistics(
+	ctx context.Context,
+	top int,
+) (*api.RoutesStatistics, []*api.RouteServerStatistics) {
+	labels := s.labels.Load()
+	rsStats := []*api.RouteServerStatistics{}
+	sourceIDs := s.sources.GetSourceIDs()
+	sort.Strings(sourceIDs) // Stable order of the results
+	for _, sourceID := range sourceIDs {
+		stats := s.statistics.get(sourceID)
+		if stats == nil {
+			continue // Not refreshed yet
 		}
+		src := s.sources.Get(sourceID)
+		rsStats = append(rsStats, &api.RouteServerStatistics{
+			ID:        src.ID,
+			Name:      src.Name,
+			Group:     src.Group,
+			UpdatedAt: stats.updatedAt,
+			RoutesStatistics: stats.toAPI(
+				top, labels.communities, labels.rejectReasons),
+		})
 	}
-	return s.backend.FindByNeighbors(ctx, query, filters)
+	global := s.statistics.global().toAPI(
+		top, labels.communities, labels.rejectReasons)
+	return &global, rsStats
 }
@@ -54,6 +54,10 @@ type Status struct {
 	Initialized         bool          `json:"initialized"`
 	SourceID            string        `json:"source_id"`
 
+	// ErrorSince is the time of the first failed
+	// refresh after the last successful refresh.
+	ErrorSince time.Time `json:"-"`
+
 	lastRefreshStart time.Time
 }
 
@@ -82,6 +86,8 @@ type SourcesStore struct {
 	refreshParallelism int
 	status             map[string]*Status
 	sources            map[string]*config.SourceConfig
+	retired            map[string]*config.SourceConfig
+	generation         uint64
 	sync.Mutex
 }
 
@@ -93,6 +99,7 @@ func NewSourcesStore(
 ) *SourcesStore {
 	status := make(map[string]*Status)
 	sources := make(map[string]*config.SourceConfig)
+	retired := make(map[string]*config.SourceConfig)
 
 	// Add sources from config
 	for _, src := range cfg.Sources {
@@ -107,6 +114,7 @@ func NewSourcesStore(
 	return &SourcesStore{
 		status:             status,
 		sources:            sources,
+		retired:            retired,
 		refreshInterval:    refreshInterval,
 		refreshParallelism: refreshParallelism,
 	}
@@ -222,14 +230,22 @@ func (s *SourcesStore) CachedAt(ctx context.Context) time.Time {
 func (s *SourcesStore) GetInstance(sourceID string) sources.Source {
 	s.Lock()
 	defer s.Unlock()
-	return s.sources[sourceID].GetInstance()
+	src, ok := s.sources[sourceID]
+	if !ok {
+		return nil
+	}
+	return src.GetInstance()
 }
 
 // GetName retrieves a source name by ID
 func (s *SourcesStore) GetName(sourceID string) string {
 	s.Lock()
 	defer s.Unlock()
-	return s.sources[sourceID].Name
+	src, ok := s.sources[sourceID]
+	if !ok {
+		return ""
+	}
+	return src.Name
 }
 
 // Get retrieves the source
@@ -313,10 +329,34 @@ func (s *SourcesStore) RefreshSuccess(sourceID string) error {
 	status.LastRefresh = time.Now().UTC()
 	status.LastRefreshDuration = time.Since(status.lastRefreshStart)
 	status.LastError = nil
+	status.ErrorSince = time.Time{}
 	status.Initialized = true // We now have data
+	s.generation++
 	return nil
 }
 
+// Generation is incremented with every successful
+// refresh of a source.
+func (s *SourcesStore) Generation() uint64 {
+	s.Lock()
+	defer s.Unlock()
+	return s.generation
+}
+
+// LastRefresh returns the time of the most recent
+// successful refresh of any source.
+func (s *SourcesStore) LastRefresh() time.Time {
+	s.Lock()
+	defer s.Unlock()
+	last := time.Time{}
+	for _, status := range s.status {
+		if status.LastRefresh.After(last) {
+			last = status.LastRefresh
+		}
+	}
+	return last
+}
+
 // RefreshError indicates that the refresh has failed
 func (s *SourcesStore) RefreshError(
 	sourceID string,
@@ -331,6 +371,9 @@ func (s *SourcesStore) RefreshError(
 	}
 	status.State = StateError
 	status.LastRefresh = time.Now().UTC()
+	if status.ErrorSince.IsZero() {
+		status.ErrorSince = status.LastRefresh
+	}
 	status.LastRefreshDuration = time.Since(status.lastRefreshStart)
 	status.LastError = sourceErr
 }
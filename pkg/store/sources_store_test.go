@@ -102,3 +102,60 @@ func TestGetSourceIDsForRefreshParallel(t *testing.T) {
 		t.Error("expected src3 to be least refreshed")
 	}
 }
+
+func TestSourcesStoreGeneration(t *testing.T) {
+	s := &SourcesStore{
+		refreshParallelism: 1,
+		status: map[string]*Status{
+			"src1": {
+				SourceID: "src1",
+			},
+		},
+	}
+	if s.Generation() != 0 {
+		t.Error("unexpected generation:", s.Generation())
+	}
+
+	s.RefreshError("src1", ErrSourceNotInitialized)
+	if s.Generation() != 0 {
+		t.Error("a failed refresh should not change the generation")
+	}
+
+	if err := s.RefreshSuccess("src1"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Generation() != 1 {
+		t.Error("unexpected generation:", s.Generation())
+	}
+	if s.LastRefresh().IsZero() {
+		t.Error("expected last refresh to be set")
+	}
+}
+
+func TestSourcesStoreErrorSince(t *testing.T) {
+	s := &SourcesStore{
+		status: map[string]*Status{
+			"src1": {SourceID: "src1"},
+		},
+	}
+	s.RefreshError("src1", "timeout")
+	status, _ := s.GetStatus("src1")
+	since := status.ErrorSince
+	if since.IsZero() {
+		t.Fatal("expected the error time to be set")
+	}
+
+	// Subsequent errors keep the time of the first error
+	time.Sleep(time.Millisecond)
+	s.RefreshError("src1", "timeout")
+	if !status.ErrorSince.Equal(since) {
+		t.Error("unexpected error time:", status.ErrorSince)
+	}
+
+	if err := s.RefreshSuccess("src1"); err != nil {
+		t.Fatal(err)
+	}
+	if !status.ErrorSince.IsZero() {
+		t.Error("expected the error time to be reset")
+	}
+}
@@ -0,0 +1,288 @@
+package store
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+// Address family names used in the statistics
+var statisticsAddrFamilies = map[uint8]string{
+	api.AddrFamilyIPv4: "ipv4",
+	api.AddrFamilyIPv6: "ipv6",
+}
+
+// routesStatistics are counters of route attributes
+// collected during a routes refresh.
+type routesStatistics struct {
+	updatedAt time.Time
+
+	imported uint
+	filtered uint
+
+	prefixLengths    map[string]map[int]uint
+	asPathLengths    map[int]uint
+	originASNs       map[int]uint
+	communities      map[string]uint
+	largeCommunities map[string]uint
+	filterReasons    map[string]uint
+
+	ranked rankedCounts
+}
+
+// rankedCounts are the counts ordered by frequency.
+// They are ranked once, when the statistics of a
+// refresh are complete.
+type rankedCounts struct {
+	originASNs       []*api.StatisticsCount
+	communities      []*api.StatisticsCount
+	largeCommunities []*api.StatisticsCount
+	filterReasons    []*api.StatisticsCount
+}
+
+// newRoutesStatistics creates empty statistics
+func newRoutesStatistics() *routesStatistics {
+	return &routesStatistics{
+		prefixLengths:    map[string]map[int]uint{},
+		asPathLengths:    map[int]uint{},
+		originASNs:       map[int]uint{},
+		communities:      map[string]uint{},
+		largeCommunities: map[string]uint{},
+		filterReasons:    map[string]uint{},
+	}
+}
+
+// add counts the attributes of a route. Filter reasons
+// are communities of filtered routes with a label in
+// the reasons map.
+func (s *routesStatistics) add(
+	r *api.LookupRoute,
+	reasons api.BGPCommunityMap,
+) {
+	switch r.State {
+	case api.RouteStateImported:
+		s.imported++
+	case api.RouteStateFiltered:
+		s.filtered++
+	}
+
+	if family, ok := statisticsAddrFamilies[r.AddrFamily]; ok {
+		lengths, ok := s.prefixLengths[family]
+		if !ok {
+			lengths = map[int]uint{}
+			s.prefixLengths[family] = lengths
+		}
+		if n := api.PrefixLength(r.Network); n >= 0 {
+			lengths[n]++
+		}
+	}
+
+	if r.BGP == nil {
+		return
+	}
+	s.asPathLengths[len(r.BGP.AsPath)]++
+	if n := len(r.BGP.AsPath); n > 0 {
+		s.originASNs[r.BGP.AsPath[n-1]]++
+	}
+	for _, c := range r.BGP.Communities {
+		s.communities[c.String()]++
+	}
+	for _, c := range r.BGP.LargeCommunities {
+		s.largeCommunities[c.String()]++
+	}
+
+	if r.State != api.RouteStateFiltered || reasons == nil {
+		return
+	}
+	for _, c := range r.BGP.LargeCommunities {
+		if _, err := reasons.Lookup(c.String()); err == nil {
+			s.filterReasons[c.String()]++
+		}
+	}
+	for _, c := range r.BGP.Communities {
+		if _, err := reasons.Lookup(c.String()); err == nil {
+			s.filterReasons[c.String()]++
+		}
+	}
+}
+
+// merge adds the counters of other statistics
+func (s *routesStatistics) merge(other *routesStatistics) {
+	s.imported += other.imported
+	s.filtered += other.filtered
+	for family, lengths := range other.prefixLengths {
+		if _, ok := s.prefixLengths[family]; !ok {
+			s.prefixLengths[family] = map[int]uint{}
+		}
+		mergeCounts(s.prefixLengths[family], lengths)
+	}
+	mergeCounts(s.asPathLengths, other.asPathLengths)
+	mergeCounts(s.originASNs, other.originASNs)
+	mergeCounts(s.communities, other.communities)
+	mergeCounts(s.largeCommunities, other.largeCommunities)
+	mergeCounts(s.filterReasons, other.filterReasons)
+	if other.updatedAt.After(s.updatedAt) {
+		s.updatedAt = other.updatedAt
+	}
+}
+
+// rank orders the counts for selecting the top values
+func (s *routesStatistics) rank() {
+	origins := make(map[string]uint, len(s.originASNs))
+	for asn, count := range s.originASNs {
+		origins[strconv.Itoa(asn)] = count
+	}
+	s.ranked = rankedCounts{
+		originASNs:       rankCounts(origins),
+		communities:      rankCounts(s.communities),
+		largeCommunities: rankCounts(s.largeCommunities),
+		filterReasons:    rankCounts(s.filterReasons),
+	}
+}
+
+func mergeCounts[K comparable](a, b map[K]uint) {
+	for k, v := range b {
+		a[k] += v
+	}
+}
+
+// toAPI creates the api response with the top n
+// values for origin ASNs, communities and filter reasons.
+func (s *routesStatistics) toAPI(
+	top int,
+	communities api.BGPCommunityMap,
+	reasons api.BGPCommunityMap,
+) api.RoutesStatistics {
+	prefixLengths := make(map[string]map[int]uint, len(s.prefixLengths))
+	for family, lengths := range s.prefixLengths {
+		prefixLengths[family] = copyCounts(lengths)
+	}
+
+	return api.RoutesStatistics{
+		Routes: api.RoutesStats{
+			Imported: s.imported,
+			Filtered: s.filtered,
+		},
+		PrefixLengths:       prefixLengths,
+		ASPathLengths:       copyCounts(s.asPathLengths),
+		TopOriginASNs:       topCounts(s.ranked.originASNs, top, nil),
+		TopCommunities:      topCounts(s.ranked.communities, top, communities),
+		TopLargeCommunities: topCounts(s.ranked.largeCommunities, top, communities),
+		TopFilterReasons:    topCounts(s.ranked.filterReasons, top, reasons),
+	}
+}
+
+func copyCounts[K comparable](counts map[K]uint) map[K]uint {
+	result := make(map[K]uint, len(counts))
+	for k, v := range counts {
+		result[k] = v
+	}
+	return result
+}
+
+// rankCounts orders the values by frequency.
+// Ties are ordered by value.
+func rankCounts(counts map[string]uint) []*api.StatisticsCount {
+	result := make([]*api.StatisticsCount, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, &api.StatisticsCount{
+			Value: value,
+			Count: count,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count == result[j].Count {
+			return result[i].Value < result[j].Value
+		}
+		return result[i].Count > result[j].Count
+	})
+	return result
+}
+
+// topCounts selects the n most frequent values of the
+// ranked counts. Labels are looked up in the names map
+// if present.
+func topCounts(
+	ranked []*api.StatisticsCount,
+	n int,
+	names api.BGPCommunityMap,
+) []*api.StatisticsCount {
+	if n >= 0 && len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	result := make([]*api.StatisticsCount, 0, len(ranked))
+	for _, c := range ranked {
+		count := &api.StatisticsCount{Value: c.Value, Count: c.Count}
+		if names != nil {
+			if name, err := names.Lookup(c.Value); err == nil {
+				count.Name = name
+			}
+		}
+		result = append(result, count)
+	}
+	return result
+}
+
+// Statistics keeps the routes statistics
+// for each source. The aggregate of all sources
+// is updated with the statistics of a source.
+type Statistics struct {
+	sync.RWMutex
+	sources map[string]*routesStatistics
+	all     *routesStatistics
+}
+
+// NewStatistics creates a new statistics collection
+func NewStatistics() *Statistics {
+	all := newRoutesStatistics()
+	all.rank()
+	return &Statistics{
+		sources: make(map[string]*routesStatistics),
+		all:     all,
+	}
+}
+
+// Private set replaces the statistics of a source
+func (s *Statistics) set(sourceID string, stats *routesStatistics) {
+	stats.rank()
+	s.Lock()
+	defer s.Unlock()
+	s.sources[sourceID] = stats
+	s.aggregate()
+}
+
+// Private remove drops the statistics of a source
+func (s *Statistics) remove(sourceID string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.sources, sourceID)
+	s.aggregate()
+}
+
+// Private get retrieves the statistics of a source
+func (s *Statistics) get(sourceID string) *routesStatistics {
+	s.RLock()
+	defer s.RUnlock()
+	return s.sources[sourceID]
+}
+
+// Private global retrieves the statistics of all sources
+func (s *Statistics) global() *routesStatistics {
+	s.RLock()
+	defer s.RUnlock()
+	return s.all
+}
+
+// Private aggregate merges the statistics of all
+// sources. The lock must be held.
+func (s *Statistics) aggregate() {
+	all := newRoutesStatistics()
+	for _, stats := range s.sources {
+		all.merge(stats)
+	}
+	all.rank()
+	s.all = all
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/alice-lg/alice-lg/pkg/api"
+)
+
+func makeTestStatisticsRoute(
+	network string,
+	family uint8,
+	state string,
+	asPath []int,
+	communities api.Communities,
+	largeCommunities api.Communities,
+) *api.LookupRoute {
+	return &api.LookupRoute{
+		Route: &api.Route{
+			Network:    network,
+			AddrFamily: family,
+			BGP: &api.BGPInfo{
+				AsPath:           asPath,
+				Communities:      communities,
+				LargeCommunities: largeCommunities,
+			},
+		},
+		State: state,
+	}
+}
+
+func TestRoutesStoreStatistics(t *testing.T) {
+	s := makeTestRoutesStore()
+	s.labels.Store(&routeLabels{
+		rejectReasons: api.BGPCommunityMap{
+			"6695": api.BGPCommunityMap{
+				"1000": api.BGPCommunityMap{
+					"1": "Bogon",
+				},
+			},
+		},
+	})
+
+	s.updateStatistics("rs1", api.LookupRoutes{
+		makeTestStatisticsRoute("10.0.0.0/8", api.AddrFamilyIPv4, api.RouteStateImported,
+			[]int{2342, 23}, api.Communities{{2342, 1}}, nil),
+		makeTestStatisticsRoute("10.1.0.0/16", api.AddrFamilyIPv4, api.RouteStateImported,
+			[]int{2342, 23}, api.Communities{{2342, 1}, {2342, 2}}, nil),
+		makeTestStatisticsRoute("10.2.0.0/16", api.AddrFamilyIPv4, api.RouteStateImported,
+			[]int{42}, nil, nil),
+		makeTestStatisticsRoute("2001:db8::/32", api.AddrFamilyIPv6, api.RouteStateFiltered,
+			[]int{42}, nil, api.Communities{{6695, 1000, 1}}),
+	})
+	s.updateStatistics("rs2", api.LookupRoutes{
+		makeTestStatisticsRoute("10.2.0.0/16", api.AddrFamilyIPv4, api.RouteStateImported,
+			[]int{42}, nil, nil),
+	})
+
+	global, rsStats := s.Statistics(context.Background(), 1)
+	if len(rsStats) != 2 {
+		t.Fatal("expected 2 route servers, got:", len(rsStats))
+	}
+
+	rs1 := rsStats[0]
+	if rs1.ID != "rs1" || rs1.Routes.Imported != 3 || rs1.Routes.Filtered != 1 {
+		t.Error("unexpected stats:", rs1)
+	}
+	if rs1.PrefixLengths["ipv4"][16] != 2 || rs1.PrefixLengths["ipv6"][32] != 1 {
+		t.Error("unexpected prefix lengths:", rs1.PrefixLengths)
+	}
+	if rs1.ASPathLengths[2] != 2 || rs1.ASPathLengths[1] != 2 {
+		t.Error("unexpected as path lengths:", rs1.ASPathLengths)
+	}
+	if len(rs1.TopOriginASNs) != 1 || rs1.TopOriginASNs[0].Value != "23" {
+		t.Error("unexpected origins:", rs1.TopOriginASNs)
+	}
+	if len(rs1.TopCommunities) != 1 || rs1.TopCommunities[0].Value != "2342:1" {
+		t.Error("unexpected communities:", rs1.TopCommunities)
+	}
+	reasons := rs1.TopFilterReasons
+	if len(reasons) != 1 || reasons[0].Name != "Bogon" || reasons[0].Count != 1 {
+		t.Error("unexpected filter reasons:", reasons)
+	}
+
+	// Global
+	if global.Routes.Imported != 4 || global.PrefixLengths["ipv4"][16] != 3 {
+		t.Error("unexpected global stats:", global)
+	}
+	if global.TopOriginASNs[0].Value != "42" || global.TopOriginASNs[0].Count != 3 {
+		t.Error("unexpected global origins:", global.TopOriginASNs)
+	}
+}
+
+func TestStatsGaugesSweep(t *testing.T) {
+	vec := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Name: "test_top_asn"},
+		[]string{"asn"})
+	g := newStatsGauges(vec)
+
+	g.set(prometheus.Labels{"asn": "1"}, 10)
+	g.set(prometheus.Labels{"asn": "2"}, 5)
+	g.sweep()
+
+	// The series are kept during the update
+	g.set(prometheus.Labels{"asn": "1"}, 11)
+	if n := testutil.CollectAndCount(vec); n != 2 {
+		t.Error("expected 2 series during the update, got:", n)
+	}
+	g.set(prometheus.Labels{"asn": "3"}, 6)
+	g.sweep()
+
+	if n := testutil.CollectAndCount(vec); n != 2 {
+		t.Error("expected 2 series, got:", n)
+	}
+	if v := testutil.ToFloat64(vec.WithLabelValues("1")); v != 11 {
+		t.Error("unexpected value:", v)
+	}
+}
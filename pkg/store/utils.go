@@ -2,8 +2,10 @@ package store
 
 // Some helper functions
 import (
+	"context"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // ContainsCi is like `strings.Contains` but case insensitive
@@ -27,3 +29,16 @@ func SerializeReasons(reasons map[int]string) map[string]string {
 	}
 	return res
 }
+
+// sleep pauses for the duration or until the context
+// is done. The error of the context is returned.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,79 @@
+// Package tracing sets up the export of the
+// OpenTelemetry traces to a collector.
+package tracing
+
+import (
+	"context"
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// Start installs the global tracer provider exporting
+// the spans via OTLP/HTTP. The returned function flushes
+// the pending spans and stops the export.
+//
+// Without tracing enabled, the spans are not recorded.
+// The trace context of incoming requests is propagated
+// to the route servers in any case.
+func Start(
+	ctx context.Context,
+	cfg config.TracingConfig,
+) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{}
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(
+		resource.Default(),
+		resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(config.Version),
+		))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Println("Tracing:", err)
+	}))
+
+	log.Println("Tracing enabled, exporting to:", exportEndpoint(cfg))
+	return provider.Shutdown, nil
+}
+
+// exportEndpoint describes the collector for the log
+func exportEndpoint(cfg config.TracingConfig) string {
+	if cfg.Endpoint != "" {
+		return cfg.Endpoint
+	}
+	return "OTEL_EXPORTER_OTLP_ENDPOINT"
+}
@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	collector "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/alice-lg/alice-lg/pkg/config"
+)
+
+// testCollector is a stand-in for the OTLP/HTTP
+// receiver of a collector.
+type testCollector struct {
+	sync.Mutex
+	services []string
+	spans    []string
+}
+
+func (c *testCollector) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/v1/traces" {
+		http.NotFound(res, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	export := &collector.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, export); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	for _, rs := range export.ResourceSpans {
+		for _, attr := range rs.Resource.Attributes {
+			if attr.Key == "service.name" {
+				c.services = append(c.services, attr.Value.GetStringValue())
+			}
+		}
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans = append(c.spans, span.Name)
+			}
+		}
+	}
+	res.Header().Set("Content-Type", "application/x-protobuf")
+	payload, _ := proto.Marshal(&collector.ExportTraceServiceResponse{})
+	res.Write(payload)
+}
+
+func TestStartExport(t *testing.T) {
+	c := &testCollector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	ctx := context.Background()
+	shutdown, err := Start(ctx, config.TracingConfig{
+		Enabled:     true,
+		Endpoint:    srv.URL,
+		ServiceName: "alice-test",
+		SampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracer := otel.Tracer("test")
+	ctx, parent := tracer.Start(ctx, "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	// Shutting down flushes the pending spans
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	slices.Sort(c.spans)
+	if !slices.Equal(c.spans, []string{"child", "parent"}) {
+		t.Error("unexpected spans:", c.spans)
+	}
+	if !slices.Contains(c.services, "alice-test") {
+		t.Error("unexpected services:", c.services)
+	}
+}
+
+func TestStartDisabled(t *testing.T) {
+	shutdown, err := Start(context.Background(), config.TracingConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}